@@ -0,0 +1,139 @@
+package main
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/providers"
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/worker"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// importProgressPeriod is how often "CVSeeker import" prints the progress of its batch.
+	importProgressPeriod = 10 * time.Second
+	// importDrainTimeout bounds the wait for the uploads of an interrupted import to record it.
+	importDrainTimeout = 30 * time.Second
+)
+
+// runImport implements "CVSeeker import": it imports an archive of resumes as one batch, ingests it
+// in the foreground and writes the report of the import. Documents go through the outbox and are
+// indexed by the server's relay. Interrupting the command fails the uploads left.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	onDuplicate := flags.String("on-duplicate", "", `policy for resumes already indexed: "skip", "replace", "version" or "review" (default DUPLICATE_POLICY)`)
+	reportPath := flags.String("report", "", `file the report is written to, as JSON if it ends in ".json" (default "import-<id>-report.csv")`)
+	_ = flags.Parse(args)
+	if flags.NArg() != 1 {
+		return errors.New("usage: import [-on-duplicate policy] [-report file] archive.zip|archive.tar.gz")
+	}
+	if *onDuplicate != "" && !models.ValidDuplicatePolicy(*onDuplicate) {
+		return fmt.Errorf("invalid duplicate policy %q", *onDuplicate)
+	}
+
+	archivePath := flags.Arg(0)
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var (
+		dataProcessing services.IDataProcessingService
+		workers        *worker.Group
+	)
+	if err := providers.GetContainer().Invoke(func(_dataProcessing services.IDataProcessingService, _workers *worker.Group) {
+		dataProcessing, workers = _dataProcessing, _workers
+	}); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	imp, err := dataProcessing.ImportArchive(ctx, filepath.Base(archivePath), file, info.Size(), *onDuplicate)
+	if err != nil {
+		return err
+	}
+	log.Printf("Import %d of %s: %d files, %d queued, %d skipped", imp.ID, archivePath, imp.Files, imp.Queued, imp.Skipped)
+
+	// The batch runs in the worker group, which is drained once it is done.
+	done := make(chan error, 1)
+	go func() {
+		done <- workers.Shutdown(ctx)
+	}()
+	ticker := time.NewTicker(importProgressPeriod)
+	defer ticker.Stop()
+wait:
+	for {
+		select {
+		case <-done:
+			break wait
+		case <-ticker.C:
+			printImportProgress(dataProcessing, imp.ID)
+		}
+	}
+	if ctx.Err() != nil {
+		log.Printf("Interrupted, failing the uploads left . . .")
+		drainCtx, cancel := context.WithTimeout(context.Background(), importDrainTimeout)
+		defer cancel()
+		_ = workers.Shutdown(drainCtx)
+	}
+
+	printImportProgress(dataProcessing, imp.ID)
+	return writeImportReport(dataProcessing, imp.ID, *reportPath)
+}
+
+func printImportProgress(dataProcessing services.IDataProcessingService, importID int64) {
+	rows, err := dataProcessing.ImportReport(context.Background(), importID)
+	if err != nil {
+		log.Printf("Import %d: failed to read progress: %v", importID, err)
+		return
+	}
+	counts := map[string]int{}
+	for _, row := range rows {
+		counts[row.Status]++
+	}
+	log.Printf("Import %d: %v", importID, counts)
+}
+
+func writeImportReport(dataProcessing services.IDataProcessingService, importID int64, path string) error {
+	rows, err := dataProcessing.ImportReport(context.Background(), importID)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = fmt.Sprintf("import-%d-report.%s", importID, services.ImportReportCSV)
+	}
+	format := services.ImportReportCSV
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		format = services.ImportReportJSON
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := services.WriteImportReport(out, format, rows); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	log.Printf("Import %d: report written to %s", importID, path)
+	return nil
+}
@@ -18,11 +18,50 @@ const (
 	ConfigApiDefaultPageSize = "API_DEFAULT_PAGE_SIZE"
 	ConfigApiMinPageSize     = "API_MIN_PAGE_SIZE"
 	ConfigApiMaxPageSize     = "API_MAX_PAGE_SIZE"
+	ConfigKeyShutdownTimeout = "SHUTDOWN_TIMEOUT"
 
 	ElasticsearchDocumentIndex = "ELK_DOCUMENT_INDEX"
 	ChatGptModel               = "CHAT_GPT_MODEL"
 	DefaultOpenAIAssistant     = "DEFAULT_OPENAI_ASSISTANT"
+	ResumeParseMaxAttempts     = "RESUME_PARSE_MAX_ATTEMPTS"
+	ResumeParseResponseFormat  = "RESUME_PARSE_RESPONSE_FORMAT"
+	ResumeBasicInfoMode        = "RESUME_BASIC_INFO_MODE"
+	PromptsDir                 = "PROMPTS_DIR"
+	PromptCacheTTL             = "PROMPT_CACHE_TTL"
+	ReprocessRatePerMinute     = "REPROCESS_RATE_PER_MINUTE"
+	ReprocessStaleAfter        = "REPROCESS_STALE_AFTER"
+	DuplicatePolicy            = "DUPLICATE_POLICY"
+	DuplicateSimilarity        = "DUPLICATE_SIMILARITY_THRESHOLD"
+	OutboxBatchSize            = "OUTBOX_BATCH_SIZE"
+	OutboxPollInterval         = "OUTBOX_POLL_INTERVAL"
+	OutboxMaxAttempts          = "OUTBOX_MAX_ATTEMPTS"
+	UploadStaleAfter           = "UPLOAD_STALE_AFTER"
+	UploadDedupeWindow         = "UPLOAD_DEDUPE_WINDOW"
+	ReconcileInterval          = "RECONCILE_INTERVAL"
+	ReconcilePolicy            = "RECONCILE_POLICY"
+	ReconcileGracePeriod       = "RECONCILE_GRACE_PERIOD"
+	IngestionConcurrency       = "INGESTION_CONCURRENCY"
+	ImportMaxFiles             = "IMPORT_MAX_FILES"
+	ImportMaxFileSize          = "IMPORT_MAX_FILE_SIZE_MB"
+	IngestFolder               = "INGEST_FOLDER"
+	IngestPollInterval         = "INGEST_POLL_INTERVAL"
+	IngestUser                 = "INGEST_USER"
+	LinkedInRecrawlInterval    = "LINKEDIN_RECRAWL_INTERVAL"
+	LinkedInRecrawlAfter       = "LINKEDIN_RECRAWL_AFTER"
+	LinkedInRecrawlLimit       = "LINKEDIN_RECRAWL_LIMIT"
 
 	HuggingfaceModel = "HUGGINGFACE_MODEL"
 	AwsBucket        = "AWS_BUCKET"
+	CrawlerBaseUrl   = "CRAWLER_BASE_URL"
+	CrawlerChunkSize = "CRAWLER_CHUNK_SIZE"
+
+	LlmPrices                = "LLM_PRICES"
+	LlmMonthlyBudget         = "LLM_MONTHLY_BUDGET_USD"
+	LlmBudgetPolicy          = "LLM_BUDGET_POLICY"
+	LlmBudgetQueuePollPeriod = "LLM_BUDGET_QUEUE_POLL_PERIOD"
+
+	OpenAIRequestsPerMinute      = "OPENAI_REQUESTS_PER_MINUTE"
+	OpenAITokensPerMinute        = "OPENAI_TOKENS_PER_MINUTE"
+	HuggingfaceRequestsPerMinute = "HUGGINGFACE_REQUESTS_PER_MINUTE"
+	HuggingfaceTokensPerMinute   = "HUGGINGFACE_TOKENS_PER_MINUTE"
 )
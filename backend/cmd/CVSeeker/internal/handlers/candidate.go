@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/dig"
+	"strconv"
+)
+
+type CandidateHandler struct {
+	BaseHandler
+	candidateService services.ICandidateService
+}
+
+type CandidateHandlerParams struct {
+	dig.In
+	BaseHandler      BaseHandler
+	CandidateService services.ICandidateService
+}
+
+func NewCandidateHandler(params CandidateHandlerParams) *CandidateHandler {
+	return &CandidateHandler{
+		BaseHandler:      params.BaseHandler,
+		candidateService: params.CandidateService,
+	}
+}
+
+// GetCandidate
+// @Summary Get a candidate
+// @Description Returns a candidate with its resume versions, oldest first. Only the latest version is searched by default.
+// @Tags Candidates
+// @Produce json
+// @Param id path int true "Candidate ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.CandidateDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/candidates/{id} [GET]
+func (_this *CandidateHandler) GetCandidate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.candidateService.GetCandidate(c, id)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// GetCandidateVersion
+// @Summary Get a resume version
+// @Description Returns one version of a candidate's resume with its parsed content and the URL of its file.
+// @Tags Candidates
+// @Produce json
+// @Param id path int true "Candidate ID"
+// @Param version path int true "Version number, starting at 1"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ResumeVersionDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/candidates/{id}/versions/{version} [GET]
+func (_this *CandidateHandler) GetCandidateVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+		version, err := strconv.Atoi(c.Param("version"))
+		if err != nil || version < 1 {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.candidateService.GetCandidateVersion(c, id, version)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// DiffVersions
+// @Summary Compare resume versions
+// @Description Lists what changed between two versions of a candidate's resume. By default the latest version is compared with the one before it.
+// @Tags Candidates
+// @Produce json
+// @Param id path int true "Candidate ID"
+// @Param from query int false "Older version, defaults to the version before to"
+// @Param to query int false "Newer version, defaults to the latest version"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ResumeDiffDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/candidates/{id}/diff [GET]
+func (_this *CandidateHandler) DiffVersions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+		from, err := strconv.Atoi(c.DefaultQuery("from", "0"))
+		if err != nil || from < 0 {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+		to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+		if err != nil || to < 0 {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.candidateService.DiffVersions(c, id, from, to)
+		_this.HandleResponse(c, resp, err)
+	}
+}
@@ -5,11 +5,18 @@ import (
 	"CVSeeker/cmd/CVSeeker/pkg/utils"
 	"CVSeeker/internal/dtos"
 	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/models"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/dig"
+	"strconv"
 	"strings"
 )
 
+// maxIdempotencyKeyLength is the length of the column idempotency keys are kept in.
+const maxIdempotencyKeyLength = 255
+
 type DataProcessingHandler struct {
 	BaseHandler
 	dataProcessingService services.IDataProcessingService
@@ -35,8 +42,9 @@ func NewDataProcessingHandler(params DataProcessingHandlerParams) *DataProcessin
 // @Accept json
 // @Produce json
 // @Param request body dtos.ResumeData true "Resume data including file bytes"
-// @Success 200 {object} meta.BasicResponse{data=dtos.ResumeProcessingResult}
-// @Failure 400,401,404,500 {object} meta.Error
+// @Param Idempotency-Key header string false "Key of the request among those of its user, so that sending it again returns the batch it created instead of uploading again (default: the uuid of the resume). Requests without X-Forward-User share a scope"
+// @Success 200 {object} meta.BasicResponse{data=dtos.BatchDTO}
+// @Failure 400,401,404,409,500 {object} meta.Error
 // @Router /cvseeker/resumes/upload [post]
 func (_this *DataProcessingHandler) ProcessDataHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -50,27 +58,44 @@ func (_this *DataProcessingHandler) ProcessDataHandler() gin.HandlerFunc {
 			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
 			return
 		}
+		if requestData.OnDuplicate != "" && !models.ValidDuplicatePolicy(requestData.OnDuplicate) {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+		idempotencyKey := c.GetHeader(services.IdempotencyKeyHeader)
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
 
 		// Process data (example function call, replace with actual processing logic)
-		resp, err := _this.dataProcessingService.ProcessData(c, requestData.Content, requestData.FileBytes, requestData.UUID)
+		resp, err := _this.dataProcessingService.ProcessData(c, requestData, idempotencyKey)
 		_this.HandleResponse(c, resp, err)
 	}
 }
 
 // ProcessDataBatchHandler
 // @Summary Batch processes resume data
-// @Description Processes multiple uploaded resume files and associated metadata as JSON in a single batch.
+// @Description Processes multiple uploaded resume files and associated metadata as JSON in a single batch. The batch is returned at once with an upload per resume; its progress is sent to the submitting user over the websocket and can be read from GET /resumes/batch/{id}.
 // @Tags Data Processing
 // @Accept json
 // @Produce json
 // @Param request body dtos.ResumesRequest true "Batch of resume data including file bytes for each"
 // @Param isLinkedin query bool false "Flag to indicate if the resumes are from LinkedIn"
-// @Success 200 {object} meta.BasicResponse{data=[]dtos.ResumeProcessingResult}
-// @Failure 400,401,404,500 {object} meta.Error
+// @Param onDuplicate query string false "Policy for resumes that are already indexed and do not set onDuplicate: skip, replace, version or review (default DUPLICATE_POLICY)"
+// @Param Idempotency-Key header string false "Key of the request among those of its user, so that sending it again returns the batch it created instead of uploading again (default: the uuids of the resumes). Requests without X-Forward-User share a scope"
+// @Success 200 {object} meta.BasicResponse{data=dtos.BatchDTO}
+// @Failure 400,401,404,409,500 {object} meta.Error
 // @Router /cvseeker/resumes/batch/upload [post]
 func (_this *DataProcessingHandler) ProcessDataBatchHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		isLinkedin := utils.Str2Bool(c.Query("isLinkedin"))
+		onDuplicate := c.Query("onDuplicate")
+		idempotencyKey := c.GetHeader(services.IdempotencyKeyHeader)
+		if (onDuplicate != "" && !models.ValidDuplicatePolicy(onDuplicate)) || len(idempotencyKey) > maxIdempotencyKeyLength {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
 
 		var requestData dtos.ResumesRequest
 		if err := c.ShouldBindJSON(&requestData); err != nil {
@@ -82,16 +107,22 @@ func (_this *DataProcessingHandler) ProcessDataBatchHandler() gin.HandlerFunc {
 			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
 			return
 		}
+		for _, resume := range requestData.Resumes {
+			if resume.OnDuplicate != "" && !models.ValidDuplicatePolicy(resume.OnDuplicate) {
+				_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+				return
+			}
+		}
 
 		// Process the batch of resumes
-		resp, err := _this.dataProcessingService.ProcessDataBatch(c, requestData.Resumes, isLinkedin)
+		resp, err := _this.dataProcessingService.ProcessDataBatch(c, requestData.Resumes, isLinkedin, onDuplicate, idempotencyKey)
 		_this.HandleResponse(c, resp, err)
 	}
 }
 
 // GetAllUploadsHandler
-// @Summary Retrieves all upload records
-// @Description Fetches a list of all upload records sorted from the most recent to the oldest
+// @Summary Retrieves the upload records of the user
+// @Description Fetches the upload records of the batches the user submitted, sorted from the most recent to the oldest. A request without X-Forward-User gets those submitted without one.
 // @Tags Data Processing
 // @Accept json
 // @Produce json
@@ -104,3 +135,228 @@ func (_this *DataProcessingHandler) GetAllUploadsHandler() gin.HandlerFunc {
 		_this.HandleResponse(c, resp, err)
 	}
 }
+
+// GetBatchHandler
+// @Summary Get an upload batch
+// @Description Returns a batch with its status, how many of its uploads are in each status and each upload with the error it failed with. The batches of other users are not found; a request without X-Forward-User only finds those submitted without one.
+// @Tags Data Processing
+// @Produce json
+// @Param id path int true "Batch ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.BatchDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/resumes/batch/{id} [get]
+func (_this *DataProcessingHandler) GetBatchHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		batchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.dataProcessingService.GetBatch(c, batchID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// RetryUploadHandler
+// @Summary Retry an upload
+// @Description Ingests a failed or cancelled upload again from the text and file kept when it was submitted, in its batch. Uploads whose file was not kept must be uploaded again.
+// @Tags Data Processing
+// @Produce json
+// @Param id path int true "Upload ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.UploadDTO}
+// @Failure 400,404,409,429,500 {object} meta.Error
+// @Router /cvseeker/resumes/upload/{id}/retry [post]
+func (_this *DataProcessingHandler) RetryUploadHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uploadID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.dataProcessingService.RetryUpload(c, uploadID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// CancelUploadHandler
+// @Summary Cancel an upload
+// @Description Stops a processing or queued upload. The calls in flight for it are cancelled, and the upload is marked as cancelled once they return.
+// @Tags Data Processing
+// @Produce json
+// @Param id path int true "Upload ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.UploadDTO}
+// @Failure 400,404,409,500 {object} meta.Error
+// @Router /cvseeker/resumes/upload/{id}/cancel [post]
+func (_this *DataProcessingHandler) CancelUploadHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uploadID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.dataProcessingService.CancelUpload(c, uploadID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// CancelBatchHandler
+// @Summary Cancel an upload batch
+// @Description Stops the processing and queued uploads of a batch, including a LinkedIn fetch in progress. Uploads already finished are kept.
+// @Tags Data Processing
+// @Produce json
+// @Param id path int true "Batch ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.BatchDTO}
+// @Failure 400,404,409,500 {object} meta.Error
+// @Router /cvseeker/resumes/batch/{id}/cancel [post]
+func (_this *DataProcessingHandler) CancelBatchHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		batchID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.dataProcessingService.CancelBatch(c, batchID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// ImportArchiveHandler
+// @Summary Import an archive of resumes
+// @Description Reads the files of a ZIP or tar.gz archive one at a time and ingests the PDF, text and Markdown resumes among them as one batch, with an upload per file. Other files, files without extractable text such as scanned PDFs, files larger than IMPORT_MAX_FILE_SIZE_MB, files beyond IMPORT_MAX_FILES and copies of an earlier file of the archive are skipped. The report of the import lists what became of each file.
+// @Tags Data Processing
+// @Accept mpfd
+// @Produce json
+// @Param file formData file true "ZIP or tar.gz archive"
+// @Param onDuplicate query string false "Policy for resumes that are already indexed: skip, replace, version or review (default DUPLICATE_POLICY)"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ImportDTO}
+// @Failure 400,429,500 {object} meta.Error
+// @Router /cvseeker/resumes/import [post]
+func (_this *DataProcessingHandler) ImportArchiveHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		onDuplicate := c.Query("onDuplicate")
+		if onDuplicate != "" && !models.ValidDuplicatePolicy(onDuplicate) {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		header, err := c.FormFile("file")
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+		defer file.Close()
+
+		resp, err := _this.dataProcessingService.StartImport(c, header.Filename, file, header.Size, onDuplicate)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// GetImportHandler
+// @Summary Get an import
+// @Description Returns an imported archive with what became of each of its files and the batch of its uploads. The imports of other users are not found; a request without X-Forward-User only finds those submitted without one.
+// @Tags Data Processing
+// @Produce json
+// @Param id path int true "Import ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ImportDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/resumes/import/{id} [get]
+func (_this *DataProcessingHandler) GetImportHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		importID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.dataProcessingService.GetImport(c, importID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// DownloadImportReportHandler
+// @Summary Download the report of an import
+// @Description Downloads a line for each file of an imported archive: the status of its upload with the error it failed with, or "Skipped" with the reason. Uploads still being processed show their current status.
+// @Tags Data Processing
+// @Produce text/csv,json
+// @Param id path int true "Import ID"
+// @Param format query string false "csv (default) or json"
+// @Success 200 {array} dtos.ImportReportRow
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/resumes/import/{id}/report [get]
+func (_this *DataProcessingHandler) DownloadImportReportHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		importID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+		format := c.DefaultQuery("format", services.ImportReportCSV)
+		if format != services.ImportReportCSV && format != services.ImportReportJSON {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		rows, err := _this.dataProcessingService.GetImportReport(c, importID)
+		if err != nil {
+			_this.RespondError(c, err)
+			return
+		}
+		contentType := "text/csv"
+		if format == services.ImportReportJSON {
+			contentType = "application/json"
+		}
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-report.%s"`, importID, format))
+		if err := services.WriteImportReport(c.Writer, format, rows); err != nil {
+			ginLogger.Gin(c).Errorf("failed to write the report of import %d: %v", importID, err)
+		}
+	}
+}
+
+// GetResumeRecordHandler
+// @Summary Get the record of a resume
+// @Description Returns the MySQL record an indexed document is built from: the extracted text, the stored file, the parsed content and the prompt and model versions that produced it.
+// @Tags Data Processing
+// @Produce json
+// @Param id path string true "Document ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ResumeRecordDTO}
+// @Failure 404,500 {object} meta.Error
+// @Router /cvseeker/resumes/{id}/record [get]
+func (_this *DataProcessingHandler) GetResumeRecordHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := _this.dataProcessingService.GetResumeRecord(c, c.Param("id"))
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// RebuildResumeHandler
+// @Summary Rebuild a resume from its record
+// @Description Rebuilds the Elasticsearch document from its MySQL record with a new embedding, without the original upload. With reparse, the stored text is parsed again first.
+// @Tags Data Processing
+// @Produce json
+// @Param id path string true "Document ID"
+// @Param reparse query bool false "Parse the stored text again"
+// @Success 200 {object} meta.BasicResponse
+// @Failure 400,404,409,429,500 {object} meta.Error
+// @Router /cvseeker/resumes/{id}/rebuild [post]
+func (_this *DataProcessingHandler) RebuildResumeHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reparse, err := strconv.ParseBool(c.DefaultQuery("reparse", "false"))
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.dataProcessingService.RebuildResume(c, c.Param("id"), reparse)
+		_this.HandleResponse(c, resp, err)
+	}
+}
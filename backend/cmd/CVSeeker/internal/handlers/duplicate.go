@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/dig"
+	"strconv"
+)
+
+type DuplicateHandler struct {
+	BaseHandler
+	duplicateService services.IDuplicateService
+}
+
+type DuplicateHandlerParams struct {
+	dig.In
+	BaseHandler      BaseHandler
+	DuplicateService services.IDuplicateService
+}
+
+func NewDuplicateHandler(params DuplicateHandlerParams) *DuplicateHandler {
+	return &DuplicateHandler{
+		BaseHandler:      params.BaseHandler,
+		duplicateService: params.DuplicateService,
+	}
+}
+
+// GetDuplicates
+// @Summary List duplicate candidates
+// @Description Lists the uploads recognised as duplicates of an indexed document, newest first. Pending candidates form the review queue.
+// @Tags Duplicates
+// @Produce json
+// @Param status query string false "pending (default), dismissed, merged, skipped, replaced, versioned or all"
+// @Success 200 {object} meta.BasicResponse{data=[]dtos.DuplicateCandidateDTO}
+// @Failure 500 {object} meta.Error
+// @Router /cvseeker/duplicates [GET]
+func (_this *DuplicateHandler) GetDuplicates() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", "pending")
+		if status == "all" {
+			status = ""
+		}
+
+		resp, err := _this.duplicateService.GetDuplicates(c, status)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// DismissDuplicate
+// @Summary Dismiss a duplicate candidate
+// @Description Removes a pending candidate from the review queue and keeps both documents.
+// @Tags Duplicates
+// @Produce json
+// @Param id path int true "Duplicate candidate ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.DuplicateCandidateDTO}
+// @Failure 400,404,409,500 {object} meta.Error
+// @Router /cvseeker/duplicates/{id}/dismiss [POST]
+func (_this *DuplicateHandler) DismissDuplicate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.duplicateService.DismissDuplicate(c, id)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// MergeDuplicate
+// @Summary Merge a duplicate candidate
+// @Description Merges the later document of a pending candidate into the document it duplicates.
+// @Tags Duplicates
+// @Produce json
+// @Param id path int true "Duplicate candidate ID"
+// @Success 200 {object} meta.BasicResponse{data=elasticsearch.ResumeSummaryDTO}
+// @Failure 400,404,409,500 {object} meta.Error
+// @Router /cvseeker/duplicates/{id}/merge [POST]
+func (_this *DuplicateHandler) MergeDuplicate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.duplicateService.MergeDuplicate(c, id)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// VersionDuplicate
+// @Summary Keep a duplicate candidate as a new version
+// @Description Makes the later document of a pending candidate the latest resume version of the candidate it duplicates. Both documents are kept, and only the later one is searched by default.
+// @Tags Duplicates
+// @Produce json
+// @Param id path int true "Duplicate candidate ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.DuplicateCandidateDTO}
+// @Failure 400,404,409,500 {object} meta.Error
+// @Router /cvseeker/duplicates/{id}/version [POST]
+func (_this *DuplicateHandler) VersionDuplicate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.duplicateService.VersionDuplicate(c, id)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// MergeResumes
+// @Summary Merge two resumes
+// @Description Combines the source document into the target: the target keeps its values and gains the skills, experience, projects and awards it lacks. The source is deleted and its chat threads and uploads point to the target.
+// @Tags Duplicates
+// @Accept json
+// @Produce json
+// @Param body body dtos.MergeResumesRequest true "Document to merge and document to keep"
+// @Success 200 {object} meta.BasicResponse{data=elasticsearch.ResumeSummaryDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/resumes/merge [POST]
+func (_this *DuplicateHandler) MergeResumes() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.MergeResumesRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.duplicateService.MergeResumes(c, request)
+		_this.HandleResponse(c, resp, err)
+	}
+}
@@ -14,6 +14,13 @@ type Handlers struct {
 	DataProcessingHandler *DataProcessingHandler
 	SearchHandler         *SearchHandler
 	ChatbotHandler        *ChatbotHandler
+	HealthHandler         *HealthHandler
+	UsageHandler          *UsageHandler
+	PromptHandler         *PromptHandler
+	ReprocessHandler      *ReprocessHandler
+	DuplicateHandler      *DuplicateHandler
+	CandidateHandler      *CandidateHandler
+	ReconcileHandler      *ReconcileHandler
 }
 
 // NewHandlersParams contains all dependencies of handlers.
@@ -22,6 +29,13 @@ type handlersParams struct {
 	DataProcessingHandler *DataProcessingHandler
 	SearchHandler         *SearchHandler
 	ChatbotHandler        *ChatbotHandler
+	HealthHandler         *HealthHandler
+	UsageHandler          *UsageHandler
+	PromptHandler         *PromptHandler
+	ReprocessHandler      *ReprocessHandler
+	DuplicateHandler      *DuplicateHandler
+	CandidateHandler      *CandidateHandler
+	ReconcileHandler      *ReconcileHandler
 }
 
 // NewHandlers returns new instance of Handlers.
@@ -30,6 +44,13 @@ func NewHandlers(params handlersParams) *Handlers {
 		DataProcessingHandler: params.DataProcessingHandler,
 		SearchHandler:         params.SearchHandler,
 		ChatbotHandler:        params.ChatbotHandler,
+		HealthHandler:         params.HealthHandler,
+		UsageHandler:          params.UsageHandler,
+		PromptHandler:         params.PromptHandler,
+		ReprocessHandler:      params.ReprocessHandler,
+		DuplicateHandler:      params.DuplicateHandler,
+		CandidateHandler:      params.CandidateHandler,
+		ReconcileHandler:      params.ReconcileHandler,
 	}
 }
 
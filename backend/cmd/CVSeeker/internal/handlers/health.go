@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/dig"
+	"net/http"
+)
+
+type HealthHandler struct {
+	BaseHandler
+	healthService services.IHealthService
+}
+
+type HealthHandlerParams struct {
+	dig.In
+	BaseHandler   BaseHandler
+	HealthService services.IHealthService
+}
+
+func NewHealthHandler(params HealthHandlerParams) *HealthHandler {
+	return &HealthHandler{
+		BaseHandler:   params.BaseHandler,
+		healthService: params.HealthService,
+	}
+}
+
+// Liveness
+// @Summary Liveness probe
+// @Description Reports that the process is running. It does not check any dependency.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} dtos.HealthDTO
+// @Router /healthz [GET]
+func (_this *HealthHandler) Liveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, _this.healthService.Liveness())
+	}
+}
+
+// Readiness
+// @Summary Readiness probe
+// @Description Checks MySQL, Elasticsearch, the resume mapping of the document index and required configuration. Returns 503 when a check fails or the server is shutting down.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} dtos.HealthDTO
+// @Failure 503 {object} dtos.HealthDTO
+// @Router /readyz [GET]
+func (_this *HealthHandler) Readiness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, ready := _this.healthService.Readiness(c.Request.Context())
+		if !ready {
+			c.JSON(http.StatusServiceUnavailable, resp)
+			return
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
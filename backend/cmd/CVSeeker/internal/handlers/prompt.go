@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/dig"
+)
+
+type PromptHandler struct {
+	BaseHandler
+	promptService services.IPromptService
+}
+
+type PromptHandlerParams struct {
+	dig.In
+	BaseHandler   BaseHandler
+	PromptService services.IPromptService
+}
+
+func NewPromptHandler(params PromptHandlerParams) *PromptHandler {
+	return &PromptHandler{
+		BaseHandler:   params.BaseHandler,
+		promptService: params.PromptService,
+	}
+}
+
+// ListPrompts
+// @Summary List prompts
+// @Description Lists the prompts with their static and stored versions and the version in use.
+// @Tags Prompts
+// @Produce json
+// @Success 200 {object} meta.BasicResponse{data=[]dtos.PromptDTO}
+// @Failure 500 {object} meta.Error
+// @Router /cvseeker/prompts [GET]
+func (_this *PromptHandler) ListPrompts() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := _this.promptService.ListPrompts(c)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// CreateVersion
+// @Summary Add a prompt version
+// @Description Stores a new version of a prompt as a Go template. The version is not used until it is rolled out.
+// @Tags Prompts
+// @Accept json
+// @Produce json
+// @Param name path string true "Prompt name, e.g. resume_parse or chat_preamble"
+// @Param body body dtos.PromptVersionRequest true "Version ID and template"
+// @Success 200 {object} meta.BasicResponse{data=dtos.PromptVersionDTO}
+// @Failure 400,404,409,500 {object} meta.Error
+// @Router /cvseeker/prompts/{name}/versions [POST]
+func (_this *PromptHandler) CreateVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.PromptVersionRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.promptService.CreateVersion(c, c.Param("name"), request)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// Preview
+// @Summary Preview a prompt
+// @Description Renders a draft template, a stored version or the active version of a prompt, with the given data or sample data.
+// @Tags Prompts
+// @Accept json
+// @Produce json
+// @Param name path string true "Prompt name, e.g. resume_parse or chat_preamble"
+// @Param body body dtos.PromptPreviewRequest true "Template or version to render and the data to render it with"
+// @Success 200 {object} meta.BasicResponse{data=dtos.PromptPreviewDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/prompts/{name}/preview [POST]
+func (_this *PromptHandler) Preview() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.PromptPreviewRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.promptService.Preview(c, c.Param("name"), request)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// Rollout
+// @Summary Roll out a prompt version
+// @Description Makes a version of a prompt the one used for new requests, without a redeploy. Rolling out an older version rolls back.
+// @Tags Prompts
+// @Accept json
+// @Produce json
+// @Param name path string true "Prompt name, e.g. resume_parse or chat_preamble"
+// @Param body body dtos.PromptRolloutRequest true "Version to use"
+// @Success 200 {object} meta.BasicResponse{data=dtos.PromptVersionDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/prompts/{name}/active [PUT]
+func (_this *PromptHandler) Rollout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.PromptRolloutRequest
+		if err := c.ShouldBindJSON(&request); err != nil || request.Version == "" {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.promptService.Rollout(c, c.Param("name"), request.Version)
+		_this.HandleResponse(c, resp, err)
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/dig"
+	"strconv"
+)
+
+type ReconcileHandler struct {
+	BaseHandler
+	reconcileService services.IReconcileService
+}
+
+type ReconcileHandlerParams struct {
+	dig.In
+	BaseHandler      BaseHandler
+	ReconcileService services.IReconcileService
+}
+
+func NewReconcileHandler(params ReconcileHandlerParams) *ReconcileHandler {
+	return &ReconcileHandler{
+		BaseHandler:      params.BaseHandler,
+		reconcileService: params.ReconcileService,
+	}
+}
+
+// StartReconcile
+// @Summary Reconcile uploads, Elasticsearch and S3
+// @Description Starts a background run that looks for successful uploads or resume records without a document (missing_document), documents with neither (orphan_document), S3 files nothing refers to (orphan_object) and uploads stuck processing (stuck_upload), and handles them with the policy. Kinds the body leaves out follow RECONCILE_POLICY.
+// @Tags Reconcile
+// @Accept json
+// @Produce json
+// @Param body body dtos.ReconcileRequest false "Policy overrides and dry run"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ReconcileRunDTO}
+// @Failure 400,409,500,503 {object} meta.Error
+// @Router /cvseeker/reconcile [POST]
+func (_this *ReconcileHandler) StartReconcile() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.ReconcileRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&request); err != nil {
+				_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+				return
+			}
+		}
+
+		resp, err := _this.reconcileService.StartReconcile(c, request)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// GetReconcileRuns
+// @Summary List reconcile runs
+// @Description Lists the latest reconcile runs with their counts, newest first.
+// @Tags Reconcile
+// @Produce json
+// @Success 200 {object} meta.BasicResponse{data=[]dtos.ReconcileRunDTO}
+// @Failure 500 {object} meta.Error
+// @Router /cvseeker/reconcile [GET]
+func (_this *ReconcileHandler) GetReconcileRuns() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := _this.reconcileService.GetReconcileRuns(c)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// GetReconcileRun
+// @Summary Get a reconcile run
+// @Description Returns the report of a reconcile run: its counts and the issues found with the action taken on each.
+// @Tags Reconcile
+// @Produce json
+// @Param id path int true "Run ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ReconcileRunDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/reconcile/{id} [GET]
+func (_this *ReconcileHandler) GetReconcileRun() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		runID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.reconcileService.GetReconcileRun(c, runID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
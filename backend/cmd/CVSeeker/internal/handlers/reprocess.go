@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/dig"
+	"strconv"
+)
+
+type ReprocessHandler struct {
+	BaseHandler
+	reprocessService services.IReprocessService
+}
+
+type ReprocessHandlerParams struct {
+	dig.In
+	BaseHandler      BaseHandler
+	ReprocessService services.IReprocessService
+}
+
+func NewReprocessHandler(params ReprocessHandlerParams) *ReprocessHandler {
+	return &ReprocessHandler{
+		BaseHandler:      params.BaseHandler,
+		reprocessService: params.ReprocessService,
+	}
+}
+
+// StartReprocess
+// @Summary Reprocess indexed resumes
+// @Description Starts a background job that re-embeds ("embed") or re-parses and re-embeds ("extract") the selected documents in place, throttled by REPROCESS_RATE_PER_MINUTE.
+// @Tags Reprocess
+// @Accept json
+// @Produce json
+// @Param body body dtos.ReprocessRequest true "Mode and document selection"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ReprocessJobDTO}
+// @Failure 400,500,503 {object} meta.Error
+// @Router /cvseeker/reprocess [POST]
+func (_this *ReprocessHandler) StartReprocess() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.ReprocessRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.reprocessService.StartReprocess(c, request)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// GetReprocessJobs
+// @Summary List reprocess jobs
+// @Description Lists the reprocess jobs with their progress, newest first.
+// @Tags Reprocess
+// @Produce json
+// @Success 200 {object} meta.BasicResponse{data=[]dtos.ReprocessJobDTO}
+// @Failure 500 {object} meta.Error
+// @Router /cvseeker/reprocess [GET]
+func (_this *ReprocessHandler) GetReprocessJobs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := _this.reprocessService.GetReprocessJobs(c)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// GetReprocessJob
+// @Summary Get a reprocess job
+// @Description Returns the progress of a reprocess job.
+// @Tags Reprocess
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ReprocessJobDTO}
+// @Failure 400,404,500 {object} meta.Error
+// @Router /cvseeker/reprocess/{id} [GET]
+func (_this *ReprocessHandler) GetReprocessJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.reprocessService.GetReprocessJob(c, jobID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// PauseReprocessJob
+// @Summary Pause a reprocess job
+// @Description Stops a reprocess job after the document in progress. It can be resumed later.
+// @Tags Reprocess
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ReprocessJobDTO}
+// @Failure 400,404,409,500 {object} meta.Error
+// @Router /cvseeker/reprocess/{id}/pause [POST]
+func (_this *ReprocessHandler) PauseReprocessJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.reprocessService.PauseReprocessJob(c, jobID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// ResumeReprocessJob
+// @Summary Resume a reprocess job
+// @Description Continues a paused, failed or interrupted reprocess job from the last document it handled.
+// @Tags Reprocess
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} meta.BasicResponse{data=dtos.ReprocessJobDTO}
+// @Failure 400,404,409,500,503 {object} meta.Error
+// @Router /cvseeker/reprocess/{id}/resume [POST]
+func (_this *ReprocessHandler) ResumeReprocessJob() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jobID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.reprocessService.ResumeReprocessJob(c, jobID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	services "CVSeeker/cmd/CVSeeker/internal/service"
 	"CVSeeker/internal/dtos"
 	"CVSeeker/internal/errors"
+	"CVSeeker/pkg/elasticsearch"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/dig"
 	"strconv"
@@ -30,6 +31,9 @@ func NewSearchHandler(params SearchHandlerParams) *SearchHandler {
 // HybridSearch
 // @Summary Perform hybridsearch on elasticsearch
 // @Description Executes a search combining keyword and vector-based queries with customizable boosting on the vector component.
+// @Description Optional filters restrict basic_info values; with min_confidence, values extracted with lower confidence do not match.
+// @Description min_years, min_skill_years and min_title_years require years of experience in total, per skill or per title.
+// @Description An optional sort orders results by years_of_experience, skill_years or title_years (of the skill or title in name).
 // @Tags Search
 // @Accept json
 // @Produce json
@@ -47,6 +51,10 @@ func (_this *SearchHandler) HybridSearch() gin.HandlerFunc {
 			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
 			return
 		}
+		if !validFilters(request.Filters) || request.Sort.Validate() != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
 
 		knnBoost, err := strconv.ParseFloat(c.DefaultQuery("knnBoost", "0.5"), 32)
 		if err != nil {
@@ -66,7 +74,7 @@ func (_this *SearchHandler) HybridSearch() gin.HandlerFunc {
 			return
 		}
 
-		resp, err := _this.searchService.HybridSearch(c, request.Content, from, size, float32(knnBoost))
+		resp, err := _this.searchService.HybridSearch(c, request.Content, from, size, float32(knnBoost), request.Filters, request.Sort)
 		if err != nil {
 			_this.HandleResponse(c, nil, err)
 			return
@@ -75,6 +83,28 @@ func (_this *SearchHandler) HybridSearch() gin.HandlerFunc {
 	}
 }
 
+// validFilters reports whether the confidence of filters is between 0 and 1 and their years are
+// not negative.
+func validFilters(filters *elasticsearch.SearchFilter) bool {
+	if filters == nil {
+		return true
+	}
+	if filters.MinConfidence < 0 || filters.MinConfidence > 1 || (filters.MinYears != nil && *filters.MinYears < 0) {
+		return false
+	}
+	for _, years := range filters.MinSkillYears {
+		if years < 0 {
+			return false
+		}
+	}
+	for _, years := range filters.MinTitleYears {
+		if years < 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // GetDocumentByID
 // @Summary Get Document By Id
 // @Description Retrieves a document by its ID from the Elasticsearch index.
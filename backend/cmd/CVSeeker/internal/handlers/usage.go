@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/dig"
+)
+
+type UsageHandler struct {
+	BaseHandler
+	usageService services.IUsageService
+}
+
+type UsageHandlerParams struct {
+	dig.In
+	BaseHandler  BaseHandler
+	UsageService services.IUsageService
+}
+
+func NewUsageHandler(params UsageHandlerParams) *UsageHandler {
+	return &UsageHandler{
+		BaseHandler:  params.BaseHandler,
+		usageService: params.UsageService,
+	}
+}
+
+// GetUsageReport
+// @Summary Report token usage and cost
+// @Description Aggregates the tokens and estimated cost of LLM and embedding calls over a period, optionally grouped.
+// @Tags Usage
+// @Produce json
+// @Param from query int false "Start of the period (unix seconds), defaults to the start of the current month"
+// @Param to query int false "End of the period (unix seconds), defaults to now"
+// @Param tenantId query string false "Only include calls billed to this tenant"
+// @Param groupBy query string false "Group by operation, model, provider, user, tenant or day"
+// @Success 200 {object} meta.BasicResponse{data=dtos.UsageReportDTO}
+// @Failure 400,500 {object} meta.Error
+// @Router /cvseeker/usage [GET]
+func (_this *UsageHandler) GetUsageReport() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		from, to, err := services.ParseUsagePeriod(c.Query("from"), c.Query("to"))
+		if err != nil || !from.Before(to) {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		var tenantID *string
+		if value, ok := c.GetQuery("tenantId"); ok {
+			tenantID = &value
+		}
+
+		resp, err := _this.usageService.GetUsageReport(c, from, to, tenantID, c.Query("groupBy"))
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// GetBudgets
+// @Summary List monthly budgets
+// @Description Lists the monthly LLM budgets with this month's spending. The budget with an empty tenant ID is the default.
+// @Tags Usage
+// @Produce json
+// @Success 200 {object} meta.BasicResponse{data=[]dtos.BudgetDTO}
+// @Failure 500 {object} meta.Error
+// @Router /cvseeker/usage/budgets [GET]
+func (_this *UsageHandler) GetBudgets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := _this.usageService.GetBudgets(c)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// SetBudget
+// @Summary Set a monthly budget
+// @Description Creates or updates the monthly LLM budget of a tenant (empty tenant ID for the default). When it is reached, new work is rejected, or ingestion is queued if the policy is "queue".
+// @Tags Usage
+// @Accept json
+// @Produce json
+// @Param body body dtos.BudgetRequest true "Budget in USD and policy (reject or queue)"
+// @Success 200 {object} meta.BasicResponse{data=dtos.BudgetDTO}
+// @Failure 400,500 {object} meta.Error
+// @Router /cvseeker/usage/budgets [PUT]
+func (_this *UsageHandler) SetBudget() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request dtos.BudgetRequest
+		if err := c.ShouldBindJSON(&request); err != nil {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.usageService.SetBudget(c, request)
+		_this.HandleResponse(c, resp, err)
+	}
+}
+
+// DeleteBudget
+// @Summary Delete a monthly budget
+// @Description Removes the budget of a tenant so that the default budget applies again.
+// @Tags Usage
+// @Produce json
+// @Param tenantId query string true "Tenant ID, empty for the default budget"
+// @Success 200 {object} meta.BasicResponse
+// @Failure 400,500 {object} meta.Error
+// @Router /cvseeker/usage/budgets [DELETE]
+func (_this *UsageHandler) DeleteBudget() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID, ok := c.GetQuery("tenantId")
+		if !ok {
+			_this.RespondError(c, errors.NewCusErr(errors.ErrCommonInvalidRequest))
+			return
+		}
+
+		resp, err := _this.usageService.DeleteBudget(c, tenantID)
+		_this.HandleResponse(c, resp, err)
+	}
+}
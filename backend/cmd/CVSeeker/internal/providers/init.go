@@ -2,19 +2,31 @@ package providers
 
 import (
 	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	services "CVSeeker/cmd/CVSeeker/internal/service"
 	"CVSeeker/internal/dtos"
 	"CVSeeker/internal/errors"
 	"CVSeeker/internal/ginServer"
 	"CVSeeker/internal/meta"
 	"CVSeeker/pkg/api"
+	pkgCfg "CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/crawler"
 	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/extract"
+	"CVSeeker/pkg/httpclient"
+	"CVSeeker/pkg/sources"
+	"CVSeeker/pkg/usage"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"go.uber.org/dig"
 	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
+// defaultCrawlerBaseURL is used when CRAWLER_BASE_URL is not set.
+const defaultCrawlerBaseURL = "http://crawler:8000"
+
 // newServerConfig returns a *server.Config.
 func newServerConfig() *ginServer.Config {
 	return &ginServer.Config{
@@ -30,6 +42,8 @@ func newErrorParserConfig() *errors.ErrorParserConfig {
 
 func newGinEngine() *gin.Engine {
 	r := gin.New()
+	// Let *gin.Context be used as a context.Context that carries the request's deadline and trace.
+	r.ContextWithFallback = true
 
 	r.Use(gin.Recovery())
 	r.NoRoute(func(c *gin.Context) {
@@ -43,6 +57,42 @@ func newGinEngine() *gin.Engine {
 	return r
 }
 
+// newCrawlerHTTPClient returns the client used to call the LinkedIn crawler, configured by CRAWLER_HTTP_*.
+func newCrawlerHTTPClient(cfgReader *viper.Viper) *httpclient.Client {
+	// Crawling a batch of profiles is slow, so allow more time per attempt than API calls.
+	defaults := httpclient.DefaultConfig()
+	defaults.Timeout = 2 * time.Minute
+	return httpclient.New("crawler", httpclient.LoadConfig(cfgReader, "CRAWLER", defaults))
+}
+
+type crawlerClientArgs struct {
+	dig.In
+	CfgReader  *viper.Viper
+	HTTPClient *httpclient.Client `name:"crawlerHTTPClient"`
+}
+
+// newCrawlerClient returns the client of the crawler at CRAWLER_BASE_URL, which sends
+// CRAWLER_CHUNK_SIZE URLs per request.
+func newCrawlerClient(args crawlerClientArgs) crawler.ICrawlerClient {
+	baseURL := pkgCfg.BaseURL(args.CfgReader, cfg.CrawlerBaseUrl, defaultCrawlerBaseURL)
+	return crawler.NewCrawlerClient(args.HTTPClient, baseURL, args.CfgReader.GetInt(cfg.CrawlerChunkSize))
+}
+
+// newIngestionSources returns the sources resumes are picked up from: the folder INGEST_FOLDER, if set.
+// A mail server connector is added here as another sources.Source.
+func newIngestionSources() []sources.Source {
+	var ingestionSources []sources.Source
+	if dir := viper.GetString(cfg.IngestFolder); dir != "" {
+		ingestionSources = append(ingestionSources, sources.NewFolder(dir, extract.Supported))
+	}
+	return ingestionSources
+}
+
+// newUsageRecorder exposes the usage service to the adaptors that report token usage.
+func newUsageRecorder(usageService services.IUsageService) usage.Recorder {
+	return usageService
+}
+
 func newMySQLConnection() *db.DB {
 	_db, err := db.Connect(&db.Config{
 		Driver:   db.DriverMySQL,
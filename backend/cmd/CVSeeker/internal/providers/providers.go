@@ -13,6 +13,8 @@ import (
 	"CVSeeker/pkg/huggingface"
 	"CVSeeker/pkg/logger"
 	"CVSeeker/pkg/summarizer"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/worker"
 	"go.uber.org/dig"
 )
 
@@ -39,8 +41,12 @@ func BuildContainer() *dig.Container {
 		_ = container.Provide(newServerConfig)
 		_ = container.Provide(newErrorParserConfig)
 		_ = container.Provide(newMySQLConnection, dig.Name("talentAcquisitionDB"))
+		_ = container.Provide(newCrawlerHTTPClient, dig.Name("crawlerHTTPClient"))
+		_ = container.Provide(newIngestionSources)
 
 		_ = container.Provide(logger.NewLogger)
+		_ = container.Provide(tracing.NewProvider)
+		_ = container.Provide(worker.NewGroup)
 		_ = container.Provide(errors.NewErrorParser)
 		_ = container.Provide(ginServer.NewGinServer)
 		_ = container.Provide(handlers.NewBaseHandler)
@@ -49,6 +55,7 @@ func BuildContainer() *dig.Container {
 		_ = container.Provide(elasticsearch.NewElasticsearchClient)
 		_ = container.Provide(summarizer.NewSummarizerAdaptorClient)
 		_ = container.Provide(huggingface.NewHuggingFaceClient)
+		_ = container.Provide(newCrawlerClient)
 		_ = container.Provide(aws.NewS3Client)
 		_ = container.Provide(gpt.NewGptAdaptorClient)
 
@@ -56,14 +63,40 @@ func BuildContainer() *dig.Container {
 		_ = container.Provide(repositories.NewThreadResumeRepository)
 		_ = container.Provide(repositories.NewThreadRepository)
 		_ = container.Provide(repositories.NewUploadRepository)
+		_ = container.Provide(repositories.NewLlmUsageRepository)
+		_ = container.Provide(repositories.NewLlmBudgetRepository)
+		_ = container.Provide(repositories.NewPromptTemplateRepository)
+		_ = container.Provide(repositories.NewReprocessJobRepository)
+		_ = container.Provide(repositories.NewDuplicateCandidateRepository)
+		_ = container.Provide(repositories.NewCandidateRepository)
+		_ = container.Provide(repositories.NewReconcileRunRepository)
+		_ = container.Provide(repositories.NewBatchRepository)
+		_ = container.Provide(repositories.NewImportRepository)
 
 		_ = container.Provide(services.NewDataProcessingService)
 		_ = container.Provide(services.NewSearchService)
 		_ = container.Provide(services.NewChatbotService)
+		_ = container.Provide(services.NewHealthService)
+		_ = container.Provide(services.NewUsageService)
+		_ = container.Provide(services.NewPromptService)
+		_ = container.Provide(services.NewReprocessService)
+		_ = container.Provide(services.NewDuplicateService)
+		_ = container.Provide(services.NewCandidateService)
+		_ = container.Provide(services.NewProjectionService)
+		_ = container.Provide(services.NewReconcileService)
+		_ = container.Provide(services.NewSourceService)
+		_ = container.Provide(newUsageRecorder)
 
 		_ = container.Provide(handlers.NewDataProcessingHandler)
 		_ = container.Provide(handlers.NewSearchHandler)
 		_ = container.Provide(handlers.NewChatbotHandler)
+		_ = container.Provide(handlers.NewHealthHandler)
+		_ = container.Provide(handlers.NewUsageHandler)
+		_ = container.Provide(handlers.NewPromptHandler)
+		_ = container.Provide(handlers.NewReprocessHandler)
+		_ = container.Provide(handlers.NewDuplicateHandler)
+		_ = container.Provide(handlers.NewCandidateHandler)
+		_ = container.Provide(handlers.NewReconcileHandler)
 	}
 
 	return container
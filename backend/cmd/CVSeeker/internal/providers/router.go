@@ -3,9 +3,13 @@ package providers
 import (
 	"CVSeeker/cmd/CVSeeker/internal/cfg"
 	"CVSeeker/cmd/CVSeeker/internal/handlers"
+	services "CVSeeker/cmd/CVSeeker/internal/service"
 	"CVSeeker/internal/ginLogger"
 	commonMiddleware "CVSeeker/internal/ginMiddleware"
 	"CVSeeker/internal/ginServer"
+	"CVSeeker/pkg/api"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/usage"
 	"CVSeeker/pkg/websocket"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/gzip"
@@ -24,7 +28,7 @@ func setupRouter(hs *handlers.Handlers) ginServer.GinRoutingFn {
 		corsConfig := cors.Config{
 			AllowOrigins:     []string{"*"},
 			AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", api.XTenantIDHeader, api.XForwardUserOpsHeader, services.IdempotencyKeyHeader},
 			ExposeHeaders:    []string{"Content-Length"},
 			AllowCredentials: true,
 			MaxAge:           12 * time.Hour,
@@ -34,10 +38,17 @@ func setupRouter(hs *handlers.Handlers) ginServer.GinRoutingFn {
 			cors.New(corsConfig),
 			gzip.Gzip(gzip.DefaultCompression),
 			commonMiddleware.RequestIDLoggingMiddleware(),
+			commonMiddleware.Tracing(),
+			commonMiddleware.Metrics(),
 			ginLogger.MiddlewareGin(AppName, zerolog.InfoLevel),
 			commonMiddleware.Recovery(),
+			commonMiddleware.UsageTags(),
 		)
 
+		router.GET("/metrics", gin.WrapH(metrics.Handler()))
+		router.GET("/healthz", hs.HealthHandler.Liveness())
+		router.GET("/readyz", hs.HealthHandler.Readiness())
+
 		baseRoute := router.Group(viper.GetString(cfg.ConfigKeyContextPath))
 		baseRoute.GET("swagger/*any", _ginSwagger.WrapHandler(_swaggerFiles.Handler))
 
@@ -45,11 +56,21 @@ func setupRouter(hs *handlers.Handlers) ginServer.GinRoutingFn {
 		{
 			data.POST("/upload", hs.DataProcessingHandler.ProcessDataHandler())
 			data.GET("/upload", hs.DataProcessingHandler.GetAllUploadsHandler())
+			data.POST("/upload/:id/retry", hs.DataProcessingHandler.RetryUploadHandler())
+			data.POST("/upload/:id/cancel", hs.DataProcessingHandler.CancelUploadHandler())
+			data.GET("/batch/:id", hs.DataProcessingHandler.GetBatchHandler())
+			data.POST("/batch/:id/cancel", hs.DataProcessingHandler.CancelBatchHandler())
 			data.POST("/batch/upload", hs.DataProcessingHandler.ProcessDataBatchHandler())
+			data.POST("/import", hs.DataProcessingHandler.ImportArchiveHandler())
+			data.GET("/import/:id", hs.DataProcessingHandler.GetImportHandler())
+			data.GET("/import/:id/report", hs.DataProcessingHandler.DownloadImportReportHandler())
 
 			data.POST("/search", hs.SearchHandler.HybridSearch())
+			data.POST("/merge", hs.DuplicateHandler.MergeResumes())
 			data.GET("/:id", hs.SearchHandler.GetDocumentByID())
 			data.DELETE("/:id", hs.SearchHandler.DeleteDocumentByID())
+			data.GET("/:id/record", hs.DataProcessingHandler.GetResumeRecordHandler())
+			data.POST("/:id/rebuild", hs.DataProcessingHandler.RebuildResumeHandler())
 
 			data.POST("/thread/start", hs.ChatbotHandler.StartChatSession())
 			data.POST("/thread/:threadId/send", hs.ChatbotHandler.SendMessage())
@@ -60,9 +81,58 @@ func setupRouter(hs *handlers.Handlers) ginServer.GinRoutingFn {
 			data.POST("/thread/:threadId/updateName", hs.ChatbotHandler.UpdateThreadName())
 		}
 
+		usageRoute := baseRoute.Group("/usage")
+		{
+			usageRoute.GET("", hs.UsageHandler.GetUsageReport())
+			usageRoute.GET("/budgets", hs.UsageHandler.GetBudgets())
+			usageRoute.PUT("/budgets", hs.UsageHandler.SetBudget())
+			usageRoute.DELETE("/budgets", hs.UsageHandler.DeleteBudget())
+		}
+
+		promptRoute := baseRoute.Group("/prompts")
+		{
+			promptRoute.GET("", hs.PromptHandler.ListPrompts())
+			promptRoute.POST("/:name/versions", hs.PromptHandler.CreateVersion())
+			promptRoute.POST("/:name/preview", hs.PromptHandler.Preview())
+			promptRoute.PUT("/:name/active", hs.PromptHandler.Rollout())
+		}
+
+		reprocessRoute := baseRoute.Group("/reprocess")
+		{
+			reprocessRoute.POST("", hs.ReprocessHandler.StartReprocess())
+			reprocessRoute.GET("", hs.ReprocessHandler.GetReprocessJobs())
+			reprocessRoute.GET("/:id", hs.ReprocessHandler.GetReprocessJob())
+			reprocessRoute.POST("/:id/pause", hs.ReprocessHandler.PauseReprocessJob())
+			reprocessRoute.POST("/:id/resume", hs.ReprocessHandler.ResumeReprocessJob())
+		}
+
+		duplicateRoute := baseRoute.Group("/duplicates")
+		{
+			duplicateRoute.GET("", hs.DuplicateHandler.GetDuplicates())
+			duplicateRoute.POST("/:id/dismiss", hs.DuplicateHandler.DismissDuplicate())
+			duplicateRoute.POST("/:id/merge", hs.DuplicateHandler.MergeDuplicate())
+			duplicateRoute.POST("/:id/version", hs.DuplicateHandler.VersionDuplicate())
+		}
+
+		candidateRoute := baseRoute.Group("/candidates")
+		{
+			candidateRoute.GET("/:id", hs.CandidateHandler.GetCandidate())
+			candidateRoute.GET("/:id/versions/:version", hs.CandidateHandler.GetCandidateVersion())
+			candidateRoute.GET("/:id/diff", hs.CandidateHandler.DiffVersions())
+		}
+
+		reconcileRoute := baseRoute.Group("/reconcile")
+		{
+			reconcileRoute.POST("", hs.ReconcileHandler.StartReconcile())
+			reconcileRoute.GET("", hs.ReconcileHandler.GetReconcileRuns())
+			reconcileRoute.GET("/:id", hs.ReconcileHandler.GetReconcileRun())
+		}
+
 		router.GET("/ws", func(c *gin.Context) {
+			// The connection only receives the batch events of its user; an anonymous one those of
+			// anonymous batches.
 			// Error handling omitted for brevity
-			_, err := websocket.HandleWebSocket(c.Writer, c.Request)
+			_, err := websocket.HandleWebSocket(c.Writer, c.Request, usage.UserFrom(c.Request.Context()))
 			if err != nil {
 				// Log error or handle it
 				return
@@ -0,0 +1,650 @@
+package services
+
+import (
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/ratelimit"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
+	"CVSeeker/pkg/websocket"
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+)
+
+// Websocket events of a batch, sent to the user who submitted it.
+const (
+	batchProgressEvent  = "batch.progress"
+	batchCompletedEvent = "batch.completed"
+)
+
+// errUploadCancelled is the cause of the contexts of the uploads cancelled by the user.
+var errUploadCancelled = errors.New("cancelled by the user")
+
+// cancelRegistry holds the cancel functions of the batches and uploads being ingested, so that a
+// request can stop them. A batch can have several runs at once when uploads of it are retried.
+type cancelRegistry struct {
+	mu      sync.Mutex
+	batches map[int64][]*context.CancelCauseFunc
+	uploads map[int]context.CancelCauseFunc
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{
+		batches: make(map[int64][]*context.CancelCauseFunc),
+		uploads: make(map[int]context.CancelCauseFunc),
+	}
+}
+
+// addBatch registers a run of a batch, and returns the function that unregisters it.
+func (_this *cancelRegistry) addBatch(batchID int64, cancel context.CancelCauseFunc) func() {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	run := &cancel
+	_this.batches[batchID] = append(_this.batches[batchID], run)
+	return func() {
+		_this.mu.Lock()
+		defer _this.mu.Unlock()
+		runs := _this.batches[batchID]
+		for i := range runs {
+			if runs[i] == run {
+				runs = append(runs[:i], runs[i+1:]...)
+				break
+			}
+		}
+		if len(runs) == 0 {
+			delete(_this.batches, batchID)
+		} else {
+			_this.batches[batchID] = runs
+		}
+	}
+}
+
+// addUpload registers an upload being ingested, and returns the function that unregisters it.
+func (_this *cancelRegistry) addUpload(uploadID int, cancel context.CancelCauseFunc) func() {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.uploads[uploadID] = cancel
+	return func() {
+		_this.mu.Lock()
+		defer _this.mu.Unlock()
+		delete(_this.uploads, uploadID)
+	}
+}
+
+// cancelBatch cancels the runs of a batch, and reports whether any was running.
+func (_this *cancelRegistry) cancelBatch(batchID int64) bool {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	for _, cancel := range _this.batches[batchID] {
+		(*cancel)(errUploadCancelled)
+	}
+	return len(_this.batches[batchID]) > 0
+}
+
+// running reports whether an upload is registered.
+func (_this *cancelRegistry) running(uploadID int) bool {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_, ok := _this.uploads[uploadID]
+	return ok
+}
+
+// cancelUpload cancels an upload, and reports whether it was running.
+func (_this *cancelRegistry) cancelUpload(uploadID int) bool {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	cancel, ok := _this.uploads[uploadID]
+	if ok {
+		cancel(errUploadCancelled)
+	}
+	return ok
+}
+
+// batchItem is an upload of a batch with the resume it ingests.
+type batchItem struct {
+	upload *models.Upload
+	resume dtos.ResumeData
+}
+
+// submitBatch records a batch of resumes from source with a queued upload for each, then
+// ingests them in the background. It returns the batch as submitted, so that the caller can follow it.
+// A request sent again with the same idempotency key, or the same uuids, returns the batch it created.
+// Keys are scoped by user; anonymous requests share a scope, in which the random keys and uuids
+// clients generate keep their requests apart.
+func (_this *DataProcessingService) submitBatch(c *gin.Context, source string, resumes []dtos.ResumeData, isLinkedin bool, key string) (*meta.BasicResponse, error) {
+	batch := &models.Batch{Source: source}
+	if key = idempotencyKey(key, resumes); key != "" {
+		batch.IdempotencyKey, batch.RequestHash = &key, requestHash(source, resumes)
+		if resp, err := _this.replayBatch(c, batch); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+
+	// The job outlives the request, so it keeps the request's trace and user but not its cancellation.
+	ctx := tracing.Detach(c.Request.Context())
+	if err := _this.usageService.CheckBudget(ctx, true); err != nil {
+		return nil, err
+	}
+
+	items, err := _this.createBatch(ctx, batch, resumes, isLinkedin)
+	if err != nil && key != "" && db.IsDuplicateKey(err) {
+		// The same request was received meanwhile.
+		if resp, err := _this.replayBatch(c, batch); resp != nil || err != nil {
+			return resp, err
+		}
+	}
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to create the upload batch: %v", err)
+		return nil, err
+	}
+
+	if err := _this.startBatch(ctx, batch, items, isLinkedin); err != nil {
+		return nil, err
+	}
+
+	uploads := make([]models.Upload, len(items))
+	for i, item := range items {
+		uploads[i] = *item.upload
+	}
+	batchDTO := toBatchDTO(*batch, uploads)
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Processing request received and is being processed",
+		},
+		Data: batchDTO,
+	}, nil
+}
+
+// createBatch records a batch of resumes and an upload for each, in one transaction.
+func (_this *DataProcessingService) createBatch(ctx context.Context, batch *models.Batch, resumes []dtos.ResumeData, isLinkedin bool) ([]batchItem, error) {
+	var items []batchItem
+	err := _this.db.Transaction(func(tx *db.DB) error {
+		var err error
+		items, err = _this.insertBatch(ctx, tx, batch, resumes, isLinkedin)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// insertBatch records batch, with its source set, and an upload for each of its resumes in tx. The
+// uploads are queued, except those of a file submitted within UPLOAD_DEDUPE_WINDOW, which are
+// linked to the upload of the file as duplicates.
+func (_this *DataProcessingService) insertBatch(ctx context.Context, tx *db.DB, batch *models.Batch, resumes []dtos.ResumeData, isLinkedin bool) ([]batchItem, error) {
+	batch.UserID, batch.Total = usage.UserFrom(ctx), len(resumes)
+	if err := _this.batchRepo.Create(tx, batch); err != nil {
+		return nil, err
+	}
+	files := newRecentFiles(_this.uploadRepo, tx)
+	items := make([]batchItem, len(resumes))
+	for i, resume := range resumes {
+		upload := &models.Upload{
+			Status:      "Queued",
+			Name:        resume.Name,
+			UUID:        resume.UUID,
+			BatchID:     &batch.ID,
+			Content:     resume.Content,
+			FileKey:     resume.FileKey,
+			OnDuplicate: resume.OnDuplicate,
+			Source:      resume.Source,
+			Origin:      resume.Origin,
+		}
+		if isLinkedin {
+			upload.URL = resume.FileBytes
+		} else if err := files.link(upload, resumeFileHash(resume)); err != nil {
+			return nil, err
+		}
+		upload, err := _this.uploadRepo.Create(tx, upload)
+		if err != nil {
+			return nil, err
+		}
+		files.add(upload)
+		items[i] = batchItem{upload: upload, resume: resume}
+	}
+	return items, nil
+}
+
+// startBatch ingests the queued items of a batch in the background; a batch without any is
+// finished at once. When the server is shutting down, the items are failed and
+// ErrCommonShuttingDown is returned.
+func (_this *DataProcessingService) startBatch(ctx context.Context, batch *models.Batch, items []batchItem, isLinkedin bool) error {
+	var queued []batchItem
+	for _, item := range items {
+		if item.upload.Status == "Queued" {
+			queued = append(queued, item)
+		}
+	}
+	if len(queued) == 0 {
+		_this.finishBatch(ctx, batch)
+		return nil
+	}
+	items = queued
+
+	metrics.IngestionQueueDepth.Add(float64(len(items)))
+	err := _this.workers.Go(ctx, func(ctx context.Context) {
+		_this.runBatch(ctx, batch, items, isLinkedin)
+	})
+	if err != nil {
+		metrics.IngestionQueueDepth.Sub(float64(len(items)))
+		for _, item := range items {
+			_this.failUpload(ctx, item.upload.ID, item.upload.Name, "the server was shutting down")
+		}
+		_this.finishBatch(ctx, batch)
+		return errors.NewCusErr(errors.ErrCommonShuttingDown)
+	}
+	return nil
+}
+
+// runBatch ingests the resumes of a batch concurrently, reporting each outcome to the user who
+// submitted it, then marks the batch as finished. The run and each upload can be cancelled
+// through the registry until they finish.
+func (_this *DataProcessingService) runBatch(ctx context.Context, batch *models.Batch, items []batchItem, isLinkedin bool) {
+	ctx, span := tracing.Start(ctx, "ingestion.ProcessBatch")
+	defer span.End()
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	defer _this.cancels.addBatch(batch.ID, cancel)()
+
+	itemCtxs := make([]context.Context, len(items))
+	for i, item := range items {
+		itemCtx, cancelItem := context.WithCancelCause(ctx)
+		defer cancelItem(nil)
+		defer _this.cancels.addUpload(item.upload.ID, cancelItem)()
+		itemCtxs[i] = itemCtx
+	}
+
+	if isLinkedin {
+		items, itemCtxs = _this.fetchLinkedInItems(ctx, batch, items, itemCtxs)
+	}
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func(ctx context.Context, item batchItem) {
+			defer wg.Done()
+			defer metrics.IngestionQueueDepth.Dec()
+
+			var err error
+			ctx, span := tracing.Start(ctx, "ingestion.ProcessResume")
+			defer tracing.End(span, &err)
+
+			err = _this.ingestItem(ctx, item, isLinkedin)
+			_this.reportProgress(ctx, batch, item.upload.ID)
+		}(itemCtxs[i], item)
+	}
+	wg.Wait()
+
+	_this.finishBatch(ctx, batch)
+}
+
+// ingestItem ingests an item once a slot of the ingestion pool is free. The upload shows as queued
+// while it waits for a slot or for the rate limits of a provider.
+func (_this *DataProcessingService) ingestItem(ctx context.Context, item batchItem, isLinkedin bool) error {
+	if err := _this.ingestion.Acquire(ctx); err != nil {
+		_this.failUpload(ctx, item.upload.ID, item.upload.Name, fmt.Sprintf("the upload was interrupted: %v", err))
+		return err
+	}
+	defer _this.ingestion.Release()
+
+	setStatus := func(status string) {
+		_this.updateUpload(ctx, &models.Upload{ID: item.upload.ID, Status: status})
+	}
+	setStatus("Processing")
+	ctx = ratelimit.WithWaitHook(ctx, func(waiting bool) {
+		if waiting {
+			setStatus("Queued")
+		} else {
+			setStatus("Processing")
+		}
+	})
+	return _this.ingestResume(ctx, item.upload.ID, item.resume, isLinkedin)
+}
+
+// fetchLinkedInItems replaces the URLs of a LinkedIn batch with the profiles the crawler returns
+// for them, skipping the items whose text is already known. The uploads whose profile could not be
+// crawled are failed with the reason, and the others are returned with their contexts.
+func (_this *DataProcessingService) fetchLinkedInItems(ctx context.Context, batch *models.Batch, items []batchItem, itemCtxs []context.Context) ([]batchItem, []context.Context) {
+	var urls []string
+	for _, item := range items {
+		if item.resume.Content == "" {
+			urls = append(urls, item.resume.FileBytes)
+		}
+	}
+	if len(urls) == 0 {
+		return items, itemCtxs
+	}
+	results := _this.crawler.Fetch(ctx, urls)
+
+	var fetched []batchItem
+	var fetchedCtxs []context.Context
+	next := 0
+	for i, item := range items {
+		if item.resume.Content == "" {
+			result := results[next]
+			next++
+			if result.Err != nil {
+				_this.logger.TraceCtx(ctx).Warnf("failed to crawl %s for batch %d: %v", result.URL, batch.ID, result.Err)
+				_this.failUpload(itemCtxs[i], item.upload.ID, item.upload.Name, fmt.Sprintf("failed to fetch the LinkedIn profile: %v", result.Err))
+				metrics.IngestionQueueDepth.Dec()
+				_this.reportProgress(ctx, batch, item.upload.ID)
+				continue
+			}
+			item.resume.Content = result.Profile.Text
+			if item.resume.Name == "" {
+				item.resume.Name = result.Profile.Name
+			}
+		}
+		fetched = append(fetched, item)
+		fetchedCtxs = append(fetchedCtxs, itemCtxs[i])
+	}
+	return fetched, fetchedCtxs
+}
+
+// reportProgress sends the outcome of an upload and the counts of its batch to the user who
+// submitted the batch.
+func (_this *DataProcessingService) reportProgress(ctx context.Context, batch *models.Batch, uploadID int) {
+	upload, err := _this.uploadRepo.FindByID(_this.db, uploadID)
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to get upload %d of batch %d: %v", uploadID, batch.ID, err)
+		return
+	}
+	counts, err := _this.uploadRepo.CountByStatus(_this.db, batch.ID)
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to count the uploads of batch %d: %v", batch.ID, err)
+		return
+	}
+
+	uploadDTO := toUploadDTO(*upload)
+	websocket.SendToUser(batch.UserID, batchProgressEvent, fmt.Sprintf("%s: %s", upload.Name, upload.Status), dtos.BatchProgressDTO{
+		BatchID: batch.ID,
+		Status:  batchStatus(batch.Total, counts),
+		Total:   batch.Total,
+		Counts:  counts,
+		Upload:  &uploadDTO,
+	})
+}
+
+// finishBatch marks a batch as finished and sends its final counts to the user who submitted it.
+// A batch with uploads still pending, such as retries started meanwhile, is left to the run that
+// ends last.
+func (_this *DataProcessingService) finishBatch(ctx context.Context, batch *models.Batch) {
+	counts, err := _this.uploadRepo.CountByStatus(_this.db, batch.ID)
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to count the uploads of batch %d: %v", batch.ID, err)
+		return
+	}
+	status := batchStatus(batch.Total, counts)
+	if status == models.BatchStatusProcessing {
+		return
+	}
+	if err := _this.batchRepo.Finish(_this.db, batch); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to mark batch %d as finished: %v", batch.ID, err)
+	}
+
+	_this.logger.TraceCtx(ctx).Infof("batch %d %s: %v", batch.ID, status, counts)
+	websocket.SendToUser(batch.UserID, batchCompletedEvent,
+		fmt.Sprintf("Batch %d finished: %d succeeded, %d duplicates, %d failed, %d cancelled.",
+			batch.ID, counts["Success"], counts["Duplicate"], counts["Failed"], counts["Cancelled"]),
+		dtos.BatchProgressDTO{BatchID: batch.ID, Status: status, Total: batch.Total, Counts: counts})
+}
+
+func (_this *DataProcessingService) GetBatch(c *gin.Context, batchID int64) (*meta.BasicResponse, error) {
+	batch, err := _this.findBatch(c, batchID)
+	if err != nil {
+		return nil, err
+	}
+	batchDTO, err := _this.batchWithUploads(c, batch)
+	if err != nil {
+		return nil, err
+	}
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Batch retrieved successfully",
+		},
+		Data: batchDTO,
+	}, nil
+}
+
+func (_this *DataProcessingService) IsIngesting(uploadID int) bool {
+	return _this.cancels.running(uploadID)
+}
+
+func (_this *DataProcessingService) RetryUpload(c *gin.Context, uploadID int) (*meta.BasicResponse, error) {
+	upload, batch, err := _this.findUpload(c, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status != "Failed" && upload.Status != "Cancelled" {
+		return nil, errors.NewCusErr(errors.ErrUploadNotRetryable)
+	}
+	isLinkedin := upload.URL != ""
+	// Uploads submitted before their input was kept, or whose file could not be stored, must be
+	// uploaded again.
+	if batch == nil || (!isLinkedin && (upload.Content == "" || upload.FileKey == "")) {
+		return nil, errors.NewCusErr(errors.ErrUploadNoStoredInput)
+	}
+
+	ctx := tracing.Detach(c.Request.Context())
+	if err := _this.usageService.CheckBudget(ctx, true); err != nil {
+		return nil, err
+	}
+	requeued, err := _this.uploadRepo.Requeue(_this.db, upload.ID, []string{"Failed", "Cancelled"})
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to requeue upload %d: %v", upload.ID, err)
+		return nil, err
+	}
+	if !requeued {
+		return nil, errors.NewCusErr(errors.ErrUploadNotRetryable)
+	}
+	if err := _this.batchRepo.Reopen(_this.db, batch); err != nil {
+		ginLogger.Gin(c).Errorf("failed to reopen batch %d: %v", batch.ID, err)
+	}
+	upload.Status, upload.Error = "Queued", ""
+
+	item := batchItem{upload: upload, resume: dtos.ResumeData{
+		Content:     upload.Content,
+		FileBytes:   upload.URL,
+		Name:        upload.Name,
+		UUID:        upload.UUID,
+		OnDuplicate: upload.OnDuplicate,
+		FileKey:     upload.FileKey,
+		Source:      upload.Source,
+		Origin:      upload.Origin,
+	}}
+	if err := _this.startBatch(ctx, batch, []batchItem{item}, isLinkedin); err != nil {
+		return nil, err
+	}
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Upload is being processed again",
+		},
+		Data: toUploadDTO(*upload),
+	}, nil
+}
+
+func (_this *DataProcessingService) CancelUpload(c *gin.Context, uploadID int) (*meta.BasicResponse, error) {
+	upload, batch, err := _this.findUpload(c, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status != "Processing" && upload.Status != "Queued" {
+		return nil, errors.NewCusErr(errors.ErrUploadNotCancellable)
+	}
+
+	// A running upload is marked by its worker once its calls return; one left pending by a stopped
+	// server is marked here.
+	if !_this.cancels.cancelUpload(upload.ID) {
+		if _, err := _this.uploadRepo.MarkCancelled(_this.db, []int{upload.ID}, errUploadCancelled.Error()); err != nil {
+			ginLogger.Gin(c).Errorf("failed to cancel upload %d: %v", upload.ID, err)
+			return nil, err
+		}
+		upload.Status, upload.Error = "Cancelled", errUploadCancelled.Error()
+		if batch != nil {
+			_this.finishBatch(c.Request.Context(), batch)
+		}
+	}
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Upload cancellation requested",
+		},
+		Data: toUploadDTO(*upload),
+	}, nil
+}
+
+func (_this *DataProcessingService) CancelBatch(c *gin.Context, batchID int64) (*meta.BasicResponse, error) {
+	batch, err := _this.findBatch(c, batchID)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := _this.uploadRepo.CountByStatus(_this.db, batch.ID)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to count the uploads of batch %d: %v", batch.ID, err)
+		return nil, err
+	}
+	if counts["Processing"]+counts["Queued"] == 0 {
+		return nil, errors.NewCusErr(errors.ErrBatchNotCancellable)
+	}
+
+	if !_this.cancels.cancelBatch(batch.ID) {
+		uploads, err := _this.uploadRepo.FindByBatch(_this.db, batch.ID)
+		if err != nil {
+			ginLogger.Gin(c).Errorf("failed to get the uploads of batch %d: %v", batch.ID, err)
+			return nil, err
+		}
+		ids := make([]int, len(uploads))
+		for i, upload := range uploads {
+			ids[i] = upload.ID
+		}
+		if _, err := _this.uploadRepo.MarkCancelled(_this.db, ids, errUploadCancelled.Error()); err != nil {
+			ginLogger.Gin(c).Errorf("failed to cancel the uploads of batch %d: %v", batch.ID, err)
+			return nil, err
+		}
+		_this.finishBatch(c.Request.Context(), batch)
+	}
+
+	batchDTO, err := _this.batchWithUploads(c, batch)
+	if err != nil {
+		return nil, err
+	}
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Batch cancellation requested",
+		},
+		Data: batchDTO,
+	}, nil
+}
+
+// findBatch returns a batch of the requesting user. The batches of other users are not found, as
+// their progress events are not sent to them.
+func (_this *DataProcessingService) findBatch(c *gin.Context, batchID int64) (*models.Batch, error) {
+	batch, err := _this.batchRepo.FindByID(_this.db, batchID)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrBatchNotFound)
+	}
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get batch %d: %v", batchID, err)
+		return nil, err
+	}
+	if !visibleTo(c, batch) {
+		return nil, errors.NewCusErr(errors.ErrBatchNotFound)
+	}
+	return batch, nil
+}
+
+// findUpload returns an upload of the requesting user with its batch, nil for uploads submitted
+// before batches, which are those of anonymous requests.
+func (_this *DataProcessingService) findUpload(c *gin.Context, uploadID int) (*models.Upload, *models.Batch, error) {
+	upload, err := _this.uploadRepo.FindByID(_this.db, uploadID)
+	if err == db.ErrRecordNotFound {
+		return nil, nil, errors.NewCusErr(errors.ErrUploadNotFound)
+	}
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get upload %d: %v", uploadID, err)
+		return nil, nil, err
+	}
+	if upload.BatchID == nil {
+		if usage.UserFrom(c.Request.Context()) != "" {
+			return nil, nil, errors.NewCusErr(errors.ErrUploadNotFound)
+		}
+		return upload, nil, nil
+	}
+	batch, err := _this.batchRepo.FindByID(_this.db, *upload.BatchID)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get batch %d of upload %d: %v", *upload.BatchID, uploadID, err)
+		return nil, nil, err
+	}
+	if !visibleTo(c, batch) {
+		return nil, nil, errors.NewCusErr(errors.ErrUploadNotFound)
+	}
+	return upload, batch, nil
+}
+
+// visibleTo reports whether the requesting user may see a batch: their own only. A missing user
+// is not a wildcard: anonymous requests see the batches submitted anonymously.
+func visibleTo(c *gin.Context, batch *models.Batch) bool {
+	return usage.UserFrom(c.Request.Context()) == batch.UserID
+}
+
+func (_this *DataProcessingService) batchWithUploads(c *gin.Context, batch *models.Batch) (*dtos.BatchDTO, error) {
+	uploads, err := _this.uploadRepo.FindByBatch(_this.db, batch.ID)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get the uploads of batch %d: %v", batch.ID, err)
+		return nil, err
+	}
+	batchDTO := toBatchDTO(*batch, uploads)
+	return &batchDTO, nil
+}
+
+func toBatchDTO(batch models.Batch, uploads []models.Upload) dtos.BatchDTO {
+	batchDTO := dtos.BatchDTO{
+		ID:        batch.ID,
+		Source:    batch.Source,
+		Total:     batch.Total,
+		Counts:    make(map[string]int),
+		CreatedAt: batch.CreatedAt.Unix(),
+		Uploads:   make([]dtos.UploadDTO, len(uploads)),
+	}
+	if batch.FinishedAt != nil {
+		finishedAt := batch.FinishedAt.Unix()
+		batchDTO.FinishedAt = &finishedAt
+	}
+	for i, upload := range uploads {
+		batchDTO.Counts[upload.Status]++
+		batchDTO.Uploads[i] = toUploadDTO(upload)
+	}
+	batchDTO.Status = batchStatus(batch.Total, batchDTO.Counts)
+	return batchDTO
+}
+
+// batchStatus derives the status of a batch of total uploads from how many are in each status.
+func batchStatus(total int, counts map[string]int) string {
+	failed := counts["Failed"]
+	switch {
+	case counts["Success"]+counts["Duplicate"]+failed+counts["Cancelled"] < total:
+		return models.BatchStatusProcessing
+	case counts["Cancelled"] > 0:
+		return models.BatchStatusCancelled
+	case failed > 0 && failed == total:
+		return models.BatchStatusFailed
+	case failed > 0:
+		return models.BatchStatusPartial
+	default:
+		return models.BatchStatusCompleted
+	}
+}
@@ -0,0 +1,359 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/logger"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/dig"
+	"net/http"
+)
+
+type ICandidateService interface {
+	// NewCandidate creates a candidate without versions.
+	NewCandidate(ctx context.Context, fullName string) (*models.Candidate, error)
+	// CandidateOf returns the candidate of an indexed document. A document indexed before
+	// candidates existed becomes version 1 of a new candidate.
+	CandidateOf(ctx context.Context, documentID string) (*models.Candidate, error)
+	// AddVersion records documentID as the latest version of candidate and marks the previous
+	// latest version as superseded.
+	AddVersion(ctx context.Context, candidate *models.Candidate, documentID string, uploadID int, fileURL string) (*models.ResumeVersion, error)
+	// MoveDocument makes documentID the latest version of the candidate of ofDocumentID.
+	MoveDocument(ctx context.Context, documentID, ofDocumentID string) error
+	// RemoveDocument forgets a deleted document. When it was the latest version, the version
+	// before it becomes the latest again; a candidate left without versions is deleted.
+	RemoveDocument(ctx context.Context, documentID string) error
+
+	GetCandidate(c *gin.Context, candidateID int64) (*meta.BasicResponse, error)
+	GetCandidateVersion(c *gin.Context, candidateID int64, version int) (*meta.BasicResponse, error)
+	// DiffVersions compares two versions of a candidate. A zero to is the latest version and a
+	// zero from the version before to.
+	DiffVersions(c *gin.Context, candidateID int64, from, to int) (*meta.BasicResponse, error)
+}
+
+type CandidateService struct {
+	db            *db.DB
+	candidateRepo repositories.ICandidateRepository
+	elasticClient elasticsearch.IElasticsearchClient
+	projections   IProjectionService
+	logger        logger.Logger
+}
+
+type CandidateServiceArgs struct {
+	dig.In
+	DB            *db.DB `name:"talentAcquisitionDB"`
+	CandidateRepo repositories.ICandidateRepository
+	ElasticClient elasticsearch.IElasticsearchClient
+	Projections   IProjectionService
+	Logger        logger.Logger
+}
+
+func NewCandidateService(args CandidateServiceArgs) ICandidateService {
+	return &CandidateService{
+		db:            args.DB,
+		candidateRepo: args.CandidateRepo,
+		elasticClient: args.ElasticClient,
+		projections:   args.Projections,
+		logger:        args.Logger,
+	}
+}
+
+func (_this *CandidateService) NewCandidate(ctx context.Context, fullName string) (*models.Candidate, error) {
+	candidate := &models.Candidate{FullName: fullName}
+	if err := _this.candidateRepo.Create(_this.db, candidate); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+func (_this *CandidateService) CandidateOf(ctx context.Context, documentID string) (*models.Candidate, error) {
+	version, err := _this.candidateRepo.FindVersionByDocumentID(_this.db, documentID)
+	if err == nil {
+		return _this.candidateRepo.FindByID(_this.db, version.CandidateID)
+	}
+	if err != db.ErrRecordNotFound {
+		return nil, err
+	}
+
+	document, err := _this.elasticClient.GetDocumentByID(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), documentID)
+	if err != nil {
+		return nil, err
+	}
+	candidate, err := _this.NewCandidate(ctx, document.BasicInfo.FullName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := _this.AddVersion(ctx, candidate, documentID, 0, document.URL); err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+func (_this *CandidateService) AddVersion(ctx context.Context, candidate *models.Candidate, documentID string, uploadID int, fileURL string) (*models.ResumeVersion, error) {
+	version := &models.ResumeVersion{
+		CandidateID: candidate.ID,
+		DocumentID:  documentID,
+		UploadID:    uploadID,
+		FileURL:     fileURL,
+	}
+	if err := _this.candidateRepo.AddVersion(_this.db, version); err != nil {
+		return nil, err
+	}
+	previous := candidate.LatestDocumentID
+	if err := _this.candidateRepo.SetLatest(_this.db, candidate.ID, documentID); err != nil {
+		return nil, err
+	}
+	candidate.LatestDocumentID = documentID
+
+	if err := _this.setVersionFields(ctx, documentID, candidate.ID, false); err != nil {
+		return nil, err
+	}
+	if previous != "" && previous != documentID {
+		if err := _this.setVersionFields(ctx, previous, candidate.ID, true); err != nil {
+			return nil, err
+		}
+	}
+	return version, nil
+}
+
+func (_this *CandidateService) MoveDocument(ctx context.Context, documentID, ofDocumentID string) error {
+	candidate, err := _this.CandidateOf(ctx, ofDocumentID)
+	if err != nil {
+		return err
+	}
+
+	var uploadID int
+	var fileURL string
+	current, err := _this.candidateRepo.FindVersionByDocumentID(_this.db, documentID)
+	switch {
+	case err == nil && current.CandidateID == candidate.ID:
+		return nil
+	case err == nil:
+		uploadID, fileURL = current.UploadID, current.FileURL
+		if err := _this.RemoveDocument(ctx, documentID); err != nil {
+			return err
+		}
+	case err == db.ErrRecordNotFound:
+		document, err := _this.elasticClient.GetDocumentByID(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), documentID)
+		if err != nil {
+			return err
+		}
+		fileURL = document.URL
+	default:
+		return err
+	}
+
+	_, err = _this.AddVersion(ctx, candidate, documentID, uploadID, fileURL)
+	return err
+}
+
+func (_this *CandidateService) RemoveDocument(ctx context.Context, documentID string) error {
+	version, err := _this.candidateRepo.FindVersionByDocumentID(_this.db, documentID)
+	if err == db.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := _this.candidateRepo.DeleteVersion(_this.db, version.ID); err != nil {
+		return err
+	}
+
+	candidate, err := _this.candidateRepo.FindByID(_this.db, version.CandidateID)
+	if err != nil {
+		return err
+	}
+	if candidate.LatestDocumentID != documentID {
+		return nil
+	}
+	versions, err := _this.candidateRepo.GetVersions(_this.db, candidate.ID)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		return _this.candidateRepo.Delete(_this.db, candidate.ID)
+	}
+	latest := versions[len(versions)-1]
+	if err := _this.candidateRepo.SetLatest(_this.db, candidate.ID, latest.DocumentID); err != nil {
+		return err
+	}
+	return _this.setVersionFields(ctx, latest.DocumentID, candidate.ID, false)
+}
+
+func (_this *CandidateService) GetCandidate(c *gin.Context, candidateID int64) (*meta.BasicResponse, error) {
+	candidate, versions, err := _this.candidateWithVersions(candidateID)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get candidate %d: %v", candidateID, err)
+		return nil, err
+	}
+
+	candidateDTO := dtos.CandidateDTO{
+		ID:               candidate.ID,
+		FullName:         candidate.FullName,
+		LatestDocumentID: candidate.LatestDocumentID,
+		Versions:         make([]dtos.ResumeVersionDTO, 0, len(versions)),
+		CreatedAt:        candidate.CreatedAt.Unix(),
+		UpdatedAt:        candidate.UpdatedAt.Unix(),
+	}
+	for _, version := range versions {
+		candidateDTO.Versions = append(candidateDTO.Versions, toResumeVersionDTO(version, candidate))
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Candidate retrieved successfully",
+		},
+		Data: candidateDTO,
+	}
+	return response, nil
+}
+
+func (_this *CandidateService) GetCandidateVersion(c *gin.Context, candidateID int64, version int) (*meta.BasicResponse, error) {
+	candidate, err := _this.candidateRepo.FindByID(_this.db, candidateID)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrCandidateNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	resumeVersion, err := _this.candidateRepo.FindVersion(_this.db, candidateID, version)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrCandidateVersionNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	documents, err := _this.elasticClient.FetchDocumentsByIDs(c, viper.GetString(cfg.ElasticsearchDocumentIndex), []string{resumeVersion.DocumentID})
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get version %d of candidate %d: %v", version, candidateID, err)
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, errors.NewCusErr(errors.ErrCandidateVersionNotFound)
+	}
+
+	versionDTO := toResumeVersionDTO(*resumeVersion, candidate)
+	versionDTO.Resume = &documents[0]
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Resume version retrieved successfully",
+		},
+		Data: versionDTO,
+	}
+	return response, nil
+}
+
+func (_this *CandidateService) DiffVersions(c *gin.Context, candidateID int64, from, to int) (*meta.BasicResponse, error) {
+	_, versions, err := _this.candidateWithVersions(candidateID)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, errors.NewCusErr(errors.ErrCandidateVersionNotFound)
+	}
+
+	toIndex := len(versions) - 1
+	if to != 0 {
+		if toIndex = versionIndex(versions, to); toIndex < 0 {
+			return nil, errors.NewCusErr(errors.ErrCandidateVersionNotFound)
+		}
+	}
+	fromIndex := toIndex - 1
+	if from != 0 {
+		fromIndex = versionIndex(versions, from)
+	}
+	if fromIndex < 0 {
+		return nil, errors.NewCusErr(errors.ErrCandidateVersionNotFound)
+	}
+	fromVersion, toVersion := versions[fromIndex], versions[toIndex]
+
+	documents, err := _this.elasticClient.FetchDocumentsByIDs(c, viper.GetString(cfg.ElasticsearchDocumentIndex), []string{fromVersion.DocumentID, toVersion.DocumentID})
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get the versions of candidate %d: %v", candidateID, err)
+		return nil, err
+	}
+	byID := make(map[string]elasticsearch.ResumeSummaryDTO, len(documents))
+	for _, document := range documents {
+		byID[document.Id] = document
+	}
+	fromDocument, foundFrom := byID[fromVersion.DocumentID]
+	toDocument, foundTo := byID[toVersion.DocumentID]
+	if !foundFrom || !foundTo {
+		return nil, errors.NewCusErr(errors.ErrCandidateVersionNotFound)
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Resume versions compared successfully",
+		},
+		Data: dtos.ResumeDiffDTO{
+			CandidateID:    candidateID,
+			From:           fromVersion.Version,
+			To:             toVersion.Version,
+			FromDocumentID: fromVersion.DocumentID,
+			ToDocumentID:   toVersion.DocumentID,
+			Changes:        elasticsearch.DiffResumes(fromDocument, toDocument),
+		},
+	}
+	return response, nil
+}
+
+func (_this *CandidateService) candidateWithVersions(candidateID int64) (*models.Candidate, []models.ResumeVersion, error) {
+	candidate, err := _this.candidateRepo.FindByID(_this.db, candidateID)
+	if err == db.ErrRecordNotFound {
+		return nil, nil, errors.NewCusErr(errors.ErrCandidateNotFound)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	versions, err := _this.candidateRepo.GetVersions(_this.db, candidateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return candidate, versions, nil
+}
+
+// setVersionFields queues the update of the candidate of a document and whether a newer version
+// replaced it.
+func (_this *CandidateService) setVersionFields(ctx context.Context, documentID string, candidateID int64, superseded bool) error {
+	err := _this.projections.UpdateResume(_this.db, documentID, map[string]interface{}{
+		"content": map[string]interface{}{"candidate_id": candidateID, "superseded": superseded},
+	})
+	if err != nil {
+		return err
+	}
+	_this.projections.Notify()
+	return nil
+}
+
+func versionIndex(versions []models.ResumeVersion, version int) int {
+	for i := range versions {
+		if versions[i].Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+func toResumeVersionDTO(version models.ResumeVersion, candidate *models.Candidate) dtos.ResumeVersionDTO {
+	return dtos.ResumeVersionDTO{
+		Version:    version.Version,
+		DocumentID: version.DocumentID,
+		UploadID:   version.UploadID,
+		FileURL:    version.FileURL,
+		Latest:     version.DocumentID == candidate.LatestDocumentID,
+		CreatedAt:  version.CreatedAt.Unix(),
+	}
+}
@@ -10,7 +10,7 @@ import (
 	"CVSeeker/pkg/db"
 	"CVSeeker/pkg/elasticsearch"
 	"CVSeeker/pkg/gpt"
-	"fmt"
+	"CVSeeker/pkg/usage"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"go.uber.org/dig"
@@ -34,6 +34,8 @@ type ChatbotService struct {
 	elasticClient    elasticsearch.IElasticsearchClient
 	threadRepo       repositories.IThreadRepository
 	threadResumeRepo repositories.IThreadResumeRepository
+	usageService     IUsageService
+	promptService    IPromptService
 }
 
 type ChatbotServiceArgs struct {
@@ -43,6 +45,8 @@ type ChatbotServiceArgs struct {
 	ElasticClient    elasticsearch.IElasticsearchClient
 	ThreadRepo       repositories.IThreadRepository
 	ThreadResumeRepo repositories.IThreadResumeRepository
+	UsageService     IUsageService
+	PromptService    IPromptService
 }
 
 func NewChatbotService(args ChatbotServiceArgs) IChatbotService {
@@ -52,6 +56,8 @@ func NewChatbotService(args ChatbotServiceArgs) IChatbotService {
 		elasticClient:    args.ElasticClient,
 		threadRepo:       args.ThreadRepo,
 		threadResumeRepo: args.ThreadResumeRepo,
+		usageService:     args.UsageService,
+		promptService:    args.PromptService,
 	}
 }
 
@@ -68,32 +74,17 @@ func (_this *ChatbotService) StartChatSession(c *gin.Context, ids string, thread
 		return nil, err
 	}
 
-	// Format the documents' content from ResumeSummaryDTO
-	var fullTextContent strings.Builder
-	fullTextContent.WriteString("You will use these information to answer questions from the user while using markdown for clarity: ")
-	for _, resume := range documents {
-		fullTextContent.WriteString(fmt.Sprintf("Name: %s", resume.BasicInfo.FullName))
-		fullTextContent.WriteString(fmt.Sprintf("Summary: %s; Skills: %v; ", resume.Summary, resume.Skills))
-		fullTextContent.WriteString(fmt.Sprintf("Education: %s, %s, GPA: %.2f; ", resume.BasicInfo.University, resume.BasicInfo.EducationLevel, resume.BasicInfo.GPA))
-		fullTextContent.WriteString("Work Experience: ")
-		for _, work := range resume.WorkExperience {
-			fullTextContent.WriteString(fmt.Sprintf("%s at %s, %s; ", work.JobTitle, work.Company, work.Duration))
-		}
-		fullTextContent.WriteString("Projects: ")
-		for _, project := range resume.ProjectExperience {
-			fullTextContent.WriteString(fmt.Sprintf("%s: %s; ", project.ProjectName, project.ProjectDescription))
-		}
-		fullTextContent.WriteString("Awards: ")
-		for _, award := range resume.Award {
-			fullTextContent.WriteString(fmt.Sprintf("%s; ", award.AwardName))
-		}
-		fullTextContent.WriteString(" | ") // Separator for multiple resumes
+	preamble, promptVersion, err := _this.promptService.Render(c, PromptChatPreamble, chatPreambleData{Resumes: documents})
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to render the chat preamble: %v", err)
+		return nil, err
 	}
+	ginLogger.Gin(c).Infof("starting chat with %s version %s", PromptChatPreamble, promptVersion)
 
 	// Create the initial message for the thread
 	initMessage := gpt.CreateMessageRequest{
 		Role:    "user",
-		Content: fullTextContent.String(),
+		Content: preamble,
 	}
 
 	// Create a new thread with the initial message
@@ -101,7 +92,7 @@ func (_this *ChatbotService) StartChatSession(c *gin.Context, ids string, thread
 		Messages: []gpt.CreateMessageRequest{initMessage},
 	}
 
-	thread, err := _this.assistantClient.CreateThread(threadRequest)
+	thread, err := _this.assistantClient.CreateThread(c, threadRequest)
 	if err != nil {
 		ginLogger.Gin(c).Errorf("failed to create thread: %v", err)
 		return nil, err
@@ -146,13 +137,18 @@ func (_this *ChatbotService) StartChatSession(c *gin.Context, ids string, thread
 func (_this *ChatbotService) SendMessageToChat(c *gin.Context, threadID, message string) (*meta.BasicResponse, error) {
 	DefaultAssistant := viper.GetString(cfg.DefaultOpenAIAssistant)
 
+	// Chat is interactive and cannot be queued, so it is rejected once the budget is reached.
+	if err := _this.usageService.CheckBudget(c, false); err != nil {
+		return nil, err
+	}
+
 	// Create message and add to thread
 	messageRequest := gpt.CreateMessageRequest{
 		Content: message,
 		Role:    "user",
 	}
 
-	_, err := _this.assistantClient.CreateMessage(threadID, messageRequest)
+	_, err := _this.assistantClient.CreateMessage(c, threadID, messageRequest)
 	if err != nil {
 		ginLogger.Gin(c).Errorf("failed to send message: %v", err)
 		return nil, err
@@ -166,7 +162,7 @@ func (_this *ChatbotService) SendMessageToChat(c *gin.Context, threadID, message
 
 	// Collect and process streamed responses
 	var messages []string
-	values, err := _this.assistantClient.CreateRunAndStreamResponse(threadID, runRequest)
+	values, err := _this.assistantClient.CreateRunAndStreamResponse(usage.WithOperation(c, usage.OperationChatRun), threadID, runRequest)
 	if err != nil {
 		ginLogger.Gin(c).Errorf("error streaming responses: %v", err)
 		return nil, err
@@ -188,7 +184,7 @@ func (_this *ChatbotService) SendMessageToChat(c *gin.Context, threadID, message
 }
 
 func (_this *ChatbotService) ListMessage(c *gin.Context, request gpt.ListMessageRequest) (*meta.BasicResponse, error) {
-	resp, err := _this.assistantClient.ListMessages(request.ThreadId, request.Limit, request.Order, request.After, request.Before)
+	resp, err := _this.assistantClient.ListMessages(c, request.ThreadId, request.Limit, request.Order, request.After, request.Before)
 	if err != nil {
 		ginLogger.Gin(c).Errorf("Error when create assistant: %v", err)
 		return nil, err
@@ -3,43 +3,121 @@ package services
 import (
 	"CVSeeker/cmd/CVSeeker/internal/cfg"
 	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
 	"CVSeeker/internal/ginLogger"
 	"CVSeeker/internal/meta"
 	"CVSeeker/internal/models"
 	"CVSeeker/internal/repositories"
 	"CVSeeker/pkg/aws"
+	"CVSeeker/pkg/crawler"
 	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/dedupe"
 	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/extract"
 	"CVSeeker/pkg/huggingface"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/ratelimit"
+	"CVSeeker/pkg/sources"
 	"CVSeeker/pkg/summarizer"
-	"CVSeeker/pkg/websocket"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
+	"CVSeeker/pkg/worker"
+	"context"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/spf13/viper"
 	"go.uber.org/dig"
-	"io/ioutil"
+	"io"
 	"net/http"
+	"path"
 	"strings"
-	"sync"
 	"time"
 )
 
 type IDataProcessingService interface {
-	ProcessData(c *gin.Context, fullText string, file string, uuid string) (*meta.BasicResponse, error)
-	ProcessDataBatch(c *gin.Context, resumes []dtos.ResumeData, isLinkedin bool) (*meta.BasicResponse, error)
+	// ProcessData ingests one resume in the background, as a batch of one. A request sent again with
+	// the same idempotency key, or else the same uuid, returns the batch of the first one.
+	ProcessData(c *gin.Context, resume dtos.ResumeData, idempotencyKey string) (*meta.BasicResponse, error)
+	// ProcessDataBatch records a batch of resumes and ingests them in the background, returning the
+	// batch so that its progress can be followed. onDuplicate applies to the resumes that do not set
+	// their own policy. A request sent again with the same idempotency key, or else the same uuids,
+	// returns the batch of the first one.
+	ProcessDataBatch(c *gin.Context, resumes []dtos.ResumeData, isLinkedin bool, onDuplicate, idempotencyKey string) (*meta.BasicResponse, error)
 	GetAllUploads(c *gin.Context) (*meta.BasicResponse, error)
+	// GetBatch returns a batch with its uploads. The batches of other users are not found.
+	GetBatch(c *gin.Context, batchID int64) (*meta.BasicResponse, error)
+	// RetryUpload ingests a failed or cancelled upload again from the text and file kept for it.
+	RetryUpload(c *gin.Context, uploadID int) (*meta.BasicResponse, error)
+	// CancelUpload stops a processing or queued upload, cancelling the calls in flight for it.
+	CancelUpload(c *gin.Context, uploadID int) (*meta.BasicResponse, error)
+	// CancelBatch stops the processing and queued uploads of a batch.
+	CancelBatch(c *gin.Context, batchID int64) (*meta.BasicResponse, error)
+	// IsIngesting reports whether an upload is being ingested by this process, queued ones included.
+	IsIngesting(uploadID int) bool
+	// ImportArchive ingests the resumes of a ZIP or tar.gz archive as one batch, skipping the other
+	// files, and records what became of each file.
+	ImportArchive(ctx context.Context, fileName string, archive io.ReaderAt, size int64, onDuplicate string) (*dtos.ImportDTO, error)
+	StartImport(c *gin.Context, fileName string, archive io.ReaderAt, size int64, onDuplicate string) (*meta.BasicResponse, error)
+	// StartRecrawlSchedule recrawls the stale LinkedIn profiles in the background every
+	// LINKEDIN_RECRAWL_INTERVAL until ctx is done.
+	StartRecrawlSchedule(ctx context.Context) error
+	// RecrawlLinkedIn crawls the stale LinkedIn profiles again and ingests those that changed.
+	RecrawlLinkedIn(ctx context.Context) (*dtos.BatchDTO, error)
+	// IngestDelivery ingests the files of a delivery of an ingestion source as one batch and records
+	// it as an import, like an archive.
+	IngestDelivery(ctx context.Context, source string, delivery sources.Delivery) (*dtos.ImportDTO, error)
+	// GetImport returns an import with its batch. The imports of other users are not found.
+	GetImport(c *gin.Context, importID int64) (*meta.BasicResponse, error)
+	// GetImportReport lists the files of an import of the requesting user with the outcome of their uploads.
+	GetImportReport(c *gin.Context, importID int64) ([]dtos.ImportReportRow, error)
+	// ImportReport lists the files of an import with the outcome of their uploads.
+	ImportReport(ctx context.Context, importID int64) ([]dtos.ImportReportRow, error)
+	// ReindexDocument stores document as the content of its MySQL record and rebuilds the
+	// Elasticsearch document from it with a new embedding. When reparse is set, the content is first
+	// parsed again from the stored text; ErrNoSourceText is returned when that text was not kept.
+	ReindexDocument(ctx context.Context, document elasticsearch.ResumeSummaryDTO, reparse bool) error
+	// RebuildDocument rebuilds the Elasticsearch document of documentID from its MySQL record alone,
+	// parsing the stored text again when reparse is set.
+	RebuildDocument(ctx context.Context, documentID string, reparse bool) error
+
+	GetResumeRecord(c *gin.Context, documentID string) (*meta.BasicResponse, error)
+	RebuildResume(c *gin.Context, documentID string, reparse bool) (*meta.BasicResponse, error)
+	// FailInterruptedUploads marks as failed the uploads left processing or queued for longer than
+	// UPLOAD_STALE_AFTER, such as those of a process that stopped while ingesting them.
+	FailInterruptedUploads(ctx context.Context)
 }
 
+var (
+	// ErrNoSourceText is returned when a document cannot be parsed again because its text was not kept.
+	ErrNoSourceText = errors.New("the source text of the document was not kept")
+	// ErrNoStoredContent is returned when a document cannot be rebuilt because its record holds no
+	// parsed content.
+	ErrNoStoredContent = errors.New("the parsed content of the document was not kept")
+)
+
 type DataProcessingService struct {
 	db            *db.DB
 	gptClient     summarizer.ISummarizerAdaptorClient
 	resumeRepo    repositories.IResumeRepository
 	uploadRepo    repositories.IUploadRepository
+	batchRepo     repositories.IBatchRepository
+	importRepo    repositories.IImportRepository
+	duplicateRepo repositories.IDuplicateCandidateRepository
 	elasticClient elasticsearch.IElasticsearchClient
 	hfClient      huggingface.IHuggingFaceClient
 	s3Client      *aws.S3Client
+	logger        logger.Logger
+	workers       *worker.Group
+	crawler       crawler.ICrawlerClient
+	usageService  IUsageService
+	promptService IPromptService
+	candidates    ICandidateService
+	projections   IProjectionService
+	cancels       *cancelRegistry
+	ingestion     *worker.Pool
+	limits        ratelimit.Limiters
 }
 
 type DataProcessingServiceArgs struct {
@@ -48,9 +126,19 @@ type DataProcessingServiceArgs struct {
 	GptClient     summarizer.ISummarizerAdaptorClient
 	ResumeRepo    repositories.IResumeRepository
 	UploadRepo    repositories.IUploadRepository
+	BatchRepo     repositories.IBatchRepository
+	ImportRepo    repositories.IImportRepository
+	DuplicateRepo repositories.IDuplicateCandidateRepository
 	ElasticClient elasticsearch.IElasticsearchClient
 	HfClient      huggingface.IHuggingFaceClient
 	S3Client      *aws.S3Client
+	Logger        logger.Logger
+	Workers       *worker.Group
+	Crawler       crawler.ICrawlerClient
+	UsageService  IUsageService
+	PromptService IPromptService
+	Candidates    ICandidateService
+	Projections   IProjectionService
 }
 
 func NewDataProcessingService(args DataProcessingServiceArgs) IDataProcessingService {
@@ -59,141 +147,241 @@ func NewDataProcessingService(args DataProcessingServiceArgs) IDataProcessingSer
 		gptClient:     args.GptClient,
 		resumeRepo:    args.ResumeRepo,
 		uploadRepo:    args.UploadRepo,
+		batchRepo:     args.BatchRepo,
+		importRepo:    args.ImportRepo,
+		duplicateRepo: args.DuplicateRepo,
 		elasticClient: args.ElasticClient,
 		hfClient:      args.HfClient,
 		s3Client:      args.S3Client,
+		logger:        args.Logger,
+		workers:       args.Workers,
+		crawler:       args.Crawler,
+		usageService:  args.UsageService,
+		promptService: args.PromptService,
+		candidates:    args.Candidates,
+		projections:   args.Projections,
+		cancels:       newCancelRegistry(),
+		ingestion:     worker.NewPool(ingestionConcurrency()),
+		limits:        providerLimits(),
 	}
 }
 
-func (_this *DataProcessingService) ProcessData(c *gin.Context, fullText string, file string, uuid string) (*meta.BasicResponse, error) {
-	// This method now schedules the processing in the background and immediately returns a response
-	go func() {
-		elasticDocumentName := viper.GetString(cfg.ElasticsearchDocumentIndex)
+func (_this *DataProcessingService) ProcessData(c *gin.Context, resume dtos.ResumeData, idempotencyKey string) (*meta.BasicResponse, error) {
+	// A single upload is a batch of one, so that it is followed like any other.
+	return _this.submitBatch(c, models.BatchSourceUpload, []dtos.ResumeData{resume}, false, idempotencyKey)
+}
 
-		initialUpload := &models.Upload{
-			Status: "Processing", // Initial status
-			UUID:   uuid,
+func (_this *DataProcessingService) ProcessDataBatch(c *gin.Context, resumes []dtos.ResumeData, isLinkedin bool, onDuplicate, idempotencyKey string) (*meta.BasicResponse, error) {
+	source := models.BatchSourceFiles
+	if isLinkedin {
+		source = models.BatchSourceLinkedIn
+	}
+	for i := range resumes {
+		if resumes[i].OnDuplicate == "" {
+			resumes[i].OnDuplicate = onDuplicate
 		}
-
-		createdUpload, err := _this.uploadRepo.Create(_this.db, initialUpload)
-		if err != nil {
-			ginLogger.Gin(c).Errorf("Failed to log initial upload: %v", err)
-			return
-		}
-
-		// Assume createElkResume is an existing method that prepares the data for Elasticsearch
-		elkResume, err := _this.createElkResume(c, fullText, file, false)
-		if err != nil {
-			_this.uploadRepo.Update(_this.db, &models.Upload{ID: createdUpload.ID, Status: "Failed"})
-			ginLogger.Gin(c).Errorf("failed to create elastic document: %v", err)
-			return
+		// A LinkedIn upload is named after its profile until the crawler returns it.
+		if isLinkedin && resumes[i].Name == "" {
+			resumes[i].Name = crawler.NameFromURL(resumes[i].FileBytes)
 		}
+	}
+	return _this.submitBatch(c, source, resumes, isLinkedin, idempotencyKey)
+}
 
-		// Add document to Elasticsearch and handle the response
-		documentID, err := _this.elasticClient.AddDocument(c, elasticDocumentName, elkResume)
-		if err != nil {
-			_this.uploadRepo.Update(_this.db, &models.Upload{ID: createdUpload.ID, Status: "Failed"})
-			ginLogger.Gin(c).Errorf("failed to upload resume data to Elasticsearch: %v", err)
-			return
-		}
+// ingestResume parses, deduplicates and indexes one resume for the upload record uploadID, and
+// records the outcome on the upload. The record, the upload status and the Elasticsearch document
+// are committed together through the outbox.
+func (_this *DataProcessingService) ingestResume(ctx context.Context, uploadID int, resume dtos.ResumeData, isLinkedin bool) error {
+	policy := duplicatePolicy(resume.OnDuplicate)
+
+	// An exact copy is recognised before parsing, so that skipping it costs no tokens.
+	fingerprint := resumeFingerprint(resume, isLinkedin)
+	match := _this.findExactDuplicate(ctx, fingerprint)
+	if match != nil && policy == models.DuplicatePolicySkip {
+		_this.skipDuplicate(ctx, uploadID, resume.Name, match)
+		return nil
+	}
 
-		_this.uploadRepo.Update(_this.db, &models.Upload{ID: createdUpload.ID, DocumentID: documentID, Status: "Success"})
+	if err := _this.storeInput(ctx, uploadID, &resume, isLinkedin); err != nil {
+		_this.failUpload(ctx, uploadID, resume.Name, fmt.Sprintf("failed to store the file: %v", err))
+		_this.logger.TraceCtx(ctx).Errorf("failed to store the input of upload %d: %v", uploadID, err)
+		return err
+	}
 
-		websocket.BroadcastNotification("All documents have been processed successfully.")
-	}()
+	if err := _this.waitForBudget(ctx, uploadID, resume.Name); err != nil {
+		_this.failUpload(ctx, uploadID, resume.Name, fmt.Sprintf("stopped waiting for the LLM budget: %v", err))
+		_this.logger.TraceCtx(ctx).Errorf("stopped waiting for LLM budget: %v", err)
+		return err
+	}
 
-	response := &meta.BasicResponse{
-		Meta: meta.Meta{
-			Code:    http.StatusOK,
-			Message: "Processing request received and is being processed",
-		},
-		Data: nil,
+	fileKey, fileURL := resume.FileKey, resume.FileBytes
+	if !isLinkedin {
+		fileURL = aws.ObjectURL(viper.GetString(cfg.AwsBucket), fileKey)
+	}
+	elkResume, err := _this.createElkResume(ctx, resume.Content, fileURL)
+	if err != nil {
+		_this.failUpload(ctx, uploadID, resume.Name, fmt.Sprintf("failed to parse the resume: %v", err))
+		_this.logger.TraceCtx(ctx).Errorf("failed to create elastic document: %v", err)
+		return err
+	}
+	// A cancelled upload stops here rather than being indexed with its last answer.
+	if err := ctx.Err(); err != nil {
+		_this.failUpload(ctx, uploadID, resume.Name, fmt.Sprintf("the upload was interrupted: %v", err))
+		return err
 	}
 
-	// Return an immediate response to indicate that processing has started
-	return response, nil
-}
+	fingerprint.NameKey = dedupe.NameKey(elkResume.Content.BasicInfo.FullName)
+	record := newResumeRecord(resume.Content, fileKey, resumeSource(resume, isLinkedin), fingerprint)
+	if match == nil {
+		match = _this.findDuplicate(ctx, fingerprint, elkResume.Embedding)
+	}
+	if match != nil && match.reason != models.DuplicateReasonEmbedding {
+		switch policy {
+		case models.DuplicatePolicySkip:
+			_this.skipDuplicate(ctx, uploadID, resume.Name, match)
+			return nil
+		case models.DuplicatePolicyReplace:
+			// The replaced document keeps its place among the versions of its candidate.
+			if candidate, err := _this.candidates.CandidateOf(ctx, match.documentID); err == nil {
+				elkResume.Content.CandidateID = candidate.ID
+				elkResume.Content.Superseded = candidate.LatestDocumentID != match.documentID
+			} else {
+				_this.logger.TraceCtx(ctx).Errorf("failed to get the candidate of document %s: %v", match.documentID, err)
+			}
+			record.DocumentID = match.documentID
+			if existing, err := _this.resumeRepo.FindByDocumentID(_this.db, match.documentID); err == nil {
+				record.ResumeId, record.CreatedAt = existing.ResumeId, existing.CreatedAt
+			}
+			if err := _this.commitResume(uploadID, resume.Name, record, elkResume); err != nil {
+				_this.failUpload(ctx, uploadID, resume.Name, fmt.Sprintf("failed to replace document %s: %v", match.documentID, err))
+				_this.logger.TraceCtx(ctx).Errorf("failed to replace document %s: %v", match.documentID, err)
+				return err
+			}
+			_this.recordDuplicate(ctx, uploadID, match.documentID, match, models.DuplicateStatusReplaced)
+			return nil
+		}
+	}
 
-func (_this *DataProcessingService) ProcessDataBatch(c *gin.Context, resumes []dtos.ResumeData, isLinkedin bool) (*meta.BasicResponse, error) {
-	elasticDocumentName := viper.GetString(cfg.ElasticsearchDocumentIndex)
+	candidate := _this.resumeCandidate(ctx, match, policy, elkResume.Content.BasicInfo.FullName)
+	if candidate != nil {
+		elkResume.Content.CandidateID = candidate.ID
+	}
 
-	// Start processing in the background
-	go func() {
-		if isLinkedin {
-			linkedInUrls := make([]string, len(resumes))
-			for i, resume := range resumes {
-				linkedInUrls[i] = resume.FileBytes // Assuming FileBytes contains the LinkedIn URL
-			}
+	record.DocumentID = uuid.New().String()
+	if err := _this.commitResume(uploadID, resume.Name, record, elkResume); err != nil {
+		_this.failUpload(ctx, uploadID, resume.Name, fmt.Sprintf("failed to save the resume: %v", err))
+		_this.logger.TraceCtx(ctx).Errorf("failed to save the resume record: %v", err)
+		return err
+	}
+	documentID := record.DocumentID
 
-			processedResumes, err := fetchLinkedInData(linkedInUrls)
-			if err != nil {
-				return
-			}
-			resumes = processedResumes // Replace or merge as necessary
+	if candidate != nil {
+		if _, err := _this.candidates.AddVersion(ctx, candidate, documentID, uploadID, elkResume.Content.URL); err != nil {
+			_this.logger.TraceCtx(ctx).Errorf("failed to add document %s to candidate %d: %v", documentID, candidate.ID, err)
+		}
+	}
+	if match != nil {
+		status := models.DuplicateStatusPending
+		if policy == models.DuplicatePolicyVersion && match.reason != models.DuplicateReasonEmbedding {
+			status = models.DuplicateStatusVersioned
 		}
+		_this.recordDuplicate(ctx, uploadID, documentID, match, status)
+	}
+	return nil
+}
 
-		var wg sync.WaitGroup
-		results := make(chan *dtos.ResumeProcessingResult, len(resumes))
-		errors := make(chan error, len(resumes))
-
-		for _, resume := range resumes {
-			wg.Add(1)
-			go func(res dtos.ResumeData) {
-				defer wg.Done()
-
-				// Create initial upload record for each document
-				initialUpload := &models.Upload{
-					Status: "Processing",
-					Name:   res.Name,
-				}
-
-				createdUpload, err := _this.uploadRepo.Create(_this.db, initialUpload)
-				if err != nil {
-					ginLogger.Gin(c).Errorf("Failed to log initial upload: %v", err)
-					return
-				}
-
-				elkResume, err := _this.createElkResume(c, res.Content, res.FileBytes, isLinkedin)
-				if err != nil {
-					_this.uploadRepo.Update(_this.db, &models.Upload{ID: createdUpload.ID, Status: "Failed", Name: res.Name})
-					ginLogger.Gin(c).Errorf("failed to create elk resume: %v", err)
-					errors <- err
-					return
-				}
-
-				documentID, err := _this.elasticClient.AddDocument(c, elasticDocumentName, elkResume)
-				if err != nil {
-					_this.uploadRepo.Update(_this.db, &models.Upload{ID: createdUpload.ID, Status: "Failed", Name: res.Name})
-					ginLogger.Gin(c).Errorf("failed to upload resume data to Elasticsearch: %v", err)
-					errors <- err
-					return
-				}
-
-				_this.uploadRepo.Update(_this.db, &models.Upload{ID: createdUpload.ID, DocumentID: documentID, Status: "Success", Name: res.Name})
-			}(resume)
+// commitResume saves record with the content of elkResume, marks the upload as successful, sets the
+// document on the uploads linked to it and queues the indexing of the document, in one transaction.
+func (_this *DataProcessingService) commitResume(uploadID int, name string, record *models.Resume, elkResume *elasticsearch.ElkResumeDTO) error {
+	err := _this.db.Transaction(func(tx *db.DB) error {
+		if err := _this.saveResume(tx, record, &elkResume.Content); err != nil {
+			return err
+		}
+		if err := _this.uploadRepo.Update(tx, &models.Upload{ID: uploadID, DocumentID: record.DocumentID, Status: "Success", Name: name}); err != nil {
+			return err
+		}
+		if err := _this.uploadRepo.LinkDocument(tx, uploadID, record.DocumentID); err != nil {
+			return err
 		}
+		return _this.projections.IndexResume(tx, record.DocumentID, elkResume)
+	})
+	if err != nil {
+		return err
+	}
+	_this.projections.Notify()
+	return nil
+}
 
-		wg.Wait()
-		close(results)
-		close(errors)
+// updateUpload records the progress of an upload. A failure is logged: the upload is then left in
+// its previous status until UPLOAD_STALE_AFTER marks it failed.
+func (_this *DataProcessingService) updateUpload(ctx context.Context, upload *models.Upload) {
+	if err := _this.uploadRepo.Update(_this.db, upload); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to set upload %d to %s: %v", upload.ID, upload.Status, err)
+	}
+}
 
-		websocket.BroadcastNotification("All documents have been processed successfully.")
-	}()
+// failUpload marks an upload as failed with reason, shown to the user with the upload. An upload
+// stopped because the user cancelled it is marked as cancelled instead. The uploads linked to it as
+// duplicates of its file end with it.
+func (_this *DataProcessingService) failUpload(ctx context.Context, uploadID int, name, reason string) {
+	if context.Cause(ctx) == errUploadCancelled {
+		if _, err := _this.uploadRepo.MarkCancelled(_this.db, []int{uploadID}, errUploadCancelled.Error()); err != nil {
+			_this.logger.TraceCtx(ctx).Errorf("failed to set upload %d to Cancelled: %v", uploadID, err)
+		}
+		return
+	}
+	_this.updateUpload(ctx, &models.Upload{ID: uploadID, Status: "Failed", Name: name, Error: reason})
+	if err := _this.uploadRepo.FailLinked(_this.db, []int{uploadID}); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to end the uploads linked to upload %d: %v", uploadID, err)
+	}
+}
 
-	response := &meta.BasicResponse{
-		Meta: meta.Meta{
-			Code:    http.StatusOK,
-			Message: "Processing request received and is being processed",
-		},
-		Data: nil,
+// resumeCandidate returns the candidate a new document belongs to: the candidate of the document it
+// duplicates when it is kept as a version, else a new candidate. Errors are logged and leave the
+// document without a candidate.
+func (_this *DataProcessingService) resumeCandidate(ctx context.Context, match *duplicateMatch, policy, fullName string) *models.Candidate {
+	var candidate *models.Candidate
+	var err error
+	if match != nil && match.reason != models.DuplicateReasonEmbedding && policy == models.DuplicatePolicyVersion {
+		candidate, err = _this.candidates.CandidateOf(ctx, match.documentID)
+	} else {
+		candidate, err = _this.candidates.NewCandidate(ctx, fullName)
 	}
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to get the candidate of a new document: %v", err)
+		return nil
+	}
+	return candidate
+}
 
-	// Return an immediate response to indicate that processing has started
-	return response, nil
+// waitForBudget holds an ingestion job while the monthly LLM budget is exhausted, showing the upload as queued.
+func (_this *DataProcessingService) waitForBudget(ctx context.Context, uploadID int, name string) error {
+	queued := false
+	err := _this.usageService.WaitForBudget(ctx, func() {
+		queued = true
+		_this.updateUpload(ctx, &models.Upload{ID: uploadID, Status: "Queued", Name: name})
+	})
+	if err == nil && queued {
+		_this.updateUpload(ctx, &models.Upload{ID: uploadID, Status: "Processing", Name: name})
+	}
+	return err
+}
+
+func (_this *DataProcessingService) FailInterruptedUploads(ctx context.Context) {
+	failed, err := _this.uploadRepo.FailStale(_this.db, time.Now().Add(-uploadStaleAfter()),
+		"the server stopped while the upload was processing")
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to mark interrupted uploads as failed: %v", err)
+		return
+	}
+	if failed > 0 {
+		_this.logger.TraceCtx(ctx).Infof("marked %d interrupted uploads as failed", failed)
+	}
 }
 
+// GetAllUploads returns the uploads of the requesting user, whose batches are the ones visibleTo them.
 func (_this *DataProcessingService) GetAllUploads(c *gin.Context) (*meta.BasicResponse, error) {
-	uploads, err := _this.uploadRepo.GetAll(_this.db)
+	uploads, err := _this.uploadRepo.FindByUser(_this.db, usage.UserFrom(c.Request.Context()))
 	if err != nil {
 		ginLogger.Gin(c).Errorf("Failed to retrieve upload records: %v", err)
 		return nil, err
@@ -202,14 +390,7 @@ func (_this *DataProcessingService) GetAllUploads(c *gin.Context) (*meta.BasicRe
 	// Convert uploads to DTOs
 	var uploadsDTO []dtos.UploadDTO
 	for _, upload := range uploads {
-		dto := dtos.UploadDTO{
-			DocumentID: upload.DocumentID,
-			Status:     upload.Status,
-			Name:       upload.Name,
-			CreatedAt:  upload.CreatedAt.Unix(),
-			UUID:       upload.UUID,
-		}
-		uploadsDTO = append(uploadsDTO, dto)
+		uploadsDTO = append(uploadsDTO, toUploadDTO(upload))
 	}
 
 	response := &meta.BasicResponse{
@@ -223,151 +404,156 @@ func (_this *DataProcessingService) GetAllUploads(c *gin.Context) (*meta.BasicRe
 	return response, nil
 }
 
-func fetchLinkedInData(urls []string) ([]dtos.ResumeData, error) {
-	apiUrl := "http://crawler:8000/api/getfulltext/?list_url=" + strings.Join(urls, ",")
-	resp, err := http.Get(apiUrl)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (_this *DataProcessingService) ReindexDocument(ctx context.Context, document elasticsearch.ResumeSummaryDTO, reparse bool) (err error) {
+	ctx, span := tracing.Start(ctx, "ingestion.ReindexDocument")
+	defer tracing.End(span, &err)
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	record, err := _this.resumeRepo.FindByDocumentID(_this.db, document.Id)
+	if err == db.ErrRecordNotFound {
+		// A document indexed before resumes were recorded gets a record holding its current content.
+		record, err = &models.Resume{DocumentID: document.Id}, nil
 	}
-
-	var linkedInResumes struct {
-		Resumes []dtos.ResumeData `json:"resumes"`
-	}
-	err = json.Unmarshal(body, &linkedInResumes)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	// Process each resume to extract the name from the URL
-	for i, resume := range linkedInResumes.Resumes {
-		if name := extractNameFromURL(resume.FileBytes); name != "" {
-			linkedInResumes.Resumes[i].Name = name
+	resume := &document
+	if reparse {
+		if resume, err = _this.reparseResume(ctx, record); err != nil {
+			return err
 		}
+		resume.URL = document.URL
 	}
-
-	return linkedInResumes.Resumes, nil
+	return _this.project(ctx, record, resume)
 }
 
-func extractNameFromURL(url string) string {
-	// Trim the trailing slash if present to ensure proper splitting
-	url = strings.TrimRight(url, "/")
-	parts := strings.Split(url, "/")
-	if len(parts) > 0 {
-		return parts[len(parts)-1] // Return the last part of the URL which is typically the username
+func toUploadDTO(upload models.Upload) dtos.UploadDTO {
+	return dtos.UploadDTO{
+		ID:          upload.ID,
+		BatchID:     upload.BatchID,
+		DocumentID:  upload.DocumentID,
+		Status:      upload.Status,
+		Name:        upload.Name,
+		Error:       upload.Error,
+		CreatedAt:   upload.CreatedAt.Unix(),
+		UUID:        upload.UUID,
+		Source:      upload.Source,
+		Origin:      upload.Origin,
+		DuplicateOf: upload.DuplicateOf,
 	}
-	return ""
 }
 
-func (_this *DataProcessingService) createElkResume(c *gin.Context, fullText string, file string, isLinkedin bool) (*elasticsearch.ElkResumeDTO, error) {
-	prompt := generatePrompt(fullText)
-
-	model := viper.GetString(cfg.ChatGptModel)
-	textEmbeddingModel := viper.GetString(cfg.HuggingfaceModel)
-	awsBucketName := viper.GetString(cfg.AwsBucket)
+// createElkResume parses a resume and returns the document to index, linking to fileURL.
+func (_this *DataProcessingService) createElkResume(ctx context.Context, fullText string, fileURL string) (_ *elasticsearch.ElkResumeDTO, err error) {
+	ctx, span := tracing.Start(ctx, "ingestion.CreateElkResume")
+	defer tracing.End(span, &err)
 
 	// Parse resume text to JSON format by making request to OpenAI
-	responseText, err := _this.gptClient.AskGPT(prompt, model)
+	resumeSummary, err := _this.parseResume(ctx, fullText)
 	if err != nil {
-		ginLogger.Gin(c).Errorf("failed to summarize using GPT: %v", err)
 		return nil, err
 	}
+	resumeSummary.URL = fileURL
 
-	// Upload file to S3 and get the URL
-	key := fmt.Sprintf("%d.pdf", time.Now().Unix())
+	return _this.embedResume(ctx, resumeSummary)
+}
 
-	var fileURL string
+// storeInput keeps the text and file of an upload on its record, so that it can be retried without
+// the original request. The file is stored in S3 under the key it is later indexed with; when it
+// already is, as for a retry, it is read back to fingerprint it, unless its hash is known, as for
+// an import.
+func (_this *DataProcessingService) storeInput(ctx context.Context, uploadID int, resume *dtos.ResumeData, isLinkedin bool) error {
+	awsBucketName := viper.GetString(cfg.AwsBucket)
+	if !isLinkedin && resume.FileKey != "" {
+		if resume.FileBytes == "" && resume.FileHash == "" {
+			fileBytes, err := _this.s3Client.DownloadFile(ctx, awsBucketName, resume.FileKey)
+			if err != nil {
+				return err
+			}
+			resume.FileBytes = base64.StdEncoding.EncodeToString(fileBytes)
+		}
+		return nil
+	}
 
-	if isLinkedin == false {
-		fileBytes, err := base64.StdEncoding.DecodeString(file)
+	if !isLinkedin {
+		fileBytes, err := base64.StdEncoding.DecodeString(resume.FileBytes)
 		if err != nil {
-			ginLogger.Gin(c).Errorf("failed to decode file: %v", err)
-			return nil, err
+			return fmt.Errorf("failed to decode file: %v", err)
 		}
-		fileURL, err = _this.s3Client.UploadFile(c, awsBucketName, key, fileBytes)
-		if err != nil {
-			ginLogger.Gin(c).Errorf("failed to upload file to S3: %v", err)
-			return nil, err
+		if resume.FileKey, err = _this.storeFile(ctx, resume.Name, fileBytes); err != nil {
+			return err
 		}
-	} else {
-		fileURL = file
 	}
-	var resumeSummary elasticsearch.ResumeSummaryDTO
-	if err := json.Unmarshal([]byte(responseText), &resumeSummary); err != nil {
-		ginLogger.Gin(c).Errorf("failed to parse JSON response: %v", err)
-		return nil, err
+	return _this.uploadRepo.Update(_this.db, &models.Upload{ID: uploadID, Content: resume.Content, FileKey: resume.FileKey})
+}
+
+// storeFile stores the file named name in S3 and returns its key. The hash keeps files uploaded in
+// the same second, such as two versions of a resume, from overwriting each other.
+func (_this *DataProcessingService) storeFile(ctx context.Context, name string, fileBytes []byte) (string, error) {
+	// Imported text files keep their extension; anything else is a PDF.
+	ext := ".pdf"
+	if extract.Supported(name) {
+		ext = strings.ToLower(path.Ext(name))
 	}
-	resumeSummary.URL = fileURL
+	fileKey := fmt.Sprintf("%d-%s%s", time.Now().Unix(), dedupe.HashBytes(fileBytes)[:12], ext)
+	if _, err := _this.s3Client.UploadFile(ctx, viper.GetString(cfg.AwsBucket), fileKey, fileBytes); err != nil {
+		return "", err
+	}
+	return fileKey, nil
+}
 
-	embeddingText := generateFulltext(resumeSummary)
+// embedResume computes the embedding of a parsed resume with HUGGINGFACE_MODEL and returns the
+// document to index.
+func (_this *DataProcessingService) embedResume(ctx context.Context, resume *elasticsearch.ResumeSummaryDTO) (*elasticsearch.ElkResumeDTO, error) {
+	textEmbeddingModel := viper.GetString(cfg.HuggingfaceModel)
+
+	embeddingText := generateFulltext(*resume)
 	// Create the vector representation of text
-	vectorEmbedding, err := _this.hfClient.GetTextEmbedding(embeddingText, textEmbeddingModel)
+	if err := _this.limits.Wait(ctx, usage.ProviderHuggingFace, usage.EstimateTokens(embeddingText)); err != nil {
+		return nil, err
+	}
+	vectorEmbedding, err := _this.hfClient.GetTextEmbedding(usage.WithOperation(ctx, usage.OperationResumeEmbedding), embeddingText, textEmbeddingModel)
 	if err != nil {
-		ginLogger.Gin(c).Errorf("failed to get text embedding: %v", err)
+		_this.logger.TraceCtx(ctx).Errorf("failed to get text embedding: %v", err)
 		return nil, err
 	}
+	resume.EmbeddingModel = textEmbeddingModel
 
 	// Prepare the document for Elasticsearch
 	elkResume := &elasticsearch.ElkResumeDTO{
-		Content:   resumeSummary,
+		Content:   *resume,
 		Embedding: vectorEmbedding,
 	}
 
 	return elkResume, nil
 }
 
-func generatePrompt(fullText string) string {
-	var sb strings.Builder
-	sb.WriteString("Full text of the resume:\n\n")
-	sb.WriteString(fullText)
-	sb.WriteString("\n\nPlease transform the above resume text into a well-structured JSON. The JSON should have the following structure and order:\n\n")
-	sb.WriteString(`{
-  "summary": "[Provide a concise professional summary based on the resume. Include key skills and experiences.]",
-  "skills": ["List all relevant skills derived from the resume, each as a separate element in the array."],
-  "basic_info": {
-    "full_name": "[Full name]",
-    "university": "[University name]",
-    "education_level": "[Education level, e.g., BS, MS, PhD, appropriate for the resume context]",
-    "majors": ["A list of majors that align with the professional background and education level]",
-    "GPA": [A GPA as a number that is plausible for the given educational background, or use null if not applicable]
-  },
-  "work_experience": [
-    {
-      "job_title": "[Title of the position]",
-      "company": "[Name of the company]",
-      "location": "[Location of the job]",
-      "duration": "[Duration of the job in years or months, e.g., '2 years']",
-      "job_summary": "[A brief summary of job responsibilities and achievements]"
-    }
-  ],
-  "project_experience": [
-    {
-      "project_name": "[Name of the project]",
-      "project_description": "[A detailed description of the project, including technologies used and outcomes]"
-    }
-  ],
-  "award": [
-    {
-      "award_name": "[Name of any award received, empty array if none]"
-    }
-  ]
-}`)
-	sb.WriteString("\n\nAll details in the 'basic_info' section should be invented but must sound logical and realistic, appropriate for the professional context. Ensure the details are consistent with typical professional and educational backgrounds relevant to the data in the rest of the resume. For other sections, ensure all entries are derived from the resume's content, maintaining consistency and accuracy with the original information. Provide clear, precise language to avoid ambiguities and ensure data types match the expected format.")
-	return sb.String()
-}
-
 func generateFulltext(resume elasticsearch.ResumeSummaryDTO) string {
 	var fullTextContent strings.Builder
 	fullTextContent.WriteString(fmt.Sprintf("Summary: %s; Skills: %v; ", resume.Summary, resume.Skills))
-	fullTextContent.WriteString(fmt.Sprintf("Education: %s, %s, GPA: %.2f; ", resume.BasicInfo.University, resume.BasicInfo.EducationLevel, resume.BasicInfo.GPA))
+	if len(resume.Education) == 0 {
+		fullTextContent.WriteString(fmt.Sprintf("Education: %s, %s, GPA: %s; ", resume.BasicInfo.University, resume.BasicInfo.EducationLevel, formatGPA(resume.BasicInfo.GPA)))
+	} else {
+		fullTextContent.WriteString("Education: ")
+		for _, degree := range resume.Education {
+			fullTextContent.WriteString(fmt.Sprintf("%s in %s, %s, GPA: %s; ", degree.Degree, strings.Join(degree.Majors, ", "), degree.Institution, formatGPA(degree.GPA)))
+		}
+	}
+	if len(resume.Certifications) > 0 {
+		fullTextContent.WriteString("Certifications: ")
+		for _, certification := range resume.Certifications {
+			fullTextContent.WriteString(fmt.Sprintf("%s; ", certification.Name))
+		}
+	}
+	if len(resume.Languages) > 0 {
+		fullTextContent.WriteString("Languages: ")
+		for _, language := range resume.Languages {
+			fullTextContent.WriteString(fmt.Sprintf("%s %s; ", language.Language, language.Proficiency))
+		}
+	}
 	fullTextContent.WriteString("Work Experience: ")
 	for _, work := range resume.WorkExperience {
-		fullTextContent.WriteString(fmt.Sprintf("%s at %s, %s; ", work.JobTitle, work.Company, work.Duration))
+		fullTextContent.WriteString(fmt.Sprintf("%s at %s, %s; ", work.JobTitle, work.Company, elasticsearch.WorkPeriod(work)))
 	}
 	fullTextContent.WriteString("Projects: ")
 	for _, project := range resume.ProjectExperience {
@@ -379,3 +565,39 @@ func generateFulltext(resume elasticsearch.ResumeSummaryDTO) string {
 	}
 	return fullTextContent.String()
 }
+
+// defaultUploadStaleAfter is how long an upload may stay processing or queued when UPLOAD_STALE_AFTER
+// is not set. An upload still running in another process when it is marked failed gets its final
+// status when it completes.
+const defaultUploadStaleAfter = time.Hour
+
+func uploadStaleAfter() time.Duration {
+	if staleAfter := viper.GetDuration(cfg.UploadStaleAfter); staleAfter > 0 {
+		return staleAfter
+	}
+	return defaultUploadStaleAfter
+}
+
+// defaultIngestionConcurrency is how many resumes are ingested at once when INGESTION_CONCURRENCY is not set.
+const defaultIngestionConcurrency = 4
+
+func ingestionConcurrency() int {
+	if concurrency := viper.GetInt(cfg.IngestionConcurrency); concurrency > 0 {
+		return concurrency
+	}
+	return defaultIngestionConcurrency
+}
+
+// providerLimits returns the rate limiters of the providers called while ingesting.
+func providerLimits() ratelimit.Limiters {
+	return ratelimit.NewLimiters(map[string]ratelimit.Limit{
+		usage.ProviderOpenAI: {
+			RequestsPerMinute: viper.GetInt(cfg.OpenAIRequestsPerMinute),
+			TokensPerMinute:   viper.GetInt(cfg.OpenAITokensPerMinute),
+		},
+		usage.ProviderHuggingFace: {
+			RequestsPerMinute: viper.GetInt(cfg.HuggingfaceRequestsPerMinute),
+			TokensPerMinute:   viper.GetInt(cfg.HuggingfaceTokensPerMinute),
+		},
+	})
+}
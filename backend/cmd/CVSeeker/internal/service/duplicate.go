@@ -0,0 +1,404 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/tracing"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/dig"
+	"net/http"
+	"strings"
+)
+
+type IDuplicateService interface {
+	// MergeDocuments combines the document sourceID into targetID and deletes sourceID. Threads,
+	// uploads and duplicate candidates of sourceID are moved to targetID.
+	MergeDocuments(ctx context.Context, sourceID, targetID string) (*elasticsearch.ResumeSummaryDTO, error)
+
+	GetDuplicates(c *gin.Context, status string) (*meta.BasicResponse, error)
+	DismissDuplicate(c *gin.Context, id int64) (*meta.BasicResponse, error)
+	MergeDuplicate(c *gin.Context, id int64) (*meta.BasicResponse, error)
+	VersionDuplicate(c *gin.Context, id int64) (*meta.BasicResponse, error)
+	MergeResumes(c *gin.Context, request dtos.MergeResumesRequest) (*meta.BasicResponse, error)
+}
+
+type DuplicateService struct {
+	db               *db.DB
+	duplicateRepo    repositories.IDuplicateCandidateRepository
+	threadResumeRepo repositories.IThreadResumeRepository
+	uploadRepo       repositories.IUploadRepository
+	resumeRepo       repositories.IResumeRepository
+	elasticClient    elasticsearch.IElasticsearchClient
+	dataProcessing   IDataProcessingService
+	candidates       ICandidateService
+	projections      IProjectionService
+	logger           logger.Logger
+}
+
+type DuplicateServiceArgs struct {
+	dig.In
+	DB               *db.DB `name:"talentAcquisitionDB"`
+	DuplicateRepo    repositories.IDuplicateCandidateRepository
+	ThreadResumeRepo repositories.IThreadResumeRepository
+	UploadRepo       repositories.IUploadRepository
+	ResumeRepo       repositories.IResumeRepository
+	ElasticClient    elasticsearch.IElasticsearchClient
+	DataProcessing   IDataProcessingService
+	Candidates       ICandidateService
+	Projections      IProjectionService
+	Logger           logger.Logger
+}
+
+func NewDuplicateService(args DuplicateServiceArgs) IDuplicateService {
+	return &DuplicateService{
+		db:               args.DB,
+		duplicateRepo:    args.DuplicateRepo,
+		threadResumeRepo: args.ThreadResumeRepo,
+		uploadRepo:       args.UploadRepo,
+		resumeRepo:       args.ResumeRepo,
+		elasticClient:    args.ElasticClient,
+		dataProcessing:   args.DataProcessing,
+		candidates:       args.Candidates,
+		projections:      args.Projections,
+		logger:           args.Logger,
+	}
+}
+
+func (_this *DuplicateService) MergeDocuments(ctx context.Context, sourceID, targetID string) (_ *elasticsearch.ResumeSummaryDTO, err error) {
+	ctx, span := tracing.Start(ctx, "duplicate.MergeDocuments",
+		attribute.String("duplicate.source_id", sourceID), attribute.String("duplicate.target_id", targetID))
+	defer tracing.End(span, &err)
+
+	if sourceID == "" || targetID == "" || sourceID == targetID {
+		return nil, errors.NewCusErr(errors.ErrCommonInvalidRequest)
+	}
+	indexName := viper.GetString(cfg.ElasticsearchDocumentIndex)
+	documents, err := _this.elasticClient.FetchDocumentsByIDs(ctx, indexName, []string{sourceID, targetID})
+	if err != nil {
+		return nil, err
+	}
+	var source, target *elasticsearch.ResumeSummaryDTO
+	for i := range documents {
+		switch documents[i].Id {
+		case sourceID:
+			source = &documents[i]
+		case targetID:
+			target = &documents[i]
+		}
+	}
+	if source == nil || target == nil {
+		return nil, errors.NewCusErr(errors.ErrDuplicateDocumentNotFound)
+	}
+
+	merged := mergeResumes(*target, *source)
+	if err := _this.dataProcessing.ReindexDocument(ctx, merged, false); err != nil {
+		return nil, err
+	}
+
+	// The target now holds the merged content; move everything that referenced the source to it
+	// and delete the source, in one transaction.
+	err = _this.db.Transaction(func(tx *db.DB) error {
+		if err := _this.threadResumeRepo.RepointResume(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := _this.uploadRepo.RepointDocument(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := _this.resumeRepo.RepointDocument(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		if err := _this.duplicateRepo.RepointDocument(tx, sourceID, targetID); err != nil {
+			return err
+		}
+		return _this.projections.DeleteResume(tx, sourceID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	_this.projections.Notify()
+	if err := _this.candidates.RemoveDocument(ctx, sourceID); err != nil {
+		return nil, err
+	}
+
+	_this.logger.TraceCtx(ctx).Infof("merged document %s into %s", sourceID, targetID)
+	return &merged, nil
+}
+
+func (_this *DuplicateService) GetDuplicates(c *gin.Context, status string) (*meta.BasicResponse, error) {
+	candidates, err := _this.duplicateRepo.GetByStatus(_this.db, status)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get duplicate candidates: %v", err)
+		return nil, err
+	}
+
+	// Show the candidate names, so that the queue can be reviewed without opening each document.
+	names := make(map[string]string)
+	var documentIDs []string
+	for _, candidate := range candidates {
+		for _, documentID := range []string{candidate.DocumentID, candidate.DuplicateOf} {
+			if _, seen := names[documentID]; !seen {
+				names[documentID] = ""
+				documentIDs = append(documentIDs, documentID)
+			}
+		}
+	}
+	if len(documentIDs) > 0 {
+		documents, err := _this.elasticClient.FetchDocumentsByIDs(c, viper.GetString(cfg.ElasticsearchDocumentIndex), documentIDs)
+		if err != nil {
+			ginLogger.Gin(c).Warningf("failed to get the names of duplicate documents: %v", err)
+		}
+		for _, document := range documents {
+			names[document.Id] = document.BasicInfo.FullName
+		}
+	}
+
+	candidateDTOs := make([]dtos.DuplicateCandidateDTO, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidateDTO := toDuplicateCandidateDTO(candidate)
+		candidateDTO.DocumentName = names[candidate.DocumentID]
+		candidateDTO.DuplicateOfName = names[candidate.DuplicateOf]
+		candidateDTOs = append(candidateDTOs, candidateDTO)
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Duplicate candidates retrieved successfully",
+		},
+		Data: candidateDTOs,
+	}
+	return response, nil
+}
+
+func (_this *DuplicateService) DismissDuplicate(c *gin.Context, id int64) (*meta.BasicResponse, error) {
+	if _, err := _this.pendingCandidate(id); err != nil {
+		return nil, err
+	}
+	resolved, err := _this.duplicateRepo.Resolve(_this.db, id, models.DuplicateStatusDismissed)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to dismiss duplicate candidate %d: %v", id, err)
+		return nil, err
+	}
+	if !resolved {
+		return nil, errors.NewCusErr(errors.ErrDuplicateResolved)
+	}
+	candidate, err := _this.duplicateRepo.FindByID(_this.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Duplicate candidate dismissed",
+		},
+		Data: toDuplicateCandidateDTO(*candidate),
+	}
+	return response, nil
+}
+
+func (_this *DuplicateService) MergeDuplicate(c *gin.Context, id int64) (*meta.BasicResponse, error) {
+	candidate, err := _this.pendingCandidate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The later upload is merged into the document it duplicates.
+	merged, err := _this.MergeDocuments(c, candidate.DocumentID, candidate.DuplicateOf)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to merge duplicate candidate %d: %v", id, err)
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Duplicate documents merged",
+		},
+		Data: merged,
+	}
+	return response, nil
+}
+
+func (_this *DuplicateService) VersionDuplicate(c *gin.Context, id int64) (*meta.BasicResponse, error) {
+	candidate, err := _this.pendingCandidate(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The later upload becomes the latest version of the candidate of the document it duplicates.
+	if err := _this.candidates.MoveDocument(c, candidate.DocumentID, candidate.DuplicateOf); err != nil {
+		ginLogger.Gin(c).Errorf("failed to keep duplicate candidate %d as a version: %v", id, err)
+		return nil, err
+	}
+	if _, err := _this.duplicateRepo.Resolve(_this.db, id, models.DuplicateStatusVersioned); err != nil {
+		ginLogger.Gin(c).Errorf("failed to resolve duplicate candidate %d: %v", id, err)
+		return nil, err
+	}
+	candidate, err = _this.duplicateRepo.FindByID(_this.db, id)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Duplicate kept as a new version",
+		},
+		Data: toDuplicateCandidateDTO(*candidate),
+	}
+	return response, nil
+}
+
+func (_this *DuplicateService) MergeResumes(c *gin.Context, request dtos.MergeResumesRequest) (*meta.BasicResponse, error) {
+	merged, err := _this.MergeDocuments(c, request.SourceID, request.TargetID)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to merge document %s into %s: %v", request.SourceID, request.TargetID, err)
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Documents merged",
+		},
+		Data: merged,
+	}
+	return response, nil
+}
+
+func (_this *DuplicateService) pendingCandidate(id int64) (*models.DuplicateCandidate, error) {
+	candidate, err := _this.duplicateRepo.FindByID(_this.db, id)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrDuplicateNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if candidate.Status != models.DuplicateStatusPending {
+		return nil, errors.NewCusErr(errors.ErrDuplicateResolved)
+	}
+	return candidate, nil
+}
+
+// mergeResumes combines two parsed resumes of the same person. Values of target win; source fills
+// the basic info target lacks and adds the skills, experience, projects and awards target does not list.
+func mergeResumes(target, source elasticsearch.ResumeSummaryDTO) elasticsearch.ResumeSummaryDTO {
+	merged := target
+	if merged.Summary == "" {
+		merged.Summary = source.Summary
+	}
+
+	var evidence, sourceEvidence elasticsearch.BasicInfoEvidence
+	if target.BasicInfoEvidence != nil {
+		evidence = *target.BasicInfoEvidence
+	}
+	if source.BasicInfoEvidence != nil {
+		sourceEvidence = *source.BasicInfoEvidence
+	}
+	info, sourceInfo := &merged.BasicInfo, source.BasicInfo
+	if info.FullName == "" && sourceInfo.FullName != "" {
+		info.FullName, evidence.FullName = sourceInfo.FullName, sourceEvidence.FullName
+	}
+	if info.University == "" && sourceInfo.University != "" {
+		info.University, evidence.University = sourceInfo.University, sourceEvidence.University
+	}
+	if info.EducationLevel == "" && sourceInfo.EducationLevel != "" {
+		info.EducationLevel, evidence.EducationLevel = sourceInfo.EducationLevel, sourceEvidence.EducationLevel
+	}
+	if info.GPA == nil && sourceInfo.GPA != nil {
+		info.GPA, evidence.GPA = sourceInfo.GPA, sourceEvidence.GPA
+	}
+	if len(info.Majors) == 0 {
+		evidence.Majors = sourceEvidence.Majors
+	}
+	info.Majors = unionFold(info.Majors, sourceInfo.Majors)
+	if evidence != (elasticsearch.BasicInfoEvidence{}) {
+		merged.BasicInfoEvidence = &evidence
+	}
+
+	merged.Skills = unionFold(target.Skills, source.Skills)
+
+	merged.WorkExperience = append([]elasticsearch.WorkExperience(nil), target.WorkExperience...)
+	seen := make(map[string]bool)
+	for _, work := range target.WorkExperience {
+		seen[strings.ToLower(work.JobTitle+"|"+work.Company+"|"+work.Duration)] = true
+	}
+	for _, work := range source.WorkExperience {
+		if key := strings.ToLower(work.JobTitle + "|" + work.Company + "|" + work.Duration); !seen[key] {
+			seen[key] = true
+			merged.WorkExperience = append(merged.WorkExperience, work)
+		}
+	}
+
+	merged.ProjectExperience = append([]elasticsearch.ProjectExperience(nil), target.ProjectExperience...)
+	seen = make(map[string]bool)
+	for _, project := range target.ProjectExperience {
+		seen[strings.ToLower(project.ProjectName)] = true
+	}
+	for _, project := range source.ProjectExperience {
+		if key := strings.ToLower(project.ProjectName); !seen[key] {
+			seen[key] = true
+			merged.ProjectExperience = append(merged.ProjectExperience, project)
+		}
+	}
+
+	merged.Award = append([]elasticsearch.Award(nil), target.Award...)
+	seen = make(map[string]bool)
+	for _, award := range target.Award {
+		seen[strings.ToLower(award.AwardName)] = true
+	}
+	for _, award := range source.Award {
+		if key := strings.ToLower(award.AwardName); !seen[key] {
+			seen[key] = true
+			merged.Award = append(merged.Award, award)
+		}
+	}
+
+	if merged.URL == "" {
+		merged.URL = source.URL
+	}
+	return merged
+}
+
+// unionFold returns the values of a followed by those of b, without case-insensitive repeats.
+func unionFold(a, b []string) []string {
+	var union []string
+	seen := make(map[string]bool)
+	for _, value := range append(append([]string(nil), a...), b...) {
+		if key := strings.ToLower(strings.TrimSpace(value)); key != "" && !seen[key] {
+			seen[key] = true
+			union = append(union, value)
+		}
+	}
+	return union
+}
+
+func toDuplicateCandidateDTO(candidate models.DuplicateCandidate) dtos.DuplicateCandidateDTO {
+	candidateDTO := dtos.DuplicateCandidateDTO{
+		ID:          candidate.ID,
+		DocumentID:  candidate.DocumentID,
+		DuplicateOf: candidate.DuplicateOf,
+		UploadID:    candidate.UploadID,
+		Reason:      candidate.Reason,
+		Score:       candidate.Score,
+		Status:      candidate.Status,
+		CreatedAt:   candidate.CreatedAt.Unix(),
+	}
+	if candidate.ResolvedAt != nil {
+		resolvedAt := candidate.ResolvedAt.Unix()
+		candidateDTO.ResolvedAt = &resolvedAt
+	}
+	return candidateDTO
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/dedupe"
+	"context"
+	"encoding/base64"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+// defaultDuplicateSimilarity is the kNN score from which an upload is queued for review when
+// DUPLICATE_SIMILARITY_THRESHOLD is not set. Scores are (1 + cosine similarity) / 2.
+const defaultDuplicateSimilarity = 0.97
+
+// duplicateMatch is an indexed document that an upload duplicates.
+type duplicateMatch struct {
+	documentID string
+	reason     string
+	score      float64
+}
+
+// resumeFingerprint computes the fingerprint known before parsing. The file hash of a LinkedIn
+// resume is the hash of its profile URL.
+func resumeFingerprint(resume dtos.ResumeData, isLinkedin bool) dedupe.Fingerprint {
+	fingerprint := dedupe.Fingerprint{
+		TextHash: dedupe.HashText(resume.Content),
+		Emails:   dedupe.Emails(resume.Content),
+		Phones:   dedupe.Phones(resume.Content),
+	}
+	if isLinkedin {
+		fingerprint.FileHash = dedupe.HashBytes([]byte(strings.TrimRight(resume.FileBytes, "/")))
+	} else {
+		fingerprint.FileHash = resumeFileHash(resume)
+	}
+	return fingerprint
+}
+
+// resumeFileHash returns the hash of the file of resume, or "" when it is not known.
+func resumeFileHash(resume dtos.ResumeData) string {
+	if resume.FileHash != "" {
+		return resume.FileHash
+	}
+	fileBytes, err := base64.StdEncoding.DecodeString(resume.FileBytes)
+	if err != nil || len(fileBytes) == 0 {
+		return ""
+	}
+	return dedupe.HashBytes(fileBytes)
+}
+
+// findExactDuplicate returns the indexed document with the same file or text, if any. Lookup
+// errors are logged and do not stop the upload.
+func (_this *DataProcessingService) findExactDuplicate(ctx context.Context, fingerprint dedupe.Fingerprint) *duplicateMatch {
+	resume, err := _this.resumeRepo.FindByHash(_this.db, fingerprint.FileHash, fingerprint.TextHash)
+	if err != nil {
+		if err != db.ErrRecordNotFound {
+			_this.logger.TraceCtx(ctx).Errorf("failed to look up duplicate hashes: %v", err)
+		}
+		return nil
+	}
+	if !_this.documentExists(ctx, resume.DocumentID) {
+		return nil
+	}
+
+	reason := models.DuplicateReasonTextHash
+	if fingerprint.FileHash != "" && resume.FileHash == fingerprint.FileHash {
+		reason = models.DuplicateReasonFileHash
+	}
+	return &duplicateMatch{documentID: resume.DocumentID, reason: reason, score: 1}
+}
+
+// findDuplicate returns the indexed document of the same candidate, recognised by name and contact
+// details, or else the most similar document when it scores above DUPLICATE_SIMILARITY_THRESHOLD.
+func (_this *DataProcessingService) findDuplicate(ctx context.Context, fingerprint dedupe.Fingerprint, embedding []float32) *duplicateMatch {
+	if fingerprint.NameKey != "" {
+		resumes, err := _this.resumeRepo.FindByNameKey(_this.db, fingerprint.NameKey)
+		if err != nil {
+			_this.logger.TraceCtx(ctx).Errorf("failed to look up duplicate names: %v", err)
+		}
+		for _, resume := range resumes {
+			if fingerprint.SameCandidate(storedFingerprint(resume)) && _this.documentExists(ctx, resume.DocumentID) {
+				return &duplicateMatch{documentID: resume.DocumentID, reason: models.DuplicateReasonContact, score: 1}
+			}
+		}
+	}
+
+	threshold := duplicateSimilarity()
+	if threshold > 1 || len(embedding) == 0 {
+		return nil
+	}
+	similar, err := _this.elasticClient.VectorSearch(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), embedding)
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to look up similar documents: %v", err)
+		return nil
+	}
+	if len(similar) == 0 || similar[0].Point < threshold {
+		return nil
+	}
+	return &duplicateMatch{documentID: similar[0].Id, reason: models.DuplicateReasonEmbedding, score: similar[0].Point}
+}
+
+// skipDuplicate records an upload that was not indexed because the document already exists.
+func (_this *DataProcessingService) skipDuplicate(ctx context.Context, uploadID int, name string, match *duplicateMatch) {
+	_this.recordDuplicate(ctx, uploadID, match.documentID, match, models.DuplicateStatusSkipped)
+	_this.updateUpload(ctx, &models.Upload{ID: uploadID, DocumentID: match.documentID, Status: "Duplicate", Name: name})
+	if err := _this.uploadRepo.LinkDocument(_this.db, uploadID, match.documentID); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to link the uploads of upload %d to document %s: %v", uploadID, match.documentID, err)
+	}
+	_this.logger.TraceCtx(ctx).Infof("upload %d skipped as a duplicate of document %s (%s)", uploadID, match.documentID, match.reason)
+}
+
+// recordDuplicate stores how a duplicate upload was handled. Failing to store it does not fail the upload.
+func (_this *DataProcessingService) recordDuplicate(ctx context.Context, uploadID int, documentID string, match *duplicateMatch, status string) {
+	err := _this.duplicateRepo.Create(_this.db, &models.DuplicateCandidate{
+		DocumentID:  documentID,
+		DuplicateOf: match.documentID,
+		UploadID:    uploadID,
+		Reason:      match.reason,
+		Score:       match.score,
+		Status:      status,
+	})
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to record document %s as a duplicate of %s: %v", documentID, match.documentID, err)
+	}
+}
+
+// documentExists reports whether a document is still indexed; a record may have lost its projection,
+// and the fingerprints of merged documents stay in MySQL.
+func (_this *DataProcessingService) documentExists(ctx context.Context, documentID string) bool {
+	_, err := _this.elasticClient.GetDocumentByID(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), documentID)
+	return err == nil
+}
+
+func storedFingerprint(resume models.Resume) dedupe.Fingerprint {
+	return dedupe.Fingerprint{
+		FileHash: resume.FileHash,
+		TextHash: resume.TextHash,
+		NameKey:  resume.NameKey,
+		Emails:   splitList(resume.Emails),
+		Phones:   splitList(resume.Phones),
+	}
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// duplicatePolicy returns the policy requested for an upload, or DUPLICATE_POLICY.
+func duplicatePolicy(requested string) string {
+	if models.ValidDuplicatePolicy(requested) {
+		return requested
+	}
+	if policy := viper.GetString(cfg.DuplicatePolicy); models.ValidDuplicatePolicy(policy) {
+		return policy
+	}
+	return models.DuplicatePolicyReview
+}
+
+func duplicateSimilarity() float64 {
+	if threshold := viper.GetFloat64(cfg.DuplicateSimilarity); threshold > 0 {
+		return threshold
+	}
+	return defaultDuplicateSimilarity
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	pkgCfg "CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/elasticsearch"
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.uber.org/dig"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// readinessCheckTimeout bounds each dependency check so a hung dependency cannot stall the probe.
+const readinessCheckTimeout = 2 * time.Second
+
+// requiredConfigKeys must be set for the service to handle uploads, search and chat.
+var requiredConfigKeys = []string{
+	pkgCfg.ElasticsearchUrl,
+	cfg.ElasticsearchDocumentIndex,
+	pkgCfg.GptApiKey,
+	cfg.ChatGptModel,
+	cfg.DefaultOpenAIAssistant,
+	pkgCfg.HuggingfaceApiKey,
+	cfg.HuggingfaceModel,
+	cfg.AwsBucket,
+}
+
+type IHealthService interface {
+	Liveness() *dtos.HealthDTO
+	Readiness(ctx context.Context) (*dtos.HealthDTO, bool)
+	// SetDraining makes readiness fail so that load balancers stop routing new traffic during shutdown.
+	SetDraining()
+}
+
+type HealthService struct {
+	db            *db.DB
+	elasticClient elasticsearch.IElasticsearchClient
+	projections   IProjectionService
+	draining      atomic.Bool
+}
+
+type HealthServiceArgs struct {
+	dig.In
+	DB            *db.DB `name:"talentAcquisitionDB"`
+	ElasticClient elasticsearch.IElasticsearchClient
+	Projections   IProjectionService
+}
+
+func NewHealthService(args HealthServiceArgs) IHealthService {
+	return &HealthService{
+		db:            args.DB,
+		elasticClient: args.ElasticClient,
+		projections:   args.Projections,
+	}
+}
+
+func (_this *HealthService) Liveness() *dtos.HealthDTO {
+	return &dtos.HealthDTO{Status: dtos.HealthStatusUp}
+}
+
+func (_this *HealthService) Readiness(ctx context.Context) (*dtos.HealthDTO, bool) {
+	if _this.draining.Load() {
+		return &dtos.HealthDTO{
+			Status: dtos.HealthStatusDown,
+			Checks: []dtos.HealthCheckDTO{{Name: "server", Status: dtos.HealthStatusDown, Error: "shutting down"}},
+		}, false
+	}
+
+	checks := []dtos.HealthCheckDTO{
+		runHealthCheck(ctx, "mysql", func(ctx context.Context) error {
+			return _this.db.DB().DB().PingContext(ctx)
+		}),
+		runHealthCheck(ctx, "elasticsearch", _this.elasticClient.Ping),
+		// Without the resume mapping, filters and sorts on the fields it maps do not work.
+		runHealthCheck(ctx, "resume_mapping", func(context.Context) error {
+			return _this.projections.MappingError()
+		}),
+		runHealthCheck(ctx, "config", func(context.Context) error {
+			return checkRequiredConfig()
+		}),
+	}
+
+	ready := true
+	for _, check := range checks {
+		if check.Status != dtos.HealthStatusUp {
+			ready = false
+		}
+	}
+
+	resp := &dtos.HealthDTO{Status: dtos.HealthStatusUp, Checks: checks}
+	if !ready {
+		resp.Status = dtos.HealthStatusDown
+	}
+	return resp, ready
+}
+
+func (_this *HealthService) SetDraining() {
+	_this.draining.Store(true)
+}
+
+func runHealthCheck(ctx context.Context, name string, check func(ctx context.Context) error) dtos.HealthCheckDTO {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	if err := check(ctx); err != nil {
+		return dtos.HealthCheckDTO{Name: name, Status: dtos.HealthStatusDown, Error: err.Error()}
+	}
+	return dtos.HealthCheckDTO{Name: name, Status: dtos.HealthStatusUp}
+}
+
+func checkRequiredConfig() error {
+	var missing []string
+	memoryBackend := strings.EqualFold(viper.GetString(pkgCfg.ElasticsearchBackend), elasticsearch.BackendMemory)
+	for _, key := range requiredConfigKeys {
+		// The in-memory search backend does not connect to a cluster.
+		if key == pkgCfg.ElasticsearchUrl && memoryBackend {
+			continue
+		}
+		if viper.GetString(key) == "" {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing configuration: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/dedupe"
+	"CVSeeker/pkg/usage"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// IdempotencyKeyHeader is the request header a client sets to make an upload request safe to send again.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// replayedHeader marks the responses that return the batch of an earlier request.
+const replayedHeader = "Idempotent-Replayed"
+
+// defaultUploadDedupeWindow is used when UPLOAD_DEDUPE_WINDOW is not set.
+const defaultUploadDedupeWindow = 10 * time.Minute
+
+// idempotencyKey returns the key a request is recognised by when it is sent again: the
+// Idempotency-Key header, or else the uuids of its resumes when they all have one. It returns ""
+// for a request that cannot be recognised.
+func idempotencyKey(header string, resumes []dtos.ResumeData) string {
+	if header = strings.TrimSpace(header); header != "" {
+		return header
+	}
+	uuids := make([]string, len(resumes))
+	for i, resume := range resumes {
+		if resume.UUID == "" {
+			return ""
+		}
+		uuids[i] = resume.UUID
+	}
+	if len(uuids) == 1 {
+		return "uuid:" + uuids[0]
+	}
+	sort.Strings(uuids)
+	return "uuids:" + dedupe.HashBytes([]byte(strings.Join(uuids, ",")))
+}
+
+// requestHash identifies what a request submits, to tell a request sent again from another one
+// reusing its key.
+func requestHash(source string, resumes []dtos.ResumeData) string {
+	encoded, _ := json.Marshal(struct {
+		Source  string            `json:"source"`
+		Resumes []dtos.ResumeData `json:"resumes"`
+	}{source, resumes})
+	return dedupe.HashBytes(encoded)
+}
+
+// replayBatch returns the batch the requesting user submitted with the idempotency key of batch, or
+// nil when there is none. ErrBatchKeyReused is returned when the batch was submitted by another request.
+func (_this *DataProcessingService) replayBatch(c *gin.Context, batch *models.Batch) (*meta.BasicResponse, error) {
+	original, err := _this.batchRepo.FindByIdempotencyKey(_this.db, usage.UserFrom(c.Request.Context()), *batch.IdempotencyKey)
+	if err == db.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to look up the batch of idempotency key %s: %v", *batch.IdempotencyKey, err)
+		return nil, err
+	}
+	if original.RequestHash != batch.RequestHash {
+		return nil, errors.NewCusErr(errors.ErrBatchKeyReused)
+	}
+
+	batchDTO, err := _this.batchWithUploads(c, original)
+	if err != nil {
+		return nil, err
+	}
+	c.Header(replayedHeader, "true")
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "The request was already received; its batch is returned",
+		},
+		Data: batchDTO,
+	}, nil
+}
+
+// recentFiles links the uploads of a batch to the uploads of the same file submitted within
+// UPLOAD_DEDUPE_WINDOW, earlier in the batch or before it.
+type recentFiles struct {
+	uploadRepo repositories.IUploadRepository
+	tx         *db.DB
+	since      time.Time
+	// uploads holds the upload of each file of the batch that is not linked to another.
+	uploads map[string]*models.Upload
+}
+
+func newRecentFiles(uploadRepo repositories.IUploadRepository, tx *db.DB) *recentFiles {
+	return &recentFiles{
+		uploadRepo: uploadRepo,
+		tx:         tx,
+		since:      time.Now().Add(-uploadDedupeWindow()),
+		uploads:    map[string]*models.Upload{},
+	}
+}
+
+// link sets fileHash, the hash of the file of upload, on upload, and marks upload as a duplicate of
+// the upload of the same file when there is one. The document of an original still being ingested
+// is set on its linked uploads when it is known (see IUploadRepository.LinkDocument).
+func (_this *recentFiles) link(upload *models.Upload, fileHash string) error {
+	if fileHash == "" {
+		return nil
+	}
+	upload.FileHash = fileHash
+	if uploadDedupeWindow() <= 0 {
+		return nil
+	}
+
+	original := _this.uploads[upload.FileHash]
+	if original == nil {
+		var err error
+		original, err = _this.uploadRepo.FindRecentByFileHash(_this.tx, upload.FileHash, _this.since)
+		if err == db.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	upload.Status, upload.DuplicateOf = "Duplicate", &original.ID
+	upload.DocumentID = original.DocumentID
+	return nil
+}
+
+// add records upload as the upload of its file, unless it is linked to another.
+func (_this *recentFiles) add(upload *models.Upload) {
+	if upload.FileHash != "" && upload.DuplicateOf == nil {
+		_this.uploads[upload.FileHash] = upload
+	}
+}
+
+func uploadDedupeWindow() time.Duration {
+	if !viper.IsSet(cfg.UploadDedupeWindow) {
+		return defaultUploadDedupeWindow
+	}
+	return viper.GetDuration(cfg.UploadDedupeWindow)
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/dedupe"
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	cases := map[string]struct {
+		header  string
+		resumes []dtos.ResumeData
+		want    string
+	}{
+		"header":               {header: " key-1 ", resumes: []dtos.ResumeData{{UUID: "a"}}, want: "key-1"},
+		"uuid fallback":        {resumes: []dtos.ResumeData{{UUID: "a"}}, want: "uuid:a"},
+		"uuids fallback":       {resumes: []dtos.ResumeData{{UUID: "b"}, {UUID: "a"}}, want: "uuids:" + dedupe.HashBytes([]byte("a,b"))},
+		"a resume without one": {resumes: []dtos.ResumeData{{UUID: "a"}, {}}, want: ""},
+		"blank header":         {header: "  ", resumes: []dtos.ResumeData{{}}, want: ""},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.want, idempotencyKey(c.header, c.resumes))
+		})
+	}
+
+	// The uuids of a request are its key whatever their order.
+	assert.Equal(t,
+		idempotencyKey("", []dtos.ResumeData{{UUID: "a"}, {UUID: "b"}}),
+		idempotencyKey("", []dtos.ResumeData{{UUID: "b"}, {UUID: "a"}}))
+}
+
+func TestRequestHash(t *testing.T) {
+	resumes := []dtos.ResumeData{{Name: "a.pdf", UUID: "a", FileBytes: "YQ=="}}
+	hash := requestHash("upload", resumes)
+
+	cases := map[string]struct {
+		source  string
+		resumes []dtos.ResumeData
+		same    bool
+	}{
+		"sent again":     {source: "upload", resumes: []dtos.ResumeData{{Name: "a.pdf", UUID: "a", FileBytes: "YQ=="}}, same: true},
+		"another file":   {source: "upload", resumes: []dtos.ResumeData{{Name: "a.pdf", UUID: "a", FileBytes: "Yg=="}}},
+		"another name":   {source: "upload", resumes: []dtos.ResumeData{{Name: "b.pdf", UUID: "a", FileBytes: "YQ=="}}},
+		"another source": {source: "batch", resumes: resumes},
+		"another resume": {source: "upload", resumes: append(resumes, dtos.ResumeData{UUID: "b"})},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.same, requestHash(c.source, c.resumes) == hash)
+		})
+	}
+}
+
+// recentUploads is an IUploadRepository holding the uploads FindRecentByFileHash looks through.
+type recentUploads struct {
+	repositories.IUploadRepository
+	uploads []models.Upload
+}
+
+// FindRecentByFileHash leaves out the uploads the query of the repository leaves out.
+func (_this *recentUploads) FindRecentByFileHash(_ *db.DB, fileHash string, since time.Time) (*models.Upload, error) {
+	for i := len(_this.uploads) - 1; i >= 0; i-- {
+		upload := _this.uploads[i]
+		if upload.FileHash == fileHash && !upload.CreatedAt.Before(since) && upload.DuplicateOf == nil &&
+			upload.Status != "Failed" && upload.Status != "Cancelled" {
+			return &upload, nil
+		}
+	}
+	return nil, db.ErrRecordNotFound
+}
+
+func TestRecentFiles(t *testing.T) {
+	viper.Set(cfg.UploadDedupeWindow, 10*time.Minute)
+	t.Cleanup(func() { viper.Set(cfg.UploadDedupeWindow, nil) })
+
+	hash := dedupe.HashBytes([]byte("resume"))
+	earlier := func(status string, age time.Duration) models.Upload {
+		return models.Upload{ID: 7, Status: status, DocumentID: "doc-7", FileHash: hash, CreatedAt: time.Now().Add(-age)}
+	}
+
+	cases := map[string]struct {
+		earlier []models.Upload
+		linked  bool
+	}{
+		"no earlier upload":          {},
+		"inside the window":          {earlier: []models.Upload{earlier("Success", time.Minute)}, linked: true},
+		"still being ingested":       {earlier: []models.Upload{earlier("Processing", time.Minute)}, linked: true},
+		"outside the window":         {earlier: []models.Upload{earlier("Success", time.Hour)}},
+		"the original failed":        {earlier: []models.Upload{earlier("Failed", time.Minute)}},
+		"the original was cancelled": {earlier: []models.Upload{earlier("Cancelled", time.Minute)}},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			files := newRecentFiles(&recentUploads{uploads: c.earlier}, nil)
+			upload := &models.Upload{Status: "Queued"}
+			require.NoError(t, files.link(upload, hash))
+
+			assert.Equal(t, hash, upload.FileHash)
+			if !c.linked {
+				assert.Equal(t, "Queued", upload.Status)
+				assert.Nil(t, upload.DuplicateOf)
+				return
+			}
+			assert.Equal(t, "Duplicate", upload.Status)
+			require.NotNil(t, upload.DuplicateOf)
+			assert.Equal(t, 7, *upload.DuplicateOf)
+			assert.Equal(t, "doc-7", upload.DocumentID)
+		})
+	}
+}
+
+func TestRecentFiles_WithinABatch(t *testing.T) {
+	viper.Set(cfg.UploadDedupeWindow, 10*time.Minute)
+	t.Cleanup(func() { viper.Set(cfg.UploadDedupeWindow, nil) })
+
+	files := newRecentFiles(&recentUploads{}, nil)
+	submit := func(id int, content string) *models.Upload {
+		upload := &models.Upload{Status: "Queued"}
+		file := dtos.ResumeData{FileBytes: base64.StdEncoding.EncodeToString([]byte(content))}
+		require.NoError(t, files.link(upload, resumeFileHash(file)))
+		upload.ID = id
+		files.add(upload)
+		return upload
+	}
+
+	first := submit(1, "resume")
+	other := submit(2, "another resume")
+	second := submit(3, "resume")
+	third := submit(4, "resume")
+
+	assert.Nil(t, first.DuplicateOf)
+	assert.Nil(t, other.DuplicateOf)
+	// The copies of a file are linked to its first upload, not to one another.
+	for _, upload := range []*models.Upload{second, third} {
+		assert.Equal(t, "Duplicate", upload.Status)
+		require.NotNil(t, upload.DuplicateOf)
+		assert.Equal(t, first.ID, *upload.DuplicateOf)
+	}
+
+	// Without a window, files are hashed but not linked.
+	viper.Set(cfg.UploadDedupeWindow, time.Duration(0))
+	files = newRecentFiles(&recentUploads{}, nil)
+	submit(1, "resume")
+	unlinked := submit(2, "resume")
+	assert.Equal(t, "Queued", unlinked.Status)
+	assert.NotEmpty(t, unlinked.FileHash)
+}
+
+// endedUploads is an IUploadRepository recording how uploads are ended.
+type endedUploads struct {
+	repositories.IUploadRepository
+	failed    []models.Upload
+	cancelled []int
+	linked    []int
+}
+
+func (_this *endedUploads) Update(_ *db.DB, upload *models.Upload) error {
+	_this.failed = append(_this.failed, *upload)
+	return nil
+}
+
+func (_this *endedUploads) MarkCancelled(_ *db.DB, ids []int, _ string) (int64, error) {
+	_this.cancelled = append(_this.cancelled, ids...)
+	return int64(len(ids)), nil
+}
+
+func (_this *endedUploads) FailLinked(_ *db.DB, ids []int) error {
+	_this.linked = append(_this.linked, ids...)
+	return nil
+}
+
+func TestFailUpload_EndsTheLinkedUploads(t *testing.T) {
+	uploads := &endedUploads{}
+	service := &DataProcessingService{uploadRepo: uploads}
+
+	service.failUpload(context.Background(), 7, "a.pdf", "failed to parse the resume")
+	require.Len(t, uploads.failed, 1)
+	assert.Equal(t, "Failed", uploads.failed[0].Status)
+	assert.Equal(t, []int{7}, uploads.linked)
+
+	// A cancelled upload is marked by MarkCancelled, which ends its linked uploads with it.
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errUploadCancelled)
+	service.failUpload(ctx, 8, "b.pdf", "the upload was interrupted")
+	assert.Equal(t, []int{8}, uploads.cancelled)
+	assert.Equal(t, []int{7}, uploads.linked)
+}
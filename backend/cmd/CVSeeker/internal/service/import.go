@@ -0,0 +1,423 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/archive"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/dedupe"
+	"CVSeeker/pkg/extract"
+	"CVSeeker/pkg/sources"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Formats of the report of an import.
+const (
+	ImportReportCSV  = "csv"
+	ImportReportJSON = "json"
+)
+
+const (
+	defaultImportMaxFiles    = 500
+	defaultImportMaxFileSize = 10
+	// maxImportFileName and maxOrigin are the lengths of the columns file names and origins are kept in.
+	maxImportFileName = 255
+	maxOrigin         = 1024
+)
+
+// ImportArchive reads the files of a ZIP or tar.gz archive one at a time and ingests the resumes
+// among them as one batch, with an upload for each. Files of other types, without extractable
+// text, larger than IMPORT_MAX_FILE_SIZE_MB, beyond IMPORT_MAX_FILES or copies of an earlier file
+// of the archive are skipped; onDuplicate applies to the resumes already indexed. Each resume is
+// stored in S3 once it is read, so that only its text is held until it is ingested. What became
+// of each file is recorded on the import, whose report lists it.
+func (_this *DataProcessingService) ImportArchive(ctx context.Context, fileName string, r io.ReaderAt, size int64, onDuplicate string) (*dtos.ImportDTO, error) {
+	// The batch outlives the request, so it keeps the request's trace and user but not its cancellation.
+	ctx = tracing.Detach(ctx)
+	if err := _this.usageService.CheckBudget(ctx, true); err != nil {
+		return nil, err
+	}
+
+	files := newImportFiles(onDuplicate, _this.fileStore(ctx))
+	if err := readArchive(r, size, files); err != nil {
+		_this.logger.TraceCtx(ctx).Warnf("failed to read archive %s: %v", fileName, err)
+		return nil, errors.NewCusErr(errors.ErrImportInvalidArchive)
+	}
+	return _this.recordImport(ctx, "", fileName, models.BatchSourceImport, files)
+}
+
+// IngestDelivery ingests the files of a delivery of the ingestion source named source as one batch,
+// skipping them for the reasons an archive's files are, and records it as an import. Files the
+// source could not read are skipped with their error. A delivery without files is not recorded and
+// nil is returned.
+func (_this *DataProcessingService) IngestDelivery(ctx context.Context, source string, delivery sources.Delivery) (*dtos.ImportDTO, error) {
+	if len(delivery.Files) == 0 {
+		return nil, nil
+	}
+	if err := _this.usageService.CheckBudget(ctx, true); err != nil {
+		return nil, err
+	}
+
+	files := newImportFiles("", _this.fileStore(ctx))
+	batchSource := models.BatchSourceFolder
+	for _, file := range delivery.Files {
+		if file.Kind == sources.KindMail {
+			batchSource = models.BatchSourceMail
+		}
+		if file.Err != nil {
+			files.skip(file.Name, file.Err.Error())
+			continue
+		}
+		if reason := files.skipReason(file.Name); reason != "" {
+			files.skip(file.Name, reason)
+			continue
+		}
+		files.add(file.Name, file.Data, file.Kind, file.Origin)
+	}
+	return _this.recordImport(ctx, source, delivery.ID, batchSource, files)
+}
+
+// recordImport records the files of an import, with a batch of the resumes among them when there are
+// any, and starts the batch.
+func (_this *DataProcessingService) recordImport(ctx context.Context, source, fileName, batchSource string, files *importFiles) (*dtos.ImportDTO, error) {
+	entries, resumes := files.entries, files.resumes
+	imp := &models.Import{
+		UserID:   usage.UserFrom(ctx),
+		Source:   truncate(source, maxImportFileName),
+		FileName: truncate(fileName, maxImportFileName),
+		Files:    len(entries),
+		Queued:   len(resumes),
+		Skipped:  len(entries) - len(resumes),
+	}
+	var batch *models.Batch
+	var items []batchItem
+	err := _this.db.Transaction(func(tx *db.DB) error {
+		if len(resumes) > 0 {
+			var err error
+			batch = &models.Batch{Source: batchSource}
+			items, err = _this.insertBatch(ctx, tx, batch, resumes, false)
+			if err != nil {
+				return err
+			}
+			imp.BatchID = &batch.ID
+			next := 0
+			for i := range entries {
+				if entries[i].Skipped == "" {
+					entries[i].UploadID = items[next].upload.ID
+					next++
+				}
+			}
+		}
+		encodedEntries, _ := json.Marshal(entries)
+		imp.Entries = string(encodedEntries)
+		return _this.importRepo.Create(tx, imp)
+	})
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to record the import of %s: %v", fileName, err)
+		return nil, err
+	}
+	_this.logger.TraceCtx(ctx).Infof("import %d of %s: %d files, %d queued, %d skipped", imp.ID, fileName, imp.Files, imp.Queued, imp.Skipped)
+
+	importDTO := toImportDTO(*imp, entries)
+	if batch != nil {
+		if err := _this.startBatch(ctx, batch, items, false); err != nil {
+			return nil, err
+		}
+		uploads := make([]models.Upload, len(items))
+		for i, item := range items {
+			uploads[i] = *item.upload
+		}
+		batchDTO := toBatchDTO(*batch, uploads)
+		importDTO.Batch = &batchDTO
+	}
+	return &importDTO, nil
+}
+
+func (_this *DataProcessingService) StartImport(c *gin.Context, fileName string, r io.ReaderAt, size int64, onDuplicate string) (*meta.BasicResponse, error) {
+	importDTO, err := _this.ImportArchive(c.Request.Context(), fileName, r, size, onDuplicate)
+	if err != nil {
+		return nil, err
+	}
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Archive imported and its resumes are being processed",
+		},
+		Data: importDTO,
+	}, nil
+}
+
+func (_this *DataProcessingService) GetImport(c *gin.Context, importID int64) (*meta.BasicResponse, error) {
+	imp, err := _this.findImport(c, importID)
+	if err != nil {
+		return nil, err
+	}
+	importDTO := toImportDTO(*imp, importEntries(*imp))
+	if imp.BatchID != nil {
+		batch, err := _this.batchRepo.FindByID(_this.db, *imp.BatchID)
+		if err != nil {
+			ginLogger.Gin(c).Errorf("failed to get batch %d of import %d: %v", *imp.BatchID, importID, err)
+			return nil, err
+		}
+		if importDTO.Batch, err = _this.batchWithUploads(c, batch); err != nil {
+			return nil, err
+		}
+	}
+	return &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Import retrieved successfully",
+		},
+		Data: importDTO,
+	}, nil
+}
+
+func (_this *DataProcessingService) GetImportReport(c *gin.Context, importID int64) ([]dtos.ImportReportRow, error) {
+	imp, err := _this.findImport(c, importID)
+	if err != nil {
+		return nil, err
+	}
+	return _this.importReport(*imp)
+}
+
+func (_this *DataProcessingService) ImportReport(ctx context.Context, importID int64) ([]dtos.ImportReportRow, error) {
+	imp, err := _this.importRepo.FindByID(_this.db, importID)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrImportNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return _this.importReport(*imp)
+}
+
+// importReport lists the files of an import with the current outcome of their uploads.
+func (_this *DataProcessingService) importReport(imp models.Import) ([]dtos.ImportReportRow, error) {
+	uploads := map[int]models.Upload{}
+	if imp.BatchID != nil {
+		batchUploads, err := _this.uploadRepo.FindByBatch(_this.db, *imp.BatchID)
+		if err != nil {
+			return nil, err
+		}
+		for _, upload := range batchUploads {
+			uploads[upload.ID] = upload
+		}
+	}
+
+	entries := importEntries(imp)
+	rows := make([]dtos.ImportReportRow, len(entries))
+	for i, entry := range entries {
+		rows[i] = dtos.ImportReportRow{File: entry.Name, Status: "Skipped", Reason: entry.Skipped}
+		if upload, ok := uploads[entry.UploadID]; ok {
+			rows[i].Status, rows[i].Reason = upload.Status, upload.Error
+			rows[i].UploadID, rows[i].DocumentID = upload.ID, upload.DocumentID
+		}
+	}
+	return rows, nil
+}
+
+// findImport returns an import of the requesting user, like findBatch.
+func (_this *DataProcessingService) findImport(c *gin.Context, importID int64) (*models.Import, error) {
+	imp, err := _this.importRepo.FindByID(_this.db, importID)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrImportNotFound)
+	}
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get import %d: %v", importID, err)
+		return nil, err
+	}
+	if !visibleTo(c, &models.Batch{UserID: imp.UserID}) {
+		return nil, errors.NewCusErr(errors.ErrImportNotFound)
+	}
+	return imp, nil
+}
+
+// WriteImportReport writes the report of an import as CSV, with a header line, or as a JSON array.
+func WriteImportReport(w io.Writer, format string, rows []dtos.ImportReportRow) error {
+	if format == ImportReportJSON {
+		return json.NewEncoder(w).Encode(rows)
+	}
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"file", "status", "reason", "upload_id", "document_id"})
+	for _, row := range rows {
+		uploadID := ""
+		if row.UploadID != 0 {
+			uploadID = strconv.Itoa(row.UploadID)
+		}
+		_ = out.Write([]string{row.File, row.Status, row.Reason, uploadID, row.DocumentID})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// fileStore returns the function the files of an import are stored with.
+func (_this *DataProcessingService) fileStore(ctx context.Context) func(name string, data []byte) (string, error) {
+	return func(name string, data []byte) (string, error) {
+		return _this.storeFile(ctx, name, data)
+	}
+}
+
+// readArchive reads the files of an archive into files.
+func readArchive(r io.ReaderAt, size int64, files *importFiles) error {
+	return archive.Walk(r, size, func(name string, body io.Reader) error {
+		if reason := files.skipReason(name); reason != "" {
+			files.skip(name, reason)
+			return nil
+		}
+		// A file over the limit is read one byte past it, to be told apart from one at the limit.
+		data, err := io.ReadAll(io.LimitReader(body, files.maxFileSize+1))
+		if err != nil {
+			files.skip(name, fmt.Sprintf("unreadable file: %v", err))
+			return nil
+		}
+		files.add(name, data, "", "")
+		return nil
+	})
+}
+
+// importFiles collects the files of an import into the resumes to ingest, and an entry for each file
+// with the reason it was skipped, if it was. The files of the resumes are stored with store as they
+// are added, and only their keys are kept.
+type importFiles struct {
+	onDuplicate string
+	maxFiles    int
+	maxFileSize int64
+	store       func(name string, data []byte) (string, error)
+	entries     []dtos.ImportEntry
+	resumes     []dtos.ResumeData
+	// seen holds the hashes of the files and texts queued, with the file they came from.
+	seen map[string]string
+}
+
+func newImportFiles(onDuplicate string, store func(name string, data []byte) (string, error)) *importFiles {
+	return &importFiles{
+		onDuplicate: onDuplicate,
+		maxFiles:    importMaxFiles(),
+		maxFileSize: importMaxFileSize(),
+		store:       store,
+		seen:        map[string]string{},
+	}
+}
+
+// skipReason returns why a file named name is skipped without being read, or "".
+func (_this *importFiles) skipReason(name string) string {
+	switch {
+	case strings.HasPrefix(path.Base(name), ".") || strings.HasPrefix(name, "__MACOSX/"):
+		return "hidden file"
+	case !extract.Supported(name):
+		return "unsupported file type"
+	case len(_this.resumes) >= _this.maxFiles:
+		return fmt.Sprintf("the import has more than %d resumes", _this.maxFiles)
+	}
+	return ""
+}
+
+func (_this *importFiles) skip(name, reason string) {
+	_this.entries = append(_this.entries, dtos.ImportEntry{Name: name, Skipped: reason})
+}
+
+// add queues the file named name with content data as a resume, unless it is too large, has no text,
+// is a copy of a file queued before or cannot be stored. source and origin are recorded on its upload.
+func (_this *importFiles) add(name string, data []byte, source, origin string) {
+	fileHash := dedupe.HashBytes(data)
+	switch {
+	case int64(len(data)) > _this.maxFileSize:
+		_this.skip(name, fmt.Sprintf("larger than %d MB", _this.maxFileSize>>20))
+		return
+	case _this.seen[fileHash] != "":
+		_this.skip(name, "same file as "+_this.seen[fileHash])
+		return
+	}
+
+	text, err := extract.Text(name, data)
+	textHash := dedupe.HashText(text)
+	if err != nil {
+		_this.skip(name, err.Error())
+		return
+	} else if _this.seen[textHash] != "" {
+		_this.skip(name, "same text as "+_this.seen[textHash])
+		return
+	}
+
+	fileKey, err := _this.store(name, data)
+	if err != nil {
+		_this.skip(name, fmt.Sprintf("failed to store the file: %v", err))
+		return
+	}
+
+	_this.entries = append(_this.entries, dtos.ImportEntry{Name: name})
+	_this.seen[fileHash], _this.seen[textHash] = name, name
+	_this.resumes = append(_this.resumes, dtos.ResumeData{
+		Content:     text,
+		FileKey:     fileKey,
+		FileHash:    fileHash,
+		Name:        truncate(path.Base(name), maxImportFileName),
+		UUID:        uuid.New().String(),
+		OnDuplicate: _this.onDuplicate,
+		Source:      source,
+		Origin:      truncate(origin, maxOrigin),
+	})
+}
+
+// truncate returns s cut to at most n bytes, without splitting a character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+func importEntries(imp models.Import) []dtos.ImportEntry {
+	var entries []dtos.ImportEntry
+	_ = json.Unmarshal([]byte(imp.Entries), &entries)
+	return entries
+}
+
+func toImportDTO(imp models.Import, entries []dtos.ImportEntry) dtos.ImportDTO {
+	return dtos.ImportDTO{
+		ID:        imp.ID,
+		Source:    imp.Source,
+		FileName:  imp.FileName,
+		Files:     imp.Files,
+		Queued:    imp.Queued,
+		Skipped:   imp.Skipped,
+		CreatedAt: imp.CreatedAt.Unix(),
+		Entries:   entries,
+	}
+}
+
+func importMaxFiles() int {
+	if maxFiles := viper.GetInt(cfg.ImportMaxFiles); maxFiles > 0 {
+		return maxFiles
+	}
+	return defaultImportMaxFiles
+}
+
+// importMaxFileSize returns IMPORT_MAX_FILE_SIZE_MB in bytes.
+func importMaxFileSize() int64 {
+	if maxSize := viper.GetInt64(cfg.ImportMaxFileSize); maxSize > 0 {
+		return maxSize << 20
+	}
+	return defaultImportMaxFileSize << 20
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/worker"
+	"context"
+	"errors"
+	"github.com/spf13/viper"
+	"go.uber.org/dig"
+	"sync"
+	"time"
+)
+
+// Topics of the outbox messages that apply resume changes to Elasticsearch. The aggregate of a
+// message is the document ID.
+const (
+	// TopicResumeIndex creates or replaces a document; the payload is an elasticsearch.ElkResumeDTO.
+	TopicResumeIndex = "resume.index"
+	// TopicResumeUpdate merges the fields of the payload into a document.
+	TopicResumeUpdate = "resume.update"
+	// TopicResumeDelete deletes a document.
+	TopicResumeDelete = "resume.delete"
+)
+
+// mappingRecheckInterval is how often a resume mapping the document index rejected is put again.
+const mappingRecheckInterval = time.Minute
+
+// IProjectionService keeps the Elasticsearch documents in step with the MySQL records they are
+// projected from. Changes are queued in the outbox within the transaction of the MySQL change and
+// applied by a relay, so that a crash between the two stores loses neither.
+type IProjectionService interface {
+	// IndexResume queues in tx the indexing of document under documentID.
+	IndexResume(tx *db.DB, documentID string, document *elasticsearch.ElkResumeDTO) error
+	// UpdateResume queues in tx a partial update of a document.
+	UpdateResume(tx *db.DB, documentID string, fields map[string]interface{}) error
+	// DeleteResume queues in tx the deletion of a document.
+	DeleteResume(tx *db.DB, documentID string) error
+	// Notify wakes the relay once a transaction with queued changes is committed.
+	Notify()
+	// StartRelay puts the resume mapping on the index, then applies the queued changes in the
+	// background until ctx is done.
+	StartRelay(ctx context.Context) error
+	// MappingError returns why the resume mapping is not on the index, or nil once it is.
+	MappingError() error
+}
+
+type ProjectionService struct {
+	relay         *db.Relay
+	elasticClient elasticsearch.IElasticsearchClient
+	workers       *worker.Group
+	logger        logger.Logger
+
+	mu         sync.Mutex
+	mappingErr error
+}
+
+type ProjectionServiceArgs struct {
+	dig.In
+	DB            *db.DB `name:"talentAcquisitionDB"`
+	ElasticClient elasticsearch.IElasticsearchClient
+	Workers       *worker.Group
+	Logger        logger.Logger
+}
+
+func NewProjectionService(args ProjectionServiceArgs) IProjectionService {
+	service := &ProjectionService{
+		elasticClient: args.ElasticClient,
+		workers:       args.Workers,
+		logger:        args.Logger,
+	}
+	service.relay = db.NewRelay(args.DB, db.RelayConfig{
+		BatchSize:    viper.GetInt(cfg.OutboxBatchSize),
+		PollInterval: viper.GetDuration(cfg.OutboxPollInterval),
+		MaxAttempts:  viper.GetInt(cfg.OutboxMaxAttempts),
+		OnError:      service.onError,
+	})
+	service.relay.Handle(TopicResumeIndex, service.applyIndex)
+	service.relay.Handle(TopicResumeUpdate, service.applyUpdate)
+	service.relay.Handle(TopicResumeDelete, service.applyDelete)
+	return service
+}
+
+func (_this *ProjectionService) IndexResume(tx *db.DB, documentID string, document *elasticsearch.ElkResumeDTO) error {
+	return db.Enqueue(tx, TopicResumeIndex, documentID, document)
+}
+
+func (_this *ProjectionService) UpdateResume(tx *db.DB, documentID string, fields map[string]interface{}) error {
+	return db.Enqueue(tx, TopicResumeUpdate, documentID, fields)
+}
+
+func (_this *ProjectionService) DeleteResume(tx *db.DB, documentID string) error {
+	return db.Enqueue(tx, TopicResumeDelete, documentID, nil)
+}
+
+func (_this *ProjectionService) Notify() {
+	_this.relay.Notify()
+}
+
+// StartRelay tries the mapping once before the relay starts, so that documents are not indexed
+// with the types dynamic mapping would guess. The relay then starts whatever the outcome, so that
+// a mapping problem does not hold back indexing; the mapping keeps being retried in the background,
+// and MappingError reports it meanwhile.
+func (_this *ProjectionService) StartRelay(ctx context.Context) error {
+	return _this.workers.Go(ctx, func(ctx context.Context) {
+		if err := _this.putMapping(ctx); err != nil {
+			if err := _this.workers.Go(ctx, _this.retryMapping); err != nil {
+				_this.logger.Errorf("failed to retry the resume mapping: %v", err)
+			}
+		}
+		_this.relay.Run(ctx)
+	})
+}
+
+func (_this *ProjectionService) MappingError() error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.mappingErr
+}
+
+// putMapping adds the resume mapping to the document index and records the outcome.
+func (_this *ProjectionService) putMapping(ctx context.Context) error {
+	err := _this.elasticClient.PutResumeMapping(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex))
+	if err != nil && ctx.Err() == nil {
+		if errors.Is(err, elasticsearch.ErrMappingRejected) {
+			_this.logger.TraceCtx(ctx).Errorf("the document index rejected the resume mapping; migrate it with \"CVSeeker reindex\": %v", err)
+		} else {
+			_this.logger.TraceCtx(ctx).Errorf("failed to put the resume mapping: %v", err)
+		}
+	}
+	_this.mu.Lock()
+	_this.mappingErr = err
+	_this.mu.Unlock()
+	return err
+}
+
+// retryMapping puts the resume mapping every OUTBOX_POLL_INTERVAL until it succeeds or ctx is
+// done. A mapping the index rejected is only tried again every mappingRecheckInterval: it succeeds
+// once "CVSeeker reindex" has pointed the index alias at a migrated index.
+func (_this *ProjectionService) retryMapping(ctx context.Context) {
+	retry := viper.GetDuration(cfg.OutboxPollInterval)
+	if retry <= 0 {
+		retry = time.Second
+	}
+	err := _this.MappingError()
+	for err != nil {
+		wait := retry
+		if errors.Is(err, elasticsearch.ErrMappingRejected) {
+			wait = mappingRecheckInterval
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		err = _this.putMapping(ctx)
+	}
+}
+
+func (_this *ProjectionService) applyIndex(ctx context.Context, message db.OutboxMessage) error {
+	var document elasticsearch.ElkResumeDTO
+	if err := message.Decode(&document); err != nil {
+		return err
+	}
+	return postponeBlocked(_this.elasticClient.IndexDocument(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), message.AggregateID, document))
+}
+
+// applyUpdate ignores documents that no longer exist: they were deleted after the update was queued.
+func (_this *ProjectionService) applyUpdate(ctx context.Context, message db.OutboxMessage) error {
+	var fields map[string]interface{}
+	if err := message.Decode(&fields); err != nil {
+		return err
+	}
+	err := _this.elasticClient.UpdateDocument(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), message.AggregateID, fields)
+	if errors.Is(err, elasticsearch.ErrDocumentNotFound) {
+		return nil
+	}
+	return postponeBlocked(err)
+}
+
+// applyDelete treats a missing document as deleted, so that a repeated message succeeds.
+func (_this *ProjectionService) applyDelete(ctx context.Context, message db.OutboxMessage) error {
+	err := _this.elasticClient.DeleteDocumentByID(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), message.AggregateID)
+	if errors.Is(err, elasticsearch.ErrDocumentNotFound) {
+		return nil
+	}
+	return postponeBlocked(err)
+}
+
+// postponeBlocked postpones the changes the document index refuses while "CVSeeker reindex" copies
+// it, so that they are applied to the new index however long the copy takes.
+func postponeBlocked(err error) error {
+	if errors.Is(err, elasticsearch.ErrIndexBlocked) {
+		return db.Postpone(err)
+	}
+	return err
+}
+
+func (_this *ProjectionService) onError(message db.OutboxMessage, err error) {
+	if message.ID == 0 {
+		_this.logger.Errorf("outbox relay: %v", err)
+		return
+	}
+	if errors.Is(err, elasticsearch.ErrIndexBlocked) {
+		// Logged on every poll until the copy ends.
+		_this.logger.Debugf("outbox relay: %s of document %s postponed: %v", message.Topic, message.AggregateID, err)
+		return
+	}
+	_this.logger.Errorf("outbox relay: %s of document %s failed (attempt %d): %v", message.Topic, message.AggregateID, message.Attempts+1, err)
+}
@@ -0,0 +1,419 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/prompt"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/dig"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Prompts used by the application. Each has a static version in statics/prompts/<name>.
+const (
+	// PromptResumeParse turns a resume into JSON. Variables: .ResumeText, .WithEvidence.
+	PromptResumeParse = "resume_parse"
+	// PromptChatPreamble is the first message of a chat thread. Variables: .Resumes, a list of
+	// elasticsearch.ResumeSummaryDTO.
+	PromptChatPreamble = "chat_preamble"
+)
+
+type resumePromptData struct {
+	ResumeText   string
+	WithEvidence bool
+}
+
+type chatPreambleData struct {
+	Resumes []elasticsearch.ResumeSummaryDTO
+}
+
+const sampleResumeText = `Jane Doe - jane.doe@example.com
+B.Sc. in Computer Science, University of Example, GPA 3.6/4.0
+Software Engineer at Acme Corp (2021 - 2024): built Go microservices and Elasticsearch search.
+Projects: CVSeeker - resume search with hybrid retrieval.`
+
+var samplePromptGPA = 3.6
+
+var sampleResume = elasticsearch.ResumeSummaryDTO{
+	Summary: "Software engineer with three years of backend experience.",
+	Skills:  []string{"Go", "Elasticsearch", "MySQL"},
+	BasicInfo: elasticsearch.BasicInfo{
+		FullName:       "Jane Doe",
+		University:     "University of Example",
+		EducationLevel: "BS",
+		Majors:         []string{"Computer Science"},
+		GPA:            &samplePromptGPA,
+	},
+	Contacts: elasticsearch.Contacts{Emails: []string{"jane.doe@example.com"}},
+	Education: []elasticsearch.Education{
+		{Institution: "University of Example", Degree: "BS", Majors: []string{"Computer Science"}, GPA: &samplePromptGPA},
+	},
+	WorkExperience:    []elasticsearch.WorkExperience{{JobTitle: "Software Engineer", Company: "Acme Corp", Duration: "3 years"}},
+	ProjectExperience: []elasticsearch.ProjectExperience{{ProjectName: "CVSeeker", ProjectDescription: "Resume search with hybrid retrieval."}},
+	Award:             []elasticsearch.Award{{AwardName: "Hackathon winner"}},
+}
+
+type IPromptService interface {
+	// Render renders the active version of a prompt and returns the text with the version used.
+	Render(ctx context.Context, name string, data interface{}) (string, string, error)
+	// ActiveVersion returns the version of a prompt that Render currently uses.
+	ActiveVersion(ctx context.Context, name string) (string, error)
+	ListPrompts(c *gin.Context) (*meta.BasicResponse, error)
+	CreateVersion(c *gin.Context, name string, request dtos.PromptVersionRequest) (*meta.BasicResponse, error)
+	Preview(c *gin.Context, name string, request dtos.PromptPreviewRequest) (*meta.BasicResponse, error)
+	Rollout(c *gin.Context, name string, version string) (*meta.BasicResponse, error)
+}
+
+type PromptService struct {
+	db            *db.DB
+	promptRepo    repositories.IPromptTemplateRepository
+	elasticClient elasticsearch.IElasticsearchClient
+	registry      *prompt.Registry
+	logger        logger.Logger
+
+	mu     sync.Mutex
+	active map[string]activePrompt
+}
+
+// activePrompt caches the active version of a prompt so that rendering does not query MySQL for
+// every resume.
+type activePrompt struct {
+	template  prompt.Template
+	expiresAt time.Time
+}
+
+type PromptServiceArgs struct {
+	dig.In
+	DB            *db.DB `name:"talentAcquisitionDB"`
+	PromptRepo    repositories.IPromptTemplateRepository
+	ElasticClient elasticsearch.IElasticsearchClient
+	Logger        logger.Logger
+}
+
+func NewPromptService(args PromptServiceArgs) (IPromptService, error) {
+	registry := prompt.NewRegistry(template.FuncMap{"gpa": formatGPA})
+	if err := registry.LoadDir(viper.GetString(cfg.PromptsDir)); err != nil {
+		return nil, err
+	}
+	for _, name := range []string{PromptResumeParse, PromptChatPreamble} {
+		if _, ok := registry.Latest(name); !ok {
+			return nil, errors.New("no static version of prompt %s in %s", name, viper.GetString(cfg.PromptsDir))
+		}
+	}
+
+	return &PromptService{
+		db:            args.DB,
+		promptRepo:    args.PromptRepo,
+		elasticClient: args.ElasticClient,
+		registry:      registry,
+		logger:        args.Logger,
+		active:        map[string]activePrompt{},
+	}, nil
+}
+
+func (_this *PromptService) Render(ctx context.Context, name string, data interface{}) (string, string, error) {
+	t, err := _this.activeTemplate(ctx, name)
+	if err != nil {
+		return "", "", err
+	}
+	text, err := _this.registry.Render(t, data)
+	if err != nil {
+		return "", "", err
+	}
+	return text, t.Version, nil
+}
+
+func (_this *PromptService) ActiveVersion(ctx context.Context, name string) (string, error) {
+	t, err := _this.activeTemplate(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return t.Version, nil
+}
+
+func (_this *PromptService) ListPrompts(c *gin.Context) (*meta.BasicResponse, error) {
+	stored, err := _this.promptRepo.GetAll(_this.db)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get prompt templates: %v", err)
+		return nil, err
+	}
+	rollouts, err := _this.promptRepo.GetAllRollouts(_this.db)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get prompt rollouts: %v", err)
+		return nil, err
+	}
+
+	storedByName := map[string][]prompt.Template{}
+	for _, t := range stored {
+		storedByName[t.Name] = append(storedByName[t.Name], toPromptTemplate(t))
+	}
+	activeByName := map[string]string{}
+	for _, rollout := range rollouts {
+		activeByName[rollout.Name] = rollout.Version
+	}
+
+	promptDTOs := make([]dtos.PromptDTO, 0)
+	for _, name := range _this.registry.Names() {
+		active, ok := activeByName[name]
+		if !ok {
+			latest, _ := _this.registry.Latest(name)
+			active = latest.Version
+		}
+		versions := append(_this.registry.Versions(name), storedByName[name]...)
+		prompt.SortVersions(versions)
+		promptDTOs = append(promptDTOs, toPromptDTO(name, active, versions))
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Prompts retrieved successfully",
+		},
+		Data: promptDTOs,
+	}
+	return response, nil
+}
+
+func (_this *PromptService) CreateVersion(c *gin.Context, name string, request dtos.PromptVersionRequest) (*meta.BasicResponse, error) {
+	if _, ok := _this.registry.Latest(name); !ok {
+		return nil, errors.NewCusErr(errors.ErrPromptNotFound)
+	}
+	if !prompt.ValidVersion(request.Version) || request.Template == "" {
+		return nil, errors.NewCusErr(errors.ErrCommonInvalidRequest)
+	}
+	if _, err := _this.lookup(name, request.Version); err == nil {
+		return nil, errors.NewCusErr(errors.ErrPromptVersionExists)
+	} else if !isPromptNotFound(err) {
+		ginLogger.Gin(c).Errorf("failed to get prompt %s version %s: %v", name, request.Version, err)
+		return nil, err
+	}
+
+	t := prompt.Template{Name: name, Version: request.Version, Body: request.Template, Source: prompt.SourceDatabase}
+	// Parsing alone does not catch a misspelled variable, so render the template once with sample data.
+	if _, err := _this.registry.Render(t, samplePromptData(name)); err != nil {
+		ginLogger.Gin(c).Warningf("rejected prompt %s version %s: %v", name, request.Version, err)
+		return nil, errors.NewCusErr(errors.ErrPromptInvalidTemplate)
+	}
+
+	stored := models.PromptTemplate{Name: name, Version: request.Version, Body: request.Template}
+	if err := _this.promptRepo.Create(_this.db, &stored); err != nil {
+		ginLogger.Gin(c).Errorf("failed to save prompt %s version %s: %v", name, request.Version, err)
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Prompt version created successfully",
+		},
+		Data: toPromptVersionDTO(toPromptTemplate(stored), false),
+	}
+	return response, nil
+}
+
+func (_this *PromptService) Preview(c *gin.Context, name string, request dtos.PromptPreviewRequest) (*meta.BasicResponse, error) {
+	if _, ok := _this.registry.Latest(name); !ok {
+		return nil, errors.NewCusErr(errors.ErrPromptNotFound)
+	}
+
+	var (
+		t   prompt.Template
+		err error
+	)
+	switch {
+	case request.Template != "":
+		t = prompt.Template{Name: name, Body: request.Template}
+	case request.Version != "":
+		t, err = _this.lookup(name, request.Version)
+	default:
+		t, err = _this.activeTemplate(c, name)
+	}
+	if err != nil {
+		if !isPromptNotFound(err) {
+			ginLogger.Gin(c).Errorf("failed to get prompt %s: %v", name, err)
+		}
+		return nil, err
+	}
+
+	data, err := _this.previewData(c, name, request)
+	if err != nil {
+		return nil, err
+	}
+	text, err := _this.registry.Render(t, data)
+	if err != nil {
+		ginLogger.Gin(c).Warningf("failed to render prompt %s: %v", name, err)
+		return nil, errors.NewCusErr(errors.ErrPromptInvalidTemplate)
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Prompt rendered successfully",
+		},
+		Data: dtos.PromptPreviewDTO{Name: name, Version: t.Version, Prompt: text},
+	}
+	return response, nil
+}
+
+func (_this *PromptService) Rollout(c *gin.Context, name string, version string) (*meta.BasicResponse, error) {
+	if _, ok := _this.registry.Latest(name); !ok {
+		return nil, errors.NewCusErr(errors.ErrPromptNotFound)
+	}
+	t, err := _this.lookup(name, version)
+	if err != nil {
+		if !isPromptNotFound(err) {
+			ginLogger.Gin(c).Errorf("failed to get prompt %s version %s: %v", name, version, err)
+		}
+		return nil, err
+	}
+
+	if err := _this.promptRepo.UpsertRollout(_this.db, &models.PromptRollout{Name: name, Version: version}); err != nil {
+		ginLogger.Gin(c).Errorf("failed to roll out prompt %s version %s: %v", name, version, err)
+		return nil, err
+	}
+	_this.mu.Lock()
+	delete(_this.active, name)
+	_this.mu.Unlock()
+	ginLogger.Gin(c).Infof("prompt %s rolled out at version %s", name, version)
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Prompt version rolled out successfully",
+		},
+		Data: toPromptVersionDTO(t, true),
+	}
+	return response, nil
+}
+
+// activeTemplate returns the rolled out version of a prompt, else its latest static version. When
+// the rollout cannot be read, the static version is used so that ingestion keeps working.
+func (_this *PromptService) activeTemplate(ctx context.Context, name string) (prompt.Template, error) {
+	_this.mu.Lock()
+	cached, ok := _this.active[name]
+	_this.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.template, nil
+	}
+
+	latest, ok := _this.registry.Latest(name)
+	if !ok {
+		return prompt.Template{}, errors.NewCusErr(errors.ErrPromptNotFound)
+	}
+	t := latest
+	rollout, err := _this.promptRepo.FindRollout(_this.db, name)
+	switch {
+	case err == nil:
+		if t, err = _this.lookup(name, rollout.Version); err != nil {
+			_this.logger.TraceCtx(ctx).Errorf("failed to load prompt %s version %s, using %s: %v", name, rollout.Version, latest.Version, err)
+			return latest, nil
+		}
+	case err != db.ErrRecordNotFound:
+		_this.logger.TraceCtx(ctx).Errorf("failed to read rollout of prompt %s, using %s: %v", name, latest.Version, err)
+		return latest, nil
+	}
+
+	if ttl := viper.GetDuration(cfg.PromptCacheTTL); ttl > 0 {
+		_this.mu.Lock()
+		_this.active[name] = activePrompt{template: t, expiresAt: time.Now().Add(ttl)}
+		_this.mu.Unlock()
+	}
+	return t, nil
+}
+
+// lookup returns a version of a prompt from the static templates or MySQL.
+func (_this *PromptService) lookup(name, version string) (prompt.Template, error) {
+	if t, ok := _this.registry.Get(name, version); ok {
+		return t, nil
+	}
+	stored, err := _this.promptRepo.Find(_this.db, name, version)
+	if err == db.ErrRecordNotFound {
+		return prompt.Template{}, errors.NewCusErr(errors.ErrPromptNotFound)
+	}
+	if err != nil {
+		return prompt.Template{}, err
+	}
+	return toPromptTemplate(*stored), nil
+}
+
+// previewData returns the variables of a prompt from the preview request, filling the rest with samples.
+func (_this *PromptService) previewData(c *gin.Context, name string, request dtos.PromptPreviewRequest) (interface{}, error) {
+	data := samplePromptData(name)
+	switch data := data.(type) {
+	case resumePromptData:
+		if request.ResumeText != "" {
+			data.ResumeText = request.ResumeText
+		}
+		if request.WithEvidence != nil {
+			data.WithEvidence = *request.WithEvidence
+		}
+		return data, nil
+	case chatPreambleData:
+		if len(request.DocumentIds) > 0 {
+			documents, err := _this.elasticClient.FetchDocumentsByIDs(c, viper.GetString(cfg.ElasticsearchDocumentIndex), request.DocumentIds)
+			if err != nil {
+				ginLogger.Gin(c).Errorf("failed to fetch documents: %v", err)
+				return nil, err
+			}
+			data.Resumes = documents
+		}
+		return data, nil
+	}
+	return data, nil
+}
+
+func samplePromptData(name string) interface{} {
+	switch name {
+	case PromptResumeParse:
+		return resumePromptData{ResumeText: sampleResumeText, WithEvidence: true}
+	case PromptChatPreamble:
+		return chatPreambleData{Resumes: []elasticsearch.ResumeSummaryDTO{sampleResume}}
+	}
+	return nil
+}
+
+func isPromptNotFound(err error) bool {
+	cusErr, ok := err.(errors.CustomError)
+	return ok && cusErr.Code == errors.ErrPromptNotFound.Error()
+}
+
+func toPromptTemplate(stored models.PromptTemplate) prompt.Template {
+	return prompt.Template{
+		Name:      stored.Name,
+		Version:   stored.Version,
+		Body:      stored.Body,
+		Source:    prompt.SourceDatabase,
+		CreatedAt: stored.CreatedAt,
+	}
+}
+
+func toPromptDTO(name, active string, versions []prompt.Template) dtos.PromptDTO {
+	promptDTO := dtos.PromptDTO{Name: name, ActiveVersion: active}
+	for _, t := range versions {
+		promptDTO.Versions = append(promptDTO.Versions, toPromptVersionDTO(t, t.Version == active))
+	}
+	return promptDTO
+}
+
+func toPromptVersionDTO(t prompt.Template, active bool) dtos.PromptVersionDTO {
+	return dtos.PromptVersionDTO{
+		Version:   t.Version,
+		Source:    t.Source,
+		Template:  t.Body,
+		Active:    active,
+		CreatedAt: t.CreatedAt.Unix(),
+	}
+}
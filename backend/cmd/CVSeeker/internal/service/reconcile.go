@@ -0,0 +1,662 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/aws"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/websocket"
+	"CVSeeker/pkg/worker"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/dig"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultReconcileGracePeriod is used when RECONCILE_GRACE_PERIOD is not set.
+	defaultReconcileGracePeriod = time.Hour
+	// reconcileMaxIssues is the number of issues kept in the report of a run; the counts cover all.
+	reconcileMaxIssues = 1000
+	reconcilePageSize  = 500
+	reconcileRunsShown = 50
+)
+
+// reconcileActions lists the actions allowed for each kind of issue.
+var reconcileActions = map[string][]string{
+	models.ReconcileIssueMissingDocument: {models.ReconcileActionReport, models.ReconcileActionRequeue, models.ReconcileActionFail},
+	models.ReconcileIssueOrphanDocument:  {models.ReconcileActionReport, models.ReconcileActionDelete},
+	models.ReconcileIssueOrphanObject:    {models.ReconcileActionReport, models.ReconcileActionDelete},
+	models.ReconcileIssueStuckUpload:     {models.ReconcileActionReport, models.ReconcileActionFail},
+}
+
+type IReconcileService interface {
+	// Run checks the upload records, the Elasticsearch index and the S3 bucket for drift and handles
+	// it according to request, recording the run under trigger. It returns ErrReconcileRunning when
+	// a run is already in progress.
+	Run(ctx context.Context, trigger string, request dtos.ReconcileRequest) (*dtos.ReconcileRunDTO, error)
+	// GetRun returns a run with its issues.
+	GetRun(ctx context.Context, runID int64) (*dtos.ReconcileRunDTO, error)
+	// StartSchedule marks the runs of a stopped process as interrupted, then runs the reconciler in
+	// the background every RECONCILE_INTERVAL until ctx is done.
+	StartSchedule(ctx context.Context) error
+
+	StartReconcile(c *gin.Context, request dtos.ReconcileRequest) (*meta.BasicResponse, error)
+	GetReconcileRuns(c *gin.Context) (*meta.BasicResponse, error)
+	GetReconcileRun(c *gin.Context, runID int64) (*meta.BasicResponse, error)
+}
+
+type ReconcileService struct {
+	db             *db.DB
+	runRepo        repositories.IReconcileRunRepository
+	uploadRepo     repositories.IUploadRepository
+	resumeRepo     repositories.IResumeRepository
+	elasticClient  elasticsearch.IElasticsearchClient
+	s3Client       aws.IS3Client
+	dataProcessing IDataProcessingService
+	candidates     ICandidateService
+	projections    IProjectionService
+	workers        *worker.Group
+	logger         logger.Logger
+
+	// running is held for the duration of a run, so that runs of this process do not overlap.
+	running sync.Mutex
+}
+
+type ReconcileServiceArgs struct {
+	dig.In
+	DB             *db.DB `name:"talentAcquisitionDB"`
+	RunRepo        repositories.IReconcileRunRepository
+	UploadRepo     repositories.IUploadRepository
+	ResumeRepo     repositories.IResumeRepository
+	ElasticClient  elasticsearch.IElasticsearchClient
+	S3Client       *aws.S3Client
+	DataProcessing IDataProcessingService
+	Candidates     ICandidateService
+	Projections    IProjectionService
+	Workers        *worker.Group
+	Logger         logger.Logger
+}
+
+func NewReconcileService(args ReconcileServiceArgs) IReconcileService {
+	return &ReconcileService{
+		db:             args.DB,
+		runRepo:        args.RunRepo,
+		uploadRepo:     args.UploadRepo,
+		resumeRepo:     args.ResumeRepo,
+		elasticClient:  args.ElasticClient,
+		s3Client:       args.S3Client,
+		dataProcessing: args.DataProcessing,
+		candidates:     args.Candidates,
+		projections:    args.Projections,
+		workers:        args.Workers,
+		logger:         args.Logger,
+	}
+}
+
+// reconcileIssue is an issue found by a run, with what is needed to fix it.
+type reconcileIssue struct {
+	dtos.ReconcileIssue
+	// uploadIDs are the uploads of a missing document or the stuck upload.
+	uploadIDs []int
+	// hasRecord is set when a missing document has a resume record to be rebuilt from.
+	hasRecord bool
+}
+
+func (_this *ReconcileService) Run(ctx context.Context, trigger string, request dtos.ReconcileRequest) (*dtos.ReconcileRunDTO, error) {
+	run, policy, err := _this.begin(trigger, request)
+	if err != nil {
+		return nil, err
+	}
+	defer _this.running.Unlock()
+
+	_this.execute(ctx, run, policy)
+	runDTO := toReconcileRunDTO(*run, true)
+	return &runDTO, nil
+}
+
+func (_this *ReconcileService) GetRun(ctx context.Context, runID int64) (*dtos.ReconcileRunDTO, error) {
+	run, err := _this.runRepo.FindByID(_this.db, runID)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrReconcileRunNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	runDTO := toReconcileRunDTO(*run, true)
+	return &runDTO, nil
+}
+
+func (_this *ReconcileService) StartSchedule(ctx context.Context) error {
+	if interrupted, err := _this.runRepo.InterruptRunning(_this.db); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to mark interrupted reconcile runs: %v", err)
+	} else if interrupted > 0 {
+		_this.logger.TraceCtx(ctx).Infof("marked %d interrupted reconcile runs", interrupted)
+	}
+
+	interval := viper.GetDuration(cfg.ReconcileInterval)
+	if interval <= 0 {
+		return nil
+	}
+	return _this.workers.Go(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			_, err := _this.Run(ctx, models.ReconcileTriggerSchedule, dtos.ReconcileRequest{})
+			if err != nil && ctx.Err() == nil {
+				_this.logger.TraceCtx(ctx).Warnf("scheduled reconcile run skipped: %v", err)
+			}
+		}
+	})
+}
+
+func (_this *ReconcileService) StartReconcile(c *gin.Context, request dtos.ReconcileRequest) (*meta.BasicResponse, error) {
+	run, policy, err := _this.begin(models.ReconcileTriggerManual, request)
+	if err != nil {
+		return nil, err
+	}
+	err = _this.workers.Go(tracing.Detach(c.Request.Context()), func(ctx context.Context) {
+		defer _this.running.Unlock()
+		_this.execute(ctx, run, policy)
+	})
+	if err != nil {
+		_this.running.Unlock()
+		run.LastError = "the server is shutting down"
+		if err := _this.runRepo.Save(_this.db, run, models.ReconcileStatusInterrupted); err != nil {
+			ginLogger.Gin(c).Errorf("failed to save reconcile run %d: %v", run.ID, err)
+		}
+		return nil, errors.NewCusErr(errors.ErrCommonShuttingDown)
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reconcile run started",
+		},
+		Data: toReconcileRunDTO(*run, false),
+	}
+	return response, nil
+}
+
+func (_this *ReconcileService) GetReconcileRuns(c *gin.Context) (*meta.BasicResponse, error) {
+	runs, err := _this.runRepo.GetRecent(_this.db, reconcileRunsShown)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get reconcile runs: %v", err)
+		return nil, err
+	}
+
+	runDTOs := make([]dtos.ReconcileRunDTO, 0, len(runs))
+	for _, run := range runs {
+		runDTOs = append(runDTOs, toReconcileRunDTO(run, false))
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reconcile runs retrieved successfully",
+		},
+		Data: runDTOs,
+	}
+	return response, nil
+}
+
+func (_this *ReconcileService) GetReconcileRun(c *gin.Context, runID int64) (*meta.BasicResponse, error) {
+	runDTO, err := _this.GetRun(c, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reconcile run retrieved successfully",
+		},
+		Data: runDTO,
+	}
+	return response, nil
+}
+
+// begin validates a request and records a new running run. On success the caller holds running
+// and must release it once the run is executed.
+func (_this *ReconcileService) begin(trigger string, request dtos.ReconcileRequest) (*models.ReconcileRun, dtos.ReconcilePolicy, error) {
+	policy, err := reconcilePolicy(request.Policy)
+	if err != nil {
+		return nil, policy, err
+	}
+	encoded, err := json.Marshal(policy)
+	if err != nil {
+		return nil, policy, err
+	}
+	if !_this.running.TryLock() {
+		return nil, policy, errors.NewCusErr(errors.ErrReconcileRunning)
+	}
+
+	run := &models.ReconcileRun{
+		Trigger: trigger,
+		Policy:  string(encoded),
+		DryRun:  request.DryRun,
+		Status:  models.ReconcileStatusRunning,
+	}
+	if err := _this.runRepo.Create(_this.db, run); err != nil {
+		_this.running.Unlock()
+		return nil, policy, err
+	}
+	return run, policy, nil
+}
+
+// execute finds the issues, handles them and stores the outcome of run.
+func (_this *ReconcileService) execute(ctx context.Context, run *models.ReconcileRun, policy dtos.ReconcilePolicy) {
+	var err error
+	ctx, span := tracing.Start(ctx, "reconcile.Run", attribute.Int64("reconcile.run_id", run.ID))
+	defer tracing.End(span, &err)
+	_this.logger.TraceCtx(ctx).Infof("reconcile run %d started (%s)", run.ID, run.Trigger)
+
+	issues, err := _this.findIssues(ctx, run)
+	if err == nil {
+		err = _this.handleIssues(ctx, run, policy, issues)
+	}
+
+	counts := make(map[string]int)
+	report := make([]dtos.ReconcileIssue, 0, len(issues))
+	for _, issue := range issues {
+		counts[issue.Kind]++
+		if len(report) < reconcileMaxIssues {
+			report = append(report, issue.ReconcileIssue)
+		}
+	}
+	run.Found = len(issues)
+	encodedCounts, _ := json.Marshal(counts)
+	encodedIssues, _ := json.Marshal(report)
+	run.Counts, run.Issues = string(encodedCounts), string(encodedIssues)
+
+	status := models.ReconcileStatusCompleted
+	switch {
+	case ctx.Err() != nil || _this.workers.ShuttingDown():
+		status = models.ReconcileStatusInterrupted
+	case err != nil:
+		status = models.ReconcileStatusFailed
+		run.LastError = err.Error()
+	}
+	if err := _this.runRepo.Save(_this.db, run, status); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("reconcile run %d: failed to save status %s: %v", run.ID, status, err)
+	}
+	_this.logger.TraceCtx(ctx).Infof("reconcile run %d %s: %d issues found, %d fixed, %d not fixed %v", run.ID, status, run.Found, run.Fixed, run.Failed, counts)
+	if run.Found > 0 {
+		websocket.BroadcastNotification(fmt.Sprintf("Reconcile run %d found %d inconsistencies and fixed %d.", run.ID, run.Found, run.Fixed))
+	}
+}
+
+// findIssues compares the stores. The bucket and the index are listed before the MySQL tables, so
+// that a document or file created during the run always has its record listed; items changed within
+// the grace period are skipped, as their change may still be in flight.
+func (_this *ReconcileService) findIssues(ctx context.Context, run *models.ReconcileRun) ([]*reconcileIssue, error) {
+	now := time.Now()
+	settledBefore := now.Add(-reconcileGracePeriod())
+	bucket := viper.GetString(cfg.AwsBucket)
+
+	// objects is nil when the bucket could not be listed; orphan files are then not looked for.
+	var objects map[string]time.Time
+	if bucket != "" {
+		objects = make(map[string]time.Time)
+		err := _this.s3Client.ListObjects(ctx, bucket, func(page []aws.ObjectInfo) error {
+			for _, object := range page {
+				objects[object.Key] = object.LastModified
+			}
+			return nil
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			_this.logger.TraceCtx(ctx).Errorf("reconcile run %d: failed to list the S3 bucket, orphan files are not checked: %v", run.ID, err)
+			run.LastError = fmt.Sprintf("orphan files not checked: %v", err)
+			objects = nil
+		}
+		run.Objects = len(objects)
+	}
+
+	index := viper.GetString(cfg.ElasticsearchDocumentIndex)
+	documents := make(map[string]bool)
+	err := _this.elasticClient.ScanDocumentIDs(ctx, index, reconcilePageSize, func(documentIDs []string) error {
+		for _, id := range documentIDs {
+			documents[id] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning the index: %w", err)
+	}
+	run.Documents = len(documents)
+
+	// The files referred to by a resume, merged ones included, are not orphans.
+	referenced := make(map[string]bool)
+	records := make(map[string]models.Resume)
+	for afterID := 0; ; {
+		page, err := _this.resumeRepo.FindAfter(_this.db, afterID, reconcilePageSize)
+		if err != nil {
+			return nil, fmt.Errorf("reading resume records: %w", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, record := range page {
+			afterID = record.ResumeId
+			referenced[record.FileKey] = true
+			referenced[aws.KeyFromURL(bucket, record.DownloadLink)] = true
+			if record.MergedFrom == "" && record.DocumentID != "" {
+				run.Records++
+				if latest, ok := records[record.DocumentID]; !ok || record.UpdatedAt.After(latest.UpdatedAt) {
+					records[record.DocumentID] = record
+				}
+			}
+		}
+	}
+
+	var issues []*reconcileIssue
+	missing := make(map[string]*reconcileIssue)
+	uploaded := make(map[string]bool)
+	stuckBefore := now.Add(-uploadStaleAfter())
+	for afterID := 0; ; {
+		page, err := _this.uploadRepo.FindAfter(_this.db, afterID, reconcilePageSize)
+		if err != nil {
+			return nil, fmt.Errorf("reading uploads: %w", err)
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, upload := range page {
+			afterID = upload.ID
+			run.Uploads++
+			// The file of an upload is kept for retries.
+			referenced[upload.FileKey] = true
+			if upload.DocumentID != "" {
+				uploaded[upload.DocumentID] = true
+			}
+			switch {
+			case upload.Status == "Success" && upload.DocumentID != "" && !documents[upload.DocumentID] && upload.UpdatedAt.Before(settledBefore):
+				if issue, ok := missing[upload.DocumentID]; ok {
+					issue.uploadIDs = append(issue.uploadIDs, upload.ID)
+					continue
+				}
+				_, hasRecord := records[upload.DocumentID]
+				issue := &reconcileIssue{
+					ReconcileIssue: dtos.ReconcileIssue{
+						Kind:       models.ReconcileIssueMissingDocument,
+						DocumentID: upload.DocumentID,
+						UploadID:   upload.ID,
+						Detail:     fmt.Sprintf("upload %q succeeded but its document is not indexed", upload.Name),
+					},
+					uploadIDs: []int{upload.ID},
+					hasRecord: hasRecord,
+				}
+				missing[upload.DocumentID] = issue
+				issues = append(issues, issue)
+			// Uploads waiting in this process for the ingestion pool or a rate limit are not stuck.
+			case (upload.Status == "Processing" || upload.Status == "Queued") && upload.UpdatedAt.Before(stuckBefore) &&
+				!_this.dataProcessing.IsIngesting(upload.ID):
+				issues = append(issues, &reconcileIssue{
+					ReconcileIssue: dtos.ReconcileIssue{
+						Kind:     models.ReconcileIssueStuckUpload,
+						UploadID: upload.ID,
+						Detail:   fmt.Sprintf("upload %q has been %s since %s", upload.Name, strings.ToLower(upload.Status), upload.UpdatedAt.Format(time.RFC3339)),
+					},
+					uploadIDs: []int{upload.ID},
+				})
+			}
+		}
+	}
+
+	for documentID, record := range records {
+		if documents[documentID] || missing[documentID] != nil || !record.UpdatedAt.Before(settledBefore) {
+			continue
+		}
+		issue := &reconcileIssue{
+			ReconcileIssue: dtos.ReconcileIssue{
+				Kind:       models.ReconcileIssueMissingDocument,
+				DocumentID: documentID,
+				Detail:     "the resume record has no indexed document",
+			},
+			hasRecord: true,
+		}
+		missing[documentID] = issue
+		issues = append(issues, issue)
+	}
+
+	// Documents indexed before resumes were recorded have an upload but no record; the files they
+	// link to are read from the documents themselves.
+	var legacy []string
+	for documentID := range documents {
+		if _, ok := records[documentID]; ok {
+			continue
+		}
+		if uploaded[documentID] {
+			legacy = append(legacy, documentID)
+			continue
+		}
+		issues = append(issues, &reconcileIssue{
+			ReconcileIssue: dtos.ReconcileIssue{
+				Kind:       models.ReconcileIssueOrphanDocument,
+				DocumentID: documentID,
+				Detail:     "the document has neither an upload nor a resume record",
+			},
+		})
+	}
+	if objects == nil {
+		return issues, nil
+	}
+	for start := 0; start < len(legacy); start += reconcilePageSize {
+		end := start + reconcilePageSize
+		if end > len(legacy) {
+			end = len(legacy)
+		}
+		resumes, err := _this.elasticClient.FetchDocumentsByIDs(ctx, index, legacy[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("reading documents: %w", err)
+		}
+		for _, resume := range resumes {
+			referenced[aws.KeyFromURL(bucket, resume.URL)] = true
+		}
+	}
+
+	for key, lastModified := range objects {
+		if referenced[key] || !lastModified.Before(settledBefore) {
+			continue
+		}
+		issues = append(issues, &reconcileIssue{
+			ReconcileIssue: dtos.ReconcileIssue{
+				Kind:    models.ReconcileIssueOrphanObject,
+				FileKey: key,
+				Detail:  "no upload, resume record or document refers to the file",
+			},
+		})
+	}
+	return issues, nil
+}
+
+// handleIssues applies the action of the policy to each issue. A failed fix is recorded on the issue
+// and does not stop the run.
+func (_this *ReconcileService) handleIssues(ctx context.Context, run *models.ReconcileRun, policy dtos.ReconcilePolicy, issues []*reconcileIssue) error {
+	for _, issue := range issues {
+		issue.Action = policyAction(policy, issue.Kind)
+		if run.DryRun || issue.Action == models.ReconcileActionReport {
+			issue.Action = models.ReconcileActionReport
+			continue
+		}
+		if ctx.Err() != nil || _this.workers.ShuttingDown() {
+			return ctx.Err()
+		}
+
+		var err error
+		switch issue.Kind {
+		case models.ReconcileIssueMissingDocument:
+			err = _this.fixMissingDocument(ctx, issue)
+		case models.ReconcileIssueOrphanDocument:
+			err = _this.deleteDocument(ctx, issue.DocumentID)
+		case models.ReconcileIssueOrphanObject:
+			err = _this.s3Client.DeleteObject(ctx, viper.GetString(cfg.AwsBucket), issue.FileKey)
+		case models.ReconcileIssueStuckUpload:
+			err = _this.failUploads(issue, "the upload was stuck and marked as failed by reconciliation", "Processing", "Queued")
+		}
+		if err != nil {
+			issue.Error = err.Error()
+			run.Failed++
+			_this.logger.TraceCtx(ctx).Errorf("reconcile run %d: failed to %s a %s (document %q, upload %d, file %q): %v",
+				run.ID, issue.Action, issue.Kind, issue.DocumentID, issue.UploadID, issue.FileKey, err)
+			continue
+		}
+		issue.Fixed = true
+		run.Fixed++
+	}
+	return nil
+}
+
+// fixMissingDocument rebuilds a missing document from its record, or marks its uploads as failed
+// when the policy says so or there is nothing to rebuild it from.
+func (_this *ReconcileService) fixMissingDocument(ctx context.Context, issue *reconcileIssue) error {
+	if issue.Action == models.ReconcileActionRequeue && issue.hasRecord {
+		err := _this.dataProcessing.RebuildDocument(ctx, issue.DocumentID, false)
+		if err != ErrNoStoredContent {
+			return err
+		}
+	}
+	if len(issue.uploadIDs) == 0 {
+		return fmt.Errorf("the document cannot be rebuilt and has no upload to mark as failed")
+	}
+	issue.Action = models.ReconcileActionFail
+	return _this.failUploads(issue, "the document was missing from the search index and could not be rebuilt", "Success")
+}
+
+// failUploads marks the uploads of an issue as failed with reason, unless they left statuses in the
+// meantime.
+func (_this *ReconcileService) failUploads(issue *reconcileIssue, reason string, statuses ...string) error {
+	failed, err := _this.uploadRepo.MarkFailed(_this.db, issue.uploadIDs, statuses, reason)
+	if err != nil {
+		return err
+	}
+	if failed == 0 {
+		return fmt.Errorf("the upload changed status during the run")
+	}
+	return nil
+}
+
+// deleteDocument deletes an orphan document through the outbox, after any change still queued for it.
+func (_this *ReconcileService) deleteDocument(ctx context.Context, documentID string) error {
+	err := _this.db.Transaction(func(tx *db.DB) error {
+		return _this.projections.DeleteResume(tx, documentID)
+	})
+	if err != nil {
+		return err
+	}
+	_this.projections.Notify()
+	if err := _this.candidates.RemoveDocument(ctx, documentID); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to remove document %s from its candidate: %v", documentID, err)
+	}
+	return nil
+}
+
+// reconcilePolicy completes the policy of a request with RECONCILE_POLICY and validates it.
+func reconcilePolicy(requested dtos.ReconcilePolicy) (dtos.ReconcilePolicy, error) {
+	policy := requested
+	for _, entry := range strings.Split(viper.GetString(cfg.ReconcilePolicy), ",") {
+		kind, action, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		if kind == "" {
+			continue
+		}
+		if field := policyField(&policy, strings.TrimSpace(kind)); field != nil && *field == "" {
+			*field = strings.TrimSpace(action)
+		}
+	}
+
+	for kind, actions := range reconcileActions {
+		field := policyField(&policy, kind)
+		if *field == "" {
+			*field = models.ReconcileActionReport
+		}
+		allowed := false
+		for _, action := range actions {
+			allowed = allowed || *field == action
+		}
+		if !allowed {
+			return policy, errors.NewCusErr(errors.ErrCommonInvalidRequest)
+		}
+	}
+	return policy, nil
+}
+
+func policyField(policy *dtos.ReconcilePolicy, kind string) *string {
+	switch kind {
+	case models.ReconcileIssueMissingDocument:
+		return &policy.MissingDocument
+	case models.ReconcileIssueOrphanDocument:
+		return &policy.OrphanDocument
+	case models.ReconcileIssueOrphanObject:
+		return &policy.OrphanObject
+	case models.ReconcileIssueStuckUpload:
+		return &policy.StuckUpload
+	}
+	return nil
+}
+
+func policyAction(policy dtos.ReconcilePolicy, kind string) string {
+	if field := policyField(&policy, kind); field != nil {
+		return *field
+	}
+	return models.ReconcileActionReport
+}
+
+func reconcileGracePeriod() time.Duration {
+	if grace := viper.GetDuration(cfg.ReconcileGracePeriod); grace > 0 {
+		return grace
+	}
+	return defaultReconcileGracePeriod
+}
+
+func toReconcileRunDTO(run models.ReconcileRun, withIssues bool) dtos.ReconcileRunDTO {
+	runDTO := dtos.ReconcileRunDTO{
+		ID:        run.ID,
+		Trigger:   run.Trigger,
+		DryRun:    run.DryRun,
+		Status:    run.Status,
+		Uploads:   run.Uploads,
+		Records:   run.Records,
+		Documents: run.Documents,
+		Objects:   run.Objects,
+		Found:     run.Found,
+		Fixed:     run.Fixed,
+		Failed:    run.Failed,
+		Counts:    map[string]int{},
+		LastError: run.LastError,
+		CreatedAt: run.CreatedAt.Unix(),
+		UpdatedAt: run.UpdatedAt.Unix(),
+	}
+	_ = json.Unmarshal([]byte(run.Policy), &runDTO.Policy)
+	_ = json.Unmarshal([]byte(run.Counts), &runDTO.Counts)
+	if withIssues {
+		_ = json.Unmarshal([]byte(run.Issues), &runDTO.Issues)
+	}
+	if run.FinishedAt != nil {
+		finishedAt := run.FinishedAt.Unix()
+		runDTO.FinishedAt = &finishedAt
+	}
+	return runDTO
+}
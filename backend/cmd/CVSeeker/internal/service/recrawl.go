@@ -0,0 +1,140 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/crawler"
+	"CVSeeker/pkg/dedupe"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/spf13/viper"
+	"time"
+)
+
+const (
+	// defaultRecrawlAfter is used when LINKEDIN_RECRAWL_AFTER is not set.
+	defaultRecrawlAfter = 30 * 24 * time.Hour
+	// defaultRecrawlLimit is used when LINKEDIN_RECRAWL_LIMIT is not set.
+	defaultRecrawlLimit = 50
+)
+
+// StartRecrawlSchedule recrawls the stale LinkedIn profiles in the background every
+// LINKEDIN_RECRAWL_INTERVAL until ctx is done.
+func (_this *DataProcessingService) StartRecrawlSchedule(ctx context.Context) error {
+	interval := viper.GetDuration(cfg.LinkedInRecrawlInterval)
+	if interval <= 0 {
+		return nil
+	}
+	return _this.workers.Go(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if _, err := _this.RecrawlLinkedIn(ctx); err != nil && ctx.Err() == nil {
+				_this.logger.TraceCtx(ctx).Warnf("scheduled LinkedIn recrawl skipped: %v", err)
+			}
+		}
+	})
+}
+
+// RecrawlLinkedIn crawls again up to LINKEDIN_RECRAWL_LIMIT LinkedIn profiles not crawled for
+// LINKEDIN_RECRAWL_AFTER. The profiles whose text changed are ingested as a recrawl batch that
+// replaces their documents, and those that could not be crawled are failed uploads of the batch;
+// the documents of unchanged profiles are left as they are. The batch is returned, or nil when no
+// profile changed or failed.
+func (_this *DataProcessingService) RecrawlLinkedIn(ctx context.Context) (*dtos.BatchDTO, error) {
+	if err := _this.usageService.CheckBudget(ctx, true); err != nil {
+		return nil, err
+	}
+	stale, err := _this.resumeRepo.FindStaleLinkedIn(_this.db, time.Now().Add(-recrawlAfter()), recrawlLimit())
+	if err != nil || len(stale) == 0 {
+		return nil, err
+	}
+
+	urls := make([]string, len(stale))
+	for i, resume := range stale {
+		urls[i] = resume.DownloadLink
+	}
+	results := _this.crawler.Fetch(ctx, urls)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	crawled := make([]int, len(stale))
+	var resumes []dtos.ResumeData
+	// failures holds the reason each resume could not be crawled, "" for those that were.
+	var failures []string
+	for i, result := range results {
+		crawled[i] = stale[i].ResumeId
+		resume := dtos.ResumeData{
+			FileBytes:   result.URL,
+			Name:        crawler.NameFromURL(result.URL),
+			UUID:        uuid.New().String(),
+			OnDuplicate: models.DuplicatePolicyReplace,
+		}
+		switch {
+		case result.Err != nil:
+			failures = append(failures, fmt.Sprintf("failed to fetch the LinkedIn profile: %v", result.Err))
+		case dedupe.HashText(result.Profile.Text) == stale[i].TextHash:
+			continue
+		default:
+			resume.Content = result.Profile.Text
+			failures = append(failures, "")
+		}
+		resumes = append(resumes, resume)
+	}
+	// Profiles that failed are tried again after LINKEDIN_RECRAWL_AFTER too, so that a removed
+	// profile does not hold back the others.
+	if err := _this.resumeRepo.MarkCrawled(_this.db, crawled, time.Now()); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to mark %d LinkedIn profiles as crawled: %v", len(crawled), err)
+	}
+	_this.logger.TraceCtx(ctx).Infof("recrawled %d LinkedIn profiles: %d changed or failed", len(stale), len(resumes))
+	if len(resumes) == 0 {
+		return nil, nil
+	}
+
+	batch := &models.Batch{Source: models.BatchSourceRecrawl}
+	items, err := _this.createBatch(ctx, batch, resumes, true)
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to create the recrawl batch: %v", err)
+		return nil, err
+	}
+	var started []batchItem
+	for i, item := range items {
+		if failures[i] != "" {
+			_this.failUpload(ctx, item.upload.ID, item.upload.Name, failures[i])
+			continue
+		}
+		started = append(started, item)
+	}
+	if err := _this.startBatch(ctx, batch, started, true); err != nil {
+		return nil, err
+	}
+
+	uploads := make([]models.Upload, len(items))
+	for i, item := range items {
+		uploads[i] = *item.upload
+	}
+	batchDTO := toBatchDTO(*batch, uploads)
+	return &batchDTO, nil
+}
+
+func recrawlAfter() time.Duration {
+	if after := viper.GetDuration(cfg.LinkedInRecrawlAfter); after > 0 {
+		return after
+	}
+	return defaultRecrawlAfter
+}
+
+func recrawlLimit() int {
+	if limit := viper.GetInt(cfg.LinkedInRecrawlLimit); limit > 0 {
+		return limit
+	}
+	return defaultRecrawlLimit
+}
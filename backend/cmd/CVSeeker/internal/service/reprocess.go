@@ -0,0 +1,510 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/websocket"
+	"CVSeeker/pkg/worker"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/dig"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultReprocessRatePerMinute throttles reprocessing when REPROCESS_RATE_PER_MINUTE is not set.
+	defaultReprocessRatePerMinute = 30
+	// defaultReprocessStaleAfter is how long a running job may go without progress before it is
+	// considered abandoned by a stopped process and may be resumed.
+	defaultReprocessStaleAfter = 10 * time.Minute
+	reprocessPageSize          = 50
+	reprocessHeartbeatPeriod   = time.Minute
+)
+
+// reprocessUnknownVersion selects documents indexed before model and prompt versions were recorded.
+const reprocessUnknownVersion = "none"
+
+type IReprocessService interface {
+	// CreateJob validates a request and stores it as a pending job.
+	CreateJob(ctx context.Context, request dtos.ReprocessRequest) (*models.ReprocessJob, error)
+	// Run processes a job until it completes, is paused or ctx is cancelled. It returns
+	// ErrReprocessJobNotResumable when the job is already running or completed.
+	Run(ctx context.Context, jobID int64) error
+	// GetJob returns the current state of a job.
+	GetJob(ctx context.Context, jobID int64) (*dtos.ReprocessJobDTO, error)
+	// ResumeInterrupted restarts in the background the jobs that were running when the process stopped.
+	ResumeInterrupted(ctx context.Context)
+
+	StartReprocess(c *gin.Context, request dtos.ReprocessRequest) (*meta.BasicResponse, error)
+	GetReprocessJobs(c *gin.Context) (*meta.BasicResponse, error)
+	GetReprocessJob(c *gin.Context, jobID int64) (*meta.BasicResponse, error)
+	PauseReprocessJob(c *gin.Context, jobID int64) (*meta.BasicResponse, error)
+	ResumeReprocessJob(c *gin.Context, jobID int64) (*meta.BasicResponse, error)
+}
+
+type ReprocessService struct {
+	db             *db.DB
+	jobRepo        repositories.IReprocessJobRepository
+	uploadRepo     repositories.IUploadRepository
+	resumeRepo     repositories.IResumeRepository
+	elasticClient  elasticsearch.IElasticsearchClient
+	dataProcessing IDataProcessingService
+	promptService  IPromptService
+	workers        *worker.Group
+	logger         logger.Logger
+}
+
+type ReprocessServiceArgs struct {
+	dig.In
+	DB             *db.DB `name:"talentAcquisitionDB"`
+	JobRepo        repositories.IReprocessJobRepository
+	UploadRepo     repositories.IUploadRepository
+	ResumeRepo     repositories.IResumeRepository
+	ElasticClient  elasticsearch.IElasticsearchClient
+	DataProcessing IDataProcessingService
+	PromptService  IPromptService
+	Workers        *worker.Group
+	Logger         logger.Logger
+}
+
+func NewReprocessService(args ReprocessServiceArgs) IReprocessService {
+	return &ReprocessService{
+		db:             args.DB,
+		jobRepo:        args.JobRepo,
+		uploadRepo:     args.UploadRepo,
+		resumeRepo:     args.ResumeRepo,
+		elasticClient:  args.ElasticClient,
+		dataProcessing: args.DataProcessing,
+		promptService:  args.PromptService,
+		workers:        args.Workers,
+		logger:         args.Logger,
+	}
+}
+
+func (_this *ReprocessService) CreateJob(ctx context.Context, request dtos.ReprocessRequest) (*models.ReprocessJob, error) {
+	if request.Mode != models.ReprocessModeEmbed && request.Mode != models.ReprocessModeExtract {
+		return nil, errors.NewCusErr(errors.ErrCommonInvalidRequest)
+	}
+	selection := request.Selection
+	if selection.From != nil && selection.To != nil && *selection.From >= *selection.To {
+		return nil, errors.NewCusErr(errors.ErrCommonInvalidRequest)
+	}
+
+	total, err := _this.uploadRepo.CountIndexedDocuments(_this.db, uploadFilter(selection))
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := json.Marshal(selection)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.ReprocessJob{
+		Mode:      request.Mode,
+		Selection: string(encoded),
+		Status:    models.ReprocessStatusPending,
+		Total:     total,
+	}
+	if err := _this.jobRepo.Create(_this.db, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (_this *ReprocessService) Run(ctx context.Context, jobID int64) (err error) {
+	ctx, span := tracing.Start(ctx, "reprocess.Run", attribute.Int64("reprocess.job_id", jobID))
+	defer tracing.End(span, &err)
+
+	claimed, err := _this.jobRepo.Claim(_this.db, jobID, time.Now().Add(-reprocessStaleAfter()))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		if _, err := _this.jobRepo.FindByID(_this.db, jobID); err == db.ErrRecordNotFound {
+			return errors.NewCusErr(errors.ErrReprocessJobNotFound)
+		}
+		return errors.NewCusErr(errors.ErrReprocessJobNotResumable)
+	}
+	job, err := _this.jobRepo.FindByID(_this.db, jobID)
+	if err != nil {
+		return err
+	}
+	var selection dtos.ReprocessSelection
+	if err := json.Unmarshal([]byte(job.Selection), &selection); err != nil {
+		_this.finish(ctx, job, models.ReprocessStatusFailed, fmt.Sprintf("invalid selection: %v", err))
+		return err
+	}
+	_this.logger.TraceCtx(ctx).Infof("reprocess job %d (%s) started at upload %d", job.ID, job.Mode, job.Cursor)
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go _this.heartbeat(heartbeatCtx, job.ID)
+
+	interval := reprocessInterval()
+	filter := uploadFilter(selection)
+	for {
+		uploads, err := _this.uploadRepo.FindIndexedDocuments(_this.db, filter, job.Cursor, reprocessPageSize)
+		if err != nil {
+			_this.finish(ctx, job, models.ReprocessStatusFailed, err.Error())
+			return err
+		}
+		if len(uploads) == 0 {
+			_this.finish(ctx, job, models.ReprocessStatusCompleted, job.LastError)
+			websocket.BroadcastNotification(fmt.Sprintf("Reprocess job %d completed: %d updated, %d skipped, %d failed.", job.ID, job.Succeeded, job.Skipped, job.Failed))
+			return nil
+		}
+
+		documentIDs := make([]string, len(uploads))
+		for i, upload := range uploads {
+			documentIDs[i] = upload.DocumentID
+		}
+		documents, err := _this.elasticClient.FetchDocumentsByIDs(ctx, viper.GetString(cfg.ElasticsearchDocumentIndex), documentIDs)
+		if err != nil {
+			_this.finish(ctx, job, _this.stopStatus(ctx, models.ReprocessStatusFailed), err.Error())
+			return err
+		}
+		byID := make(map[string]elasticsearch.ResumeSummaryDTO, len(documents))
+		for _, document := range documents {
+			byID[document.Id] = document
+		}
+		// The MySQL record is the system of record; documents indexed before it was kept are read
+		// from Elasticsearch, and records that lost their document are indexed again.
+		records, err := _this.resumeRepo.FindByDocumentIDs(_this.db, documentIDs)
+		if err != nil {
+			_this.finish(ctx, job, _this.stopStatus(ctx, models.ReprocessStatusFailed), err.Error())
+			return err
+		}
+		for _, record := range records {
+			if content, err := resumeContent(record); err == nil {
+				byID[record.DocumentID] = *content
+			}
+		}
+
+		for _, upload := range uploads {
+			if ctx.Err() != nil || _this.workers.ShuttingDown() {
+				_this.finish(ctx, job, models.ReprocessStatusInterrupted, job.LastError)
+				return ctx.Err()
+			}
+
+			document, found := byID[upload.DocumentID]
+			switch {
+			case !found:
+				job.Skipped++
+			case !_this.selected(ctx, document, job.Mode, selection):
+				job.Skipped++
+			default:
+				err := _this.dataProcessing.ReindexDocument(ctx, document, job.Mode == models.ReprocessModeExtract)
+				switch {
+				case err == nil:
+					job.Succeeded++
+				case err == ErrNoSourceText:
+					job.Skipped++
+				case ctx.Err() != nil:
+					// The document was not reprocessed; it is retried when the job resumes.
+					_this.finish(ctx, job, models.ReprocessStatusInterrupted, job.LastError)
+					return ctx.Err()
+				default:
+					job.Failed++
+					job.LastError = fmt.Sprintf("document %s: %v", upload.DocumentID, err)
+					_this.logger.TraceCtx(ctx).Errorf("reprocess job %d: failed to reprocess document %s: %v", job.ID, upload.DocumentID, err)
+				}
+				if interval > 0 {
+					select {
+					case <-ctx.Done():
+					case <-time.After(interval):
+					}
+				}
+			}
+
+			job.Cursor = upload.ID
+			running, err := _this.jobRepo.SaveProgress(_this.db, job, models.ReprocessStatusRunning)
+			if err != nil {
+				_this.logger.TraceCtx(ctx).Errorf("reprocess job %d: failed to save progress: %v", job.ID, err)
+			} else if !running {
+				_this.logger.TraceCtx(ctx).Infof("reprocess job %d paused at upload %d", job.ID, job.Cursor)
+				return nil
+			}
+		}
+	}
+}
+
+func (_this *ReprocessService) GetJob(ctx context.Context, jobID int64) (*dtos.ReprocessJobDTO, error) {
+	job, err := _this.jobRepo.FindByID(_this.db, jobID)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrReprocessJobNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	jobDTO := toReprocessJobDTO(*job)
+	return &jobDTO, nil
+}
+
+func (_this *ReprocessService) ResumeInterrupted(ctx context.Context) {
+	jobs, err := _this.jobRepo.FindResumable(_this.db, time.Now().Add(-reprocessStaleAfter()))
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to find interrupted reprocess jobs: %v", err)
+		return
+	}
+	for _, job := range jobs {
+		_this.logger.TraceCtx(ctx).Infof("resuming reprocess job %d", job.ID)
+		_this.start(ctx, job.ID)
+	}
+}
+
+func (_this *ReprocessService) StartReprocess(c *gin.Context, request dtos.ReprocessRequest) (*meta.BasicResponse, error) {
+	job, err := _this.CreateJob(c, request)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to create reprocess job: %v", err)
+		return nil, err
+	}
+	if err := _this.start(tracing.Detach(c.Request.Context()), job.ID); err != nil {
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reprocess job started",
+		},
+		Data: toReprocessJobDTO(*job),
+	}
+	return response, nil
+}
+
+func (_this *ReprocessService) GetReprocessJobs(c *gin.Context) (*meta.BasicResponse, error) {
+	jobs, err := _this.jobRepo.GetAll(_this.db)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get reprocess jobs: %v", err)
+		return nil, err
+	}
+
+	jobDTOs := make([]dtos.ReprocessJobDTO, 0, len(jobs))
+	for _, job := range jobs {
+		jobDTOs = append(jobDTOs, toReprocessJobDTO(job))
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reprocess jobs retrieved successfully",
+		},
+		Data: jobDTOs,
+	}
+	return response, nil
+}
+
+func (_this *ReprocessService) GetReprocessJob(c *gin.Context, jobID int64) (*meta.BasicResponse, error) {
+	jobDTO, err := _this.GetJob(c, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reprocess job retrieved successfully",
+		},
+		Data: jobDTO,
+	}
+	return response, nil
+}
+
+func (_this *ReprocessService) PauseReprocessJob(c *gin.Context, jobID int64) (*meta.BasicResponse, error) {
+	paused, err := _this.jobRepo.Pause(_this.db, jobID)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to pause reprocess job %d: %v", jobID, err)
+		return nil, err
+	}
+	jobDTO, err := _this.GetJob(c, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if !paused {
+		return nil, errors.NewCusErr(errors.ErrReprocessJobNotResumable)
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reprocess job paused",
+		},
+		Data: jobDTO,
+	}
+	return response, nil
+}
+
+func (_this *ReprocessService) ResumeReprocessJob(c *gin.Context, jobID int64) (*meta.BasicResponse, error) {
+	jobDTO, err := _this.GetJob(c, jobID)
+	if err != nil {
+		return nil, err
+	}
+	// A running job can only be taken over once its runner has stopped reporting progress.
+	active := jobDTO.Status == models.ReprocessStatusRunning && time.Unix(jobDTO.UpdatedAt, 0).After(time.Now().Add(-reprocessStaleAfter()))
+	if jobDTO.Status == models.ReprocessStatusCompleted || active {
+		return nil, errors.NewCusErr(errors.ErrReprocessJobNotResumable)
+	}
+	if err := _this.start(tracing.Detach(c.Request.Context()), jobID); err != nil {
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Reprocess job resumed",
+		},
+		Data: jobDTO,
+	}
+	return response, nil
+}
+
+// start runs a job in the background on the ingestion workers.
+func (_this *ReprocessService) start(ctx context.Context, jobID int64) error {
+	err := _this.workers.Go(ctx, func(ctx context.Context) {
+		if err := _this.Run(ctx, jobID); err != nil && ctx.Err() == nil {
+			_this.logger.TraceCtx(ctx).Errorf("reprocess job %d stopped: %v", jobID, err)
+		}
+	})
+	if err != nil {
+		return errors.NewCusErr(errors.ErrCommonShuttingDown)
+	}
+	return nil
+}
+
+// selected applies the version filters of a selection to a document.
+func (_this *ReprocessService) selected(ctx context.Context, document elasticsearch.ResumeSummaryDTO, mode string, selection dtos.ReprocessSelection) bool {
+	if !versionMatches(document.ParseModel, selection.ParseModel) ||
+		!versionMatches(document.EmbeddingModel, selection.EmbeddingModel) ||
+		!versionMatches(document.PromptVersion, selection.PromptVersion) {
+		return false
+	}
+	if !selection.Stale {
+		return true
+	}
+
+	if document.SchemaVersion < elasticsearch.ResumeSchemaVersion {
+		return true
+	}
+	if document.EmbeddingModel != viper.GetString(cfg.HuggingfaceModel) {
+		return true
+	}
+	if mode != models.ReprocessModeExtract {
+		return false
+	}
+	promptVersion, err := _this.promptService.ActiveVersion(ctx, PromptResumeParse)
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to get the active prompt version: %v", err)
+		return true
+	}
+	return document.ParseModel != viper.GetString(cfg.ChatGptModel) || document.PromptVersion != promptVersion
+}
+
+func (_this *ReprocessService) heartbeat(ctx context.Context, jobID int64) {
+	ticker := time.NewTicker(reprocessHeartbeatPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := _this.jobRepo.Touch(_this.db, jobID); err != nil {
+				_this.logger.TraceCtx(ctx).Errorf("reprocess job %d: failed to report progress: %v", jobID, err)
+			}
+		}
+	}
+}
+
+// finish stores the final state of a job.
+func (_this *ReprocessService) finish(ctx context.Context, job *models.ReprocessJob, status, lastError string) {
+	job.LastError = lastError
+	if _, err := _this.jobRepo.SaveProgress(_this.db, job, status); err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("reprocess job %d: failed to save status %s: %v", job.ID, status, err)
+	}
+	_this.logger.TraceCtx(ctx).Infof("reprocess job %d %s: %d updated, %d skipped, %d failed", job.ID, status, job.Succeeded, job.Skipped, job.Failed)
+}
+
+// stopStatus returns interrupted when the job stops because the process is stopping, else status.
+func (_this *ReprocessService) stopStatus(ctx context.Context, status string) string {
+	if ctx.Err() != nil || _this.workers.ShuttingDown() {
+		return models.ReprocessStatusInterrupted
+	}
+	return status
+}
+
+func versionMatches(actual, wanted string) bool {
+	switch wanted {
+	case "":
+		return true
+	case reprocessUnknownVersion:
+		return actual == ""
+	}
+	return actual == wanted
+}
+
+func uploadFilter(selection dtos.ReprocessSelection) repositories.IndexedUploadFilter {
+	filter := repositories.IndexedUploadFilter{DocumentIDs: selection.DocumentIds}
+	if selection.From != nil {
+		from := time.Unix(*selection.From, 0)
+		filter.From = &from
+	}
+	if selection.To != nil {
+		to := time.Unix(*selection.To, 0)
+		filter.To = &to
+	}
+	return filter
+}
+
+// reprocessInterval is the minimum time between two reprocessed documents.
+func reprocessInterval() time.Duration {
+	rate := viper.GetInt(cfg.ReprocessRatePerMinute)
+	if rate < 0 {
+		return 0
+	}
+	if rate == 0 {
+		rate = defaultReprocessRatePerMinute
+	}
+	return time.Minute / time.Duration(rate)
+}
+
+func reprocessStaleAfter() time.Duration {
+	if staleAfter := viper.GetDuration(cfg.ReprocessStaleAfter); staleAfter > 0 {
+		return staleAfter
+	}
+	return defaultReprocessStaleAfter
+}
+
+func toReprocessJobDTO(job models.ReprocessJob) dtos.ReprocessJobDTO {
+	jobDTO := dtos.ReprocessJobDTO{
+		ID:        job.ID,
+		Mode:      job.Mode,
+		Status:    job.Status,
+		Total:     job.Total,
+		Processed: job.Processed(),
+		Succeeded: job.Succeeded,
+		Skipped:   job.Skipped,
+		Failed:    job.Failed,
+		LastError: job.LastError,
+		CreatedAt: job.CreatedAt.Unix(),
+		UpdatedAt: job.UpdatedAt.Unix(),
+	}
+	_ = json.Unmarshal([]byte(job.Selection), &jobDTO.Selection)
+	if job.FinishedAt != nil {
+		finishedAt := job.FinishedAt.Unix()
+		jobDTO.FinishedAt = &finishedAt
+	}
+	return jobDTO
+}
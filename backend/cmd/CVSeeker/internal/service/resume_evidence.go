@@ -0,0 +1,109 @@
+package services
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/llmjson"
+	"CVSeeker/pkg/utils"
+	"fmt"
+	"strings"
+)
+
+// Modes of RESUME_BASIC_INFO_MODE.
+const (
+	// BasicInfoModeEvidence only keeps basic_info values the model quotes from the resume text.
+	BasicInfoModeEvidence = "evidence"
+	// BasicInfoModeGenerated lets the model invent plausible basic_info values (the original behaviour).
+	BasicInfoModeGenerated = "generated"
+)
+
+var fieldEvidenceSchema = &llmjson.Schema{
+	Type:     llmjson.TypeObject,
+	Nullable: true,
+	Properties: map[string]*llmjson.Schema{
+		"confidence": {Type: llmjson.TypeNumber},
+		"source":     stringSchema,
+	},
+}
+
+// evidenceResumeSchema is resumeSchema with the basic_info_evidence section requested in evidence mode.
+var evidenceResumeSchema = func() *llmjson.Schema {
+	schema := *resumeSchema
+	schema.Properties = make(map[string]*llmjson.Schema, len(resumeSchema.Properties)+1)
+	for key, property := range resumeSchema.Properties {
+		schema.Properties[key] = property
+	}
+	schema.Properties["basic_info_evidence"] = &llmjson.Schema{
+		Type:     llmjson.TypeObject,
+		Nullable: true,
+		Properties: map[string]*llmjson.Schema{
+			"full_name":       fieldEvidenceSchema,
+			"university":      fieldEvidenceSchema,
+			"education_level": fieldEvidenceSchema,
+			"majors":          fieldEvidenceSchema,
+			"gpa":             fieldEvidenceSchema,
+		},
+	}
+	return &schema
+}()
+
+// verifyBasicInfo keeps the basic_info values whose quoted source is found in fullText, records the
+// span of that source and clears every other value, so that nothing unsupported by the resume is
+// indexed. It returns the names of the cleared fields.
+func verifyBasicInfo(resume *elasticsearch.ResumeSummaryDTO, fullText string) []string {
+	if resume.BasicInfoEvidence == nil {
+		resume.BasicInfoEvidence = &elasticsearch.BasicInfoEvidence{}
+	}
+	info, evidence := &resume.BasicInfo, resume.BasicInfoEvidence
+
+	var cleared []string
+	verify := func(name string, present bool, fieldEvidence **elasticsearch.FieldEvidence, clear func()) {
+		if !present {
+			*fieldEvidence = nil
+			return
+		}
+		ev := *fieldEvidence
+		if ev == nil {
+			clear()
+			cleared = append(cleared, name)
+			return
+		}
+		start, end, ok := utils.FindTextSpan(fullText, ev.Source)
+		if !ok {
+			clear()
+			*fieldEvidence = nil
+			cleared = append(cleared, name)
+			return
+		}
+		ev.Start, ev.End = start, end
+		ev.Confidence = clampConfidence(ev.Confidence)
+	}
+
+	verify("full_name", strings.TrimSpace(info.FullName) != "", &evidence.FullName, func() { info.FullName = "" })
+	verify("university", strings.TrimSpace(info.University) != "", &evidence.University, func() { info.University = "" })
+	verify("education_level", strings.TrimSpace(info.EducationLevel) != "", &evidence.EducationLevel, func() { info.EducationLevel = "" })
+	verify("majors", len(info.Majors) > 0, &evidence.Majors, func() { info.Majors = []string{} })
+	verify("gpa", info.GPA != nil, &evidence.GPA, func() { info.GPA = nil })
+
+	if *evidence == (elasticsearch.BasicInfoEvidence{}) {
+		resume.BasicInfoEvidence = nil
+	}
+	return cleared
+}
+
+func clampConfidence(confidence float64) float64 {
+	if confidence < 0 {
+		return 0
+	}
+	if confidence > 1 {
+		return 1
+	}
+	return confidence
+}
+
+// formatGPA renders an optional GPA for prompts and embedding text.
+func formatGPA(gpa *float64) string {
+	if gpa == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.2f", *gpa)
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/httpclient"
+	"CVSeeker/pkg/llmjson"
+	"CVSeeker/pkg/summarizer"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"strings"
+)
+
+// defaultResumeParseAttempts bounds the GPT calls made for one resume when RESUME_PARSE_MAX_ATTEMPTS is not set.
+const defaultResumeParseAttempts = 3
+
+// parseAnswerTokens is the expected size of a parsed resume, counted against OPENAI_TOKENS_PER_MINUTE
+// before the answer is known.
+const parseAnswerTokens = 1000
+
+var stringSchema = &llmjson.Schema{Type: llmjson.TypeString}
+
+// resumeSchema describes the JSON GPT must return for a resume; it matches elasticsearch.ResumeSummaryDTO.
+var resumeSchema = &llmjson.Schema{
+	Type:     llmjson.TypeObject,
+	Required: []string{"summary", "skills", "basic_info"},
+	Properties: map[string]*llmjson.Schema{
+		"summary": stringSchema,
+		"skills":  {Type: llmjson.TypeArray, Items: stringSchema},
+		"basic_info": {
+			Type:     llmjson.TypeObject,
+			Required: []string{"full_name"},
+			Properties: map[string]*llmjson.Schema{
+				"full_name":       stringSchema,
+				"university":      stringSchema,
+				"education_level": stringSchema,
+				"majors":          {Type: llmjson.TypeArray, Items: stringSchema},
+				"gpa":             {Type: llmjson.TypeNumber, Nullable: true},
+			},
+		},
+		"contacts": {
+			Type: llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{
+				"emails": {Type: llmjson.TypeArray, Items: stringSchema},
+				"phones": {Type: llmjson.TypeArray, Items: stringSchema},
+				"links": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+					Type:       llmjson.TypeObject,
+					Properties: map[string]*llmjson.Schema{"url": stringSchema},
+				}},
+			},
+		},
+		"education": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+			Type: llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{
+				"institution":     stringSchema,
+				"degree":          stringSchema,
+				"majors":          {Type: llmjson.TypeArray, Items: stringSchema},
+				"gpa":             {Type: llmjson.TypeNumber, Nullable: true},
+				"graduation_year": {Type: llmjson.TypeInteger, Nullable: true},
+			},
+		}},
+		"certifications": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+			Type: llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{
+				"name":   stringSchema,
+				"issuer": stringSchema,
+				"year":   {Type: llmjson.TypeInteger, Nullable: true},
+			},
+		}},
+		"languages": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+			Type: llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{
+				"language":    stringSchema,
+				"proficiency": stringSchema,
+			},
+		}},
+		"work_experience": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+			Type: llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{
+				"job_title":   stringSchema,
+				"company":     stringSchema,
+				"location":    stringSchema,
+				"duration":    stringSchema,
+				"job_summary": stringSchema,
+				"start_date":  stringSchema,
+				"end_date":    stringSchema,
+				"current":     {Type: llmjson.TypeBoolean},
+				"skills":      {Type: llmjson.TypeArray, Items: stringSchema},
+			},
+		}},
+		"project_experience": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+			Type: llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{
+				"project_name":        stringSchema,
+				"project_description": stringSchema,
+			},
+		}},
+		"award": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+			Type:       llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{"award_name": stringSchema},
+		}},
+	},
+}
+
+// parseResume asks GPT to structure fullText and validates the answer against resumeSchema. In
+// evidence mode, basic_info values are then checked against the resume text (see verifyBasicInfo);
+// contacts are validated in every mode (see cleanProfile). Answers
+// that cannot be repaired locally are sent back to the model together with the validation errors,
+// up to RESUME_PARSE_MAX_ATTEMPTS calls in total. The prompt is the active version of PromptResumeParse,
+// which is recorded on the resume.
+func (_this *DataProcessingService) parseResume(ctx context.Context, fullText string) (_ *elasticsearch.ResumeSummaryDTO, err error) {
+	ctx, span := tracing.Start(ctx, "ingestion.ParseResume")
+	defer tracing.End(span, &err)
+	ctx = usage.WithOperation(ctx, usage.OperationResumeSummarize)
+
+	maxAttempts := viper.GetInt(cfg.ResumeParseMaxAttempts)
+	if maxAttempts <= 0 {
+		maxAttempts = defaultResumeParseAttempts
+	}
+
+	withEvidence := !strings.EqualFold(viper.GetString(cfg.ResumeBasicInfoMode), BasicInfoModeGenerated)
+	schema := resumeSchema
+	if withEvidence {
+		schema = evidenceResumeSchema
+	}
+
+	prompt, promptVersion, err := _this.promptService.Render(ctx, PromptResumeParse, resumePromptData{ResumeText: fullText, WithEvidence: withEvidence})
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to render the resume prompt: %v", err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.String("resume.prompt_version", promptVersion))
+
+	request := summarizer.ChatRequest{
+		Model:          viper.GetString(cfg.ChatGptModel),
+		Messages:       []summarizer.ChatMessage{{Role: "user", Content: prompt}},
+		ResponseFormat: resumeResponseFormat(viper.GetString(cfg.ResumeParseResponseFormat), schema),
+	}
+
+	for attempt := 1; ; attempt++ {
+		answer, err := _this.chat(ctx, request)
+		if err != nil && request.ResponseFormat != nil && httpclient.StatusCode(err) == http.StatusBadRequest {
+			// The model does not support the requested response format; fall back to plain text.
+			_this.logger.TraceCtx(ctx).Warnf("response format %s rejected, retrying without it: %v", request.ResponseFormat.Type, err)
+			request.ResponseFormat = nil
+			answer, err = _this.chat(ctx, request)
+		}
+		if err != nil {
+			_this.logger.TraceCtx(ctx).Errorf("failed to summarize using GPT: %v", err)
+			return nil, err
+		}
+
+		var resume elasticsearch.ResumeSummaryDTO
+		err = llmjson.Decode(answer, schema, &resume)
+		if err == nil {
+			span.SetAttributes(attribute.Int("resume.parse_attempts", attempt))
+			if withEvidence {
+				if cleared := verifyBasicInfo(&resume, fullText); len(cleared) > 0 {
+					_this.logger.TraceCtx(ctx).Infof("dropped basic_info values without evidence in the resume text: %s", strings.Join(cleared, ", "))
+				}
+			} else {
+				resume.BasicInfoEvidence = nil
+			}
+			if dropped := cleanProfile(&resume); len(dropped) > 0 {
+				// The values are personal data and are left out of the logs.
+				_this.logger.TraceCtx(ctx).Infof("dropped %d invalid contact values", len(dropped))
+			}
+			resume.SchemaVersion = elasticsearch.ResumeSchemaVersion
+			resume.PromptVersion = promptVersion
+			resume.ParseModel = request.Model
+			return &resume, nil
+		}
+
+		_this.logger.TraceCtx(ctx).Warnf("invalid resume JSON from GPT (attempt %d/%d): %v", attempt, maxAttempts, err)
+		if attempt >= maxAttempts {
+			return nil, fmt.Errorf("resume could not be parsed after %d attempts: %w", attempt, err)
+		}
+		request.Messages = append(request.Messages,
+			summarizer.ChatMessage{Role: "assistant", Content: answer},
+			summarizer.ChatMessage{Role: "user", Content: generateRepairPrompt(err)},
+		)
+	}
+}
+
+// resumeResponseFormat maps RESUME_PARSE_RESPONSE_FORMAT to the response_format sent with the request.
+// chat sends request to GPT once OPENAI_REQUESTS_PER_MINUTE and OPENAI_TOKENS_PER_MINUTE allow it.
+func (_this *DataProcessingService) chat(ctx context.Context, request summarizer.ChatRequest) (string, error) {
+	tokens := parseAnswerTokens
+	for _, message := range request.Messages {
+		tokens += usage.EstimateTokens(message.Content)
+	}
+	if err := _this.limits.Wait(ctx, usage.ProviderOpenAI, tokens); err != nil {
+		return "", err
+	}
+	return _this.gptClient.Chat(ctx, request)
+}
+
+func resumeResponseFormat(format string, schema *llmjson.Schema) *summarizer.ResponseFormat {
+	switch strings.ToLower(format) {
+	case summarizer.ResponseFormatJSONObject:
+		return &summarizer.ResponseFormat{Type: summarizer.ResponseFormatJSONObject}
+	case summarizer.ResponseFormatJSONSchema:
+		return &summarizer.ResponseFormat{
+			Type:       summarizer.ResponseFormatJSONSchema,
+			JSONSchema: &summarizer.JSONSchema{Name: "resume", Schema: schema},
+		}
+	}
+	return nil
+}
+
+func generateRepairPrompt(err error) string {
+	var sb strings.Builder
+	sb.WriteString("Your previous answer could not be used")
+	var validationErrs llmjson.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		sb.WriteString(" because it does not match the required structure:\n")
+		for _, fieldErr := range validationErrs {
+			sb.WriteString("- ")
+			sb.WriteString(fieldErr.Error())
+			sb.WriteString("\n")
+		}
+	} else {
+		sb.WriteString(": ")
+		sb.WriteString(err.Error())
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\nReply with the corrected JSON object only, following the structure given above, without Markdown or any other text.")
+	return sb.String()
+}
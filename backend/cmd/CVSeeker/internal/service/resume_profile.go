@@ -0,0 +1,151 @@
+package services
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/utils"
+	"strings"
+	"time"
+)
+
+// minProfileYear is the earliest graduation or certification year kept; earlier years are
+// misread dates.
+const minProfileYear = 1950
+
+// cleanProfile validates the contacts, education, certifications, languages and role dates of a
+// parsed resume. Contacts that are not valid e-mail addresses, phone numbers or web URLs are
+// dropped and the others normalized, entries without a name and implausible years are dropped,
+// duplicates are removed, proficiency levels are mapped to the elasticsearch.Proficiency values
+// and role dates are normalized (see elasticsearch.NormalizeWorkDates). It returns the contacts it
+// dropped.
+func cleanProfile(resume *elasticsearch.ResumeSummaryDTO) []string {
+	var dropped []string
+	contacts := &resume.Contacts
+
+	emails := make([]string, 0, len(contacts.Emails))
+	for _, email := range contacts.Emails {
+		if normalized, ok := utils.NormalizeEmail(email); ok {
+			emails = appendUniqueFold(emails, normalized)
+		} else if strings.TrimSpace(email) != "" {
+			dropped = append(dropped, email)
+		}
+	}
+	phones := make([]string, 0, len(contacts.Phones))
+	for _, phone := range contacts.Phones {
+		if normalized, ok := utils.NormalizePhone(phone); ok {
+			phones = appendUniqueFold(phones, normalized)
+		} else if strings.TrimSpace(phone) != "" {
+			dropped = append(dropped, phone)
+		}
+	}
+	links := make([]elasticsearch.Link, 0, len(contacts.Links))
+	seenLinks := map[string]bool{}
+	for _, link := range contacts.Links {
+		normalized, ok := utils.NormalizeURL(link.URL)
+		if !ok {
+			if strings.TrimSpace(link.URL) != "" {
+				dropped = append(dropped, link.URL)
+			}
+			continue
+		}
+		if key := strings.ToLower(normalized); !seenLinks[key] {
+			seenLinks[key] = true
+			links = append(links, elasticsearch.Link{Kind: elasticsearch.LinkKindOf(normalized), URL: normalized})
+		}
+	}
+	resume.Contacts = elasticsearch.Contacts{Emails: emails, Phones: phones, Links: links}
+
+	education := make([]elasticsearch.Education, 0, len(resume.Education))
+	for _, degree := range resume.Education {
+		degree.Institution, degree.Degree = strings.TrimSpace(degree.Institution), strings.TrimSpace(degree.Degree)
+		if degree.Institution == "" && degree.Degree == "" {
+			continue
+		}
+		if degree.Majors == nil {
+			degree.Majors = []string{}
+		}
+		// Ongoing studies may state the expected year.
+		degree.GraduationYear = plausibleYear(degree.GraduationYear, 10)
+		education = append(education, degree)
+	}
+	resume.Education = education
+
+	certifications := make([]elasticsearch.Certification, 0, len(resume.Certifications))
+	for _, certification := range resume.Certifications {
+		certification.Name = strings.TrimSpace(certification.Name)
+		if certification.Name == "" {
+			continue
+		}
+		certification.Issuer = strings.TrimSpace(certification.Issuer)
+		certification.Year = plausibleYear(certification.Year, 1)
+		certifications = append(certifications, certification)
+	}
+	resume.Certifications = certifications
+
+	languages := make([]elasticsearch.Language, 0, len(resume.Languages))
+	seenLanguages := map[string]bool{}
+	for _, language := range resume.Languages {
+		language.Language = strings.TrimSpace(language.Language)
+		key := strings.ToLower(language.Language)
+		if key == "" || seenLanguages[key] {
+			continue
+		}
+		seenLanguages[key] = true
+		language.Proficiency = elasticsearch.NormalizeProficiency(language.Proficiency)
+		languages = append(languages, language)
+	}
+	resume.Languages = languages
+
+	now := time.Now()
+	for i := range resume.WorkExperience {
+		elasticsearch.NormalizeWorkDates(&resume.WorkExperience[i], now)
+	}
+
+	return dropped
+}
+
+// upgradeResume brings content parsed with an older schema up to elasticsearch.ResumeSchemaVersion
+// with what is known without parsing it again: the contacts found in the text when the resume was
+// fingerprinted, and an education entry for basic_info. Languages, certifications and links need
+// a reparse, which a reprocess job in extract mode does, and so do the dates of roles whose
+// duration does not state them. Current content is only given the fingerprinted contacts the model
+// missed. Years of experience are computed again every time, so that current roles count up to
+// the day the resume is projected.
+func upgradeResume(record *models.Resume, resume *elasticsearch.ResumeSummaryDTO) {
+	if len(resume.Contacts.Emails) == 0 {
+		resume.Contacts.Emails = splitList(record.Emails)
+	}
+	if len(resume.Contacts.Phones) == 0 {
+		resume.Contacts.Phones = splitList(record.Phones)
+	}
+	info := resume.BasicInfo
+	if resume.SchemaVersion < elasticsearch.ResumeSchemaVersion && len(resume.Education) == 0 &&
+		(strings.TrimSpace(info.University) != "" || strings.TrimSpace(info.EducationLevel) != "") {
+		resume.Education = []elasticsearch.Education{{
+			Institution: info.University,
+			Degree:      info.EducationLevel,
+			Majors:      info.Majors,
+			GPA:         info.GPA,
+		}}
+	}
+	cleanProfile(resume)
+	elasticsearch.ComputeExperience(resume, time.Now())
+	resume.SchemaVersion = elasticsearch.ResumeSchemaVersion
+}
+
+// plausibleYear returns year when it lies between minProfileYear and maxAhead years from now.
+func plausibleYear(year *int, maxAhead int) *int {
+	if year == nil || *year < minProfileYear || *year > time.Now().Year()+maxAhead {
+		return nil
+	}
+	return year
+}
+
+func appendUniqueFold(values []string, value string) []string {
+	for _, existing := range values {
+		if strings.EqualFold(existing, value) {
+			return values
+		}
+	}
+	return append(values, value)
+}
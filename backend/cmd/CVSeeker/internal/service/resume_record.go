@@ -0,0 +1,217 @@
+package services
+
+import (
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/dedupe"
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/tracing"
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// resumeSource returns where a resume came from: the ingestion source that picked it up, LinkedIn or
+// an upload.
+func resumeSource(resume dtos.ResumeData, isLinkedin bool) string {
+	switch {
+	case resume.Source != "":
+		return resume.Source
+	case isLinkedin:
+		return models.ResumeSourceLinkedIn
+	default:
+		return models.ResumeSourceUpload
+	}
+}
+
+// newResumeRecord returns the record of a new upload, before its content is parsed.
+func newResumeRecord(fullText, fileKey, source string, fingerprint dedupe.Fingerprint) *models.Resume {
+	return &models.Resume{
+		FullText: fullText,
+		FileKey:  fileKey,
+		Source:   source,
+		FileHash: fingerprint.FileHash,
+		TextHash: fingerprint.TextHash,
+		NameKey:  fingerprint.NameKey,
+		Emails:   strings.Join(fingerprint.Emails, ","),
+		Phones:   strings.Join(fingerprint.Phones, ","),
+	}
+}
+
+// saveResume stores resume as the content of record, with the versions of the models that produced
+// it, and creates the record when it is new. The candidate fields are left out: they are derived
+// from the candidate tables when the document is projected.
+func (_this *DataProcessingService) saveResume(tx *db.DB, record *models.Resume, resume *elasticsearch.ResumeSummaryDTO) error {
+	stored := *resume
+	stored.Id, stored.Point = "", 0
+	stored.CandidateID, stored.Superseded = 0, false
+	content, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	record.Content = string(content)
+	record.DownloadLink = resume.URL
+	record.PromptVersion = resume.PromptVersion
+	record.ParseModel = resume.ParseModel
+	record.EmbeddingModel = resume.EmbeddingModel
+
+	if record.ResumeId == 0 {
+		now := time.Now()
+		record.CreatedAt, record.UpdatedAt = now, now
+		_, err = _this.resumeRepo.Create(tx, record)
+		return err
+	}
+	return _this.resumeRepo.Update(tx, record)
+}
+
+// resumeContent decodes the parsed content stored in a record.
+func resumeContent(record models.Resume) (*elasticsearch.ResumeSummaryDTO, error) {
+	if record.Content == "" {
+		return nil, ErrNoStoredContent
+	}
+	var resume elasticsearch.ResumeSummaryDTO
+	if err := json.Unmarshal([]byte(record.Content), &resume); err != nil {
+		return nil, err
+	}
+	resume.Id = record.DocumentID
+	return &resume, nil
+}
+
+// reparseResume parses the text stored in a record again, once the LLM budget allows it.
+func (_this *DataProcessingService) reparseResume(ctx context.Context, record *models.Resume) (*elasticsearch.ResumeSummaryDTO, error) {
+	if record.FullText == "" {
+		return nil, ErrNoSourceText
+	}
+	if err := _this.usageService.WaitForBudget(ctx, nil); err != nil {
+		return nil, err
+	}
+	return _this.parseResume(ctx, record.FullText)
+}
+
+// project saves resume as the content of record and queues the rebuild of the Elasticsearch
+// document of the record from it, with a new embedding. Content of an older schema is upgraded
+// first (see upgradeResume).
+func (_this *DataProcessingService) project(ctx context.Context, record *models.Resume, resume *elasticsearch.ResumeSummaryDTO) error {
+	upgradeResume(record, resume)
+	resume.Id, resume.Point = "", 0
+	resume.CandidateID, resume.Superseded = 0, false
+	if candidate, err := _this.candidates.CandidateOf(ctx, record.DocumentID); err == nil {
+		resume.CandidateID = candidate.ID
+		resume.Superseded = candidate.LatestDocumentID != record.DocumentID
+	} else {
+		_this.logger.TraceCtx(ctx).Errorf("failed to get the candidate of document %s: %v", record.DocumentID, err)
+	}
+
+	elkResume, err := _this.embedResume(ctx, resume)
+	if err != nil {
+		return err
+	}
+	err = _this.db.Transaction(func(tx *db.DB) error {
+		if err := _this.saveResume(tx, record, resume); err != nil {
+			return err
+		}
+		return _this.projections.IndexResume(tx, record.DocumentID, elkResume)
+	})
+	if err != nil {
+		return err
+	}
+	_this.projections.Notify()
+	return nil
+}
+
+func (_this *DataProcessingService) RebuildDocument(ctx context.Context, documentID string, reparse bool) (err error) {
+	ctx, span := tracing.Start(ctx, "ingestion.RebuildDocument", attribute.String("resume.document_id", documentID))
+	defer tracing.End(span, &err)
+
+	record, err := _this.resumeRepo.FindByDocumentID(_this.db, documentID)
+	if err != nil {
+		return err
+	}
+	var resume *elasticsearch.ResumeSummaryDTO
+	if reparse {
+		if resume, err = _this.reparseResume(ctx, record); err != nil {
+			return err
+		}
+		resume.URL = record.DownloadLink
+	} else if resume, err = resumeContent(*record); err != nil {
+		return err
+	}
+	return _this.project(ctx, record, resume)
+}
+
+func (_this *DataProcessingService) GetResumeRecord(c *gin.Context, documentID string) (*meta.BasicResponse, error) {
+	record, err := _this.resumeRepo.FindByDocumentID(_this.db, documentID)
+	if err == db.ErrRecordNotFound {
+		return nil, errors.NewCusErr(errors.ErrResumeNotFound)
+	}
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get the record of document %s: %v", documentID, err)
+		return nil, err
+	}
+
+	recordDTO := dtos.ResumeRecordDTO{
+		DocumentID:     record.DocumentID,
+		Source:         record.Source,
+		FileKey:        record.FileKey,
+		DownloadLink:   record.DownloadLink,
+		FullText:       record.FullText,
+		PromptVersion:  record.PromptVersion,
+		ParseModel:     record.ParseModel,
+		EmbeddingModel: record.EmbeddingModel,
+		CreatedAt:      record.CreatedAt.Unix(),
+		UpdatedAt:      record.UpdatedAt.Unix(),
+	}
+	if content, err := resumeContent(*record); err == nil {
+		recordDTO.Content = content
+	} else if err != ErrNoStoredContent {
+		ginLogger.Gin(c).Warningf("failed to decode the content of document %s: %v", documentID, err)
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Resume record retrieved successfully",
+		},
+		Data: recordDTO,
+	}
+	return response, nil
+}
+
+func (_this *DataProcessingService) RebuildResume(c *gin.Context, documentID string, reparse bool) (*meta.BasicResponse, error) {
+	// The rebuild runs within the request, so an exhausted budget fails it instead of queueing it.
+	if reparse {
+		if err := _this.usageService.CheckBudget(c, false); err != nil {
+			return nil, err
+		}
+	}
+
+	err := _this.RebuildDocument(c, documentID, reparse)
+	switch {
+	case err == db.ErrRecordNotFound:
+		return nil, errors.NewCusErr(errors.ErrResumeNotFound)
+	case err == ErrNoStoredContent:
+		return nil, errors.NewCusErr(errors.ErrResumeNoContent)
+	case err == ErrNoSourceText:
+		return nil, errors.NewCusErr(errors.ErrResumeNoSourceText)
+	case err != nil:
+		ginLogger.Gin(c).Errorf("failed to rebuild document %s: %v", documentID, err)
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Resume rebuilt from its record",
+		},
+		Data: nil,
+	}
+	return response, nil
+}
@@ -4,8 +4,11 @@ import (
 	"CVSeeker/cmd/CVSeeker/internal/cfg"
 	"CVSeeker/internal/ginLogger"
 	"CVSeeker/internal/meta"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
 	"CVSeeker/pkg/elasticsearch"
 	"CVSeeker/pkg/huggingface"
+	"CVSeeker/pkg/usage"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"go.uber.org/dig"
@@ -13,42 +16,54 @@ import (
 )
 
 type SearchService interface {
-	HybridSearch(c *gin.Context, query string, from, size int, knnBoost float32) (*meta.BasicResponse, error)
+	HybridSearch(c *gin.Context, query string, from, size int, knnBoost float32, filter *elasticsearch.SearchFilter, sort *elasticsearch.SearchSort) (*meta.BasicResponse, error)
 	GetDocumentByID(c *gin.Context, documentID string) (*meta.BasicResponse, error)
 	DeleteDocumentByID(c *gin.Context, documentID string) (*meta.BasicResponse, error)
 }
 
 type searchServiceImpl struct {
+	db            *db.DB
+	resumeRepo    repositories.IResumeRepository
 	elasticClient elasticsearch.IElasticsearchClient
 	hfClient      huggingface.IHuggingFaceClient
+	candidates    ICandidateService
+	projections   IProjectionService
 }
 
 type SearchServiceArgs struct {
 	dig.In
+	DB            *db.DB `name:"talentAcquisitionDB"`
+	ResumeRepo    repositories.IResumeRepository
 	ElasticClient elasticsearch.IElasticsearchClient
 	HfClient      huggingface.IHuggingFaceClient
+	Candidates    ICandidateService
+	Projections   IProjectionService
 }
 
 func NewSearchService(args SearchServiceArgs) SearchService {
 	return &searchServiceImpl{
+		db:            args.DB,
+		resumeRepo:    args.ResumeRepo,
 		elasticClient: args.ElasticClient,
 		hfClient:      args.HfClient,
+		candidates:    args.Candidates,
+		projections:   args.Projections,
 	}
 }
 
-func (_this *searchServiceImpl) HybridSearch(c *gin.Context, query string, from, size int, knnBoost float32) (*meta.BasicResponse, error) {
+func (_this *searchServiceImpl) HybridSearch(c *gin.Context, query string, from, size int, knnBoost float32, filter *elasticsearch.SearchFilter, sort *elasticsearch.SearchSort) (*meta.BasicResponse, error) {
 	textEmbeddingModel := viper.GetString(cfg.HuggingfaceModel)
 	indexName := viper.GetString(cfg.ElasticsearchDocumentIndex) // Ensure you configure your index name in viper settings
 
 	// Create the vector representation of text
-	vectorEmbedding, err := _this.hfClient.GetTextEmbedding(query, textEmbeddingModel)
+	vectorEmbedding, err := _this.hfClient.GetTextEmbedding(usage.WithOperation(c, usage.OperationSearchEmbedding), query, textEmbeddingModel)
 	if err != nil {
 		ginLogger.Gin(c).Errorf("failed to get text embedding: %v", err)
 		return nil, err
 	}
 
 	// Conduct the hybrid search with pagination
-	results, err := _this.elasticClient.HybridSearchWithBoost(c, indexName, query, vectorEmbedding, from, size, knnBoost)
+	results, err := _this.elasticClient.HybridSearchWithBoost(c, indexName, query, vectorEmbedding, from, size, knnBoost, filter, sort)
 	if err != nil {
 		ginLogger.Gin(c).Errorf("failed to conduct hybrid search: %v", err)
 		return nil, err
@@ -88,13 +103,31 @@ func (_this *searchServiceImpl) GetDocumentByID(c *gin.Context, documentID strin
 
 func (_this *searchServiceImpl) DeleteDocumentByID(c *gin.Context, documentID string) (*meta.BasicResponse, error) {
 	indexName := viper.GetString(cfg.ElasticsearchDocumentIndex)
+	if _, err := _this.elasticClient.GetDocumentByID(c, indexName, documentID); err != nil {
+		// A record whose document was not indexed yet can still be deleted.
+		if _, recordErr := _this.resumeRepo.FindByDocumentID(_this.db, documentID); recordErr != nil {
+			ginLogger.Gin(c).Errorf("failed to get document %s: %v", documentID, err)
+			return nil, err
+		}
+	}
 
-	// Delete the document by ID using the Elasticsearch client
-	err := _this.elasticClient.DeleteDocumentByID(c, indexName, documentID)
+	// The record and the document are deleted together; the document is deleted by the outbox relay.
+	err := _this.db.Transaction(func(tx *db.DB) error {
+		if err := _this.resumeRepo.DeleteByDocumentID(tx, documentID); err != nil {
+			return err
+		}
+		return _this.projections.DeleteResume(tx, documentID)
+	})
 	if err != nil {
 		ginLogger.Gin(c).Errorf("failed to delete document by ID: %v", err)
 		return nil, err
 	}
+	_this.projections.Notify()
+	// The previous version of the candidate, if any, is searched again.
+	if err := _this.candidates.RemoveDocument(c, documentID); err != nil {
+		ginLogger.Gin(c).Errorf("failed to remove document %s from its candidate: %v", documentID, err)
+		return nil, err
+	}
 
 	response := &meta.BasicResponse{
 		Meta: meta.Meta{
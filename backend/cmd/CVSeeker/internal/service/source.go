@@ -0,0 +1,102 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/sources"
+	"CVSeeker/pkg/usage"
+	"CVSeeker/pkg/worker"
+	"context"
+	"github.com/spf13/viper"
+	"go.uber.org/dig"
+	"time"
+)
+
+// defaultIngestPollInterval is used when INGEST_POLL_INTERVAL is not set.
+const defaultIngestPollInterval = time.Minute
+
+// ISourceService feeds the files of the ingestion sources, such as the watched folder, to the
+// ingestion pipeline.
+type ISourceService interface {
+	// Start polls the sources in the background every INGEST_POLL_INTERVAL until ctx is done. Each
+	// delivery is ingested as an import and acknowledged, so that the source does not deliver it
+	// again; a delivery that could not be ingested is left to the next poll.
+	Start(ctx context.Context) error
+}
+
+type SourceService struct {
+	sources        []sources.Source
+	dataProcessing IDataProcessingService
+	workers        *worker.Group
+	logger         logger.Logger
+}
+
+type SourceServiceArgs struct {
+	dig.In
+	Sources        []sources.Source
+	DataProcessing IDataProcessingService
+	Workers        *worker.Group
+	Logger         logger.Logger
+}
+
+func NewSourceService(args SourceServiceArgs) ISourceService {
+	return &SourceService{
+		sources:        args.Sources,
+		dataProcessing: args.DataProcessing,
+		workers:        args.Workers,
+		logger:         args.Logger,
+	}
+}
+
+func (_this *SourceService) Start(ctx context.Context) error {
+	if len(_this.sources) == 0 {
+		return nil
+	}
+	interval := viper.GetDuration(cfg.IngestPollInterval)
+	if interval <= 0 {
+		interval = defaultIngestPollInterval
+	}
+	// The resumes of the sources are attributed to INGEST_USER, who is sent the progress of their batches.
+	ctx = usage.WithUser(ctx, viper.GetString(cfg.IngestUser))
+	for _, source := range _this.sources {
+		_this.logger.TraceCtx(ctx).Infof("ingesting from %s every %s", source.Name(), interval)
+	}
+	return _this.workers.Go(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			_this.poll(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// poll fetches the deliveries of each source once and ingests them.
+func (_this *SourceService) poll(ctx context.Context) {
+	for _, source := range _this.sources {
+		deliveries, err := source.Fetch(ctx)
+		if err != nil {
+			_this.logger.TraceCtx(ctx).Warnf("failed to fetch from %s: %v", source.Name(), err)
+			continue
+		}
+		for _, delivery := range deliveries {
+			if ctx.Err() != nil {
+				return
+			}
+			imp, err := _this.dataProcessing.IngestDelivery(ctx, source.Name(), delivery)
+			switch {
+			case err != nil:
+				_this.logger.TraceCtx(ctx).Warnf("failed to ingest %s from %s, it will be retried: %v", delivery.ID, source.Name(), err)
+			case imp == nil:
+				_this.logger.TraceCtx(ctx).Infof("%s from %s has no files to ingest", delivery.ID, source.Name())
+			}
+			if err := source.Ack(ctx, delivery, err); err != nil {
+				_this.logger.TraceCtx(ctx).Errorf("failed to acknowledge %s from %s: %v", delivery.ID, source.Name(), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,378 @@
+package services
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/errors"
+	"CVSeeker/internal/ginLogger"
+	"CVSeeker/internal/meta"
+	"CVSeeker/internal/models"
+	"CVSeeker/internal/repositories"
+	"CVSeeker/pkg/db"
+	"CVSeeker/pkg/logger"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
+	"context"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"go.uber.org/dig"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultBudgetQueuePollPeriod is how often queued work re-checks the budget.
+const defaultBudgetQueuePollPeriod = time.Minute
+
+type IUsageService interface {
+	usage.Recorder
+	GetUsageReport(c *gin.Context, from, to time.Time, tenantID *string, groupBy string) (*meta.BasicResponse, error)
+	GetBudgets(c *gin.Context) (*meta.BasicResponse, error)
+	SetBudget(c *gin.Context, request dtos.BudgetRequest) (*meta.BasicResponse, error)
+	DeleteBudget(c *gin.Context, tenantID string) (*meta.BasicResponse, error)
+	// CheckBudget returns ErrUsageBudgetExceeded when the tenant in ctx has reached its monthly budget,
+	// unless the work is queueable and the tenant's policy is to queue.
+	CheckBudget(ctx context.Context, queueable bool) error
+	// WaitForBudget blocks until the tenant in ctx is within its monthly budget. onQueued is called
+	// once if the caller has to wait.
+	WaitForBudget(ctx context.Context, onQueued func()) error
+}
+
+type UsageService struct {
+	db         *db.DB
+	usageRepo  repositories.ILlmUsageRepository
+	budgetRepo repositories.ILlmBudgetRepository
+	prices     usage.PriceTable
+	logger     logger.Logger
+}
+
+type UsageServiceArgs struct {
+	dig.In
+	DB         *db.DB `name:"talentAcquisitionDB"`
+	UsageRepo  repositories.ILlmUsageRepository
+	BudgetRepo repositories.ILlmBudgetRepository
+	Logger     logger.Logger
+}
+
+func NewUsageService(args UsageServiceArgs) IUsageService {
+	return &UsageService{
+		db:         args.DB,
+		usageRepo:  args.UsageRepo,
+		budgetRepo: args.BudgetRepo,
+		prices:     usage.NewPriceTable(viper.GetStringMap(cfg.LlmPrices)),
+		logger:     args.Logger,
+	}
+}
+
+// Record completes rec with the tags carried by ctx and its estimated cost, then stores it.
+func (_this *UsageService) Record(ctx context.Context, rec usage.Record) {
+	rec.Operation = usage.OperationFrom(ctx)
+	rec.UserID = usage.UserFrom(ctx)
+	rec.TenantID = usage.TenantFrom(ctx)
+	rec.TraceID = tracing.TraceID(ctx)
+	rec.Cost = _this.prices.Cost(rec.Model, rec.PromptTokens, rec.CompletionTokens)
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+
+	metrics.LLMTokens.WithLabelValues(rec.Provider, rec.Model, rec.Operation, "prompt").Add(float64(rec.PromptTokens))
+	metrics.LLMTokens.WithLabelValues(rec.Provider, rec.Model, rec.Operation, "completion").Add(float64(rec.CompletionTokens))
+	metrics.LLMCost.WithLabelValues(rec.Provider, rec.Model, rec.Operation).Add(rec.Cost)
+
+	err := _this.usageRepo.Create(_this.db, &models.LlmUsage{
+		Provider:         rec.Provider,
+		Model:            rec.Model,
+		Operation:        rec.Operation,
+		UserID:           rec.UserID,
+		TenantID:         rec.TenantID,
+		PromptTokens:     rec.PromptTokens,
+		CompletionTokens: rec.CompletionTokens,
+		TotalTokens:      rec.TotalTokens(),
+		Estimated:        rec.Estimated,
+		Cost:             rec.Cost,
+		TraceID:          rec.TraceID,
+		CreatedAt:        rec.CreatedAt,
+	})
+	if err != nil {
+		_this.logger.TraceCtx(ctx).Errorf("failed to record LLM usage: %v", err)
+	}
+}
+
+func (_this *UsageService) GetUsageReport(c *gin.Context, from, to time.Time, tenantID *string, groupBy string) (*meta.BasicResponse, error) {
+	if _, ok := repositories.UsageGroupColumns[groupBy]; groupBy != "" && !ok {
+		return nil, errors.NewCusErr(errors.ErrCommonInvalidRequest)
+	}
+
+	totals, err := _this.usageRepo.Summarize(_this.db, from, to, tenantID, "")
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to summarize usage: %v", err)
+		return nil, err
+	}
+
+	report := dtos.UsageReportDTO{
+		From:    from.Unix(),
+		To:      to.Unix(),
+		GroupBy: groupBy,
+	}
+	if len(totals) > 0 {
+		report.Total = toUsageSummaryDTO(totals[0])
+		report.Total.Group = ""
+	}
+
+	if groupBy != "" {
+		groups, err := _this.usageRepo.Summarize(_this.db, from, to, tenantID, groupBy)
+		if err != nil {
+			ginLogger.Gin(c).Errorf("failed to summarize usage by %s: %v", groupBy, err)
+			return nil, err
+		}
+		for _, group := range groups {
+			report.Groups = append(report.Groups, toUsageSummaryDTO(group))
+		}
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Usage report retrieved successfully",
+		},
+		Data: report,
+	}
+	return response, nil
+}
+
+func (_this *UsageService) GetBudgets(c *gin.Context) (*meta.BasicResponse, error) {
+	budgets, err := _this.budgetRepo.GetAll(_this.db)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to get budgets: %v", err)
+		return nil, err
+	}
+
+	hasDefault := false
+	budgetDTOs := make([]dtos.BudgetDTO, 0, len(budgets)+1)
+	for _, budget := range budgets {
+		if budget.TenantID == "" {
+			hasDefault = true
+		}
+		budgetDTO, err := _this.toBudgetDTO(budget)
+		if err != nil {
+			ginLogger.Gin(c).Errorf("failed to compute spending of tenant %q: %v", budget.TenantID, err)
+			return nil, err
+		}
+		budgetDTOs = append(budgetDTOs, budgetDTO)
+	}
+	// Show the configured default when it has not been overridden in the database.
+	if configured := configuredBudget(); !hasDefault && configured.MonthlyLimit > 0 {
+		budgetDTO, err := _this.toBudgetDTO(configured)
+		if err != nil {
+			ginLogger.Gin(c).Errorf("failed to compute default spending: %v", err)
+			return nil, err
+		}
+		budgetDTOs = append(budgetDTOs, budgetDTO)
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Budgets retrieved successfully",
+		},
+		Data: budgetDTOs,
+	}
+	return response, nil
+}
+
+func (_this *UsageService) SetBudget(c *gin.Context, request dtos.BudgetRequest) (*meta.BasicResponse, error) {
+	if request.Policy == "" {
+		request.Policy = models.BudgetPolicyReject
+	}
+	if request.MonthlyLimit < 0 || (request.Policy != models.BudgetPolicyReject && request.Policy != models.BudgetPolicyQueue) {
+		return nil, errors.NewCusErr(errors.ErrCommonInvalidRequest)
+	}
+
+	budget := models.LlmBudget{
+		TenantID:     request.TenantID,
+		MonthlyLimit: request.MonthlyLimit,
+		Policy:       request.Policy,
+	}
+	if err := _this.budgetRepo.Upsert(_this.db, &budget); err != nil {
+		ginLogger.Gin(c).Errorf("failed to save budget: %v", err)
+		return nil, err
+	}
+
+	budgetDTO, err := _this.toBudgetDTO(budget)
+	if err != nil {
+		ginLogger.Gin(c).Errorf("failed to compute spending of tenant %q: %v", budget.TenantID, err)
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Budget saved successfully",
+		},
+		Data: budgetDTO,
+	}
+	return response, nil
+}
+
+func (_this *UsageService) DeleteBudget(c *gin.Context, tenantID string) (*meta.BasicResponse, error) {
+	if err := _this.budgetRepo.Delete(_this.db, tenantID); err != nil {
+		ginLogger.Gin(c).Errorf("failed to delete budget: %v", err)
+		return nil, err
+	}
+
+	response := &meta.BasicResponse{
+		Meta: meta.Meta{
+			Code:    http.StatusOK,
+			Message: "Budget deleted successfully",
+		},
+		Data: nil,
+	}
+	return response, nil
+}
+
+func (_this *UsageService) CheckBudget(ctx context.Context, queueable bool) error {
+	budget, exceeded, err := _this.budgetStatus(usage.TenantFrom(ctx))
+	if err != nil {
+		// Failing to read the budget must not stop the application from working.
+		_this.logger.TraceCtx(ctx).Errorf("failed to check LLM budget: %v", err)
+		return nil
+	}
+	if !exceeded || (queueable && budget.Policy == models.BudgetPolicyQueue) {
+		return nil
+	}
+	return errors.NewCusErr(errors.ErrUsageBudgetExceeded)
+}
+
+func (_this *UsageService) WaitForBudget(ctx context.Context, onQueued func()) error {
+	pollPeriod := viper.GetDuration(cfg.LlmBudgetQueuePollPeriod)
+	if pollPeriod <= 0 {
+		pollPeriod = defaultBudgetQueuePollPeriod
+	}
+
+	queued := false
+	for {
+		_, exceeded, err := _this.budgetStatus(usage.TenantFrom(ctx))
+		if err != nil {
+			_this.logger.TraceCtx(ctx).Errorf("failed to check LLM budget: %v", err)
+			return nil
+		}
+		if !exceeded {
+			return nil
+		}
+		if !queued {
+			queued = true
+			if onQueued != nil {
+				onQueued()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollPeriod):
+		}
+	}
+}
+
+// budgetStatus returns the budget that applies to tenantID and whether this month's spending has reached it.
+func (_this *UsageService) budgetStatus(tenantID string) (models.LlmBudget, bool, error) {
+	budget, err := _this.findBudget(tenantID)
+	if err != nil || budget.MonthlyLimit <= 0 {
+		return budget, false, err
+	}
+	spent, err := _this.usageRepo.SumCost(_this.db, tenantID, startOfMonth(time.Now()))
+	if err != nil {
+		return budget, false, err
+	}
+	return budget, spent >= budget.MonthlyLimit, nil
+}
+
+// findBudget returns the tenant's own budget, else the default row, else the configured default.
+func (_this *UsageService) findBudget(tenantID string) (models.LlmBudget, error) {
+	candidates := []string{tenantID}
+	if tenantID != "" {
+		candidates = append(candidates, "")
+	}
+	for _, candidate := range candidates {
+		budget, err := _this.budgetRepo.FindByTenant(_this.db, candidate)
+		if err == nil {
+			budget.TenantID = tenantID
+			return *budget, nil
+		}
+		if err != db.ErrRecordNotFound {
+			return models.LlmBudget{}, err
+		}
+	}
+
+	budget := configuredBudget()
+	budget.TenantID = tenantID
+	return budget, nil
+}
+
+func (_this *UsageService) toBudgetDTO(budget models.LlmBudget) (dtos.BudgetDTO, error) {
+	spent, err := _this.usageRepo.SumCost(_this.db, budget.TenantID, startOfMonth(time.Now()))
+	if err != nil {
+		return dtos.BudgetDTO{}, err
+	}
+	remaining := budget.MonthlyLimit - spent
+	if remaining < 0 {
+		remaining = 0
+	}
+	return dtos.BudgetDTO{
+		TenantID:     budget.TenantID,
+		MonthlyLimit: budget.MonthlyLimit,
+		Policy:       budget.Policy,
+		Spent:        spent,
+		Remaining:    remaining,
+	}, nil
+}
+
+func configuredBudget() models.LlmBudget {
+	policy := viper.GetString(cfg.LlmBudgetPolicy)
+	if policy == "" {
+		policy = models.BudgetPolicyReject
+	}
+	return models.LlmBudget{
+		MonthlyLimit: viper.GetFloat64(cfg.LlmMonthlyBudget),
+		Policy:       policy,
+	}
+}
+
+func toUsageSummaryDTO(summary models.LlmUsageSummary) dtos.UsageSummaryDTO {
+	return dtos.UsageSummaryDTO{
+		Group:            summary.GroupKey,
+		Calls:            summary.Calls,
+		PromptTokens:     summary.PromptTokens,
+		CompletionTokens: summary.CompletionTokens,
+		TotalTokens:      summary.TotalTokens,
+		Cost:             summary.Cost,
+	}
+}
+
+// startOfMonth returns midnight UTC on the first day of t's month, when monthly budgets reset.
+func startOfMonth(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ParseUsagePeriod parses the from/to query parameters (unix seconds), defaulting to the current month.
+func ParseUsagePeriod(fromParam, toParam string) (time.Time, time.Time, error) {
+	from, to := startOfMonth(time.Now()), time.Now().UTC().Add(time.Second)
+	if fromParam != "" {
+		seconds, err := strconv.ParseInt(fromParam, 10, 64)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from: %w", err)
+		}
+		from = time.Unix(seconds, 0).UTC()
+	}
+	if toParam != "" {
+		seconds, err := strconv.ParseInt(toParam, 10, 64)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to: %w", err)
+		}
+		to = time.Unix(seconds, 0).UTC()
+	}
+	return from, to, nil
+}
@@ -1,16 +1,31 @@
 package main
 
 import (
+	appCfg "CVSeeker/cmd/CVSeeker/internal/cfg"
 	"CVSeeker/cmd/CVSeeker/internal/providers"
+	services "CVSeeker/cmd/CVSeeker/internal/service"
 	_ "CVSeeker/docs"
 	"CVSeeker/internal/ginServer"
 	"CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/websocket"
+	"CVSeeker/pkg/worker"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/spf13/viper"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// defaultShutdownTimeout is used when SHUTDOWN_TIMEOUT is not configured.
+const defaultShutdownTimeout = 30 * time.Second
+
 // @title           CVSeeker Server
 // @version         1.0
 // @description     This is the server for api endpoints related to the CVSeeker application
@@ -33,6 +48,31 @@ import (
 func main() {
 	providers.BuildContainer()
 
+	if len(os.Args) > 1 && os.Args[1] == "reprocess" {
+		if err := runReprocess(os.Args[2:]); err != nil {
+			log.Fatalf("Reprocessing: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reconcile" {
+		if err := runReconcile(os.Args[2:]); err != nil {
+			log.Fatalf("Reconciling: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		if err := runReindex(os.Args[2:]); err != nil {
+			log.Fatalf("Reindexing: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		if err := runImport(os.Args[2:]); err != nil {
+			log.Fatalf("Importing: %v", err)
+		}
+		return
+	}
+
 	if os.Getenv("ENVIRONMENT") == cfg.EnvironmentLocal {
 		gin.SetMode(gin.DebugMode)
 	} else {
@@ -50,14 +90,85 @@ func run() error {
 	if c == nil {
 		log.Fatalf("Container hasn't been initialized yet")
 	}
-	var s ginServer.Server
-	if err := c.Invoke(func(_s ginServer.Server) { s = _s }); err != nil {
+	var (
+		s              ginServer.Server
+		tp             *tracing.Provider
+		workers        *worker.Group
+		health         services.IHealthService
+		reprocess      services.IReprocessService
+		projections    services.IProjectionService
+		dataProcessing services.IDataProcessingService
+		reconcile      services.IReconcileService
+		ingestion      services.ISourceService
+	)
+	if err := c.Invoke(func(_s ginServer.Server, _tp *tracing.Provider, _workers *worker.Group, _health services.IHealthService,
+		_reprocess services.IReprocessService, _projections services.IProjectionService, _dataProcessing services.IDataProcessingService,
+		_reconcile services.IReconcileService, _ingestion services.ISourceService) {
+		s, tp, workers, health, reprocess, projections, dataProcessing, reconcile, ingestion = _s, _tp, _workers, _health, _reprocess, _projections, _dataProcessing, _reconcile, _ingestion
+	}); err != nil {
 		return err
 	}
 
-	if err := s.Open(); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- s.Open()
+	}()
+	dataProcessing.FailInterruptedUploads(ctx)
+	if err := projections.StartRelay(ctx); err != nil {
+		return err
+	}
+	reprocess.ResumeInterrupted(ctx)
+	if err := reconcile.StartSchedule(ctx); err != nil {
+		return err
+	}
+	if err := dataProcessing.StartRecrawlSchedule(ctx); err != nil {
+		return err
+	}
+	if err := ingestion.Start(ctx); err != nil {
 		return err
 	}
 
-	return nil
+	select {
+	case err := <-serverErr:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+		log.Println("Shutdown signal received, draining . . . !")
+	}
+	stop()
+
+	return shutdown(s, tp, workers, health)
+}
+
+// shutdown stops accepting traffic and drains in-flight work in dependency order: HTTP requests
+// (including streaming chats) first, then background ingestion jobs, then WebSockets and finally
+// the tracer so that spans recorded while draining are still exported.
+func shutdown(s ginServer.Server, tp *tracing.Provider, workers *worker.Group, health services.IHealthService) error {
+	health.SetDraining()
+
+	timeout := time.Duration(viper.GetInt(appCfg.ConfigKeyShutdownTimeout)) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+	if err := s.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutting down HTTP server: %w", err))
+	}
+	if err := workers.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("draining background jobs: %w", err))
+	}
+	websocket.CloseAll()
+	if err := tp.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("shutting down tracer provider: %w", err))
+	}
+
+	log.Println("Shutdown complete")
+	return errors.Join(errs...)
 }
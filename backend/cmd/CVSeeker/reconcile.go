@@ -0,0 +1,65 @@
+package main
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/providers"
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/models"
+	"context"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+)
+
+// runReconcile implements "CVSeeker reconcile": it runs the reconciler in the foreground and prints
+// its report. Changes to Elasticsearch go through the outbox and are applied by the server's relay.
+func runReconcile(args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "report the issues without fixing any")
+	missingDocument := flags.String("missing-document", "", `"report", "requeue" or "fail" uploads and records without a document`)
+	orphanDocument := flags.String("orphan-document", "", `"report" or "delete" documents without an upload or record`)
+	orphanObject := flags.String("orphan-object", "", `"report" or "delete" S3 files nothing refers to`)
+	stuckUpload := flags.String("stuck-upload", "", `"report" or "fail" uploads stuck processing`)
+	_ = flags.Parse(args)
+
+	var reconcile services.IReconcileService
+	if err := providers.GetContainer().Invoke(func(_reconcile services.IReconcileService) {
+		reconcile = _reconcile
+	}); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	run, err := reconcile.Run(ctx, models.ReconcileTriggerManual, dtos.ReconcileRequest{
+		Policy: dtos.ReconcilePolicy{
+			MissingDocument: *missingDocument,
+			OrphanDocument:  *orphanDocument,
+			OrphanObject:    *orphanObject,
+			StuckUpload:     *stuckUpload,
+		},
+		DryRun: *dryRun,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, issue := range run.Issues {
+		outcome := issue.Action
+		switch {
+		case issue.Error != "":
+			outcome += " failed: " + issue.Error
+		case issue.Fixed:
+			outcome += " done"
+		}
+		log.Printf("%-16s document=%q upload=%d file=%q: %s (%s)", issue.Kind, issue.DocumentID, issue.UploadID, issue.FileKey, issue.Detail, outcome)
+	}
+	log.Printf("Reconcile run %d %s: checked %d uploads, %d records, %d documents and %d files; %d issues found %v, %d fixed, %d not fixed",
+		run.ID, run.Status, run.Uploads, run.Records, run.Documents, run.Objects, run.Found, run.Counts, run.Fixed, run.Failed)
+	if run.LastError != "" {
+		log.Printf("Reconcile run %d: %s", run.ID, run.LastError)
+	}
+	return nil
+}
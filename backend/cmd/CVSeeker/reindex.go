@@ -0,0 +1,44 @@
+package main
+
+import (
+	appCfg "CVSeeker/cmd/CVSeeker/internal/cfg"
+	"CVSeeker/cmd/CVSeeker/internal/providers"
+	"CVSeeker/pkg/elasticsearch"
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/viper"
+)
+
+// runReindex implements "CVSeeker reindex": it migrates ELK_DOCUMENT_INDEX to a new index created
+// with the resume mapping, for an index that rejects the mapping, and points the alias at it.
+func runReindex(args []string) error {
+	flags := flag.NewFlagSet("reindex", flag.ExitOnError)
+	index := flags.String("index", "", "name of the new index, for example resumes-v2")
+	replaceIndex := flags.Bool("replace-index", false, "when ELK_DOCUMENT_INDEX is an index, delete it once copied and give its name to an alias")
+	_ = flags.Parse(args)
+	if *index == "" {
+		return errors.New("-index is required")
+	}
+
+	var elasticClient elasticsearch.IElasticsearchClient
+	if err := providers.GetContainer().Invoke(func(_elasticClient elasticsearch.IElasticsearchClient) {
+		elasticClient = _elasticClient
+	}); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	alias := viper.GetString(appCfg.ElasticsearchDocumentIndex)
+	if err := elasticClient.ReindexResumes(ctx, alias, *index, *replaceIndex); err != nil {
+		return err
+	}
+	log.Printf("%s now points at %s", alias, *index)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"CVSeeker/cmd/CVSeeker/internal/providers"
+	services "CVSeeker/cmd/CVSeeker/internal/service"
+	"CVSeeker/internal/dtos"
+	"CVSeeker/internal/models"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// reprocessProgressPeriod is how often "CVSeeker reprocess" prints the progress of its job.
+const reprocessProgressPeriod = 10 * time.Second
+
+// runReprocess implements "CVSeeker reprocess": it creates a reprocess job, or continues one with
+// -resume, and runs it in the foreground. Interrupting the command leaves the job interrupted so
+// that it can be resumed, by this command or by the server on its next start.
+func runReprocess(args []string) error {
+	flags := flag.NewFlagSet("reprocess", flag.ExitOnError)
+	mode := flags.String("mode", models.ReprocessModeEmbed, `"embed" to recompute embeddings, "extract" to parse the resumes again and re-embed them`)
+	resume := flags.Int64("resume", 0, "ID of a paused, failed or interrupted job to continue instead of creating one")
+	ids := flags.String("ids", "", "comma-separated IDs of the documents to reprocess")
+	from := flags.String("from", "", "only documents uploaded on or after this date (YYYY-MM-DD)")
+	to := flags.String("to", "", "only documents uploaded before this date (YYYY-MM-DD)")
+	parseModel := flags.String("parse-model", "", `only documents parsed with this model ("none" for unrecorded)`)
+	embeddingModel := flags.String("embedding-model", "", `only documents embedded with this model ("none" for unrecorded)`)
+	promptVersion := flags.String("prompt-version", "", `only documents parsed with this prompt version ("none" for unrecorded)`)
+	stale := flags.Bool("stale", false, "only documents the current models and prompt would produce differently")
+	_ = flags.Parse(args)
+
+	var reprocess services.IReprocessService
+	if err := providers.GetContainer().Invoke(func(_reprocess services.IReprocessService) {
+		reprocess = _reprocess
+	}); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	jobID := *resume
+	if jobID == 0 {
+		selection := dtos.ReprocessSelection{
+			ParseModel:     *parseModel,
+			EmbeddingModel: *embeddingModel,
+			PromptVersion:  *promptVersion,
+			Stale:          *stale,
+		}
+		if *ids != "" {
+			selection.DocumentIds = strings.Split(*ids, ",")
+		}
+		var err error
+		if selection.From, err = parseReprocessDate(*from); err != nil {
+			return err
+		}
+		if selection.To, err = parseReprocessDate(*to); err != nil {
+			return err
+		}
+
+		job, err := reprocess.CreateJob(ctx, dtos.ReprocessRequest{Mode: *mode, Selection: selection})
+		if err != nil {
+			return err
+		}
+		jobID = job.ID
+		log.Printf("Created reprocess job %d for %d documents", job.ID, job.Total)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- reprocess.Run(ctx, jobID)
+	}()
+
+	ticker := time.NewTicker(reprocessProgressPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-done:
+			printReprocessProgress(reprocess, jobID)
+			if ctx.Err() != nil {
+				log.Printf("Interrupted, continue with: reprocess -resume %d", jobID)
+				return nil
+			}
+			return err
+		case <-ticker.C:
+			printReprocessProgress(reprocess, jobID)
+		}
+	}
+}
+
+func printReprocessProgress(reprocess services.IReprocessService, jobID int64) {
+	job, err := reprocess.GetJob(context.Background(), jobID)
+	if err != nil {
+		log.Printf("Reprocess job %d: failed to read progress: %v", jobID, err)
+		return
+	}
+	log.Printf("Reprocess job %d %s: %d/%d processed (%d updated, %d skipped, %d failed)",
+		job.ID, job.Status, job.Processed, job.Total, job.Succeeded, job.Skipped, job.Failed)
+}
+
+func parseReprocessDate(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", value, err)
+	}
+	unix := date.Unix()
+	return &unix, nil
+}
@@ -0,0 +1,60 @@
+// Command fakes serves the deterministic OpenAI, Hugging Face, crawler and Elasticsearch fakes
+// on local ports so that the server can run without API keys.
+package main
+
+import (
+	"CVSeeker/pkg/fakes"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+)
+
+func main() {
+	openAIAddr := flag.String("openai", "127.0.0.1:9001", "listen address of the OpenAI fake")
+	huggingFaceAddr := flag.String("huggingface", "127.0.0.1:9002", "listen address of the Hugging Face fake")
+	crawlerAddr := flag.String("crawler", "127.0.0.1:9003", "listen address of the crawler fake")
+	elasticsearchAddr := flag.String("elasticsearch", "127.0.0.1:9200", "listen address of the Elasticsearch fake")
+	flag.Parse()
+
+	servers := []struct {
+		addr    string
+		handler http.Handler
+	}{
+		{*openAIAddr, fakes.NewOpenAI()},
+		{*huggingFaceAddr, fakes.NewHuggingFace()},
+		{*crawlerAddr, fakes.NewCrawler()},
+		{*elasticsearchAddr, fakes.NewElasticsearch()},
+	}
+	for _, s := range servers {
+		s := s
+		go func() {
+			log.Fatal(http.ListenAndServe(s.addr, s.handler))
+		}()
+	}
+
+	env := map[string]string{
+		"GPT_BASE_URL":         "http://" + *openAIAddr,
+		"GPT_API_KEY":          "fake",
+		"HUGGINGFACE_BASE_URL": "http://" + *huggingFaceAddr,
+		"HUGGINGFACE_API_KEY":  "fake",
+		"CRAWLER_BASE_URL":     "http://" + *crawlerAddr,
+		"ELK_URL":              "http://" + *elasticsearchAddr,
+	}
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("export %s=%q\n", key, env[key])
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+}
@@ -24,34 +24,23 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/cvseeker/resumes/batch/upload": {
-            "post": {
-                "description": "Processes multiple uploaded resume files and associated metadata as JSON in a single batch.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/cvseeker/candidates/{id}": {
+            "get": {
+                "description": "Returns a candidate with its resume versions, oldest first. Only the latest version is searched by default.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Data Processing"
+                    "Candidates"
                 ],
-                "summary": "Batch processes resume data",
+                "summary": "Get a candidate",
                 "parameters": [
                     {
-                        "description": "Batch of resume data including file bytes for each",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.ResumesRequest"
-                        }
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Flag to indicate if the resumes are from LinkedIn",
-                        "name": "isLinkedin",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -66,10 +55,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/dtos.ResumeProcessingResult"
-                                            }
+                                            "$ref": "#/definitions/dtos.CandidateDTO"
                                         }
                                     }
                                 }
@@ -82,12 +68,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/meta.Error"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -103,48 +83,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/search": {
-            "post": {
-                "description": "Executes a search combining keyword and vector-based queries with customizable boosting on the vector component.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/cvseeker/candidates/{id}/diff": {
+            "get": {
+                "description": "Lists what changed between two versions of a candidate's resume. By default the latest version is compared with the one before it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Search"
+                    "Candidates"
                 ],
-                "summary": "Perform hybridsearch on elasticsearch",
+                "summary": "Compare resume versions",
                 "parameters": [
                     {
-                        "description": "Message content",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.QueryRequest"
-                        }
-                    },
-                    {
-                        "type": "number",
-                        "default": 0.5,
-                        "description": "Boost factor for the KNN component",
-                        "name": "knnBoost",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "integer",
-                        "default": 0,
-                        "description": "Start index for search results",
+                        "description": "Older version, defaults to the version before to",
                         "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "default": 10,
-                        "description": "Number of search results to return",
-                        "name": "size",
+                        "description": "Newer version, defaults to the latest version",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
@@ -160,10 +126,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
-                                            }
+                                            "$ref": "#/definitions/dtos.ResumeDiffDTO"
                                         }
                                     }
                                 }
@@ -176,12 +139,6 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/meta.Error"
-                        }
-                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -197,19 +154,32 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/thread": {
+        "/cvseeker/candidates/{id}/versions/{version}": {
             "get": {
-                "description": "Retrieves all thread IDs from the database.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns one version of a candidate's resume with its parsed content and the URL of its file.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Chatbot"
+                    "Candidates"
+                ],
+                "summary": "Get a resume version",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Candidate ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version number, starting at 1",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all thread IDs",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -222,10 +192,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/dtos.Thread"
-                                            }
+                                            "$ref": "#/definitions/dtos.ResumeVersionDTO"
                                         }
                                     }
                                 }
@@ -238,6 +205,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -247,28 +220,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/thread/start": {
-            "post": {
-                "description": "Starts a new chat session by creating an assistant and a thread, using specified documents.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/cvseeker/duplicates": {
+            "get": {
+                "description": "Lists the uploads recognised as duplicates of an indexed document, newest first. Pending candidates form the review queue.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Chatbot"
+                    "Duplicates"
                 ],
-                "summary": "Start a new chat session",
+                "summary": "List duplicate candidates",
                 "parameters": [
                     {
-                        "description": "Comma-separated list of document IDs",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.StartChatRequest"
-                        }
+                        "type": "string",
+                        "description": "pending (default), dismissed, merged, skipped, replaced, versioned or all",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -283,19 +250,16 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/gpt.ThreadResponse"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dtos.DuplicateCandidateDTO"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/meta.Error"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -305,24 +269,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/thread/{threadId}": {
-            "get": {
-                "description": "Retrieves all resume IDs associated with a given thread ID.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/cvseeker/duplicates/{id}/dismiss": {
+            "post": {
+                "description": "Removes a pending candidate from the review queue and keeps both documents.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Chatbot"
+                    "Duplicates"
                 ],
-                "summary": "Get resume IDs by thread ID",
+                "summary": "Dismiss a duplicate candidate",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
+                        "type": "integer",
+                        "description": "Duplicate candidate ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -331,7 +292,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/meta.BasicResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.DuplicateCandidateDTO"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -340,6 +313,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -349,49 +334,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/thread/{threadId}/messages": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get a list of messages for a thread.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/cvseeker/duplicates/{id}/merge": {
+            "post": {
+                "description": "Merges the later document of a pending candidate into the document it duplicates.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Chatbot"
+                    "Duplicates"
                 ],
-                "summary": "List messages belonging to a thread",
+                "summary": "Merge a duplicate candidate",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
+                        "type": "integer",
+                        "description": "Duplicate candidate ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Maximum number of messages to return",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Cursor for pagination, specifying an exclusive start point for the list (ID of a message)",
-                        "name": "after",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Cursor for pagination, specifying an exclusive end point for the list (ID of a message)",
-                        "name": "before",
-                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -406,7 +365,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/gpt.ListMessagesResponse"
+                                            "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
                                         }
                                     }
                                 }
@@ -419,14 +378,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
@@ -440,35 +399,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/thread/{threadId}/send": {
+        "/cvseeker/duplicates/{id}/version": {
             "post": {
-                "description": "Sends a message to the specified chat session using message content provided in the request body.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Makes the later document of a pending candidate the latest resume version of the candidate it duplicates. Both documents are kept, and only the later one is searched by default.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Chatbot"
+                    "Duplicates"
                 ],
-                "summary": "Send a message to a chat session",
+                "summary": "Keep a duplicate candidate as a new version",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
+                        "type": "integer",
+                        "description": "Duplicate candidate ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Message content",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dtos.QueryRequest"
-                        }
                     }
                 ],
                 "responses": {
@@ -483,7 +430,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/gpt.ListMessagesResponse"
+                                            "$ref": "#/definitions/dtos.DuplicateCandidateDTO"
                                         }
                                     }
                                 }
@@ -496,6 +443,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -505,34 +464,75 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/thread/{threadId}/updateName": {
-            "post": {
-                "description": "Updates the name of an existing thread by thread ID.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/cvseeker/prompts": {
+            "get": {
+                "description": "Lists the prompts with their static and stored versions and the version in use.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Chatbot"
+                    "Prompts"
                 ],
-                "summary": "Update a thread's name",
+                "summary": "List prompts",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dtos.PromptDTO"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/prompts/{name}/active": {
+            "put": {
+                "description": "Makes a version of a prompt the one used for new requests, without a redeploy. Rolling out an older version rolls back.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Prompts"
+                ],
+                "summary": "Roll out a prompt version",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Thread ID",
-                        "name": "threadId",
+                        "description": "Prompt name, e.g. resume_parse or chat_preamble",
+                        "name": "name",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "New Name for the Thread",
-                        "name": "newName",
+                        "description": "Version to use",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/dtos.PromptRolloutRequest"
                         }
                     }
                 ],
@@ -548,10 +548,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
-                                            }
+                                            "$ref": "#/definitions/dtos.PromptVersionDTO"
                                         }
                                     }
                                 }
@@ -564,6 +561,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -573,9 +576,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/threads/{threadId}": {
-            "delete": {
-                "description": "Deletes the specified thread by its ID.",
+        "/cvseeker/prompts/{name}/preview": {
+            "post": {
+                "description": "Renders a draft template, a stored version or the active version of a prompt, with the given data or sample data.",
                 "consumes": [
                     "application/json"
                 ],
@@ -583,23 +586,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Chatbot"
+                    "Prompts"
                 ],
-                "summary": "Delete a thread by ID",
+                "summary": "Preview a prompt",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Thread ID to be deleted",
-                        "name": "threadId",
+                        "description": "Prompt name, e.g. resume_parse or chat_preamble",
+                        "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Template or version to render and the data to render it with",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PromptPreviewRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/meta.BasicResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.PromptPreviewDTO"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -623,9 +647,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/upload": {
-            "get": {
-                "description": "Fetches a list of all upload records sorted from the most recent to the oldest",
+        "/cvseeker/prompts/{name}/versions": {
+            "post": {
+                "description": "Stores a new version of a prompt as a Go template. The version is not used until it is rolled out.",
                 "consumes": [
                     "application/json"
                 ],
@@ -633,9 +657,27 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Data Processing"
+                    "Prompts"
+                ],
+                "summary": "Add a prompt version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Prompt name, e.g. resume_parse or chat_preamble",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Version ID and template",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.PromptVersionRequest"
+                        }
+                    }
                 ],
-                "summary": "Retrieves all upload records",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -648,10 +690,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/dtos.UploadDTO"
-                                            }
+                                            "$ref": "#/definitions/dtos.PromptVersionDTO"
                                         }
                                     }
                                 }
@@ -664,17 +703,58 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
+                    }
+                }
+            }
+        },
+        "/cvseeker/reconcile": {
+            "get": {
+                "description": "Lists the latest reconcile runs with their counts, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reconcile"
+                ],
+                "summary": "List reconcile runs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dtos.ReconcileRunDTO"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -685,7 +765,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Processes uploaded resume files and associated metadata as JSON",
+                "description": "Starts a background run that looks for successful uploads or resume records without a document (missing_document), documents with neither (orphan_document), S3 files nothing refers to (orphan_object) and uploads stuck processing (stuck_upload), and handles them with the policy. Kinds the body leaves out follow RECONCILE_POLICY.",
                 "consumes": [
                     "application/json"
                 ],
@@ -693,17 +773,16 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Data Processing"
+                    "Reconcile"
                 ],
-                "summary": "Processes resume data",
+                "summary": "Reconcile uploads, Elasticsearch and S3",
                 "parameters": [
                     {
-                        "description": "Resume data including file bytes",
-                        "name": "request",
+                        "description": "Policy overrides and dry run",
+                        "name": "body",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dtos.ResumeData"
+                            "$ref": "#/definitions/dtos.ReconcileRequest"
                         }
                     }
                 ],
@@ -719,7 +798,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/dtos.ResumeProcessingResult"
+                                            "$ref": "#/definitions/dtos.ReconcileRunDTO"
                                         }
                                     }
                                 }
@@ -732,20 +811,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
@@ -753,23 +832,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/cvseeker/resumes/{id}": {
+        "/cvseeker/reconcile/{id}": {
             "get": {
-                "description": "Retrieves a document by its ID from the Elasticsearch index.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns the report of a reconcile run: its counts and the issues found with the action taken on each.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Search"
+                    "Reconcile"
                 ],
-                "summary": "Get Document By Id",
+                "summary": "Get a reconcile run",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Document ID",
+                        "type": "integer",
+                        "description": "Run ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -787,7 +863,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
+                                            "$ref": "#/definitions/dtos.ReconcileRunDTO"
                                         }
                                     }
                                 }
@@ -800,17 +876,52 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
+                    }
+                }
+            }
+        },
+        "/cvseeker/reprocess": {
+            "get": {
+                "description": "Lists the reprocess jobs with their progress, newest first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reprocess"
+                ],
+                "summary": "List reprocess jobs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dtos.ReprocessJobDTO"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
@@ -820,8 +931,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Deletes a document by its ID from the Elasticsearch index.",
+            "post": {
+                "description": "Starts a background job that re-embeds (\"embed\") or re-parses and re-embeds (\"extract\") the selected documents in place, throttled by REPROCESS_RATE_PER_MINUTE.",
                 "consumes": [
                     "application/json"
                 ],
@@ -829,23 +940,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Search"
+                    "Reprocess"
                 ],
-                "summary": "Delete Document By Id",
+                "summary": "Reprocess indexed resumes",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Document ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Mode and document selection",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ReprocessRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Document deletion successful",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/meta.BasicResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.ReprocessJobDTO"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -854,34 +979,2537 @@ const docTemplate = `{
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/meta.Error"
-                        }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "503": {
+                        "description": "Service Unavailable",
                         "schema": {
                             "$ref": "#/definitions/meta.Error"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "/cvseeker/reprocess/{id}": {
+            "get": {
+                "description": "Returns the progress of a reprocess job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reprocess"
+                ],
+                "summary": "Get a reprocess job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.ReprocessJobDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/reprocess/{id}/pause": {
+            "post": {
+                "description": "Stops a reprocess job after the document in progress. It can be resumed later.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reprocess"
+                ],
+                "summary": "Pause a reprocess job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.ReprocessJobDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/reprocess/{id}/resume": {
+            "post": {
+                "description": "Continues a paused, failed or interrupted reprocess job from the last document it handled.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Reprocess"
+                ],
+                "summary": "Resume a reprocess job",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.ReprocessJobDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/batch/upload": {
+            "post": {
+                "description": "Processes multiple uploaded resume files and associated metadata as JSON in a single batch. The batch is returned at once with an upload per resume; its progress is sent to the submitting user over the websocket and can be read from GET /resumes/batch/{id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Batch processes resume data",
+                "parameters": [
+                    {
+                        "description": "Batch of resume data including file bytes for each",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ResumesRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Flag to indicate if the resumes are from LinkedIn",
+                        "name": "isLinkedin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Policy for resumes that are already indexed and do not set onDuplicate: skip, replace, version or review (default DUPLICATE_POLICY)",
+                        "name": "onDuplicate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key of the request among those of its user, so that sending it again returns the batch it created instead of uploading again (default: the uuids of the resumes). Requests without X-Forward-User share a scope",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.BatchDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/batch/{id}": {
+            "get": {
+                "description": "Returns a batch with its status, how many of its uploads are in each status and each upload with the error it failed with. The batches of other users are not found; a request without X-Forward-User only finds those submitted without one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Get an upload batch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.BatchDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/batch/{id}/cancel": {
+            "post": {
+                "description": "Stops the processing and queued uploads of a batch, including a LinkedIn fetch in progress. Uploads already finished are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Cancel an upload batch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Batch ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.BatchDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/import": {
+            "post": {
+                "description": "Reads the files of a ZIP or tar.gz archive one at a time and ingests the PDF, text and Markdown resumes among them as one batch, with an upload per file. Other files, files without extractable text such as scanned PDFs, files larger than IMPORT_MAX_FILE_SIZE_MB, files beyond IMPORT_MAX_FILES and copies of an earlier file of the archive are skipped. The report of the import lists what became of each file.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Import an archive of resumes",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "ZIP or tar.gz archive",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Policy for resumes that are already indexed: skip, replace, version or review (default DUPLICATE_POLICY)",
+                        "name": "onDuplicate",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.ImportDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/import/{id}": {
+            "get": {
+                "description": "Returns an imported archive with what became of each of its files and the batch of its uploads. The imports of other users are not found; a request without X-Forward-User only finds those submitted without one.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Get an import",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.ImportDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/import/{id}/report": {
+            "get": {
+                "description": "Downloads a line for each file of an imported archive: the status of its upload with the error it failed with, or \"Skipped\" with the reason. Uploads still being processed show their current status.",
+                "produces": [
+                    "text/csv",
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Download the report of an import",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Import ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "csv (default) or json",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dtos.ImportReportRow"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/merge": {
+            "post": {
+                "description": "Combines the source document into the target: the target keeps its values and gains the skills, experience, projects and awards it lacks. The source is deleted and its chat threads and uploads point to the target.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Duplicates"
+                ],
+                "summary": "Merge two resumes",
+                "parameters": [
+                    {
+                        "description": "Document to merge and document to keep",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.MergeResumesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/search": {
+            "post": {
+                "description": "Executes a search combining keyword and vector-based queries with customizable boosting on the vector component.\nOptional filters restrict basic_info values; with min_confidence, values extracted with lower confidence do not match.\nmin_years, min_skill_years and min_title_years require years of experience in total, per skill or per title.\nAn optional sort orders results by years_of_experience, skill_years or title_years (of the skill or title in name).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Perform hybridsearch on elasticsearch",
+                "parameters": [
+                    {
+                        "description": "Message content",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.QueryRequest"
+                        }
+                    },
+                    {
+                        "type": "number",
+                        "default": 0.5,
+                        "description": "Boost factor for the KNN component",
+                        "name": "knnBoost",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Start index for search results",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Number of search results to return",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/thread": {
+            "get": {
+                "description": "Retrieves all thread IDs from the database.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chatbot"
+                ],
+                "summary": "Get all thread IDs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dtos.Thread"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/thread/start": {
+            "post": {
+                "description": "Starts a new chat session by creating an assistant and a thread, using specified documents.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chatbot"
+                ],
+                "summary": "Start a new chat session",
+                "parameters": [
+                    {
+                        "description": "Comma-separated list of document IDs",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.StartChatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/gpt.ThreadResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/thread/{threadId}": {
+            "get": {
+                "description": "Retrieves all resume IDs associated with a given thread ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chatbot"
+                ],
+                "summary": "Get resume IDs by thread ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/meta.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/thread/{threadId}/messages": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of messages for a thread.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chatbot"
+                ],
+                "summary": "List messages belonging to a thread",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of messages to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor for pagination, specifying an exclusive start point for the list (ID of a message)",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor for pagination, specifying an exclusive end point for the list (ID of a message)",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/gpt.ListMessagesResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/thread/{threadId}/send": {
+            "post": {
+                "description": "Sends a message to the specified chat session using message content provided in the request body.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chatbot"
+                ],
+                "summary": "Send a message to a chat session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Message content",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.QueryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/gpt.ListMessagesResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/thread/{threadId}/updateName": {
+            "post": {
+                "description": "Updates the name of an existing thread by thread ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chatbot"
+                ],
+                "summary": "Update a thread's name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New Name for the Thread",
+                        "name": "newName",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/threads/{threadId}": {
+            "delete": {
+                "description": "Deletes the specified thread by its ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Chatbot"
+                ],
+                "summary": "Delete a thread by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Thread ID to be deleted",
+                        "name": "threadId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/meta.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/upload": {
+            "get": {
+                "description": "Fetches the upload records of the batches the user submitted, sorted from the most recent to the oldest. A request without X-Forward-User gets those submitted without one.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Retrieves the upload records of the user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dtos.UploadDTO"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Processes uploaded resume files and associated metadata as JSON",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Processes resume data",
+                "parameters": [
+                    {
+                        "description": "Resume data including file bytes",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.ResumeData"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Key of the request among those of its user, so that sending it again returns the batch it created instead of uploading again (default: the uuid of the resume). Requests without X-Forward-User share a scope",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.BatchDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/upload/{id}/cancel": {
+            "post": {
+                "description": "Stops a processing or queued upload. The calls in flight for it are cancelled, and the upload is marked as cancelled once they return.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Cancel an upload",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.UploadDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/upload/{id}/retry": {
+            "post": {
+                "description": "Ingests a failed or cancelled upload again from the text and file kept when it was submitted, in its batch. Uploads whose file was not kept must be uploaded again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Retry an upload",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.UploadDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/{id}": {
+            "get": {
+                "description": "Retrieves a document by its ID from the Elasticsearch index.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Get Document By Id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Deletes a document by its ID from the Elasticsearch index.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Search"
+                ],
+                "summary": "Delete Document By Id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Document deletion successful",
+                        "schema": {
+                            "$ref": "#/definitions/meta.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/{id}/rebuild": {
+            "post": {
+                "description": "Rebuilds the Elasticsearch document from its MySQL record with a new embedding, without the original upload. With reparse, the stored text is parsed again first.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Rebuild a resume from its record",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Parse the stored text again",
+                        "name": "reparse",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/meta.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/resumes/{id}/record": {
+            "get": {
+                "description": "Returns the MySQL record an indexed document is built from: the extracted text, the stored file, the parsed content and the prompt and model versions that produced it.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Data Processing"
+                ],
+                "summary": "Get the record of a resume",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Document ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.ResumeRecordDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/usage": {
+            "get": {
+                "description": "Aggregates the tokens and estimated cost of LLM and embedding calls over a period, optionally grouped.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Usage"
+                ],
+                "summary": "Report token usage and cost",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Start of the period (unix seconds), defaults to the start of the current month",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of the period (unix seconds), defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only include calls billed to this tenant",
+                        "name": "tenantId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Group by operation, model, provider, user, tenant or day",
+                        "name": "groupBy",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.UsageReportDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/cvseeker/usage/budgets": {
+            "get": {
+                "description": "Lists the monthly LLM budgets with this month's spending. The budget with an empty tenant ID is the default.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Usage"
+                ],
+                "summary": "List monthly budgets",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/dtos.BudgetDTO"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Creates or updates the monthly LLM budget of a tenant (empty tenant ID for the default). When it is reached, new work is rejected, or ingestion is queued if the policy is \"queue\".",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Usage"
+                ],
+                "summary": "Set a monthly budget",
+                "parameters": [
+                    {
+                        "description": "Budget in USD and policy (reject or queue)",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dtos.BudgetRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/meta.BasicResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/dtos.BudgetDTO"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes the budget of a tenant so that the default budget applies again.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Usage"
+                ],
+                "summary": "Delete a monthly budget",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Tenant ID, empty for the default budget",
+                        "name": "tenantId",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/meta.BasicResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/meta.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Reports that the process is running. It does not check any dependency.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.HealthDTO"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Checks MySQL, Elasticsearch, the resume mapping of the document index and required configuration. Returns 503 when a check fails or the server is shutting down.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.HealthDTO"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dtos.HealthDTO"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dtos.BatchDTO": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "finishedAt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "uploads": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.UploadDTO"
+                    }
+                }
+            }
+        },
+        "dtos.BudgetDTO": {
+            "type": "object",
+            "properties": {
+                "monthlyLimit": {
+                    "type": "number"
+                },
+                "policy": {
+                    "type": "string"
+                },
+                "remaining": {
+                    "type": "number"
+                },
+                "spent": {
+                    "type": "number"
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.BudgetRequest": {
+            "type": "object",
+            "properties": {
+                "monthlyLimit": {
+                    "type": "number"
+                },
+                "policy": {
+                    "type": "string"
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.CandidateDTO": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer"
+                },
+                "fullName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latestDocumentId": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "integer"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ResumeVersionDTO"
+                    }
+                }
+            }
+        },
+        "dtos.DuplicateCandidateDTO": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer"
+                },
+                "documentId": {
+                    "type": "string"
+                },
+                "documentName": {
+                    "description": "DocumentName and DuplicateOfName are the candidate names of the two documents, when they are\nstill indexed.",
+                    "type": "string"
+                },
+                "duplicateOf": {
+                    "type": "string"
+                },
+                "duplicateOfName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "resolvedAt": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "uploadId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.HealthCheckDTO": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.HealthDTO": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.HealthCheckDTO"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ImportDTO": {
+            "type": "object",
+            "properties": {
+                "batch": {
+                    "$ref": "#/definitions/dtos.BatchDTO"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ImportEntry"
+                    }
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "files": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "queued": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ImportEntry": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "skipped": {
+                    "type": "string"
+                },
+                "uploadId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.ImportReportRow": {
+            "type": "object",
+            "properties": {
+                "documentId": {
+                    "type": "string"
+                },
+                "file": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "uploadId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.MergeResumesRequest": {
+            "type": "object",
+            "properties": {
+                "sourceId": {
+                    "type": "string"
+                },
+                "targetId": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.PromptDTO": {
+            "type": "object",
+            "properties": {
+                "activeVersion": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.PromptVersionDTO"
+                    }
+                }
+            }
+        },
+        "dtos.PromptPreviewDTO": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "prompt": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.PromptPreviewRequest": {
+            "type": "object",
+            "properties": {
+                "documentIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "resumeText": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "withEvidence": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dtos.PromptRolloutRequest": {
+            "type": "object",
+            "properties": {
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.PromptVersionDTO": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "template": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.PromptVersionRequest": {
+            "type": "object",
+            "properties": {
+                "template": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
         "dtos.QueryRequest": {
             "type": "object",
             "properties": {
                 "content": {
                     "type": "string"
+                },
+                "filters": {
+                    "$ref": "#/definitions/elasticsearch.SearchFilter"
+                },
+                "sort": {
+                    "$ref": "#/definitions/elasticsearch.SearchSort"
+                }
+            }
+        },
+        "dtos.ReconcileIssue": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "documentId": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "fileKey": {
+                    "type": "string"
+                },
+                "fixed": {
+                    "type": "boolean"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "uploadId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.ReconcilePolicy": {
+            "type": "object",
+            "properties": {
+                "missingDocument": {
+                    "description": "MissingDocument is \"report\", \"requeue\" (rebuild the document from its resume record, or mark\nthe upload failed when there is no record to rebuild from) or \"fail\" (mark the upload failed).",
+                    "type": "string"
+                },
+                "orphanDocument": {
+                    "description": "OrphanDocument is \"report\" or \"delete\".",
+                    "type": "string"
+                },
+                "orphanObject": {
+                    "description": "OrphanObject is \"report\" or \"delete\".",
+                    "type": "string"
+                },
+                "stuckUpload": {
+                    "description": "StuckUpload is \"report\" or \"fail\".",
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ReconcileRequest": {
+            "type": "object",
+            "properties": {
+                "dryRun": {
+                    "description": "DryRun reports every issue without fixing any.",
+                    "type": "boolean"
+                },
+                "policy": {
+                    "$ref": "#/definitions/dtos.ReconcilePolicy"
+                }
+            }
+        },
+        "dtos.ReconcileRunDTO": {
+            "type": "object",
+            "properties": {
+                "counts": {
+                    "description": "Counts is the number of issues found of each kind.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "documents": {
+                    "type": "integer"
+                },
+                "dryRun": {
+                    "type": "boolean"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finishedAt": {
+                    "type": "integer"
+                },
+                "fixed": {
+                    "type": "integer"
+                },
+                "found": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "issues": {
+                    "description": "Issues lists the issues found, up to a limit; it is only returned for a single run.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.ReconcileIssue"
+                    }
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "objects": {
+                    "type": "integer"
+                },
+                "policy": {
+                    "$ref": "#/definitions/dtos.ReconcilePolicy"
+                },
+                "records": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "trigger": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "integer"
+                },
+                "uploads": {
+                    "description": "Uploads, Records, Documents and Objects are the numbers of items checked in each store.",
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.ReprocessJobDTO": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "finishedAt": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "mode": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "selection": {
+                    "$ref": "#/definitions/dtos.ReprocessSelection"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "succeeded": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.ReprocessRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string"
+                },
+                "selection": {
+                    "$ref": "#/definitions/dtos.ReprocessSelection"
+                }
+            }
+        },
+        "dtos.ReprocessSelection": {
+            "type": "object",
+            "properties": {
+                "documentIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "embeddingModel": {
+                    "type": "string"
+                },
+                "from": {
+                    "description": "From and To bound the upload time (unix seconds, To excluded).",
+                    "type": "integer"
+                },
+                "parseModel": {
+                    "description": "ParseModel, EmbeddingModel and PromptVersion only keep documents produced by that version;\nuse \"none\" for documents indexed before versions were recorded.",
+                    "type": "string"
+                },
+                "promptVersion": {
+                    "type": "string"
+                },
+                "stale": {
+                    "description": "Stale only keeps documents that the current configuration would produce differently, and\ndocuments of an older schema.",
+                    "type": "boolean"
+                },
+                "to": {
+                    "type": "integer"
                 }
             }
         },
@@ -897,20 +3525,108 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "onDuplicate": {
+                    "description": "OnDuplicate overrides DUPLICATE_POLICY for this resume: skip, replace, version or review.",
+                    "type": "string"
+                },
                 "uuid": {
                     "type": "string"
                 }
             }
         },
-        "dtos.ResumeProcessingResult": {
+        "dtos.ResumeDiffDTO": {
             "type": "object",
             "properties": {
-                "id": {
-                    "description": "Assume each resume has a reference or ID.",
+                "candidateId": {
+                    "type": "integer"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/elasticsearch.FieldChange"
+                    }
+                },
+                "from": {
+                    "type": "integer"
+                },
+                "fromDocumentId": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "integer"
+                },
+                "toDocumentId": {
+                    "type": "string"
+                }
+            }
+        },
+        "dtos.ResumeRecordDTO": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
+                },
+                "createdAt": {
+                    "type": "integer"
+                },
+                "documentId": {
+                    "type": "string"
+                },
+                "downloadLink": {
+                    "type": "string"
+                },
+                "embeddingModel": {
+                    "type": "string"
+                },
+                "fileKey": {
+                    "type": "string"
+                },
+                "fullText": {
+                    "type": "string"
+                },
+                "parseModel": {
+                    "type": "string"
+                },
+                "promptVersion": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dtos.ResumeVersionDTO": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "integer"
+                },
+                "documentId": {
                     "type": "string"
                 },
-                "status": {
+                "fileUrl": {
+                    "description": "FileURL is the file the version was parsed from.",
                     "type": "string"
+                },
+                "latest": {
+                    "type": "boolean"
+                },
+                "resume": {
+                    "description": "Resume is the parsed content, returned when a single version is requested.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/elasticsearch.ResumeSummaryDTO"
+                        }
+                    ]
+                },
+                "uploadId": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
@@ -953,6 +3669,9 @@ const docTemplate = `{
         "dtos.UploadDTO": {
             "type": "object",
             "properties": {
+                "batchId": {
+                    "type": "integer"
+                },
                 "createdAt": {
                     "description": "Assuming date is formatted as a string for the client",
                     "type": "integer"
@@ -961,9 +3680,25 @@ const docTemplate = `{
                     "description": "omitempty to not display if empty",
                     "type": "string"
                 },
+                "duplicateOf": {
+                    "description": "DuplicateOf is the upload of the same file this upload is linked to, when it was submitted again\nwithin UPLOAD_DEDUPE_WINDOW.",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
+                "origin": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
                 "status": {
                     "type": "string"
                 },
@@ -972,6 +3707,52 @@ const docTemplate = `{
                 }
             }
         },
+        "dtos.UsageReportDTO": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "integer"
+                },
+                "groupBy": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dtos.UsageSummaryDTO"
+                    }
+                },
+                "to": {
+                    "type": "integer"
+                },
+                "total": {
+                    "$ref": "#/definitions/dtos.UsageSummaryDTO"
+                }
+            }
+        },
+        "dtos.UsageSummaryDTO": {
+            "type": "object",
+            "properties": {
+                "calls": {
+                    "type": "integer"
+                },
+                "completionTokens": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "promptTokens": {
+                    "type": "integer"
+                },
+                "totalTokens": {
+                    "type": "integer"
+                }
+            }
+        },
         "elasticsearch.Award": {
             "type": "object",
             "properties": {
@@ -991,6 +3772,7 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "gpa": {
+                    "description": "nil when the resume does not state it",
                     "type": "number"
                 },
                 "majors": {
@@ -1004,6 +3786,165 @@ const docTemplate = `{
                 }
             }
         },
+        "elasticsearch.BasicInfoEvidence": {
+            "type": "object",
+            "properties": {
+                "education_level": {
+                    "$ref": "#/definitions/elasticsearch.FieldEvidence"
+                },
+                "full_name": {
+                    "$ref": "#/definitions/elasticsearch.FieldEvidence"
+                },
+                "gpa": {
+                    "$ref": "#/definitions/elasticsearch.FieldEvidence"
+                },
+                "majors": {
+                    "$ref": "#/definitions/elasticsearch.FieldEvidence"
+                },
+                "university": {
+                    "$ref": "#/definitions/elasticsearch.FieldEvidence"
+                }
+            }
+        },
+        "elasticsearch.Certification": {
+            "type": "object",
+            "properties": {
+                "issuer": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "year": {
+                    "description": "nil when unknown",
+                    "type": "integer"
+                }
+            }
+        },
+        "elasticsearch.Contacts": {
+            "type": "object",
+            "properties": {
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "links": {
+                    "description": "Links are the candidate's profiles and personal pages.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/elasticsearch.Link"
+                    }
+                },
+                "phones": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "elasticsearch.Education": {
+            "type": "object",
+            "properties": {
+                "degree": {
+                    "description": "BS, MS, PhD, or the stated degree",
+                    "type": "string"
+                },
+                "gpa": {
+                    "description": "nil when the resume does not state it",
+                    "type": "number"
+                },
+                "graduation_year": {
+                    "description": "nil when unknown; may be expected for ongoing studies",
+                    "type": "integer"
+                },
+                "institution": {
+                    "type": "string"
+                },
+                "majors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "elasticsearch.ExperienceYears": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "years": {
+                    "type": "number"
+                }
+            }
+        },
+        "elasticsearch.FieldChange": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "after": {},
+                "before": {},
+                "field": {
+                    "type": "string"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "elasticsearch.FieldEvidence": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "type": "number"
+                },
+                "end": {
+                    "type": "integer"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "elasticsearch.Language": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string"
+                },
+                "proficiency": {
+                    "description": "one of the Proficiency values, empty when not stated",
+                    "type": "string"
+                }
+            }
+        },
+        "elasticsearch.Link": {
+            "type": "object",
+            "properties": {
+                "kind": {
+                    "description": "one of the LinkKind values",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
         "elasticsearch.ProjectExperience": {
             "type": "object",
             "properties": {
@@ -1027,9 +3968,44 @@ const docTemplate = `{
                 "basic_info": {
                     "$ref": "#/definitions/elasticsearch.BasicInfo"
                 },
+                "basic_info_evidence": {
+                    "$ref": "#/definitions/elasticsearch.BasicInfoEvidence"
+                },
+                "candidate_id": {
+                    "description": "CandidateID is the candidate the resume is a version of. Superseded marks versions replaced\nby a newer one; searches skip them unless the filter asks for all versions.",
+                    "type": "integer"
+                },
+                "certifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/elasticsearch.Certification"
+                    }
+                },
+                "contacts": {
+                    "$ref": "#/definitions/elasticsearch.Contacts"
+                },
+                "education": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/elasticsearch.Education"
+                    }
+                },
+                "embedding_model": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "string"
                 },
+                "languages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/elasticsearch.Language"
+                    }
+                },
+                "parse_model": {
+                    "description": "ParseModel and EmbeddingModel are the models that produced the content and the embedding.",
+                    "type": "string"
+                },
                 "point": {
                     "type": "number"
                 },
@@ -1039,6 +4015,20 @@ const docTemplate = `{
                         "$ref": "#/definitions/elasticsearch.ProjectExperience"
                     }
                 },
+                "prompt_version": {
+                    "description": "PromptVersion is the version of the prompt the resume was parsed with.",
+                    "type": "string"
+                },
+                "schema_version": {
+                    "description": "SchemaVersion is the version of this structure the content was produced with; documents\nbelow ResumeSchemaVersion lack the sections added since.",
+                    "type": "integer"
+                },
+                "skill_experience": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/elasticsearch.ExperienceYears"
+                    }
+                },
                 "skills": {
                     "type": "array",
                     "items": {
@@ -1048,6 +4038,15 @@ const docTemplate = `{
                 "summary": {
                     "type": "string"
                 },
+                "superseded": {
+                    "type": "boolean"
+                },
+                "title_experience": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/elasticsearch.ExperienceYears"
+                    }
+                },
                 "url": {
                     "type": "string"
                 },
@@ -1056,6 +4055,62 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/elasticsearch.WorkExperience"
                     }
+                },
+                "years_of_experience": {
+                    "description": "YearsOfExperience, SkillExperience and TitleExperience are computed from WorkExperience\nwhen the resume is indexed (see ComputeExperience).",
+                    "type": "number"
+                }
+            }
+        },
+        "elasticsearch.SearchFilter": {
+            "type": "object",
+            "properties": {
+                "all_versions": {
+                    "type": "boolean"
+                },
+                "education_level": {
+                    "type": "string"
+                },
+                "major": {
+                    "type": "string"
+                },
+                "min_confidence": {
+                    "type": "number"
+                },
+                "min_gpa": {
+                    "type": "number"
+                },
+                "min_skill_years": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "min_title_years": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "min_years": {
+                    "type": "number"
+                },
+                "university": {
+                    "type": "string"
+                }
+            }
+        },
+        "elasticsearch.SearchSort": {
+            "type": "object",
+            "properties": {
+                "ascending": {
+                    "type": "boolean"
+                },
+                "by": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
                 }
             }
         },
@@ -1065,8 +4120,14 @@ const docTemplate = `{
                 "company": {
                     "type": "string"
                 },
+                "current": {
+                    "type": "boolean"
+                },
                 "duration": {
-                    "description": "Could be changed to a more structured format if necessary",
+                    "description": "as stated in the resume",
+                    "type": "string"
+                },
+                "end_date": {
                     "type": "string"
                 },
                 "job_summary": {
@@ -1077,6 +4138,17 @@ const docTemplate = `{
                 },
                 "location": {
                     "type": "string"
+                },
+                "skills": {
+                    "description": "skills used in the role",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start_date": {
+                    "description": "StartDate and EndDate are YYYY-MM, or YYYY when the month is not stated, and empty when\nunknown (left out of the document, which maps them as dates). A Current role has no EndDate.",
+                    "type": "string"
                 }
             }
         },
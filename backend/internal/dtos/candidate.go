@@ -0,0 +1,33 @@
+package dtos
+
+import "CVSeeker/pkg/elasticsearch"
+
+type CandidateDTO struct {
+	ID               int64              `json:"id"`
+	FullName         string             `json:"fullName"`
+	LatestDocumentID string             `json:"latestDocumentId"`
+	Versions         []ResumeVersionDTO `json:"versions"`
+	CreatedAt        int64              `json:"createdAt"`
+	UpdatedAt        int64              `json:"updatedAt"`
+}
+
+type ResumeVersionDTO struct {
+	Version    int    `json:"version"`
+	DocumentID string `json:"documentId"`
+	UploadID   int    `json:"uploadId,omitempty"`
+	// FileURL is the file the version was parsed from.
+	FileURL   string `json:"fileUrl"`
+	Latest    bool   `json:"latest"`
+	CreatedAt int64  `json:"createdAt"`
+	// Resume is the parsed content, returned when a single version is requested.
+	Resume *elasticsearch.ResumeSummaryDTO `json:"resume,omitempty"`
+}
+
+type ResumeDiffDTO struct {
+	CandidateID    int64                       `json:"candidateId"`
+	From           int                         `json:"from"`
+	To             int                         `json:"to"`
+	FromDocumentID string                      `json:"fromDocumentId"`
+	ToDocumentID   string                      `json:"toDocumentId"`
+	Changes        []elasticsearch.FieldChange `json:"changes"`
+}
@@ -0,0 +1,23 @@
+package dtos
+
+type DuplicateCandidateDTO struct {
+	ID         int64  `json:"id"`
+	DocumentID string `json:"documentId"`
+	// DocumentName and DuplicateOfName are the candidate names of the two documents, when they are
+	// still indexed.
+	DocumentName    string  `json:"documentName,omitempty"`
+	DuplicateOf     string  `json:"duplicateOf"`
+	DuplicateOfName string  `json:"duplicateOfName,omitempty"`
+	UploadID        int     `json:"uploadId,omitempty"`
+	Reason          string  `json:"reason"`
+	Score           float64 `json:"score"`
+	Status          string  `json:"status"`
+	CreatedAt       int64   `json:"createdAt"`
+	ResolvedAt      *int64  `json:"resolvedAt,omitempty"`
+}
+
+// MergeResumesRequest merges the document SourceID into TargetID. SourceID is deleted.
+type MergeResumesRequest struct {
+	SourceID string `json:"sourceId"`
+	TargetID string `json:"targetId"`
+}
@@ -0,0 +1,20 @@
+package dtos
+
+// Health check statuses.
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// HealthCheckDTO is the result of a single dependency check.
+type HealthCheckDTO struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthDTO is the body returned by the liveness and readiness endpoints.
+type HealthDTO struct {
+	Status string           `json:"status"`
+	Checks []HealthCheckDTO `json:"checks,omitempty"`
+}
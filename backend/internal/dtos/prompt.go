@@ -0,0 +1,41 @@
+package dtos
+
+type PromptVersionDTO struct {
+	Version   string `json:"version"`
+	Source    string `json:"source"`
+	Template  string `json:"template"`
+	Active    bool   `json:"active"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type PromptDTO struct {
+	Name          string             `json:"name"`
+	ActiveVersion string             `json:"activeVersion"`
+	Versions      []PromptVersionDTO `json:"versions"`
+}
+
+type PromptVersionRequest struct {
+	Version  string `json:"version"`
+	Template string `json:"template"`
+}
+
+// PromptPreviewRequest selects the template to preview (a draft template, a version, or the
+// active version when both are empty) and the data to render it with. Sample data is used for
+// the fields that are not given.
+type PromptPreviewRequest struct {
+	Version      string   `json:"version,omitempty"`
+	Template     string   `json:"template,omitempty"`
+	ResumeText   string   `json:"resumeText,omitempty"`
+	WithEvidence *bool    `json:"withEvidence,omitempty"`
+	DocumentIds  []string `json:"documentIds,omitempty"`
+}
+
+type PromptPreviewDTO struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	Prompt  string `json:"prompt"`
+}
+
+type PromptRolloutRequest struct {
+	Version string `json:"version"`
+}
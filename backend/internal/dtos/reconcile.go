@@ -0,0 +1,57 @@
+package dtos
+
+// ReconcilePolicy chooses the action taken on each kind of issue. Empty fields take the action of
+// RECONCILE_POLICY, and "report" when it sets none.
+type ReconcilePolicy struct {
+	// MissingDocument is "report", "requeue" (rebuild the document from its resume record, or mark
+	// the upload failed when there is no record to rebuild from) or "fail" (mark the upload failed).
+	MissingDocument string `json:"missingDocument,omitempty"`
+	// OrphanDocument is "report" or "delete".
+	OrphanDocument string `json:"orphanDocument,omitempty"`
+	// OrphanObject is "report" or "delete".
+	OrphanObject string `json:"orphanObject,omitempty"`
+	// StuckUpload is "report" or "fail".
+	StuckUpload string `json:"stuckUpload,omitempty"`
+}
+
+type ReconcileRequest struct {
+	Policy ReconcilePolicy `json:"policy"`
+	// DryRun reports every issue without fixing any.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ReconcileIssue is an inconsistency found by a reconcile run and what was done about it.
+type ReconcileIssue struct {
+	Kind       string `json:"kind"`
+	DocumentID string `json:"documentId,omitempty"`
+	UploadID   int    `json:"uploadId,omitempty"`
+	FileKey    string `json:"fileKey,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+	Action     string `json:"action"`
+	Fixed      bool   `json:"fixed"`
+	Error      string `json:"error,omitempty"`
+}
+
+type ReconcileRunDTO struct {
+	ID      int64           `json:"id"`
+	Trigger string          `json:"trigger"`
+	Policy  ReconcilePolicy `json:"policy"`
+	DryRun  bool            `json:"dryRun"`
+	Status  string          `json:"status"`
+	// Uploads, Records, Documents and Objects are the numbers of items checked in each store.
+	Uploads   int `json:"uploads"`
+	Records   int `json:"records"`
+	Documents int `json:"documents"`
+	Objects   int `json:"objects"`
+	Found     int `json:"found"`
+	Fixed     int `json:"fixed"`
+	Failed    int `json:"failed"`
+	// Counts is the number of issues found of each kind.
+	Counts map[string]int `json:"counts"`
+	// Issues lists the issues found, up to a limit; it is only returned for a single run.
+	Issues     []ReconcileIssue `json:"issues,omitempty"`
+	LastError  string           `json:"lastError,omitempty"`
+	CreatedAt  int64            `json:"createdAt"`
+	UpdatedAt  int64            `json:"updatedAt"`
+	FinishedAt *int64           `json:"finishedAt,omitempty"`
+}
@@ -0,0 +1,39 @@
+package dtos
+
+// ReprocessSelection chooses the documents of a reprocess job. Empty fields do not restrict the
+// selection, so an empty selection reprocesses every indexed document.
+type ReprocessSelection struct {
+	DocumentIds []string `json:"documentIds,omitempty"`
+	// From and To bound the upload time (unix seconds, To excluded).
+	From *int64 `json:"from,omitempty"`
+	To   *int64 `json:"to,omitempty"`
+	// ParseModel, EmbeddingModel and PromptVersion only keep documents produced by that version;
+	// use "none" for documents indexed before versions were recorded.
+	ParseModel     string `json:"parseModel,omitempty"`
+	EmbeddingModel string `json:"embeddingModel,omitempty"`
+	PromptVersion  string `json:"promptVersion,omitempty"`
+	// Stale only keeps documents that the current configuration would produce differently, and
+	// documents of an older schema.
+	Stale bool `json:"stale,omitempty"`
+}
+
+type ReprocessRequest struct {
+	Mode      string             `json:"mode"`
+	Selection ReprocessSelection `json:"selection"`
+}
+
+type ReprocessJobDTO struct {
+	ID         int64              `json:"id"`
+	Mode       string             `json:"mode"`
+	Selection  ReprocessSelection `json:"selection"`
+	Status     string             `json:"status"`
+	Total      int                `json:"total"`
+	Processed  int                `json:"processed"`
+	Succeeded  int                `json:"succeeded"`
+	Skipped    int                `json:"skipped"`
+	Failed     int                `json:"failed"`
+	LastError  string             `json:"lastError,omitempty"`
+	CreatedAt  int64              `json:"createdAt"`
+	UpdatedAt  int64              `json:"updatedAt"`
+	FinishedAt *int64             `json:"finishedAt,omitempty"`
+}
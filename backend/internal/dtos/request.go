@@ -1,7 +1,11 @@
 package dtos
 
+import "CVSeeker/pkg/elasticsearch"
+
 type QueryRequest struct {
-	Content string `json:"content"`
+	Content string                      `json:"content"`
+	Filters *elasticsearch.SearchFilter `json:"filters,omitempty"`
+	Sort    *elasticsearch.SearchSort   `json:"sort,omitempty"`
 }
 
 type StartChatRequest struct {
@@ -18,4 +22,13 @@ type ResumeData struct {
 	FileBytes string `json:"fileBytes"`
 	Name      string `json:"name"`
 	UUID      string `json:"uuid"`
+	// OnDuplicate overrides DUPLICATE_POLICY for this resume: skip, replace, version or review.
+	OnDuplicate string `json:"onDuplicate,omitempty"`
+	// FileKey is the S3 key the file is already stored under, when an upload is retried or imported.
+	// FileHash is the hash of that file when FileBytes does not hold it.
+	FileKey  string `json:"-"`
+	FileHash string `json:"-"`
+	// Source and Origin are the ingestion source a file was picked up by and where it came from.
+	Source string `json:"-"`
+	Origin string `json:"-"`
 }
@@ -0,0 +1,18 @@
+package dtos
+
+import "CVSeeker/pkg/elasticsearch"
+
+// ResumeRecordDTO is the MySQL record an indexed document is projected from.
+type ResumeRecordDTO struct {
+	DocumentID     string                          `json:"documentId"`
+	Source         string                          `json:"source"`
+	FileKey        string                          `json:"fileKey,omitempty"`
+	DownloadLink   string                          `json:"downloadLink"`
+	FullText       string                          `json:"fullText"`
+	PromptVersion  string                          `json:"promptVersion,omitempty"`
+	ParseModel     string                          `json:"parseModel,omitempty"`
+	EmbeddingModel string                          `json:"embeddingModel,omitempty"`
+	Content        *elasticsearch.ResumeSummaryDTO `json:"content,omitempty"`
+	CreatedAt      int64                           `json:"createdAt"`
+	UpdatedAt      int64                           `json:"updatedAt"`
+}
@@ -1,9 +1,70 @@
 package dtos
 
 type UploadDTO struct {
+	ID         int    `json:"id"`
+	BatchID    *int64 `json:"batchId,omitempty"`
 	DocumentID string `json:"documentId,omitempty"` // omitempty to not display if empty
 	Status     string `json:"status"`
 	Name       string `json:"name"`
+	Error      string `json:"error,omitempty"`
 	CreatedAt  int64  `json:"createdAt"` // Assuming date is formatted as a string for the client
 	UUID       string `json:"uuid"`
+	Source     string `json:"source,omitempty"`
+	Origin     string `json:"origin,omitempty"`
+	// DuplicateOf is the upload of the same file this upload is linked to, when it was submitted again
+	// within UPLOAD_DEDUPE_WINDOW.
+	DuplicateOf *int `json:"duplicateOf,omitempty"`
+}
+
+// BatchDTO is an upload batch with how many of its uploads are in each status.
+type BatchDTO struct {
+	ID         int64          `json:"id"`
+	Source     string         `json:"source"`
+	Status     string         `json:"status"`
+	Total      int            `json:"total"`
+	Counts     map[string]int `json:"counts"`
+	CreatedAt  int64          `json:"createdAt"`
+	FinishedAt *int64         `json:"finishedAt,omitempty"`
+	Uploads    []UploadDTO    `json:"uploads,omitempty"`
+}
+
+// BatchProgressDTO is the payload of the websocket events of a batch. Upload is the upload that just
+// finished, absent from the completion event.
+type BatchProgressDTO struct {
+	BatchID int64          `json:"batchId"`
+	Status  string         `json:"status"`
+	Total   int            `json:"total"`
+	Counts  map[string]int `json:"counts"`
+	Upload  *UploadDTO     `json:"upload,omitempty"`
+}
+
+// ImportEntry is a file of an imported archive: the upload it became, or why it was skipped.
+type ImportEntry struct {
+	Name     string `json:"name"`
+	UploadID int    `json:"uploadId,omitempty"`
+	Skipped  string `json:"skipped,omitempty"`
+}
+
+// ImportDTO is an imported archive, or a delivery of the ingestion source Source, with the batch of
+// its uploads, absent when every file was skipped.
+type ImportDTO struct {
+	ID        int64         `json:"id"`
+	Source    string        `json:"source,omitempty"`
+	FileName  string        `json:"fileName"`
+	Files     int           `json:"files"`
+	Queued    int           `json:"queued"`
+	Skipped   int           `json:"skipped"`
+	CreatedAt int64         `json:"createdAt"`
+	Entries   []ImportEntry `json:"entries"`
+	Batch     *BatchDTO     `json:"batch,omitempty"`
+}
+
+// ImportReportRow is a line of the report of an import: a file with the current outcome of its
+// upload, or "Skipped" with the reason.
+type ImportReportRow struct {
+	File       string `json:"file"`
+	Status     string `json:"status"`
+	Reason     string `json:"reason,omitempty"`
+	UploadID   int    `json:"uploadId,omitempty"`
+	DocumentID string `json:"documentId,omitempty"`
 }
@@ -0,0 +1,32 @@
+package dtos
+
+type UsageSummaryDTO struct {
+	Group            string  `json:"group,omitempty"`
+	Calls            int64   `json:"calls"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	TotalTokens      int64   `json:"totalTokens"`
+	Cost             float64 `json:"cost"`
+}
+
+type UsageReportDTO struct {
+	From    int64             `json:"from"`
+	To      int64             `json:"to"`
+	GroupBy string            `json:"groupBy,omitempty"`
+	Total   UsageSummaryDTO   `json:"total"`
+	Groups  []UsageSummaryDTO `json:"groups,omitempty"`
+}
+
+type BudgetDTO struct {
+	TenantID     string  `json:"tenantId"`
+	MonthlyLimit float64 `json:"monthlyLimit"`
+	Policy       string  `json:"policy"`
+	Spent        float64 `json:"spent"`
+	Remaining    float64 `json:"remaining"`
+}
+
+type BudgetRequest struct {
+	TenantID     string  `json:"tenantId"`
+	MonthlyLimit float64 `json:"monthlyLimit"`
+	Policy       string  `json:"policy"`
+}
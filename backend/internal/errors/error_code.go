@@ -6,6 +6,14 @@ Example error code is 5000102:
 - 01 is module represents for each handler
   - 00 for common error for all handler
   - 01 for health check handler
+  - 02 for usage and budget handler
+  - 03 for prompt handler
+  - 04 for reprocess handler
+  - 05 for duplicate handler
+  - 06 for candidate handler
+  - 07 for resume record handler
+  - 08 for reconcile handler
+  - 09 for batch and upload handler
 
 - 02 is actual error code, just auto increment and start at 1
 */
@@ -17,5 +25,55 @@ var (
 	ErrCommonInvalidRequest    = ErrorCode("40000001")
 	ErrCommonBindRequestError  = ErrorCode("40000002")
 	ErrCommonExpiredToken      = ErrorCode("40100006")
+	ErrCommonShuttingDown      = ErrorCode("50300001")
 	ErrAuthorizedNotPermission = ErrorCode("40000108")
+
+	// Errors of module usage
+	// Format: ErrUsage<ERROR_NAME> = xxx02yy
+	ErrUsageBudgetExceeded = ErrorCode("42900201")
+
+	// Errors of module prompt
+	// Format: ErrPrompt<ERROR_NAME> = xxx03yy
+	ErrPromptNotFound        = ErrorCode("40400301")
+	ErrPromptInvalidTemplate = ErrorCode("40000302")
+	ErrPromptVersionExists   = ErrorCode("40900303")
+
+	// Errors of module reprocess
+	// Format: ErrReprocess<ERROR_NAME> = xxx04yy
+	ErrReprocessJobNotFound     = ErrorCode("40400401")
+	ErrReprocessJobNotResumable = ErrorCode("40900402")
+
+	// Errors of module duplicate
+	// Format: ErrDuplicate<ERROR_NAME> = xxx05yy
+	ErrDuplicateNotFound         = ErrorCode("40400501")
+	ErrDuplicateResolved         = ErrorCode("40900502")
+	ErrDuplicateDocumentNotFound = ErrorCode("40400503")
+
+	// Errors of module candidate
+	// Format: ErrCandidate<ERROR_NAME> = xxx06yy
+	ErrCandidateNotFound        = ErrorCode("40400601")
+	ErrCandidateVersionNotFound = ErrorCode("40400602")
+
+	// Errors of module resume record
+	// Format: ErrResume<ERROR_NAME> = xxx07yy
+	ErrResumeNotFound     = ErrorCode("40400701")
+	ErrResumeNoContent    = ErrorCode("40900702")
+	ErrResumeNoSourceText = ErrorCode("40900703")
+
+	// Errors of module reconcile
+	// Format: ErrReconcile<ERROR_NAME> = xxx08yy
+	ErrReconcileRunNotFound = ErrorCode("40400801")
+	ErrReconcileRunning     = ErrorCode("40900802")
+
+	// Errors of module batch and upload
+	// Format: ErrBatch<ERROR_NAME> = xxx09yy
+	ErrBatchNotFound        = ErrorCode("40400901")
+	ErrUploadNotFound       = ErrorCode("40400902")
+	ErrUploadNotRetryable   = ErrorCode("40900903")
+	ErrUploadNoStoredInput  = ErrorCode("40900904")
+	ErrUploadNotCancellable = ErrorCode("40900905")
+	ErrBatchNotCancellable  = ErrorCode("40900906")
+	ErrImportNotFound       = ErrorCode("40400907")
+	ErrImportInvalidArchive = ErrorCode("40000908")
+	ErrBatchKeyReused       = ErrorCode("40900909")
 )
@@ -39,7 +39,7 @@ func MiddlewareGin(appName string, logLevel zerolog.Level) gin.HandlerFunc {
 			deviceID       = c.GetHeader(HeaderDeviceID)
 		)
 
-		if strings.Contains(reqEndpoint, "health-check") || strings.Contains(reqEndpoint, "swagger") {
+		if reqEndpoint == "/healthz" || reqEndpoint == "/readyz" || strings.Contains(reqEndpoint, "swagger") || reqEndpoint == "/metrics" {
 			return
 		}
 
@@ -0,0 +1,28 @@
+package ginMiddleware
+
+import (
+	"CVSeeker/pkg/metrics"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute is the route label used for requests that did not match any registered route.
+const unmatchedRoute = "unmatched"
+
+// Metrics records a latency histogram for every request, labelled by the route template
+// (e.g. /cvseeker/resumes/:id) rather than the raw path to keep label cardinality bounded.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,30 @@
+package ginMiddleware
+
+import (
+	"CVSeeker/internal/dtos"
+	"CVSeeker/pkg/api"
+	"CVSeeker/pkg/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageTags tags the request context with the calling user and tenant so that the token usage
+// of LLM calls made while serving the request, including background jobs it starts, is attributed to them.
+func UsageTags() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		userID := c.GetHeader(api.XForwardUserOpsHeader)
+		if userID == "" {
+			userID = c.GetString(dtos.GinContextBasicUsername)
+		}
+		if userID != "" {
+			ctx = usage.WithUser(ctx, userID)
+		}
+		if tenantID := c.GetHeader(api.XTenantIDHeader); tenantID != "" {
+			ctx = usage.WithTenant(ctx, tenantID)
+		}
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
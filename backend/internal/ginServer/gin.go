@@ -1,8 +1,11 @@
 package ginServer
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/dig"
+	"net/http"
 )
 
 // GinRoutingFn is callback function for setting up routers.
@@ -22,6 +25,10 @@ func NewGinServer(params Params) Server {
 		conf:    params.Conf,
 		router:  params.Router,
 		routing: params.Routing,
+		server: &http.Server{
+			Addr:    params.Conf.ListenerAddr(),
+			Handler: params.Router,
+		},
 	}
 }
 
@@ -29,6 +36,7 @@ type ginServer struct {
 	routing GinRoutingFn
 	conf    *Config
 	router  *gin.Engine
+	server  *http.Server
 }
 
 func (_this *ginServer) Open() error {
@@ -37,13 +45,14 @@ func (_this *ginServer) Open() error {
 	}
 	_this.routing(_this.router)
 
-	if err := _this.router.Run(_this.conf.ListenerAddr()); err != nil {
+	if err := _this.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 
 	return nil
 }
 
-func (_this *ginServer) Close() {
-	// Blank function
+// Shutdown stops accepting connections and waits for in-flight requests to complete until ctx is done.
+func (_this *ginServer) Shutdown(ctx context.Context) error {
+	return _this.server.Shutdown(ctx)
 }
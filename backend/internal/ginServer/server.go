@@ -1,6 +1,7 @@
 package ginServer
 
 import (
+	"context"
 	"errors"
 	"fmt"
 )
@@ -8,7 +9,7 @@ import (
 // Server describes http server.
 type Server interface {
 	Open() error
-	Close()
+	Shutdown(ctx context.Context) error
 }
 
 // Errors definition.
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameBatch = "batches"
+
+// Sources of a batch.
+const (
+	BatchSourceUpload   = "upload"
+	BatchSourceFiles    = "files"
+	BatchSourceLinkedIn = "linkedin"
+	BatchSourceImport   = "import"
+	BatchSourceFolder   = "folder"
+	BatchSourceMail     = "mail"
+	// BatchSourceRecrawl is a batch of the LinkedIn profiles that changed since they were crawled.
+	BatchSourceRecrawl = "recrawl"
+)
+
+// Statuses of a batch, derived from the statuses of its uploads.
+const (
+	BatchStatusProcessing = "processing"
+	BatchStatusCompleted  = "completed"
+	// BatchStatusPartial is a finished batch in which some uploads failed.
+	BatchStatusPartial = "completed_with_errors"
+	BatchStatusFailed  = "failed"
+	// BatchStatusCancelled is a finished batch in which some uploads were cancelled.
+	BatchStatusCancelled = "cancelled"
+)
+
+// Batch groups the uploads submitted in one request, so that their progress can be followed
+// together. UserID is the user who submitted it, and the only one who is sent its progress.
+// IdempotencyKey identifies the request among those of the user, so that sending it again returns
+// this batch, and is nil for a request without one; RequestHash tells a request sent again from
+// another one reusing its key.
+type Batch struct {
+	ID             int64      `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	UserID         string     `gorm:"column:user_id;type:varchar(255)" json:"userId"`
+	Source         string     `gorm:"column:source;type:varchar(20)" json:"source"`
+	Total          int        `gorm:"column:total" json:"total"`
+	IdempotencyKey *string    `gorm:"column:idempotency_key;type:varchar(255)" json:"idempotencyKey"`
+	RequestHash    string     `gorm:"column:request_hash;type:char(64)" json:"-"`
+	CreatedAt      time.Time  `gorm:"column:created_at;type:datetime" json:"createdAt"`
+	FinishedAt     *time.Time `gorm:"column:finished_at;type:datetime" json:"finishedAt"`
+}
+
+func (Batch) TableName() string {
+	return TableNameBatch
+}
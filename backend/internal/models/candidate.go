@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TableNameCandidate     = "candidates"
+	TableNameResumeVersion = "resume_versions"
+)
+
+// Candidate is a person whose resumes are kept as versions. LatestDocumentID is the version
+// searches return.
+type Candidate struct {
+	ID               int64     `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	FullName         string    `gorm:"column:full_name;type:varchar(255)" json:"fullName"`
+	LatestDocumentID string    `gorm:"column:latest_document_id;type:varchar(100)" json:"latestDocumentId"`
+	CreatedAt        time.Time `gorm:"column:created_at;type:datetime" json:"createdAt"`
+	UpdatedAt        time.Time `gorm:"column:updated_at;type:datetime" json:"updatedAt"`
+}
+
+func (Candidate) TableName() string {
+	return TableNameCandidate
+}
+
+// ResumeVersion is one indexed resume of a candidate. Versions are numbered from 1 in upload order.
+type ResumeVersion struct {
+	ID          int64     `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	CandidateID int64     `gorm:"column:candidate_id" json:"candidateId"`
+	Version     int       `gorm:"column:version" json:"version"`
+	DocumentID  string    `gorm:"column:document_id;type:varchar(100)" json:"documentId"`
+	UploadID    int       `gorm:"column:upload_id" json:"uploadId"`
+	FileURL     string    `gorm:"column:file_url;type:varchar(1024)" json:"fileUrl"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:datetime" json:"createdAt"`
+}
+
+func (ResumeVersion) TableName() string {
+	return TableNameResumeVersion
+}
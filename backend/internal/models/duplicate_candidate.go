@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameDuplicateCandidate = "duplicate_candidates"
+
+// Policies applied when an upload is recognised as a resume that is already indexed.
+const (
+	// DuplicatePolicySkip does not index the upload.
+	DuplicatePolicySkip = "skip"
+	// DuplicatePolicyReplace overwrites the existing document with the upload.
+	DuplicatePolicyReplace = "replace"
+	// DuplicatePolicyVersion indexes the upload as a new version of the existing document.
+	DuplicatePolicyVersion = "version"
+	// DuplicatePolicyReview indexes the upload and queues the pair for review.
+	DuplicatePolicyReview = "review"
+)
+
+// Reasons for which two documents are considered duplicates.
+const (
+	DuplicateReasonFileHash = "file_hash"
+	DuplicateReasonTextHash = "text_hash"
+	// DuplicateReasonContact is the same normalized name with an e-mail address or phone number in common.
+	DuplicateReasonContact = "contact"
+	// DuplicateReasonEmbedding is a similar embedding only; it is always queued for review.
+	DuplicateReasonEmbedding = "embedding"
+)
+
+// Statuses of a duplicate candidate. Pending candidates form the review queue; the other statuses
+// record how the pair was resolved.
+const (
+	DuplicateStatusPending   = "pending"
+	DuplicateStatusDismissed = "dismissed"
+	DuplicateStatusMerged    = "merged"
+	DuplicateStatusSkipped   = "skipped"
+	DuplicateStatusReplaced  = "replaced"
+	DuplicateStatusVersioned = "versioned"
+)
+
+// DuplicateCandidate records that the document uploaded as DocumentID duplicates DuplicateOf. When
+// the upload was skipped or replaced the existing document, DocumentID equals DuplicateOf.
+type DuplicateCandidate struct {
+	ID          int64      `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	DocumentID  string     `gorm:"column:document_id;type:varchar(100)" json:"documentId"`
+	DuplicateOf string     `gorm:"column:duplicate_of;type:varchar(100)" json:"duplicateOf"`
+	UploadID    int        `gorm:"column:upload_id" json:"uploadId"`
+	Reason      string     `gorm:"column:reason;type:varchar(20)" json:"reason"`
+	Score       float64    `gorm:"column:score" json:"score"`
+	Status      string     `gorm:"column:status;type:varchar(20)" json:"status"`
+	CreatedAt   time.Time  `gorm:"column:created_at;type:datetime" json:"createdAt"`
+	ResolvedAt  *time.Time `gorm:"column:resolved_at;type:datetime" json:"resolvedAt"`
+}
+
+func (DuplicateCandidate) TableName() string {
+	return TableNameDuplicateCandidate
+}
+
+// ValidDuplicatePolicy reports whether policy is one of the duplicate policies.
+func ValidDuplicatePolicy(policy string) bool {
+	switch policy {
+	case DuplicatePolicySkip, DuplicatePolicyReplace, DuplicatePolicyVersion, DuplicatePolicyReview:
+		return true
+	}
+	return false
+}
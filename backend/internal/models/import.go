@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameImport = "imports"
+
+// Import is an archive of resumes imported as one batch, or a delivery of an ingestion source, named
+// by Source. Files is the number of files read from the archive or delivery: Queued became uploads
+// of the batch and Skipped were left out. Entries holds, as JSON, what was done with each file.
+type Import struct {
+	ID        int64     `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	UserID    string    `gorm:"column:user_id;type:varchar(255)" json:"userId"`
+	BatchID   *int64    `gorm:"column:batch_id" json:"batchId"`
+	Source    string    `gorm:"column:source;type:varchar(255)" json:"source"`
+	FileName  string    `gorm:"column:file_name;type:varchar(255)" json:"fileName"`
+	Files     int       `gorm:"column:files" json:"files"`
+	Queued    int       `gorm:"column:queued" json:"queued"`
+	Skipped   int       `gorm:"column:skipped" json:"skipped"`
+	Entries   string    `gorm:"column:entries;type:longtext" json:"entries"`
+	CreatedAt time.Time `gorm:"column:created_at;type:datetime" json:"createdAt"`
+}
+
+func (Import) TableName() string {
+	return TableNameImport
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameLlmBudget = "llm_budgets"
+
+// Policies applied when a monthly budget is reached.
+const (
+	// BudgetPolicyReject rejects new work with an error.
+	BudgetPolicyReject = "reject"
+	// BudgetPolicyQueue accepts ingestion work and holds it until budget is available again.
+	// Interactive work such as chat is still rejected.
+	BudgetPolicyQueue = "queue"
+)
+
+// LlmBudget is the monthly spending cap of a tenant. The row with an empty tenant ID applies
+// to tenants without a budget of their own.
+type LlmBudget struct {
+	TenantID     string    `gorm:"column:tenant_id;primary_key;type:varchar(100)" json:"tenantId"`
+	MonthlyLimit float64   `gorm:"column:monthly_limit;type:decimal(12,2)" json:"monthlyLimit"`
+	Policy       string    `gorm:"column:policy;type:varchar(20)" json:"policy"`
+	CreatedAt    time.Time `gorm:"column:created_at;type:datetime" json:"createdAt"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;type:datetime" json:"updatedAt"`
+}
+
+func (LlmBudget) TableName() string {
+	return TableNameLlmBudget
+}
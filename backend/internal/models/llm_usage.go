@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameLlmUsage = "llm_usage"
+
+// LlmUsage is the token usage and estimated cost of one LLM or embedding call.
+type LlmUsage struct {
+	ID               int64     `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	Provider         string    `gorm:"column:provider;type:varchar(50)" json:"provider"`
+	Model            string    `gorm:"column:model;type:varchar(255)" json:"model"`
+	Operation        string    `gorm:"column:operation;type:varchar(100)" json:"operation"`
+	UserID           string    `gorm:"column:user_id;type:varchar(255)" json:"userId"`
+	TenantID         string    `gorm:"column:tenant_id;type:varchar(100)" json:"tenantId"`
+	PromptTokens     int       `gorm:"column:prompt_tokens" json:"promptTokens"`
+	CompletionTokens int       `gorm:"column:completion_tokens" json:"completionTokens"`
+	TotalTokens      int       `gorm:"column:total_tokens" json:"totalTokens"`
+	Estimated        bool      `gorm:"column:estimated" json:"estimated"`
+	Cost             float64   `gorm:"column:cost;type:decimal(12,6)" json:"cost"`
+	TraceID          string    `gorm:"column:trace_id;type:varchar(64)" json:"traceId"`
+	CreatedAt        time.Time `gorm:"column:created_at;type:datetime" json:"createdAt"`
+}
+
+func (LlmUsage) TableName() string {
+	return TableNameLlmUsage
+}
+
+// LlmUsageSummary is the aggregated usage of one group of calls.
+type LlmUsageSummary struct {
+	GroupKey         string  `gorm:"column:group_key"`
+	Calls            int64   `gorm:"column:calls"`
+	PromptTokens     int64   `gorm:"column:prompt_tokens"`
+	CompletionTokens int64   `gorm:"column:completion_tokens"`
+	TotalTokens      int64   `gorm:"column:total_tokens"`
+	Cost             float64 `gorm:"column:cost"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	TableNamePromptTemplate = "prompt_templates"
+	TableNamePromptRollout  = "prompt_rollouts"
+)
+
+// PromptTemplate is a version of a prompt added at runtime, on top of the static versions shipped
+// in statics/prompts. Versions are immutable: a change is a new version.
+type PromptTemplate struct {
+	ID        int64     `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	Name      string    `gorm:"column:name;type:varchar(100)" json:"name"`
+	Version   string    `gorm:"column:version;type:varchar(32)" json:"version"`
+	Body      string    `gorm:"column:body;type:text" json:"body"`
+	CreatedAt time.Time `gorm:"column:created_at;type:datetime" json:"createdAt"`
+}
+
+func (PromptTemplate) TableName() string {
+	return TableNamePromptTemplate
+}
+
+// PromptRollout is the version of a prompt currently in use. Prompts without a rollout use their
+// latest static version.
+type PromptRollout struct {
+	Name      string    `gorm:"column:name;primary_key;type:varchar(100)" json:"name"`
+	Version   string    `gorm:"column:version;type:varchar(32)" json:"version"`
+	UpdatedAt time.Time `gorm:"column:updated_at;type:datetime" json:"updatedAt"`
+}
+
+func (PromptRollout) TableName() string {
+	return TableNamePromptRollout
+}
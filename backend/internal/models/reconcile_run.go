@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameReconcileRun = "reconcile_runs"
+
+// What started a reconcile run.
+const (
+	ReconcileTriggerSchedule = "schedule"
+	ReconcileTriggerManual   = "manual"
+)
+
+// Statuses of a reconcile run.
+const (
+	ReconcileStatusRunning   = "running"
+	ReconcileStatusCompleted = "completed"
+	ReconcileStatusFailed    = "failed"
+	// ReconcileStatusInterrupted is set on the runs of a process that stopped during them.
+	ReconcileStatusInterrupted = "interrupted"
+)
+
+// Kinds of drift between the upload records, the Elasticsearch index and the blob store.
+const (
+	// ReconcileIssueMissingDocument is a successful upload or a resume record whose document is
+	// not in Elasticsearch.
+	ReconcileIssueMissingDocument = "missing_document"
+	// ReconcileIssueOrphanDocument is a document with neither an upload nor a resume record.
+	ReconcileIssueOrphanDocument = "orphan_document"
+	// ReconcileIssueOrphanObject is a stored file that no resume record or document refers to.
+	ReconcileIssueOrphanObject = "orphan_object"
+	// ReconcileIssueStuckUpload is an upload processing or queued for longer than UPLOAD_STALE_AFTER.
+	ReconcileIssueStuckUpload = "stuck_upload"
+)
+
+// Actions a reconcile policy takes on an issue.
+const (
+	ReconcileActionReport = "report"
+	// ReconcileActionRequeue rebuilds a missing document from its resume record.
+	ReconcileActionRequeue = "requeue"
+	// ReconcileActionDelete deletes an orphan document or file.
+	ReconcileActionDelete = "delete"
+	// ReconcileActionFail marks the upload of the issue as failed.
+	ReconcileActionFail = "fail"
+)
+
+// ReconcileRun is a pass over the upload records, the Elasticsearch index and the blob store that
+// looks for drift between them and handles it according to Policy. Issues holds the issues found,
+// up to a limit; Counts holds the number found of each kind.
+type ReconcileRun struct {
+	ID         int64      `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	Trigger    string     `gorm:"column:trigger_type;type:varchar(20)" json:"trigger"`
+	Policy     string     `gorm:"column:policy;type:text" json:"policy"`
+	DryRun     bool       `gorm:"column:dry_run" json:"dryRun"`
+	Status     string     `gorm:"column:status;type:varchar(20)" json:"status"`
+	Uploads    int        `gorm:"column:uploads" json:"uploads"`
+	Records    int        `gorm:"column:records" json:"records"`
+	Documents  int        `gorm:"column:documents" json:"documents"`
+	Objects    int        `gorm:"column:objects" json:"objects"`
+	Found      int        `gorm:"column:found" json:"found"`
+	Fixed      int        `gorm:"column:fixed" json:"fixed"`
+	Failed     int        `gorm:"column:failed" json:"failed"`
+	Counts     string     `gorm:"column:counts;type:text" json:"counts"`
+	Issues     string     `gorm:"column:issues;type:longtext" json:"issues"`
+	LastError  string     `gorm:"column:last_error;type:text" json:"lastError"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:datetime" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;type:datetime" json:"updatedAt"`
+	FinishedAt *time.Time `gorm:"column:finished_at;type:datetime" json:"finishedAt"`
+}
+
+func (ReconcileRun) TableName() string {
+	return TableNameReconcileRun
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+)
+
+const TableNameReprocessJob = "reprocess_jobs"
+
+// Modes of a reprocess job.
+const (
+	// ReprocessModeEmbed recomputes the embedding of the stored content.
+	ReprocessModeEmbed = "embed"
+	// ReprocessModeExtract parses the source text again, then recomputes the embedding.
+	ReprocessModeExtract = "extract"
+)
+
+// Statuses of a reprocess job.
+const (
+	ReprocessStatusPending   = "pending"
+	ReprocessStatusRunning   = "running"
+	ReprocessStatusPaused    = "paused"
+	ReprocessStatusCompleted = "completed"
+	ReprocessStatusFailed    = "failed"
+	// ReprocessStatusInterrupted is set when the process stops during a job; the job is resumed
+	// on the next start.
+	ReprocessStatusInterrupted = "interrupted"
+)
+
+// ReprocessJob re-runs extraction and/or embedding over a selection of indexed documents. Documents
+// are processed once each, in the order of their first upload, and Cursor is the ID of the first
+// upload of the last document handled, so that a job can continue where it stopped.
+type ReprocessJob struct {
+	ID         int64      `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	Mode       string     `gorm:"column:mode;type:varchar(20)" json:"mode"`
+	Selection  string     `gorm:"column:selection;type:text" json:"selection"`
+	Status     string     `gorm:"column:status;type:varchar(20)" json:"status"`
+	Total      int        `gorm:"column:total" json:"total"`
+	Succeeded  int        `gorm:"column:succeeded" json:"succeeded"`
+	Skipped    int        `gorm:"column:skipped" json:"skipped"`
+	Failed     int        `gorm:"column:failed" json:"failed"`
+	Cursor     int        `gorm:"column:cursor_upload_id" json:"cursor"`
+	LastError  string     `gorm:"column:last_error;type:text" json:"lastError"`
+	CreatedAt  time.Time  `gorm:"column:created_at;type:datetime" json:"createdAt"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;type:datetime" json:"updatedAt"`
+	FinishedAt *time.Time `gorm:"column:finished_at;type:datetime" json:"finishedAt"`
+}
+
+func (ReprocessJob) TableName() string {
+	return TableNameReprocessJob
+}
+
+// Processed is the number of documents handled so far.
+func (_this ReprocessJob) Processed() int {
+	return _this.Succeeded + _this.Skipped + _this.Failed
+}
@@ -6,12 +6,45 @@ import (
 
 const TableNameResume = "resumes"
 
+// Sources of a resume.
+const (
+	ResumeSourceUpload   = "upload"
+	ResumeSourceLinkedIn = "linkedin"
+	// ResumeSourceFolder and ResumeSourceMail are resumes picked up by an ingestion source: a file
+	// dropped in the watched folder, or the attachment of an email.
+	ResumeSourceFolder = "folder"
+	ResumeSourceMail   = "mail"
+)
+
+// Resume is the system of record of an indexed document: the text it was parsed from, the stored
+// file, the parsed content with the versions of the parser and models that produced it, and the
+// fingerprint used to recognise later uploads of the same resume or person. The Elasticsearch
+// document is a projection of Content and can be rebuilt from it. Emails and Phones are
+// comma-separated.
+//
+// A resume merged into another document keeps its fingerprint under the document it was merged
+// into, with MergedFrom set to its former document; it no longer describes that document.
 type Resume struct {
-	ResumeId     int       `gorm:"column:resume_id;PRIMARY_KEY;AUTO_INCREMENT" json:"resumeId"`
-	FullText     string    `gorm:"column:full_text;type:text" json:"fullText"`
-	DownloadLink string    `gorm:"column:download_link" json:"downloadLink"`
-	CreatedAt    time.Time `gorm:"column:created_at" json:"createdAt"`
-	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	ResumeId       int       `gorm:"column:resume_id;PRIMARY_KEY;AUTO_INCREMENT" json:"resumeId"`
+	DocumentID     string    `gorm:"column:document_id;type:varchar(100)" json:"documentId"`
+	FullText       string    `gorm:"column:full_text;type:text" json:"fullText"`
+	DownloadLink   string    `gorm:"column:download_link" json:"downloadLink"`
+	FileKey        string    `gorm:"column:file_key;type:varchar(255)" json:"fileKey"`
+	Source         string    `gorm:"column:source;type:varchar(20)" json:"source"`
+	Content        string    `gorm:"column:content;type:longtext" json:"content"`
+	PromptVersion  string    `gorm:"column:prompt_version;type:varchar(50)" json:"promptVersion"`
+	ParseModel     string    `gorm:"column:parse_model;type:varchar(100)" json:"parseModel"`
+	EmbeddingModel string    `gorm:"column:embedding_model;type:varchar(255)" json:"embeddingModel"`
+	FileHash       string    `gorm:"column:file_hash;type:char(64)" json:"fileHash"`
+	TextHash       string    `gorm:"column:text_hash;type:char(64)" json:"textHash"`
+	NameKey        string    `gorm:"column:name_key;type:varchar(255)" json:"nameKey"`
+	Emails         string    `gorm:"column:emails;type:varchar(1024)" json:"emails"`
+	Phones         string    `gorm:"column:phones;type:varchar(512)" json:"phones"`
+	MergedFrom     string    `gorm:"column:merged_from;type:varchar(100)" json:"mergedFrom"`
+	CreatedAt      time.Time `gorm:"column:created_at" json:"createdAt"`
+	UpdatedAt      time.Time `gorm:"column:updated_at" json:"updatedAt"`
+	// CrawledAt is when the profile of a LinkedIn resume was last crawled again.
+	CrawledAt *time.Time `gorm:"column:crawled_at" json:"crawledAt"`
 }
 
 func (Resume) TableName() string {
@@ -6,15 +6,31 @@ import (
 
 const TableNameUpload = "upload"
 
-// Upload represents the schema of the "upload_history" table.
+// Upload represents the schema of the "upload_history" table. BatchID is the batch the upload was
+// submitted in, and Error why it failed. Content, FileKey, URL and OnDuplicate keep what was
+// submitted, so that a failed upload can be retried without the original request. Source is the
+// ingestion source the file was picked up by, if any, and Origin where it came from: the path of
+// the file or the email it was attached to. FileHash is the hash of the file submitted, and
+// DuplicateOf the upload of the same file, submitted shortly before, that the upload is linked to
+// instead of being ingested.
 type Upload struct {
-	ID         int       `gorm:"column:id;primary_key;auto_increment" json:"id"`
-	DocumentID string    `gorm:"column:document_id;type:varchar(255)" json:"documentId"`
-	Status     string    `gorm:"column:status;type:varchar(100)" json:"status"`
-	Name       string    `gorm:"column:name;type:varchar(255)" json:"name"`
-	UUID       string    `gorm:"column:uuid;type:varchar(255)" json:"uuid"`
-	CreatedAt  time.Time `gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
-	UpdatedAt  time.Time `gorm:"column:updated_at;type:datetime;default:CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP" json:"updatedAt"`
+	ID          int       `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	DocumentID  string    `gorm:"column:document_id;type:varchar(255)" json:"documentId"`
+	Status      string    `gorm:"column:status;type:varchar(100)" json:"status"`
+	Name        string    `gorm:"column:name;type:varchar(255)" json:"name"`
+	UUID        string    `gorm:"column:uuid;type:varchar(255)" json:"uuid"`
+	BatchID     *int64    `gorm:"column:batch_id" json:"batchId"`
+	Error       string    `gorm:"column:error;type:text" json:"error"`
+	Content     string    `gorm:"column:content;type:longtext" json:"-"`
+	FileKey     string    `gorm:"column:file_key;type:varchar(255)" json:"fileKey"`
+	URL         string    `gorm:"column:url;type:varchar(1024)" json:"url"`
+	OnDuplicate string    `gorm:"column:on_duplicate;type:varchar(20)" json:"onDuplicate"`
+	Source      string    `gorm:"column:source;type:varchar(20)" json:"source"`
+	Origin      string    `gorm:"column:origin;type:varchar(1024)" json:"origin"`
+	FileHash    string    `gorm:"column:file_hash;type:char(64)" json:"fileHash"`
+	DuplicateOf *int      `gorm:"column:duplicate_of" json:"duplicateOf"`
+	CreatedAt   time.Time `gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;type:datetime;default:CURRENT_TIMESTAMP on update CURRENT_TIMESTAMP" json:"updatedAt"`
 }
 
 // TableName overrides the table name used by Upload to `upload_history`
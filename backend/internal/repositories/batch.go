@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"time"
+)
+
+type IBatchRepository interface {
+	Create(db *db.DB, batch *models.Batch) error
+	FindByID(db *db.DB, id int64) (*models.Batch, error)
+	// FindByIdempotencyKey returns the batch a user submitted with an idempotency key.
+	FindByIdempotencyKey(db *db.DB, userID, key string) (*models.Batch, error)
+	// Finish marks the batch as finished now, unless it already is.
+	Finish(db *db.DB, batch *models.Batch) error
+	// Reopen marks a finished batch as running again, for an upload of it that is retried.
+	Reopen(db *db.DB, batch *models.Batch) error
+}
+
+type batchRepository struct{}
+
+func NewBatchRepository() IBatchRepository {
+	return &batchRepository{}
+}
+
+func (_this *batchRepository) Create(db *db.DB, batch *models.Batch) error {
+	batch.CreatedAt = time.Now()
+	return db.DB().Table(models.TableNameBatch).Create(batch).Error
+}
+
+func (_this *batchRepository) FindByID(db *db.DB, id int64) (*models.Batch, error) {
+	var batch models.Batch
+	if err := db.DB().Table(models.TableNameBatch).Where("id = ?", id).First(&batch).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (_this *batchRepository) FindByIdempotencyKey(db *db.DB, userID, key string) (*models.Batch, error) {
+	var batch models.Batch
+	err := db.DB().Table(models.TableNameBatch).Where("user_id = ? AND idempotency_key = ?", userID, key).First(&batch).Error
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func (_this *batchRepository) Finish(db *db.DB, batch *models.Batch) error {
+	now := time.Now()
+	batch.FinishedAt = &now
+	return db.DB().Table(models.TableNameBatch).Where("id = ? AND finished_at IS NULL", batch.ID).
+		Update("finished_at", now).Error
+}
+
+func (_this *batchRepository) Reopen(db *db.DB, batch *models.Batch) error {
+	batch.FinishedAt = nil
+	return db.DB().Table(models.TableNameBatch).Where("id = ?", batch.ID).Update("finished_at", nil).Error
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"time"
+)
+
+type ICandidateRepository interface {
+	Create(db *db.DB, candidate *models.Candidate) error
+	FindByID(db *db.DB, id int64) (*models.Candidate, error)
+	SetLatest(db *db.DB, id int64, documentID string) error
+	Delete(db *db.DB, id int64) error
+
+	// AddVersion stores version as the next version of its candidate and fills its number.
+	AddVersion(db *db.DB, version *models.ResumeVersion) error
+	FindVersion(db *db.DB, candidateID int64, version int) (*models.ResumeVersion, error)
+	FindVersionByDocumentID(db *db.DB, documentID string) (*models.ResumeVersion, error)
+	// GetVersions returns the versions of a candidate, oldest first.
+	GetVersions(db *db.DB, candidateID int64) ([]models.ResumeVersion, error)
+	DeleteVersion(db *db.DB, id int64) error
+}
+
+type candidateRepository struct{}
+
+func NewCandidateRepository() ICandidateRepository {
+	return &candidateRepository{}
+}
+
+func (_this *candidateRepository) Create(db *db.DB, candidate *models.Candidate) error {
+	now := time.Now()
+	candidate.CreatedAt = now
+	candidate.UpdatedAt = now
+	return db.DB().Table(models.TableNameCandidate).Create(candidate).Error
+}
+
+func (_this *candidateRepository) FindByID(db *db.DB, id int64) (*models.Candidate, error) {
+	var candidate models.Candidate
+	if err := db.DB().Table(models.TableNameCandidate).Where("id = ?", id).First(&candidate).Error; err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+func (_this *candidateRepository) SetLatest(db *db.DB, id int64, documentID string) error {
+	return db.DB().Table(models.TableNameCandidate).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"latest_document_id": documentID, "updated_at": time.Now()}).Error
+}
+
+func (_this *candidateRepository) Delete(db *db.DB, id int64) error {
+	return db.DB().Table(models.TableNameCandidate).Where("id = ?", id).Delete(&models.Candidate{}).Error
+}
+
+func (_this *candidateRepository) AddVersion(db *db.DB, version *models.ResumeVersion) error {
+	version.CreatedAt = time.Now()
+	// The number is computed by the insert itself so that concurrent uploads of a candidate do not
+	// pick the same one; the unique key rejects the rare collision left.
+	err := db.DB().Exec("INSERT INTO "+models.TableNameResumeVersion+
+		" (candidate_id, version, document_id, upload_id, file_url, created_at)"+
+		" SELECT ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ? FROM "+models.TableNameResumeVersion+" WHERE candidate_id = ?",
+		version.CandidateID, version.DocumentID, version.UploadID, version.FileURL, version.CreatedAt, version.CandidateID).Error
+	if err != nil {
+		return err
+	}
+	stored, err := _this.FindVersionByDocumentID(db, version.DocumentID)
+	if err != nil {
+		return err
+	}
+	*version = *stored
+	return nil
+}
+
+func (_this *candidateRepository) FindVersion(db *db.DB, candidateID int64, version int) (*models.ResumeVersion, error) {
+	var resumeVersion models.ResumeVersion
+	err := db.DB().Table(models.TableNameResumeVersion).
+		Where("candidate_id = ? AND version = ?", candidateID, version).First(&resumeVersion).Error
+	if err != nil {
+		return nil, err
+	}
+	return &resumeVersion, nil
+}
+
+func (_this *candidateRepository) FindVersionByDocumentID(db *db.DB, documentID string) (*models.ResumeVersion, error) {
+	var resumeVersion models.ResumeVersion
+	if err := db.DB().Table(models.TableNameResumeVersion).Where("document_id = ?", documentID).First(&resumeVersion).Error; err != nil {
+		return nil, err
+	}
+	return &resumeVersion, nil
+}
+
+func (_this *candidateRepository) GetVersions(db *db.DB, candidateID int64) ([]models.ResumeVersion, error) {
+	var versions []models.ResumeVersion
+	if err := db.DB().Table(models.TableNameResumeVersion).Where("candidate_id = ?", candidateID).Order("version").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (_this *candidateRepository) DeleteVersion(db *db.DB, id int64) error {
+	return db.DB().Table(models.TableNameResumeVersion).Where("id = ?", id).Delete(&models.ResumeVersion{}).Error
+}
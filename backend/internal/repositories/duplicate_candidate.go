@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+type IDuplicateCandidateRepository interface {
+	Create(db *db.DB, candidate *models.DuplicateCandidate) error
+	FindByID(db *db.DB, id int64) (*models.DuplicateCandidate, error)
+	// GetByStatus returns the candidates with the given status, or all of them when status is
+	// empty, newest first.
+	GetByStatus(db *db.DB, status string) ([]models.DuplicateCandidate, error)
+	// Resolve moves a pending candidate to status. It returns false when the candidate is not pending.
+	Resolve(db *db.DB, id int64, status string) (bool, error)
+	// RepointDocument replaces document from by document to after a merge. Pending candidates
+	// between the two documents are resolved as merged.
+	RepointDocument(db *db.DB, from, to string) error
+}
+
+type duplicateCandidateRepository struct{}
+
+func NewDuplicateCandidateRepository() IDuplicateCandidateRepository {
+	return &duplicateCandidateRepository{}
+}
+
+func (_this *duplicateCandidateRepository) Create(db *db.DB, candidate *models.DuplicateCandidate) error {
+	candidate.CreatedAt = time.Now()
+	if candidate.Status != models.DuplicateStatusPending {
+		candidate.ResolvedAt = &candidate.CreatedAt
+	}
+	return db.DB().Table(models.TableNameDuplicateCandidate).Create(candidate).Error
+}
+
+func (_this *duplicateCandidateRepository) FindByID(db *db.DB, id int64) (*models.DuplicateCandidate, error) {
+	var candidate models.DuplicateCandidate
+	if err := db.DB().Table(models.TableNameDuplicateCandidate).Where("id = ?", id).First(&candidate).Error; err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+func (_this *duplicateCandidateRepository) GetByStatus(db *db.DB, status string) ([]models.DuplicateCandidate, error) {
+	query := db.DB().Table(models.TableNameDuplicateCandidate)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var candidates []models.DuplicateCandidate
+	if err := query.Order("id DESC").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+func (_this *duplicateCandidateRepository) Resolve(db *db.DB, id int64, status string) (bool, error) {
+	result := db.DB().Table(models.TableNameDuplicateCandidate).
+		Where("id = ? AND status = ?", id, models.DuplicateStatusPending).
+		Updates(map[string]interface{}{"status": status, "resolved_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (_this *duplicateCandidateRepository) RepointDocument(db *db.DB, from, to string) error {
+	return db.GormTransaction(func(tx *gorm.DB) error {
+		err := tx.Table(models.TableNameDuplicateCandidate).
+			Where("status = ? AND ((document_id = ? AND duplicate_of = ?) OR (document_id = ? AND duplicate_of = ?))",
+				models.DuplicateStatusPending, from, to, to, from).
+			Updates(map[string]interface{}{"status": models.DuplicateStatusMerged, "resolved_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Table(models.TableNameDuplicateCandidate).Where("document_id = ?", from).Update("document_id", to).Error; err != nil {
+			return err
+		}
+		return tx.Table(models.TableNameDuplicateCandidate).Where("duplicate_of = ?", from).Update("duplicate_of", to).Error
+	})
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"time"
+)
+
+type IImportRepository interface {
+	Create(db *db.DB, imp *models.Import) error
+	FindByID(db *db.DB, id int64) (*models.Import, error)
+}
+
+type importRepository struct{}
+
+func NewImportRepository() IImportRepository {
+	return &importRepository{}
+}
+
+func (_this *importRepository) Create(db *db.DB, imp *models.Import) error {
+	imp.CreatedAt = time.Now()
+	return db.DB().Table(models.TableNameImport).Create(imp).Error
+}
+
+func (_this *importRepository) FindByID(db *db.DB, id int64) (*models.Import, error) {
+	var imp models.Import
+	if err := db.DB().Table(models.TableNameImport).Where("id = ?", id).First(&imp).Error; err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
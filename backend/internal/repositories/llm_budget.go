@@ -0,0 +1,50 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"time"
+)
+
+type ILlmBudgetRepository interface {
+	FindByTenant(db *db.DB, tenantID string) (*models.LlmBudget, error)
+	GetAll(db *db.DB) ([]models.LlmBudget, error)
+	Upsert(db *db.DB, budget *models.LlmBudget) error
+	Delete(db *db.DB, tenantID string) error
+}
+
+type llmBudgetRepository struct{}
+
+func NewLlmBudgetRepository() ILlmBudgetRepository {
+	return &llmBudgetRepository{}
+}
+
+func (_this *llmBudgetRepository) FindByTenant(db *db.DB, tenantID string) (*models.LlmBudget, error) {
+	var budget models.LlmBudget
+	if err := db.DB().Table(models.TableNameLlmBudget).Where("tenant_id = ?", tenantID).First(&budget).Error; err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (_this *llmBudgetRepository) GetAll(db *db.DB) ([]models.LlmBudget, error) {
+	var budgets []models.LlmBudget
+	if err := db.DB().Table(models.TableNameLlmBudget).Order("tenant_id").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (_this *llmBudgetRepository) Upsert(db *db.DB, budget *models.LlmBudget) error {
+	now := time.Now()
+	budget.UpdatedAt = now
+	return db.DB().Exec(
+		"INSERT INTO "+models.TableNameLlmBudget+" (tenant_id, monthly_limit, policy, created_at, updated_at) VALUES (?, ?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE monthly_limit = VALUES(monthly_limit), policy = VALUES(policy), updated_at = VALUES(updated_at)",
+		budget.TenantID, budget.MonthlyLimit, budget.Policy, now, now,
+	).Error
+}
+
+func (_this *llmBudgetRepository) Delete(db *db.DB, tenantID string) error {
+	return db.DB().Table(models.TableNameLlmBudget).Where("tenant_id = ?", tenantID).Delete(&models.LlmBudget{}).Error
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"fmt"
+	"time"
+)
+
+// UsageGroupColumns maps the supported report groupings to SQL expressions.
+var UsageGroupColumns = map[string]string{
+	"operation": "operation",
+	"model":     "model",
+	"provider":  "provider",
+	"user":      "user_id",
+	"tenant":    "tenant_id",
+	"day":       "DATE_FORMAT(created_at, '%Y-%m-%d')",
+}
+
+type ILlmUsageRepository interface {
+	Create(db *db.DB, usage *models.LlmUsage) error
+	Summarize(db *db.DB, from, to time.Time, tenantID *string, groupBy string) ([]models.LlmUsageSummary, error)
+	SumCost(db *db.DB, tenantID string, from time.Time) (float64, error)
+}
+
+type llmUsageRepository struct{}
+
+func NewLlmUsageRepository() ILlmUsageRepository {
+	return &llmUsageRepository{}
+}
+
+func (_this *llmUsageRepository) Create(db *db.DB, usage *models.LlmUsage) error {
+	return db.DB().Table(models.TableNameLlmUsage).Create(usage).Error
+}
+
+// Summarize aggregates usage in [from, to), optionally for a single tenant. An empty groupBy returns a single total row.
+func (_this *llmUsageRepository) Summarize(db *db.DB, from, to time.Time, tenantID *string, groupBy string) ([]models.LlmUsageSummary, error) {
+	groupExpr := "''"
+	if groupBy != "" {
+		column, ok := UsageGroupColumns[groupBy]
+		if !ok {
+			return nil, fmt.Errorf("unsupported usage grouping %q", groupBy)
+		}
+		groupExpr = column
+	}
+
+	query := db.DB().Table(models.TableNameLlmUsage).
+		Select(fmt.Sprintf("%s AS group_key, COUNT(*) AS calls, COALESCE(SUM(prompt_tokens), 0) AS prompt_tokens, "+
+			"COALESCE(SUM(completion_tokens), 0) AS completion_tokens, COALESCE(SUM(total_tokens), 0) AS total_tokens, "+
+			"COALESCE(SUM(cost), 0) AS cost", groupExpr)).
+		Where("created_at >= ? AND created_at < ?", from, to)
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	if groupBy != "" {
+		query = query.Group(groupExpr).Order("cost DESC")
+	}
+
+	var summaries []models.LlmUsageSummary
+	if err := query.Scan(&summaries).Error; err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// SumCost returns the cost recorded for tenantID since from.
+func (_this *llmUsageRepository) SumCost(db *db.DB, tenantID string, from time.Time) (float64, error) {
+	var result struct {
+		Cost float64 `gorm:"column:cost"`
+	}
+	err := db.DB().Table(models.TableNameLlmUsage).
+		Select("COALESCE(SUM(cost), 0) AS cost").
+		Where("tenant_id = ? AND created_at >= ?", tenantID, from).
+		Scan(&result).Error
+	return result.Cost, err
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"time"
+)
+
+type IPromptTemplateRepository interface {
+	Create(db *db.DB, template *models.PromptTemplate) error
+	Find(db *db.DB, name, version string) (*models.PromptTemplate, error)
+	GetAll(db *db.DB) ([]models.PromptTemplate, error)
+	FindRollout(db *db.DB, name string) (*models.PromptRollout, error)
+	GetAllRollouts(db *db.DB) ([]models.PromptRollout, error)
+	UpsertRollout(db *db.DB, rollout *models.PromptRollout) error
+}
+
+type promptTemplateRepository struct{}
+
+func NewPromptTemplateRepository() IPromptTemplateRepository {
+	return &promptTemplateRepository{}
+}
+
+func (_this *promptTemplateRepository) Create(db *db.DB, template *models.PromptTemplate) error {
+	template.CreatedAt = time.Now()
+	return db.DB().Table(models.TableNamePromptTemplate).Create(template).Error
+}
+
+func (_this *promptTemplateRepository) Find(db *db.DB, name, version string) (*models.PromptTemplate, error) {
+	var template models.PromptTemplate
+	if err := db.DB().Table(models.TableNamePromptTemplate).Where("name = ? AND version = ?", name, version).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (_this *promptTemplateRepository) GetAll(db *db.DB) ([]models.PromptTemplate, error) {
+	var templates []models.PromptTemplate
+	if err := db.DB().Table(models.TableNamePromptTemplate).Order("name, created_at").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+func (_this *promptTemplateRepository) FindRollout(db *db.DB, name string) (*models.PromptRollout, error) {
+	var rollout models.PromptRollout
+	if err := db.DB().Table(models.TableNamePromptRollout).Where("name = ?", name).First(&rollout).Error; err != nil {
+		return nil, err
+	}
+	return &rollout, nil
+}
+
+func (_this *promptTemplateRepository) GetAllRollouts(db *db.DB) ([]models.PromptRollout, error) {
+	var rollouts []models.PromptRollout
+	if err := db.DB().Table(models.TableNamePromptRollout).Order("name").Find(&rollouts).Error; err != nil {
+		return nil, err
+	}
+	return rollouts, nil
+}
+
+func (_this *promptTemplateRepository) UpsertRollout(db *db.DB, rollout *models.PromptRollout) error {
+	rollout.UpdatedAt = time.Now()
+	return db.DB().Exec(
+		"INSERT INTO "+models.TableNamePromptRollout+" (name, version, updated_at) VALUES (?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE version = VALUES(version), updated_at = VALUES(updated_at)",
+		rollout.Name, rollout.Version, rollout.UpdatedAt,
+	).Error
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"time"
+)
+
+type IReconcileRunRepository interface {
+	Create(db *db.DB, run *models.ReconcileRun) error
+	FindByID(db *db.DB, id int64) (*models.ReconcileRun, error)
+	// GetRecent returns the latest runs, newest first, without their issues.
+	GetRecent(db *db.DB, limit int) ([]models.ReconcileRun, error)
+	// Save stores the results of a run and moves it to status.
+	Save(db *db.DB, run *models.ReconcileRun, status string) error
+	// InterruptRunning marks the running runs as interrupted, and returns how many were marked.
+	InterruptRunning(db *db.DB) (int64, error)
+}
+
+type reconcileRunRepository struct{}
+
+func NewReconcileRunRepository() IReconcileRunRepository {
+	return &reconcileRunRepository{}
+}
+
+func (_this *reconcileRunRepository) Create(db *db.DB, run *models.ReconcileRun) error {
+	now := time.Now()
+	run.CreatedAt = now
+	run.UpdatedAt = now
+	return db.DB().Table(models.TableNameReconcileRun).Create(run).Error
+}
+
+func (_this *reconcileRunRepository) FindByID(db *db.DB, id int64) (*models.ReconcileRun, error) {
+	var run models.ReconcileRun
+	if err := db.DB().Table(models.TableNameReconcileRun).Where("id = ?", id).First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (_this *reconcileRunRepository) GetRecent(db *db.DB, limit int) ([]models.ReconcileRun, error) {
+	var runs []models.ReconcileRun
+	err := db.DB().Table(models.TableNameReconcileRun).
+		Select("id, trigger_type, policy, dry_run, status, uploads, records, documents, objects, found, fixed, failed, counts, last_error, created_at, updated_at, finished_at").
+		Order("id DESC").Limit(limit).Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (_this *reconcileRunRepository) Save(db *db.DB, run *models.ReconcileRun, status string) error {
+	run.Status = status
+	run.UpdatedAt = time.Now()
+	if status != models.ReconcileStatusRunning {
+		run.FinishedAt = &run.UpdatedAt
+	}
+	return db.DB().Table(models.TableNameReconcileRun).Where("id = ?", run.ID).
+		Updates(map[string]interface{}{
+			"status":      run.Status,
+			"uploads":     run.Uploads,
+			"records":     run.Records,
+			"documents":   run.Documents,
+			"objects":     run.Objects,
+			"found":       run.Found,
+			"fixed":       run.Fixed,
+			"failed":      run.Failed,
+			"counts":      run.Counts,
+			"issues":      run.Issues,
+			"last_error":  run.LastError,
+			"updated_at":  run.UpdatedAt,
+			"finished_at": run.FinishedAt,
+		}).Error
+}
+
+func (_this *reconcileRunRepository) InterruptRunning(db *db.DB) (int64, error) {
+	result := db.DB().Table(models.TableNameReconcileRun).
+		Where("status = ?", models.ReconcileStatusRunning).
+		Updates(map[string]interface{}{"status": models.ReconcileStatusInterrupted, "updated_at": time.Now(), "finished_at": time.Now()})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"CVSeeker/internal/models"
+	"CVSeeker/pkg/db"
+	"time"
+)
+
+type IReprocessJobRepository interface {
+	Create(db *db.DB, job *models.ReprocessJob) error
+	FindByID(db *db.DB, id int64) (*models.ReprocessJob, error)
+	GetAll(db *db.DB) ([]models.ReprocessJob, error)
+	// FindResumable returns the interrupted jobs and the running jobs whose runner stopped
+	// reporting progress before staleBefore.
+	FindResumable(db *db.DB, staleBefore time.Time) ([]models.ReprocessJob, error)
+	// Claim marks a job as running for the caller. It returns false when the job is completed or
+	// already being run.
+	Claim(db *db.DB, id int64, staleBefore time.Time) (bool, error)
+	// SaveProgress stores the counters and cursor of a running job and moves it to status. It
+	// returns false when the job is no longer running, e.g. because it was paused.
+	SaveProgress(db *db.DB, job *models.ReprocessJob, status string) (bool, error)
+	Touch(db *db.DB, id int64) error
+	Pause(db *db.DB, id int64) (bool, error)
+}
+
+type reprocessJobRepository struct{}
+
+func NewReprocessJobRepository() IReprocessJobRepository {
+	return &reprocessJobRepository{}
+}
+
+func (_this *reprocessJobRepository) Create(db *db.DB, job *models.ReprocessJob) error {
+	now := time.Now()
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	return db.DB().Table(models.TableNameReprocessJob).Create(job).Error
+}
+
+func (_this *reprocessJobRepository) FindByID(db *db.DB, id int64) (*models.ReprocessJob, error) {
+	var job models.ReprocessJob
+	if err := db.DB().Table(models.TableNameReprocessJob).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (_this *reprocessJobRepository) GetAll(db *db.DB) ([]models.ReprocessJob, error) {
+	var jobs []models.ReprocessJob
+	if err := db.DB().Table(models.TableNameReprocessJob).Order("id DESC").Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (_this *reprocessJobRepository) FindResumable(db *db.DB, staleBefore time.Time) ([]models.ReprocessJob, error) {
+	var jobs []models.ReprocessJob
+	err := db.DB().Table(models.TableNameReprocessJob).
+		Where("status = ? OR (status = ? AND updated_at < ?)", models.ReprocessStatusInterrupted, models.ReprocessStatusRunning, staleBefore).
+		Order("id").Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (_this *reprocessJobRepository) Claim(db *db.DB, id int64, staleBefore time.Time) (bool, error) {
+	claimable := []string{models.ReprocessStatusPending, models.ReprocessStatusPaused, models.ReprocessStatusInterrupted, models.ReprocessStatusFailed}
+	result := db.DB().Table(models.TableNameReprocessJob).
+		Where("id = ? AND (status IN (?) OR (status = ? AND updated_at < ?))", id, claimable, models.ReprocessStatusRunning, staleBefore).
+		Updates(map[string]interface{}{"status": models.ReprocessStatusRunning, "updated_at": time.Now(), "finished_at": nil})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (_this *reprocessJobRepository) SaveProgress(db *db.DB, job *models.ReprocessJob, status string) (bool, error) {
+	job.Status = status
+	job.UpdatedAt = time.Now()
+	if status != models.ReprocessStatusRunning {
+		job.FinishedAt = &job.UpdatedAt
+	}
+	result := db.DB().Table(models.TableNameReprocessJob).
+		Where("id = ? AND status = ?", job.ID, models.ReprocessStatusRunning).
+		Updates(map[string]interface{}{
+			"status":           job.Status,
+			"total":            job.Total,
+			"succeeded":        job.Succeeded,
+			"skipped":          job.Skipped,
+			"failed":           job.Failed,
+			"cursor_upload_id": job.Cursor,
+			"last_error":       job.LastError,
+			"updated_at":       job.UpdatedAt,
+			"finished_at":      job.FinishedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (_this *reprocessJobRepository) Touch(db *db.DB, id int64) error {
+	return db.DB().Table(models.TableNameReprocessJob).
+		Where("id = ? AND status = ?", id, models.ReprocessStatusRunning).
+		Update("updated_at", time.Now()).Error
+}
+
+func (_this *reprocessJobRepository) Pause(db *db.DB, id int64) (bool, error) {
+	result := db.DB().Table(models.TableNameReprocessJob).
+		Where("id = ? AND status IN (?)", id, []string{models.ReprocessStatusPending, models.ReprocessStatusRunning, models.ReprocessStatusInterrupted}).
+		Updates(map[string]interface{}{"status": models.ReprocessStatusPaused, "updated_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"CVSeeker/internal/models"
 	"CVSeeker/pkg/db"
+	"github.com/jinzhu/gorm"
 	"time"
 )
 
@@ -10,6 +11,28 @@ type IResumeRepository interface {
 	Create(db *db.DB, resume *models.Resume) (*models.Resume, error)
 	Update(db *db.DB, resume *models.Resume) error
 	FindByID(db *db.DB, resumeID int) (*models.Resume, error)
+	// FindByDocumentID returns the record of a document, leaving out resumes merged into it.
+	FindByDocumentID(db *db.DB, documentID string) (*models.Resume, error)
+	// FindByDocumentIDs returns the records of the given documents.
+	FindByDocumentIDs(db *db.DB, documentIDs []string) ([]models.Resume, error)
+	// FindByHash returns the latest indexed resume with the given file or text hash. Empty hashes
+	// are ignored.
+	FindByHash(db *db.DB, fileHash, textHash string) (*models.Resume, error)
+	// FindByNameKey returns the indexed resumes with the given normalized name.
+	FindByNameKey(db *db.DB, nameKey string) ([]models.Resume, error)
+	// RepointDocument moves the resumes of document from to document to after a merge. They keep
+	// their fingerprint but are marked as merged, so that they do not replace the record of to.
+	RepointDocument(db *db.DB, from, to string) error
+	// DeleteByDocumentID deletes every resume of a document.
+	DeleteByDocumentID(db *db.DB, documentID string) error
+	// FindAfter returns the resumes with an ID above afterID in ID order, merged ones included,
+	// without their text and parsed content.
+	FindAfter(db *db.DB, afterID, limit int) ([]models.Resume, error)
+	// FindStaleLinkedIn returns up to limit indexed LinkedIn resumes not crawled since before, the
+	// least recently crawled first, without their text and parsed content.
+	FindStaleLinkedIn(db *db.DB, before time.Time, limit int) ([]models.Resume, error)
+	// MarkCrawled records that the profiles of resumes were crawled at crawledAt.
+	MarkCrawled(db *db.DB, resumeIDs []int, crawledAt time.Time) error
 }
 
 type resumeRepository struct{}
@@ -37,3 +60,105 @@ func (_this *resumeRepository) FindByID(db *db.DB, resumeID int) (*models.Resume
 	}
 	return &resume, nil
 }
+
+func (_this *resumeRepository) FindByDocumentID(db *db.DB, documentID string) (*models.Resume, error) {
+	var resume models.Resume
+	if err := db.DB().Table(models.TableNameResume).Where("document_id = ? AND merged_from = ''", documentID).Order("resume_id DESC").First(&resume).Error; err != nil {
+		return nil, err
+	}
+	return &resume, nil
+}
+
+func (_this *resumeRepository) FindByDocumentIDs(db *db.DB, documentIDs []string) ([]models.Resume, error) {
+	var resumes []models.Resume
+	if len(documentIDs) == 0 {
+		return resumes, nil
+	}
+	err := db.DB().Table(models.TableNameResume).
+		Where("document_id IN (?) AND merged_from = ''", documentIDs).
+		Order("resume_id").Find(&resumes).Error
+	if err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+func (_this *resumeRepository) FindByHash(db *db.DB, fileHash, textHash string) (*models.Resume, error) {
+	query := db.DB().Table(models.TableNameResume).Where("document_id <> ''")
+	switch {
+	case fileHash != "" && textHash != "":
+		query = query.Where("file_hash = ? OR text_hash = ?", fileHash, textHash)
+	case fileHash != "":
+		query = query.Where("file_hash = ?", fileHash)
+	case textHash != "":
+		query = query.Where("text_hash = ?", textHash)
+	default:
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var resume models.Resume
+	if err := query.Order("resume_id DESC").First(&resume).Error; err != nil {
+		return nil, err
+	}
+	return &resume, nil
+}
+
+func (_this *resumeRepository) FindByNameKey(db *db.DB, nameKey string) ([]models.Resume, error) {
+	var resumes []models.Resume
+	err := db.DB().Table(models.TableNameResume).
+		Where("name_key = ? AND document_id <> ''", nameKey).
+		Order("resume_id DESC").Find(&resumes).Error
+	if err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+func (_this *resumeRepository) RepointDocument(db *db.DB, from, to string) error {
+	return db.GormTransaction(func(tx *gorm.DB) error {
+		err := tx.Table(models.TableNameResume).
+			Where("document_id = ? AND merged_from = ''", from).
+			Updates(map[string]interface{}{"merged_from": from, "updated_at": time.Now()}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Table(models.TableNameResume).
+			Where("document_id = ?", from).
+			Updates(map[string]interface{}{"document_id": to, "updated_at": time.Now()}).Error
+	})
+}
+
+func (_this *resumeRepository) DeleteByDocumentID(db *db.DB, documentID string) error {
+	return db.DB().Table(models.TableNameResume).Where("document_id = ?", documentID).Delete(&models.Resume{}).Error
+}
+
+func (_this *resumeRepository) FindAfter(db *db.DB, afterID, limit int) ([]models.Resume, error) {
+	var resumes []models.Resume
+	err := db.DB().Table(models.TableNameResume).
+		Select("resume_id, document_id, download_link, file_key, source, merged_from, created_at, updated_at").
+		Where("resume_id > ?", afterID).Order("resume_id").Limit(limit).Find(&resumes).Error
+	if err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+func (_this *resumeRepository) FindStaleLinkedIn(db *db.DB, before time.Time, limit int) ([]models.Resume, error) {
+	var resumes []models.Resume
+	err := db.DB().Table(models.TableNameResume).
+		Select("resume_id, document_id, download_link, source, text_hash, created_at, updated_at, crawled_at").
+		Where("source = ? AND document_id <> '' AND merged_from = ''", models.ResumeSourceLinkedIn).
+		Where("COALESCE(crawled_at, updated_at) < ?", before).
+		Order("COALESCE(crawled_at, updated_at)").Limit(limit).Find(&resumes).Error
+	if err != nil {
+		return nil, err
+	}
+	return resumes, nil
+}
+
+func (_this *resumeRepository) MarkCrawled(db *db.DB, resumeIDs []int, crawledAt time.Time) error {
+	if len(resumeIDs) == 0 {
+		return nil
+	}
+	return db.DB().Table(models.TableNameResume).Where("resume_id IN (?)", resumeIDs).UpdateColumn("crawled_at", crawledAt).Error
+}
@@ -10,6 +10,9 @@ type IThreadResumeRepository interface {
 	Create(db *db.DB, threadResume *models.ThreadResume) error
 	CreateBulkThreadResume(db *db.DB, threadResumes []models.ThreadResume) error
 	GetResumeIDsByThreadID(db *db.DB, threadID string) ([]string, error)
+	// RepointResume moves the threads of resume from to resume to. Threads that already contain
+	// both keep a single row.
+	RepointResume(db *db.DB, from, to string) error
 }
 
 type threadResumeRepository struct{}
@@ -23,7 +26,7 @@ func (_this *threadResumeRepository) Create(db *db.DB, threadResume *models.Thre
 }
 
 func (_this *threadResumeRepository) CreateBulkThreadResume(db *db.DB, threadResumes []models.ThreadResume) error {
-	return db.DB().Transaction(func(tx *gorm.DB) error {
+	return db.GormTransaction(func(tx *gorm.DB) error {
 		for _, threadResume := range threadResumes {
 			if err := tx.Create(&threadResume).Error; err != nil {
 				return err
@@ -40,3 +43,14 @@ func (_this *threadResumeRepository) GetResumeIDsByThreadID(db *db.DB, threadID
 	}
 	return ids, nil
 }
+
+func (_this *threadResumeRepository) RepointResume(db *db.DB, from, to string) error {
+	return db.GormTransaction(func(tx *gorm.DB) error {
+		err := tx.Exec("UPDATE IGNORE "+models.TableNameThreadResume+" SET resume_id = ? WHERE resume_id = ?", to, from).Error
+		if err != nil {
+			return err
+		}
+		// Rows left behind already had a row for the target in the same thread.
+		return tx.Table(models.TableNameThreadResume).Where("resume_id = ?", from).Delete(&models.ThreadResume{}).Error
+	})
+}
@@ -3,15 +3,66 @@ package repositories
 import (
 	"CVSeeker/internal/models"
 	"CVSeeker/pkg/db"
+	"github.com/jinzhu/gorm"
+	"time"
 )
 
 // IUploadRepository defines the interface for the upload repository.
 type IUploadRepository interface {
 	Create(db *db.DB, upload *models.Upload) (*models.Upload, error)
-	GetAll(db *db.DB) ([]models.Upload, error)
+	// FindByUser returns the uploads of the batches userID submitted, sorted from latest to oldest.
+	// The uploads submitted before batches are those of anonymous requests.
+	FindByUser(db *db.DB, userID string) ([]models.Upload, error)
+	FindByID(db *db.DB, id int) (*models.Upload, error)
 	Update(db *db.DB, upload *models.Upload) error
+	// FindIndexedDocuments returns the documents of the successful uploads matching filter, each
+	// once as its first such upload, for the documents whose first upload has an ID above afterID,
+	// in ID order. Uploads that replaced a document or were merged into it do not make it come up again.
+	FindIndexedDocuments(db *db.DB, filter IndexedUploadFilter, afterID, limit int) ([]models.Upload, error)
+	// CountIndexedDocuments returns how many documents FindIndexedDocuments walks through.
+	CountIndexedDocuments(db *db.DB, filter IndexedUploadFilter) (int, error)
+	// RepointDocument moves the uploads of document from to document to.
+	RepointDocument(db *db.DB, from, to string) error
+	// LinkDocument sets documentID on the uploads linked to the upload id as duplicates of its file.
+	LinkDocument(db *db.DB, id int, documentID string) error
+	// FailStale marks as failed with reason the uploads processing or queued since before before,
+	// and the uploads linked to them, and returns how many were marked, not counting the linked ones.
+	FailStale(db *db.DB, before time.Time, reason string) (int64, error)
+	// MarkFailed marks as failed with reason the uploads among ids that are still in one of
+	// statuses, and the uploads linked to them, and returns how many were marked, not counting the
+	// linked ones.
+	MarkFailed(db *db.DB, ids []int, statuses []string, reason string) (int64, error)
+	// MarkCancelled marks as cancelled with reason the uploads among ids that are still processing or
+	// queued, and the uploads linked to them, and returns how many were marked, not counting the
+	// linked ones.
+	MarkCancelled(db *db.DB, ids []int, reason string) (int64, error)
+	// FailLinked ends the uploads linked as duplicates to the uploads among ids that failed or were
+	// cancelled with the status of their original, so that they do not wait for a document that
+	// will not come.
+	FailLinked(db *db.DB, ids []int) error
+	// Requeue moves an upload in one of statuses back to queued and clears its error. It returns
+	// whether the upload was moved, so that two retries do not both run.
+	Requeue(db *db.DB, id int, statuses []string) (bool, error)
+	// FindByBatch returns the uploads of a batch, in ID order.
+	FindByBatch(db *db.DB, batchID int64) ([]models.Upload, error)
+	// CountByStatus returns how many uploads of a batch are in each status.
+	CountByStatus(db *db.DB, batchID int64) (map[string]int, error)
+	// FindAfter returns the uploads with an ID above afterID, in ID order.
+	FindAfter(db *db.DB, afterID, limit int) ([]models.Upload, error)
+	// FindRecentByFileHash returns the latest upload of a file created since since, leaving out the
+	// failed and cancelled uploads and those linked to another upload.
+	FindRecentByFileHash(db *db.DB, fileHash string, since time.Time) (*models.Upload, error)
 }
 
+// IndexedUploadFilter restricts the uploads that produced a document. Zero fields do not restrict.
+type IndexedUploadFilter struct {
+	DocumentIDs []string
+	From, To    *time.Time
+}
+
+// uploadColumns are the columns read for lists of uploads, which leave out the submitted text.
+const uploadColumns = "id, document_id, status, name, uuid, batch_id, error, file_key, url, on_duplicate, source, origin, file_hash, duplicate_of, created_at, updated_at"
+
 // uploadRepository implements the IUploadRepository interface.
 type uploadRepository struct{}
 
@@ -28,15 +79,177 @@ func (_this *uploadRepository) Create(db *db.DB, upload *models.Upload) (*models
 	return upload, nil
 }
 
-// GetAll retrieves all upload records from the database, sorted from latest to oldest.
-func (_this *uploadRepository) GetAll(db *db.DB) ([]models.Upload, error) {
+func (_this *uploadRepository) FindByUser(db *db.DB, userID string) ([]models.Upload, error) {
 	var uploads []models.Upload
-	if err := db.DB().Table(models.TableNameUpload).Order("created_at DESC").Find(&uploads).Error; err != nil {
+	query := db.DB().Table(models.TableNameUpload).Select(uploadColumns).
+		Where("batch_id IN (SELECT id FROM "+models.TableNameBatch+" WHERE user_id = ?)", userID)
+	if userID == "" {
+		query = query.Or("batch_id IS NULL")
+	}
+	if err := query.Order("created_at DESC").Find(&uploads).Error; err != nil {
 		return nil, err
 	}
 	return uploads, nil
 }
 
+func (_this *uploadRepository) FindByID(db *db.DB, id int) (*models.Upload, error) {
+	var upload models.Upload
+	if err := db.DB().Table(models.TableNameUpload).Where("id = ?", id).First(&upload).Error; err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
 func (_this *uploadRepository) Update(db *db.DB, upload *models.Upload) error {
 	return db.DB().Table(models.TableNameUpload).Where("id = ?", upload.ID).Updates(upload).Error
 }
+
+func (_this *uploadRepository) RepointDocument(db *db.DB, from, to string) error {
+	return db.DB().Table(models.TableNameUpload).Where("document_id = ?", from).Update("document_id", to).Error
+}
+
+func (_this *uploadRepository) LinkDocument(db *db.DB, id int, documentID string) error {
+	return db.DB().Table(models.TableNameUpload).Where("duplicate_of = ?", id).Update("document_id", documentID).Error
+}
+
+func (_this *uploadRepository) FailStale(db *db.DB, before time.Time, reason string) (int64, error) {
+	result := db.DB().Table(models.TableNameUpload).
+		Where("status IN (?) AND updated_at < ?", []string{"Processing", "Queued"}, before).
+		Updates(map[string]interface{}{"status": "Failed", "error": reason})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.RowsAffected, result.Error
+	}
+	// The uploads failed are not known by ID, so every original that failed is looked at.
+	return result.RowsAffected, failLinked(db, nil)
+}
+
+func (_this *uploadRepository) MarkFailed(db *db.DB, ids []int, statuses []string, reason string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := db.DB().Table(models.TableNameUpload).
+		Where("id IN (?) AND status IN (?)", ids, statuses).
+		Updates(map[string]interface{}{"status": "Failed", "error": reason})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.RowsAffected, result.Error
+	}
+	return result.RowsAffected, failLinked(db, ids)
+}
+
+func (_this *uploadRepository) MarkCancelled(db *db.DB, ids []int, reason string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := db.DB().Table(models.TableNameUpload).
+		Where("id IN (?) AND status IN (?)", ids, []string{"Processing", "Queued"}).
+		Updates(map[string]interface{}{"status": "Cancelled", "error": reason})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.RowsAffected, result.Error
+	}
+	return result.RowsAffected, failLinked(db, ids)
+}
+
+func (_this *uploadRepository) FailLinked(db *db.DB, ids []int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return failLinked(db, ids)
+}
+
+// failLinked ends the duplicates linked to the uploads among ids, or to any upload when ids is nil,
+// that failed or were cancelled, with the status of their original and an error naming it.
+func failLinked(db *db.DB, ids []int) error {
+	query := "UPDATE " + models.TableNameUpload + " AS linked JOIN " + models.TableNameUpload + " AS original ON linked.duplicate_of = original.id" +
+		" SET linked.status = original.status, linked.error = CONCAT('upload ', original.id, ' of the same file ended as ', original.status, ': ', COALESCE(original.error, ''))" +
+		" WHERE linked.status = 'Duplicate' AND original.status IN ('Failed', 'Cancelled')"
+	args := []interface{}{}
+	if ids != nil {
+		query += " AND original.id IN (?)"
+		args = append(args, ids)
+	}
+	return db.DB().Exec(query, args...).Error
+}
+
+func (_this *uploadRepository) Requeue(db *db.DB, id int, statuses []string) (bool, error) {
+	result := db.DB().Table(models.TableNameUpload).
+		Where("id = ? AND status IN (?)", id, statuses).
+		Updates(map[string]interface{}{"status": "Queued", "error": ""})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (_this *uploadRepository) FindByBatch(db *db.DB, batchID int64) ([]models.Upload, error) {
+	var uploads []models.Upload
+	if err := db.DB().Table(models.TableNameUpload).Select(uploadColumns).Where("batch_id = ?", batchID).Order("id").Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+func (_this *uploadRepository) CountByStatus(db *db.DB, batchID int64) (map[string]int, error) {
+	var rows []struct {
+		Status string
+		Count  int
+	}
+	err := db.DB().Table(models.TableNameUpload).Select("status, COUNT(*) AS count").
+		Where("batch_id = ?", batchID).Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (_this *uploadRepository) FindAfter(db *db.DB, afterID, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+	if err := db.DB().Table(models.TableNameUpload).Select(uploadColumns).Where("id > ?", afterID).Order("id").Limit(limit).Find(&uploads).Error; err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+func (_this *uploadRepository) FindIndexedDocuments(db *db.DB, filter IndexedUploadFilter, afterID, limit int) ([]models.Upload, error) {
+	var uploads []models.Upload
+	err := indexedUploads(db, filter).Select("MIN(id) AS id, document_id").Group("document_id").
+		Having("MIN(id) > ?", afterID).Order("MIN(id)").Limit(limit).Find(&uploads).Error
+	if err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+func (_this *uploadRepository) CountIndexedDocuments(db *db.DB, filter IndexedUploadFilter) (int, error) {
+	var row struct{ Count int }
+	if err := indexedUploads(db, filter).Select("COUNT(DISTINCT document_id) AS count").Scan(&row).Error; err != nil {
+		return 0, err
+	}
+	return row.Count, nil
+}
+
+func indexedUploads(db *db.DB, filter IndexedUploadFilter) *gorm.DB {
+	query := db.DB().Table(models.TableNameUpload).Where("status = ? AND document_id <> ''", "Success")
+	if len(filter.DocumentIDs) > 0 {
+		query = query.Where("document_id IN (?)", filter.DocumentIDs)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	return query
+}
+
+func (_this *uploadRepository) FindRecentByFileHash(db *db.DB, fileHash string, since time.Time) (*models.Upload, error) {
+	var upload models.Upload
+	err := db.DB().Table(models.TableNameUpload).Select(uploadColumns).
+		Where("file_hash = ? AND created_at >= ? AND duplicate_of IS NULL", fileHash, since).
+		Where("status NOT IN (?)", []string{"Failed", "Cancelled"}).
+		Order("id DESC").First(&upload).Error
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
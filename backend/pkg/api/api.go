@@ -2,6 +2,7 @@ package api
 
 const (
 	XForwardUserOpsHeader = "X-Forward-User"
+	XTenantIDHeader       = "X-Tenant-ID"
 	OsTypeHeader          = "Os-Type"
 	OsVersionHeader       = "Os-Version"
 )
@@ -0,0 +1,90 @@
+// Package archive reads the files of ZIP and gzipped tar archives one at a time, without
+// extracting them to disk.
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"path"
+)
+
+// ErrUnsupported is returned for data that is neither a ZIP nor a gzipped tar archive.
+var ErrUnsupported = errors.New("not a ZIP or tar.gz archive")
+
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	emptyZipMagic = []byte("PK\x05\x06")
+	gzipMagic     = []byte{0x1f, 0x8b}
+)
+
+// Walk calls fn with the name and content of each regular file of the archive in r, in the order
+// they are stored. Directories, links and other special entries are left out. The format is told by
+// the content rather than by the name of the archive. Walk stops at the first error fn returns and
+// returns it; body must not be used after fn returns.
+func Walk(r io.ReaderAt, size int64, fn func(name string, body io.Reader) error) error {
+	magic := make([]byte, 4)
+	n, err := r.ReadAt(magic, 0)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	magic = magic[:n]
+
+	switch {
+	case bytes.HasPrefix(magic, zipMagic), bytes.HasPrefix(magic, emptyZipMagic):
+		return walkZip(r, size, fn)
+	case bytes.HasPrefix(magic, gzipMagic):
+		return walkTarGz(io.NewSectionReader(r, 0, size), fn)
+	}
+	return ErrUnsupported
+}
+
+func walkZip(r io.ReaderAt, size int64, fn func(name string, body io.Reader) error) error {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, file := range archive.File {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+		body, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = fn(path.Clean(file.Name), body)
+		_ = body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTarGz(r io.Reader, fn func(name string, body io.Reader) error) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(path.Clean(header.Name), archive); err != nil {
+			return err
+		}
+	}
+}
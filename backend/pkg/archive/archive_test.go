@@ -0,0 +1,78 @@
+package archive_test
+
+import (
+	"CVSeeker/pkg/archive"
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type entry struct {
+	name, content string
+}
+
+func walk(t *testing.T, data []byte) []entry {
+	var entries []entry
+	err := archive.Walk(bytes.NewReader(data), int64(len(data)), func(name string, body io.Reader) error {
+		content, err := io.ReadAll(body)
+		require.NoError(t, err)
+		entries = append(entries, entry{name, string(content)})
+		return nil
+	})
+	require.NoError(t, err)
+	return entries
+}
+
+func TestWalkZip(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	_, _ = w.Create("resumes/")
+	f, _ := w.Create("resumes/jane.pdf")
+	_, _ = f.Write([]byte("%PDF-1.7"))
+	f, _ = w.Create("./notes.txt")
+	_, _ = f.Write([]byte("notes"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []entry{{"resumes/jane.pdf", "%PDF-1.7"}, {"notes.txt", "notes"}}, walk(t, buf.Bytes()))
+}
+
+func TestWalkTarGz(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: "resumes/", Typeflag: tar.TypeDir, Mode: 0755}))
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: "resumes/jane.pdf", Typeflag: tar.TypeReg, Mode: 0644, Size: 8}))
+	_, _ = w.Write([]byte("%PDF-1.7"))
+	require.NoError(t, w.WriteHeader(&tar.Header{Name: "resumes/latest.pdf", Typeflag: tar.TypeSymlink, Linkname: "jane.pdf"}))
+	require.NoError(t, w.Close())
+	require.NoError(t, gz.Close())
+
+	assert.Equal(t, []entry{{"resumes/jane.pdf", "%PDF-1.7"}}, walk(t, buf.Bytes()))
+}
+
+func TestWalkErrors(t *testing.T) {
+	data := []byte("%PDF-1.7 not an archive")
+	err := archive.Walk(bytes.NewReader(data), int64(len(data)), func(string, io.Reader) error { return nil })
+	assert.ErrorIs(t, err, archive.ErrUnsupported)
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	_, _ = w.Create("a.txt")
+	_, _ = w.Create("b.txt")
+	require.NoError(t, w.Close())
+	stop := errors.New("stop")
+	calls := 0
+	err = archive.Walk(bytes.NewReader(buf.Bytes()), int64(buf.Len()), func(string, io.Reader) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
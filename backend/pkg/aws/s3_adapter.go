@@ -2,6 +2,8 @@ package aws
 
 import (
 	"CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/tracing"
 	"bytes"
 	"context"
 	"fmt"
@@ -9,10 +11,29 @@ import (
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	"io"
+	"strings"
+	"time"
 )
 
+// adaptorName identifies this adaptor in metrics.
+const adaptorName = "s3"
+
 type IS3Client interface {
 	UploadFile(ctx context.Context, bucket, key string, fileData []byte) (string, error)
+	DownloadFile(ctx context.Context, bucket, key string) ([]byte, error)
+	// ListObjects calls fn with the objects of a bucket, a page at a time, and stops at the first
+	// error fn returns.
+	ListObjects(ctx context.Context, bucket string, fn func(objects []ObjectInfo) error) error
+	DeleteObject(ctx context.Context, bucket, key string) error
+}
+
+// ObjectInfo describes an object listed by ListObjects.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
 }
 
 type S3Client struct {
@@ -46,9 +67,13 @@ func NewS3Client(cfgReader *viper.Viper) (*S3Client, error) {
 }
 
 // UploadFile uploads file data to the specified S3 bucket and returns the URL of the uploaded file
-func (aw *S3Client) UploadFile(ctx context.Context, bucket, key string, fileData []byte) (string, error) {
+func (aw *S3Client) UploadFile(ctx context.Context, bucket, key string, fileData []byte) (_ string, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "upload_file", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "s3.UploadFile", attribute.String("s3.bucket", bucket), attribute.String("s3.key", key))
+	defer tracing.End(span, &err)
+
 	// Directly use the provided ctx which is expected to be managed by the caller
-	_, err := aw.Client.PutObject(ctx, &s3.PutObjectInput{
+	_, err = aw.Client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(bucket),
 		Key:    aws.String(key),
 		Body:   bytes.NewReader(fileData),
@@ -59,5 +84,82 @@ func (aw *S3Client) UploadFile(ctx context.Context, bucket, key string, fileData
 	}
 
 	// Return the URL of the uploaded file
-	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, key), nil
+	return ObjectURL(bucket, key), nil
+}
+
+func (aw *S3Client) DownloadFile(ctx context.Context, bucket, key string) (_ []byte, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "download_file", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "s3.DownloadFile", attribute.String("s3.bucket", bucket), attribute.String("s3.key", key))
+	defer tracing.End(span, &err)
+
+	output, err := aw.Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file from S3: %v", err)
+	}
+	defer output.Body.Close()
+
+	fileData, err := io.ReadAll(output.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file from S3: %v", err)
+	}
+	return fileData, nil
+}
+
+func (aw *S3Client) ListObjects(ctx context.Context, bucket string, fn func(objects []ObjectInfo) error) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "list_objects", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "s3.ListObjects", attribute.String("s3.bucket", bucket))
+	defer tracing.End(span, &err)
+
+	paginator := s3.NewListObjectsV2Paginator(aw.Client, &s3.ListObjectsV2Input{Bucket: aws.String(bucket)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list S3 objects: %v", err)
+		}
+		objects := make([]ObjectInfo, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			objects = append(objects, ObjectInfo{
+				Key:          aws.ToString(object.Key),
+				Size:         aws.ToInt64(object.Size),
+				LastModified: aws.ToTime(object.LastModified),
+			})
+		}
+		if err := fn(objects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (aw *S3Client) DeleteObject(ctx context.Context, bucket, key string) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "delete_object", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "s3.DeleteObject", attribute.String("s3.bucket", bucket), attribute.String("s3.key", key))
+	defer tracing.End(span, &err)
+
+	_, err = aw.Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete S3 object: %v", err)
+	}
+	return nil
+}
+
+// ObjectURL returns the URL of the object stored under key in bucket.
+func ObjectURL(bucket, key string) string {
+	return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", bucket, key)
+}
+
+// KeyFromURL returns the key of an object from the URL UploadFile returned for it, or "" when url
+// is not such a URL for bucket.
+func KeyFromURL(bucket, url string) string {
+	prefix := ObjectURL(bucket, "")
+	if !strings.HasPrefix(url, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(url, prefix)
 }
@@ -6,12 +6,31 @@ const (
 	ElasticsearchCloudId  = "ELK_CLOUD_ID"
 	ElasticsearchUserName = "ELK_USERNAME"
 	ElasticsearchPassword = "ELK_PASSWORD"
+	ElasticsearchBackend  = "ELK_BACKEND"
+	ElasticsearchDataFile = "ELK_DATA_FILE"
 
-	HuggingfaceApiKey = "HUGGINGFACE_API_KEY"
+	HuggingfaceApiKey  = "HUGGINGFACE_API_KEY"
+	HuggingfaceBaseUrl = "HUGGINGFACE_BASE_URL"
 
-	GptApiKey = "GPT_API_KEY"
+	GptApiKey  = "GPT_API_KEY"
+	GptBaseUrl = "GPT_BASE_URL"
 
 	AwsAccessKey = "AWS_ACCESS_KEY"
 	AwsSecretKey = "AWS_SECRET_KEY"
 	AwsRegion    = "AWS_REGION"
+
+	OtelEnabled          = "OTEL_ENABLED"
+	OtelServiceName      = "OTEL_SERVICE_NAME"
+	OtelExporterEndpoint = "OTEL_COLLECTOR_ENDPOINT"
+	OtelExporterInsecure = "OTEL_COLLECTOR_INSECURE"
+	OtelSampleRatio      = "OTEL_TRACES_SAMPLER_ARG"
+
+	// Outbound HTTP client settings, formatted with the service prefix (GPT, HUGGINGFACE, CRAWLER).
+	HttpClientTimeout          = "%s_HTTP_TIMEOUT"
+	HttpClientMaxRetries       = "%s_HTTP_MAX_RETRIES"
+	HttpClientInitialBackoff   = "%s_HTTP_INITIAL_BACKOFF"
+	HttpClientMaxBackoff       = "%s_HTTP_MAX_BACKOFF"
+	HttpClientMaxRetryAfter    = "%s_HTTP_MAX_RETRY_AFTER"
+	HttpClientBreakerThreshold = "%s_HTTP_BREAKER_THRESHOLD"
+	HttpClientBreakerCooldown  = "%s_HTTP_BREAKER_COOLDOWN"
 )
@@ -5,6 +5,7 @@ import (
 	"github.com/spf13/viper"
 	"log"
 	"os"
+	"strings"
 )
 
 func SetupConfig() {
@@ -24,3 +25,11 @@ func SetupConfig() {
 		}
 	}
 }
+
+// BaseURL returns the base URL configured under key without a trailing slash, or fallback when it is not set.
+func BaseURL(cfgReader *viper.Viper, key, fallback string) string {
+	if url := strings.TrimRight(cfgReader.GetString(key), "/"); url != "" {
+		return url
+	}
+	return fallback
+}
@@ -0,0 +1,165 @@
+// Package crawler is the client of the LinkedIn crawler service, which returns the text of public
+// profiles. The URLs of a batch are sent in chunks, and each URL gets its own result, so that a
+// profile the crawler cannot read does not fail the others.
+package crawler
+
+import (
+	"CVSeeker/pkg/httpclient"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"go.opentelemetry.io/otel/attribute"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// adaptorName identifies this adaptor in metrics.
+const adaptorName = "crawler"
+
+// defaultChunkSize is used when no chunk size is configured.
+const defaultChunkSize = 10
+
+var (
+	// ErrNoProfile is the error of a URL the crawler answered without a profile for.
+	ErrNoProfile = errors.New("the crawler returned no profile for this URL")
+	// ErrEmptyProfile is the error of a URL whose profile has no text.
+	ErrEmptyProfile = errors.New("the crawler returned an empty profile")
+)
+
+// Profile is the text of a LinkedIn profile, with the name of its owner taken from its URL.
+type Profile struct {
+	URL  string
+	Name string
+	Text string
+}
+
+// Result is the outcome of crawling one URL: its profile, or why it could not be crawled.
+type Result struct {
+	URL     string
+	Profile *Profile
+	Err     error
+}
+
+type ICrawlerClient interface {
+	// Fetch crawls urls in chunks of the chunk size and returns a result for each, in the order
+	// of urls. When a chunk fails, its URLs are crawled one at a time so that only those the crawler
+	// fails on are failed.
+	Fetch(ctx context.Context, urls []string) []Result
+}
+
+type CrawlerClient struct {
+	httpClient *httpclient.Client
+	baseURL    string
+	chunkSize  int
+}
+
+// NewCrawlerClient returns a client of the crawler at baseURL that sends chunkSize URLs per request.
+func NewCrawlerClient(httpClient *httpclient.Client, baseURL string, chunkSize int) ICrawlerClient {
+	if chunkSize <= 0 {
+		chunkSize = defaultChunkSize
+	}
+	return &CrawlerClient{
+		httpClient: httpClient,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		chunkSize:  chunkSize,
+	}
+}
+
+func (_this *CrawlerClient) Fetch(ctx context.Context, urls []string) []Result {
+	results := make([]Result, len(urls))
+	for start := 0; start < len(urls); start += _this.chunkSize {
+		end := min(start+_this.chunkSize, len(urls))
+		chunk := results[start:end]
+		for i, profileURL := range urls[start:end] {
+			chunk[i].URL = profileURL
+		}
+		if err := ctx.Err(); err != nil {
+			fail(chunk, err)
+			continue
+		}
+
+		profiles, err := _this.getFullText(ctx, urls[start:end])
+		if err != nil && len(chunk) > 1 && ctx.Err() == nil {
+			for i := range chunk {
+				profiles, err := _this.getFullText(ctx, []string{chunk[i].URL})
+				match(chunk[i:i+1], profiles, err)
+			}
+			continue
+		}
+		match(chunk, profiles, err)
+	}
+	return results
+}
+
+// getFullText asks the crawler for the profiles of urls.
+func (_this *CrawlerClient) getFullText(ctx context.Context, urls []string) (_ map[string]Profile, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "get_full_text", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "crawler.GetFullText", attribute.Int("crawler.urls", len(urls)))
+	defer tracing.End(span, &err)
+
+	query := url.Values{"list_url": {strings.Join(urls, ",")}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, _this.baseURL+"/api/getfulltext/?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := _this.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Resumes []struct {
+			Content string `json:"content"`
+			URL     string `json:"fileBytes"`
+		} `json:"resumes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	profiles := make(map[string]Profile, len(body.Resumes))
+	for _, resume := range body.Resumes {
+		profiles[normalize(resume.URL)] = Profile{URL: resume.URL, Name: NameFromURL(resume.URL), Text: resume.Content}
+	}
+	return profiles, nil
+}
+
+// match sets the results of a chunk from the profiles the crawler returned for it, or err.
+func match(chunk []Result, profiles map[string]Profile, err error) {
+	if err != nil {
+		fail(chunk, err)
+		return
+	}
+	for i := range chunk {
+		profile, ok := profiles[normalize(chunk[i].URL)]
+		switch {
+		case !ok:
+			chunk[i].Err = ErrNoProfile
+		case strings.TrimSpace(profile.Text) == "":
+			chunk[i].Err = ErrEmptyProfile
+		default:
+			chunk[i].Profile = &profile
+		}
+	}
+}
+
+func fail(chunk []Result, err error) {
+	for i := range chunk {
+		chunk[i].Err = err
+	}
+}
+
+// normalize returns the form of a profile URL the crawler's answers are matched with.
+func normalize(profileURL string) string {
+	return strings.TrimRight(strings.TrimSpace(profileURL), "/")
+}
+
+// NameFromURL returns the handle of a profile URL, its last path segment.
+func NameFromURL(profileURL string) string {
+	parts := strings.Split(normalize(profileURL), "/")
+	return parts[len(parts)-1]
+}
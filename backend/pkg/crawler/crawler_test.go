@@ -0,0 +1,82 @@
+package crawler_test
+
+import (
+	"CVSeeker/pkg/crawler"
+	"CVSeeker/pkg/httpclient"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCrawler answers with a profile for each URL, leaves out the URLs in missing and fails the
+// requests that include a URL in broken.
+type fakeCrawler struct {
+	mu       sync.Mutex
+	requests [][]string
+	missing  map[string]bool
+	broken   map[string]bool
+}
+
+func (_this *fakeCrawler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	urls := strings.Split(r.URL.Query().Get("list_url"), ",")
+	_this.mu.Lock()
+	_this.requests = append(_this.requests, urls)
+	_this.mu.Unlock()
+
+	var resumes []map[string]string
+	for _, url := range urls {
+		if _this.broken[url] {
+			http.Error(w, "cannot crawl "+url, http.StatusUnprocessableEntity)
+			return
+		}
+		if !_this.missing[url] {
+			resumes = append(resumes, map[string]string{"content": "profile of " + url, "fileBytes": url + "/"})
+		}
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"resumes": resumes})
+}
+
+func newClient(t *testing.T, fake *fakeCrawler) crawler.ICrawlerClient {
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	httpClient := httpclient.New("crawler", httpclient.Config{Timeout: 5 * time.Second})
+	return crawler.NewCrawlerClient(httpClient, srv.URL+"/", 2)
+}
+
+func TestCrawlerClient_FetchInChunks(t *testing.T) {
+	fake := &fakeCrawler{missing: map[string]bool{"https://linkedin.com/in/c": true}}
+	client := newClient(t, fake)
+
+	urls := []string{"https://linkedin.com/in/a", "https://linkedin.com/in/b?x=1&y=2", "https://linkedin.com/in/c"}
+	results := client.Fetch(context.Background(), urls)
+
+	assert.Equal(t, [][]string{urls[:2], urls[2:]}, fake.requests)
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, "profile of "+urls[0], results[0].Profile.Text)
+	assert.Equal(t, "a", results[0].Profile.Name)
+	require.NoError(t, results[1].Err)
+	assert.Equal(t, urls[2], results[2].URL)
+	assert.ErrorIs(t, results[2].Err, crawler.ErrNoProfile)
+}
+
+func TestCrawlerClient_FailedChunkIsSplit(t *testing.T) {
+	fake := &fakeCrawler{broken: map[string]bool{"https://linkedin.com/in/b": true}}
+	client := newClient(t, fake)
+
+	urls := []string{"https://linkedin.com/in/a", "https://linkedin.com/in/b"}
+	results := client.Fetch(context.Background(), urls)
+
+	assert.Equal(t, [][]string{urls, urls[:1], urls[1:]}, fake.requests)
+	require.NoError(t, results[0].Err)
+	assert.Error(t, results[1].Err, "only the URL the crawler fails on is failed")
+	assert.Nil(t, results[1].Profile)
+}
@@ -1,7 +1,10 @@
 package db
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"github.com/go-sql-driver/mysql"
 	"github.com/jinzhu/gorm"
 	"github.com/jmoiron/sqlx"
 
@@ -39,6 +42,12 @@ var (
 	ErrRecordNotFound = gorm.ErrRecordNotFound
 )
 
+// IsDuplicateKey reports whether err is the violation of a unique key.
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
 // Constants definition.
 const (
 	DriverMySQL  = "mysql"
@@ -101,6 +110,22 @@ func (_this *DB) Commit() *gorm.DB {
 	return _this.db.Commit()
 }
 
+// Transaction runs fn in a transaction, committed when fn returns nil and rolled back otherwise.
+// When _this is already a transaction, fn runs in it and the outer transaction decides.
+func (_this *DB) Transaction(fn func(tx *DB) error) error {
+	return _this.GormTransaction(func(tx *GormDB) error {
+		return fn(&DB{tx})
+	})
+}
+
+// GormTransaction is Transaction for code working with *GormDB.
+func (_this *DB) GormTransaction(fn func(tx *GormDB) error) error {
+	if _, inTransaction := _this.db.CommonDB().(*sql.Tx); inTransaction {
+		return fn(_this.db)
+	}
+	return _this.db.Transaction(fn)
+}
+
 // Config contains connection info of DB.
 type Config struct {
 	Driver   string
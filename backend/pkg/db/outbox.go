@@ -0,0 +1,344 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+/*
+
+# Outbox
+
+A state change and the operations it requires in another store are committed together: the
+operations are written to the outbox table in the transaction of the change, and a Relay applies
+them afterwards.
+
+	err := db.Transaction(func(tx *db.DB) error {
+		if err := repo.Update(tx, . . .); err != nil {
+			return err
+		}
+		return db.Enqueue(tx, "resume.index", documentID, document)
+	})
+	relay.Notify()
+
+A message is applied at least once, so handlers must be idempotent. Messages with the same
+aggregate ID are applied in the order they were enqueued. A handler that cannot apply a message
+for a while, such as while the other store is read-only, returns its error wrapped with Postpone
+so that the wait does not count toward RelayConfig.MaxAttempts.
+
+*/
+
+// TableNameOutbox is the table of outbox messages.
+const TableNameOutbox = "outbox"
+
+// OutboxMessage is an operation to apply to another store once the transaction that enqueued it is
+// committed. AggregateID identifies what the operation applies to, such as a document ID.
+type OutboxMessage struct {
+	ID          int64      `gorm:"column:id;primary_key;auto_increment" json:"id"`
+	Topic       string     `gorm:"column:topic;type:varchar(100)" json:"topic"`
+	AggregateID string     `gorm:"column:aggregate_id;type:varchar(255)" json:"aggregateId"`
+	Payload     string     `gorm:"column:payload;type:longtext" json:"payload"`
+	Attempts    int        `gorm:"column:attempts" json:"attempts"`
+	LastError   string     `gorm:"column:last_error;type:text" json:"lastError"`
+	AvailableAt time.Time  `gorm:"column:available_at" json:"availableAt"`
+	LockedBy    string     `gorm:"column:locked_by;type:varchar(64)" json:"lockedBy"`
+	LockedUntil *time.Time `gorm:"column:locked_until" json:"lockedUntil"`
+	ProcessedAt *time.Time `gorm:"column:processed_at" json:"processedAt"`
+	// FailedAt is set when the message was given up after RelayConfig.MaxAttempts.
+	FailedAt  *time.Time `gorm:"column:failed_at" json:"failedAt"`
+	CreatedAt time.Time  `gorm:"column:created_at" json:"createdAt"`
+}
+
+func (OutboxMessage) TableName() string {
+	return TableNameOutbox
+}
+
+// Decode unmarshals the payload of the message into v.
+func (_this OutboxMessage) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(_this.Payload), v)
+}
+
+// Enqueue adds a message to the outbox. Call it with the transaction of the state change the
+// message belongs to. A nil payload is stored as an empty payload.
+func Enqueue(tx *DB, topic, aggregateID string, payload interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("encoding outbox payload: %w", err)
+		}
+	}
+	now := time.Now()
+	return tx.DB().Table(TableNameOutbox).Create(&OutboxMessage{
+		Topic:       topic,
+		AggregateID: aggregateID,
+		Payload:     string(body),
+		AvailableAt: now,
+		CreatedAt:   now,
+	}).Error
+}
+
+// postponedError is the error of a message that is retried without counting the attempt.
+type postponedError struct {
+	err error
+}
+
+func (_this postponedError) Error() string { return _this.err.Error() }
+func (_this postponedError) Unwrap() error { return _this.err }
+
+// Postpone wraps the error of a handler that cannot apply a message yet, but will once a temporary
+// condition clears. The message is retried after RelayConfig.PollInterval, and the attempt does
+// not count toward RelayConfig.MaxAttempts.
+func Postpone(err error) error {
+	return postponedError{err: err}
+}
+
+// OutboxHandler applies the messages of a topic. It may be called again for a message it already
+// applied, when the relay stops before recording the message as processed.
+type OutboxHandler func(ctx context.Context, message OutboxMessage) error
+
+// RelayConfig tunes a Relay. Zero fields take the defaults below.
+type RelayConfig struct {
+	// BatchSize is the number of messages claimed at once.
+	BatchSize int
+	// PollInterval is how often the outbox is read when Notify is not called.
+	PollInterval time.Duration
+	// Lease is how long claimed messages are reserved; messages of a relay that stopped while
+	// applying them are claimed again once it expires.
+	Lease time.Duration
+	// MaxAttempts is the number of failed attempts after which a message is given up.
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry of a message. It doubles with every
+	// attempt, up to MaxRetryBackoff.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Retention is how long processed messages are kept.
+	Retention time.Duration
+	// OnError is called when a message fails, and with an empty message when the outbox cannot be read.
+	OnError func(message OutboxMessage, err error)
+}
+
+// Defaults of RelayConfig.
+const (
+	DefaultRelayBatchSize       = 50
+	DefaultRelayPollInterval    = 5 * time.Second
+	DefaultRelayLease           = 2 * time.Minute
+	DefaultRelayMaxAttempts     = 10
+	DefaultRelayRetryBackoff    = time.Second
+	DefaultRelayMaxRetryBackoff = 10 * time.Minute
+	DefaultRelayRetention       = 7 * 24 * time.Hour
+
+	relayPurgeInterval = time.Hour
+)
+
+// Relay applies outbox messages with the handler registered for their topic. Several relays may
+// run against the same outbox.
+type Relay struct {
+	db     *DB
+	config RelayConfig
+
+	mu       sync.RWMutex
+	handlers map[string]OutboxHandler
+
+	wake chan struct{}
+}
+
+// NewRelay returns a relay reading the outbox of db.
+func NewRelay(db *DB, config RelayConfig) *Relay {
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultRelayBatchSize
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultRelayPollInterval
+	}
+	if config.Lease <= 0 {
+		config.Lease = DefaultRelayLease
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = DefaultRelayMaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = DefaultRelayRetryBackoff
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = DefaultRelayMaxRetryBackoff
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultRelayRetention
+	}
+	return &Relay{
+		db:       db,
+		config:   config,
+		handlers: make(map[string]OutboxHandler),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Handle registers the handler of a topic.
+func (_this *Relay) Handle(topic string, handler OutboxHandler) {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.handlers[topic] = handler
+}
+
+// Notify wakes the relay, so that messages committed just now are applied without waiting for
+// the next poll.
+func (_this *Relay) Notify() {
+	select {
+	case _this.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run applies messages until ctx is done.
+func (_this *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(_this.config.PollInterval)
+	defer ticker.Stop()
+
+	var lastPurge time.Time
+	for {
+		for {
+			applied, err := _this.RelayOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				_this.report(OutboxMessage{}, fmt.Errorf("reading the outbox: %w", err))
+			}
+			if err != nil || applied < _this.config.BatchSize || ctx.Err() != nil {
+				break
+			}
+		}
+		if time.Since(lastPurge) >= relayPurgeInterval {
+			_this.purge(time.Now().Add(-_this.config.Retention))
+			lastPurge = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-_this.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce claims the messages that are due and applies them. It returns the number of messages
+// claimed.
+func (_this *Relay) RelayOnce(ctx context.Context) (int, error) {
+	messages, err := _this.claim(time.Now())
+	if err != nil || len(messages) == 0 {
+		return 0, err
+	}
+
+	for _, message := range messages {
+		if ctx.Err() != nil {
+			// The remaining messages are claimed again when the lease expires.
+			return len(messages), ctx.Err()
+		}
+		_this.mu.RLock()
+		handler := _this.handlers[message.Topic]
+		_this.mu.RUnlock()
+
+		if handler == nil {
+			err = fmt.Errorf("no handler for outbox topic %q", message.Topic)
+		} else {
+			err = handler(ctx, message)
+		}
+		if err != nil && ctx.Err() != nil {
+			// The message is claimed again when the lease expires.
+			return len(messages), ctx.Err()
+		}
+		if err != nil {
+			_this.report(message, err)
+			err = _this.markFailed(message, err)
+		} else {
+			err = _this.markProcessed(message)
+		}
+		if err != nil {
+			_this.report(message, fmt.Errorf("recording the outcome of the message: %w", err))
+		}
+	}
+	return len(messages), nil
+}
+
+// claim reserves the due messages. Only the oldest pending message of each aggregate is claimed,
+// so that the operations on the same aggregate are applied in order.
+func (_this *Relay) claim(now time.Time) ([]OutboxMessage, error) {
+	var ids []int64
+	err := _this.db.DB().Table(TableNameOutbox+" o").
+		Where("o.processed_at IS NULL AND o.failed_at IS NULL AND o.available_at <= ?", now).
+		Where("o.locked_until IS NULL OR o.locked_until < ?", now).
+		Where("NOT EXISTS (SELECT 1 FROM "+TableNameOutbox+" p WHERE p.aggregate_id = o.aggregate_id AND p.id < o.id AND p.processed_at IS NULL AND p.failed_at IS NULL)").
+		Order("o.id").Limit(_this.config.BatchSize).Pluck("o.id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	// Another relay may claim some of the same messages; the lock is taken by whichever update
+	// comes first.
+	token := uuid.New().String()
+	err = _this.db.DB().Table(TableNameOutbox).
+		Where("id IN (?) AND processed_at IS NULL AND (locked_until IS NULL OR locked_until < ?)", ids, now).
+		Updates(map[string]interface{}{"locked_by": token, "locked_until": now.Add(_this.config.Lease)}).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []OutboxMessage
+	if err := _this.db.DB().Table(TableNameOutbox).Where("locked_by = ?", token).Order("id").Find(&messages).Error; err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (_this *Relay) markProcessed(message OutboxMessage) error {
+	return _this.db.DB().Table(TableNameOutbox).Where("id = ? AND locked_by = ?", message.ID, message.LockedBy).
+		Updates(map[string]interface{}{"processed_at": time.Now(), "locked_by": "", "locked_until": nil}).Error
+}
+
+// markFailed schedules the retry of a message, or gives it up after MaxAttempts. A postponed
+// message keeps its attempts.
+func (_this *Relay) markFailed(message OutboxMessage, cause error) error {
+	attempts := message.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":     attempts,
+		"last_error":   cause.Error(),
+		"locked_by":    "",
+		"locked_until": nil,
+	}
+	if errors.As(cause, new(postponedError)) {
+		updates["attempts"] = message.Attempts
+		updates["available_at"] = time.Now().Add(_this.config.PollInterval)
+	} else if attempts >= _this.config.MaxAttempts {
+		updates["failed_at"] = time.Now()
+	} else {
+		updates["available_at"] = time.Now().Add(_this.backoff(attempts))
+	}
+	return _this.db.DB().Table(TableNameOutbox).Where("id = ? AND locked_by = ?", message.ID, message.LockedBy).Updates(updates).Error
+}
+
+func (_this *Relay) report(message OutboxMessage, err error) {
+	if _this.config.OnError != nil {
+		_this.config.OnError(message, err)
+	}
+}
+
+func (_this *Relay) backoff(attempts int) time.Duration {
+	delay := _this.config.RetryBackoff
+	for i := 1; i < attempts && delay < _this.config.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > _this.config.MaxRetryBackoff {
+		delay = _this.config.MaxRetryBackoff
+	}
+	return delay
+}
+
+// purge deletes the messages processed before before. Errors are ignored; the messages are
+// deleted by the next purge.
+func (_this *Relay) purge(before time.Time) {
+	_this.db.DB().Table(TableNameOutbox).Where("processed_at < ?", before).Delete(&OutboxMessage{})
+}
@@ -0,0 +1,240 @@
+package db_test
+
+import (
+	"CVSeeker/pkg/db"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOutboxDB returns a database with an empty outbox table, in memory.
+func newOutboxDB(t *testing.T) *db.DB {
+	gormDB, err := gorm.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	// Every connection to :memory: is a database of its own.
+	gormDB.DB().SetMaxOpenConns(1)
+	t.Cleanup(func() { gormDB.Close() })
+	require.NoError(t, gormDB.AutoMigrate(&db.OutboxMessage{}).Error)
+	return db.NewDB(gormDB)
+}
+
+func enqueue(t *testing.T, database *db.DB, topic, aggregateID string, payload interface{}) {
+	require.NoError(t, database.Transaction(func(tx *db.DB) error {
+		return db.Enqueue(tx, topic, aggregateID, payload)
+	}))
+}
+
+func outboxMessages(t *testing.T, database *db.DB) []db.OutboxMessage {
+	var messages []db.OutboxMessage
+	require.NoError(t, database.DB().Order("id").Find(&messages).Error)
+	return messages
+}
+
+func TestRelay_AppliesMessagesOfAnAggregateInOrder(t *testing.T) {
+	database := newOutboxDB(t)
+	relay := db.NewRelay(database, db.RelayConfig{})
+	var applied []string
+	relay.Handle("index", func(ctx context.Context, message db.OutboxMessage) error {
+		var step string
+		require.NoError(t, message.Decode(&step))
+		applied = append(applied, message.AggregateID+":"+step)
+		return nil
+	})
+
+	enqueue(t, database, "index", "a", "1")
+	enqueue(t, database, "index", "b", "1")
+	enqueue(t, database, "index", "a", "2")
+
+	// The second message of a waits for the first one to be processed.
+	claimed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+	assert.Equal(t, []string{"a:1", "b:1"}, applied)
+
+	claimed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, []string{"a:1", "b:1", "a:2"}, applied)
+
+	claimed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, claimed)
+	for _, message := range outboxMessages(t, database) {
+		assert.NotNil(t, message.ProcessedAt)
+		assert.Empty(t, message.LockedBy)
+		assert.Nil(t, message.LockedUntil)
+	}
+}
+
+func TestRelay_ClaimedMessagesAreLeased(t *testing.T) {
+	database := newOutboxDB(t)
+	other := db.NewRelay(database, db.RelayConfig{})
+	other.Handle("index", func(ctx context.Context, message db.OutboxMessage) error { return nil })
+
+	relay := db.NewRelay(database, db.RelayConfig{})
+	var otherClaimed []int
+	relay.Handle("index", func(ctx context.Context, message db.OutboxMessage) error {
+		// The messages of this batch are leased: another relay cannot claim them meanwhile.
+		claimed, err := other.RelayOnce(ctx)
+		require.NoError(t, err)
+		otherClaimed = append(otherClaimed, claimed)
+		return nil
+	})
+
+	enqueue(t, database, "index", "a", nil)
+	enqueue(t, database, "index", "b", nil)
+	claimed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, claimed)
+	assert.Equal(t, []int{0, 0}, otherClaimed)
+}
+
+func TestRelay_ClaimsMessagesAgainOnceTheLeaseExpires(t *testing.T) {
+	database := newOutboxDB(t)
+	relay := db.NewRelay(database, db.RelayConfig{})
+	calls := 0
+	relay.Handle("index", func(ctx context.Context, message db.OutboxMessage) error {
+		calls++
+		return nil
+	})
+	enqueue(t, database, "index", "a", nil)
+
+	// A relay that stopped while applying the message left it locked.
+	lockedUntil := time.Now().Add(time.Minute)
+	require.NoError(t, database.DB().Table(db.TableNameOutbox).
+		Updates(map[string]interface{}{"locked_by": "stopped", "locked_until": lockedUntil}).Error)
+	claimed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, claimed)
+
+	lockedUntil = time.Now().Add(-time.Second)
+	require.NoError(t, database.DB().Table(db.TableNameOutbox).Update("locked_until", lockedUntil).Error)
+	claimed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRelay_RetriesFailedMessagesWithBackoffThenGivesUp(t *testing.T) {
+	database := newOutboxDB(t)
+	var reported []error
+	relay := db.NewRelay(database, db.RelayConfig{
+		MaxAttempts:     2,
+		RetryBackoff:    time.Hour,
+		MaxRetryBackoff: time.Hour,
+		OnError:         func(message db.OutboxMessage, err error) { reported = append(reported, err) },
+	})
+	var applied []string
+	relay.Handle("index", func(ctx context.Context, message db.OutboxMessage) error {
+		if message.Payload == `"bad"` {
+			return errors.New("rejected")
+		}
+		applied = append(applied, message.Payload)
+		return nil
+	})
+	enqueue(t, database, "index", "a", "bad")
+	enqueue(t, database, "index", "a", "next")
+
+	claimed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	require.Len(t, reported, 1)
+
+	// The retry is not due yet, and the next message of the aggregate waits for it.
+	message := outboxMessages(t, database)[0]
+	assert.Equal(t, 1, message.Attempts)
+	assert.Equal(t, "rejected", message.LastError)
+	assert.True(t, message.AvailableAt.After(time.Now().Add(59*time.Minute)))
+	claimed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0, claimed)
+
+	require.NoError(t, database.DB().Table(db.TableNameOutbox).Where("id = ?", message.ID).
+		Update("available_at", time.Now().Add(-time.Second)).Error)
+	claimed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+
+	// Given up after MaxAttempts, the message no longer holds back the aggregate.
+	messages := outboxMessages(t, database)
+	assert.Equal(t, 2, messages[0].Attempts)
+	assert.NotNil(t, messages[0].FailedAt)
+	claimed, err = relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.Equal(t, []string{`"next"`}, applied)
+}
+
+func TestRelay_PostponedMessagesKeepTheirAttempts(t *testing.T) {
+	database := newOutboxDB(t)
+	relay := db.NewRelay(database, db.RelayConfig{MaxAttempts: 1, PollInterval: time.Hour})
+	blocked := true
+	relay.Handle("index", func(ctx context.Context, message db.OutboxMessage) error {
+		if blocked {
+			return db.Postpone(errors.New("read-only"))
+		}
+		return nil
+	})
+	enqueue(t, database, "index", "a", "1")
+
+	for i := 0; i < 3; i++ {
+		claimed, err := relay.RelayOnce(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, claimed)
+
+		// The message is due again after PollInterval, and not given up however often it is postponed.
+		message := outboxMessages(t, database)[0]
+		assert.Equal(t, 0, message.Attempts)
+		assert.Equal(t, "read-only", message.LastError)
+		assert.Nil(t, message.FailedAt)
+		assert.True(t, message.AvailableAt.After(time.Now().Add(59*time.Minute)))
+		require.NoError(t, database.DB().Table(db.TableNameOutbox).Where("id = ?", message.ID).
+			Update("available_at", time.Now().Add(-time.Second)).Error)
+	}
+
+	blocked = false
+	claimed, err := relay.RelayOnce(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, claimed)
+	assert.NotNil(t, outboxMessages(t, database)[0].ProcessedAt)
+}
+
+func TestRelay_RunPurgesProcessedMessages(t *testing.T) {
+	database := newOutboxDB(t)
+	relay := db.NewRelay(database, db.RelayConfig{Retention: time.Hour, PollInterval: time.Hour})
+	relay.Handle("index", func(ctx context.Context, message db.OutboxMessage) error { return nil })
+
+	enqueue(t, database, "index", "old", nil)
+	enqueue(t, database, "index", "failed", nil)
+	processedAt, failedAt := time.Now().Add(-2*time.Hour), time.Now().Add(-2*time.Hour)
+	require.NoError(t, database.DB().Table(db.TableNameOutbox).Where("aggregate_id = ?", "old").Update("processed_at", processedAt).Error)
+	require.NoError(t, database.DB().Table(db.TableNameOutbox).Where("aggregate_id = ?", "failed").Update("failed_at", failedAt).Error)
+	enqueue(t, database, "index", "new", nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		relay.Run(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		var count int
+		database.DB().Table(db.TableNameOutbox).Where("aggregate_id = ?", "old").Count(&count)
+		return count == 0
+	}, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+
+	// Failed messages are kept for inspection, and recent ones until the retention passes.
+	messages := outboxMessages(t, database)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "failed", messages[0].AggregateID)
+	assert.Equal(t, "new", messages[1].AggregateID)
+	assert.NotNil(t, messages[1].ProcessedAt)
+}
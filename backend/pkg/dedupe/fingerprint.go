@@ -0,0 +1,158 @@
+// Package dedupe computes the fingerprints used to recognise the same resume, or the same person,
+// across uploads: content hashes for exact copies and a normalized name with contact details for
+// the same candidate coming from different sources.
+package dedupe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Fingerprint identifies a resume for duplicate detection.
+type Fingerprint struct {
+	// FileHash is the hash of the uploaded file, or of the profile URL for crawled profiles.
+	FileHash string
+	// TextHash is the hash of the resume text, insensitive to case and whitespace.
+	TextHash string
+	NameKey  string
+	Emails   []string
+	Phones   []string
+}
+
+// minPhoneDigits and maxPhoneDigits bound the length of a phone number, as in E.164.
+const (
+	minPhoneDigits = 9
+	maxPhoneDigits = 15
+	// phoneSuffixDigits are compared so that national and international forms of a number match.
+	phoneSuffixDigits = 9
+)
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`)
+	phonePattern = regexp.MustCompile(`\+?\d[\d\s().-]{7,20}\d`)
+)
+
+// diacritics maps accented Latin letters, including Vietnamese ones, to their base letter.
+var diacritics = map[rune]rune{}
+
+func init() {
+	for base, variants := range map[rune]string{
+		'a': "àáâãäåāăąạảấầẩẫậắằẳẵặ",
+		'c': "çćĉċč",
+		'd': "ďđ",
+		'e': "èéêëēĕėęěẹẻẽếềểễệ",
+		'g': "ĝğġģ",
+		'i': "ìíîïĩīĭįıỉị",
+		'n': "ñńņňŉ",
+		'o': "òóôõöøōŏőơọỏốồổỗộớờởỡợ",
+		's': "śŝşšș",
+		't': "ţťțŧ",
+		'u': "ùúûüũūŭůűųưụủứừửữự",
+		'y': "ýÿŷỳỵỷỹ",
+		'z': "źżž",
+	} {
+		for _, variant := range variants {
+			diacritics[variant] = base
+		}
+	}
+}
+
+// HashBytes returns the hex SHA-256 of data.
+func HashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashText returns the hash of text ignoring case and whitespace, so that the same resume
+// extracted twice with different line breaks hashes the same.
+func HashText(text string) string {
+	return HashBytes([]byte(strings.Join(strings.Fields(strings.ToLower(text)), " ")))
+}
+
+// NameKey normalizes a person's name: case, accents and punctuation are ignored and the words are
+// sorted, so that "Nguyễn Văn An" and "An Nguyen-Van" give the same key.
+func NameKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = strings.Map(func(r rune) rune {
+			if base, ok := diacritics[r]; ok {
+				return base
+			}
+			return r
+		}, word)
+	}
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// Emails returns the distinct e-mail addresses in text, lower-cased.
+func Emails(text string) []string {
+	var emails []string
+	for _, match := range emailPattern.FindAllString(text, -1) {
+		emails = appendUnique(emails, strings.ToLower(strings.TrimRight(match, ".")))
+	}
+	return emails
+}
+
+// Phones returns the distinct phone numbers in text as digits only.
+func Phones(text string) []string {
+	var phones []string
+	for _, match := range phonePattern.FindAllString(text, -1) {
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, match)
+		if len(digits) >= minPhoneDigits && len(digits) <= maxPhoneDigits {
+			phones = appendUnique(phones, digits)
+		}
+	}
+	return phones
+}
+
+// SharesContact reports whether two fingerprints have an e-mail address or phone number in common.
+func (f Fingerprint) SharesContact(other Fingerprint) bool {
+	for _, email := range f.Emails {
+		for _, otherEmail := range other.Emails {
+			if email == otherEmail {
+				return true
+			}
+		}
+	}
+	for _, phone := range f.Phones {
+		for _, otherPhone := range other.Phones {
+			if phoneSuffix(phone) == phoneSuffix(otherPhone) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SameCandidate reports whether two fingerprints have the same name and a contact in common.
+func (f Fingerprint) SameCandidate(other Fingerprint) bool {
+	return f.NameKey != "" && f.NameKey == other.NameKey && f.SharesContact(other)
+}
+
+func phoneSuffix(digits string) string {
+	if len(digits) > phoneSuffixDigits {
+		return digits[len(digits)-phoneSuffixDigits:]
+	}
+	return digits
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
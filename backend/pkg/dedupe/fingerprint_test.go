@@ -0,0 +1,35 @@
+package dedupe_test
+
+import (
+	"CVSeeker/pkg/dedupe"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashText(t *testing.T) {
+	assert.Equal(t, dedupe.HashText("Jane Doe\nGo  developer"), dedupe.HashText("jane doe go developer "))
+	assert.NotEqual(t, dedupe.HashText("Jane Doe"), dedupe.HashText("John Doe"))
+}
+
+func TestNameKey(t *testing.T) {
+	assert.Equal(t, "an nguyen van", dedupe.NameKey("Nguyễn Văn An"))
+	assert.Equal(t, dedupe.NameKey("Nguyễn Văn An"), dedupe.NameKey("An NGUYEN-VAN"))
+	assert.Equal(t, "do duc", dedupe.NameKey("Đỗ Đức"))
+	assert.Equal(t, "", dedupe.NameKey(" - "))
+}
+
+func TestContacts(t *testing.T) {
+	text := "Contact: Jane.Doe@Example.com, jane.doe@example.com. Phone +84 912 345 678 or (091) 234-5678; born 2001-05-06"
+	assert.Equal(t, []string{"jane.doe@example.com"}, dedupe.Emails(text))
+	assert.Equal(t, []string{"84912345678", "0912345678"}, dedupe.Phones(text))
+}
+
+func TestSameCandidate(t *testing.T) {
+	pdf := dedupe.Fingerprint{NameKey: dedupe.NameKey("Jane Doe"), Phones: []string{"0912345678"}}
+	linkedIn := dedupe.Fingerprint{NameKey: dedupe.NameKey("DOE Jane"), Phones: []string{"84912345678"}}
+	assert.True(t, pdf.SameCandidate(linkedIn), "national and international forms of a number match")
+
+	namesake := dedupe.Fingerprint{NameKey: pdf.NameKey, Emails: []string{"other@example.com"}}
+	assert.False(t, pdf.SameCandidate(namesake), "a name alone is not enough")
+}
@@ -5,33 +5,78 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/elastic/elastic-transport-go/v8/elastictransport"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/tracing"
 )
 
+// adaptorName identifies this adaptor in metrics.
+const adaptorName = "elasticsearch"
+
+// ErrDocumentNotFound is wrapped by the errors of operations on a document that does not exist.
+var ErrDocumentNotFound = errors.New("document not found")
+
+// ErrIndexBlocked is wrapped by the errors of writes to an index that refuses them, such as the
+// index ReindexResumes is copying. The write succeeds once the alias points at the new index.
+var ErrIndexBlocked = errors.New("index blocked for writes")
+
+// ErrMappingRejected is wrapped by the error of PutResumeMapping when the index refuses the
+// mapping, typically because a field is already mapped with another type. Putting it again does
+// not help: the documents must be migrated to a new index with ReindexResumes.
+var ErrMappingRejected = errors.New("mapping rejected by the index")
+
 type IElasticsearchClient interface {
 	AddDocument(ctx context.Context, indexName string, document interface{}) (string, error)
+	IndexDocument(ctx context.Context, indexName, documentID string, document interface{}) error
+	// UpdateDocument merges fields into an existing document, as an Elasticsearch partial update.
+	UpdateDocument(ctx context.Context, indexName, documentID string, fields map[string]interface{}) error
 	KeywordSearch(ctx context.Context, indexName string, query string) ([]ResumeSummaryDTO, error)
 	VectorSearch(ctx context.Context, indexName string, vector []float32) ([]ResumeSummaryDTO, error)
 	DeleteDocumentByID(ctx context.Context, indexName, documentID string) error
-	HybridSearchWithBoost(ctx context.Context, indexName, query string, queryVector []float32, from, size int, knnBoost float32) ([]ResumeSummaryDTO, error)
+	HybridSearchWithBoost(ctx context.Context, indexName, query string, queryVector []float32, from, size int, knnBoost float32, filter *SearchFilter, sort *SearchSort) ([]ResumeSummaryDTO, error)
 	GetDocumentByID(ctx context.Context, indexName, documentId string) (*ResumeSummaryDTO, error)
 	FetchDocumentsByIDs(ctx context.Context, indexName string, documentIDs []string) ([]ResumeSummaryDTO, error)
+	// ScanDocumentIDs calls fn with the IDs of every document of an index, batchSize at a time,
+	// and stops at the first error fn returns.
+	ScanDocumentIDs(ctx context.Context, indexName string, batchSize int, fn func(documentIDs []string) error) error
+	// PutResumeMapping adds the fields of ResumeMapping to an existing index. It is safe to call
+	// on every start: fields already mapped the same way are left as they are. A field mapped
+	// otherwise, for example by dynamic mapping, makes it fail with ErrMappingRejected.
+	PutResumeMapping(ctx context.Context, indexName string) error
+	// ReindexResumes migrates the documents of alias to a new index, created with the mappings of
+	// the current one and ResumeMapping in place of the fields it defines, then points alias at it.
+	// The current index is made read-only first, so that no change is lost while the documents are
+	// copied: writes fail meanwhile with ErrIndexBlocked, and succeed once retried against the new
+	// index. When alias is the name of an index rather than an alias, replaceIndex must be set:
+	// the index is then deleted once copied, and its name given to the alias.
+	ReindexResumes(ctx context.Context, alias, indexName string, replaceIndex bool) error
+	Ping(ctx context.Context) error
 }
 
 type ElasticsearchClient struct {
 	client *elasticsearch.TypedClient
 }
 
+// NewElasticsearchClient connects to the cluster at ELK_URL, or returns a MemoryClient persisted to
+// ELK_DATA_FILE when ELK_BACKEND is "memory".
 func NewElasticsearchClient(cfgReader *viper.Viper) (IElasticsearchClient, error) {
+	if strings.EqualFold(cfgReader.GetString(cfg.ElasticsearchBackend), BackendMemory) {
+		return NewMemoryClient(cfgReader.GetString(cfg.ElasticsearchDataFile))
+	}
+
 	url := cfgReader.GetString(cfg.ElasticsearchUrl)
 	username := cfgReader.GetString(cfg.ElasticsearchUserName)
 	password := cfgReader.GetString(cfg.ElasticsearchPassword)
@@ -55,8 +100,26 @@ func NewElasticsearchClient(cfgReader *viper.Viper) (IElasticsearchClient, error
 	return &ElasticsearchClient{client: es}, nil
 }
 
+// Ping checks that the cluster is reachable.
+func (ec *ElasticsearchClient) Ping(ctx context.Context) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "ping", time.Now(), &err)
+
+	ok, err := ec.client.Ping().Do(ctx)
+	if err != nil {
+		return fmt.Errorf("error pinging Elasticsearch: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("elasticsearch ping returned a non-success status")
+	}
+	return nil
+}
+
 // AddDocument adds a new document to the specified index
-func (ec *ElasticsearchClient) AddDocument(ctx context.Context, indexName string, document interface{}) (string, error) {
+func (ec *ElasticsearchClient) AddDocument(ctx context.Context, indexName string, document interface{}) (_ string, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "add_document", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.AddDocument", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
 	docJSON, err := json.Marshal(document)
 	if err != nil {
 		return "", fmt.Errorf("error marshaling document: %w", err)
@@ -92,8 +155,82 @@ func (ec *ElasticsearchClient) AddDocument(ctx context.Context, indexName string
 	return result.ID, nil
 }
 
+// IndexDocument creates or replaces the document with the given ID.
+func (ec *ElasticsearchClient) IndexDocument(ctx context.Context, indexName, documentID string, document interface{}) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "index_document", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.IndexDocument", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
+	docJSON, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("error marshaling document: %w", err)
+	}
+
+	req := esapi.IndexRequest{
+		Index:      indexName,
+		DocumentID: documentID,
+		Body:       bytes.NewReader(docJSON),
+		Refresh:    "true",
+	}
+	res, err := req.Do(ctx, ec.client)
+	if err != nil {
+		return fmt.Errorf("error indexing document: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return writeError(res)
+	}
+	return nil
+}
+
+// UpdateDocument merges fields into the document with the given ID.
+func (ec *ElasticsearchClient) UpdateDocument(ctx context.Context, indexName, documentID string, fields map[string]interface{}) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "update_document", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.UpdateDocument", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
+	body, err := json.Marshal(map[string]interface{}{"doc": fields})
+	if err != nil {
+		return fmt.Errorf("error marshaling document update: %w", err)
+	}
+
+	req := esapi.UpdateRequest{
+		Index:      indexName,
+		DocumentID: documentID,
+		Body:       bytes.NewReader(body),
+		Refresh:    "true",
+	}
+	res, err := req.Do(ctx, ec.client)
+	if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrDocumentNotFound, documentID)
+	}
+	if res.IsError() {
+		return writeError(res)
+	}
+	return nil
+}
+
+// writeError returns the error of a write Elasticsearch refused.
+func writeError(res *esapi.Response) error {
+	body := res.String()
+	if strings.Contains(body, "cluster_block_exception") {
+		return fmt.Errorf("%w: %s", ErrIndexBlocked, body)
+	}
+	return fmt.Errorf("error response from Elasticsearch: %s", body)
+}
+
 // GetDocumentByID retrieves a document by its ID from a specific index and converts it to an ResumeSummaryDTO.
-func (ec *ElasticsearchClient) GetDocumentByID(ctx context.Context, indexName string, documentID string) (*ResumeSummaryDTO, error) {
+func (ec *ElasticsearchClient) GetDocumentByID(ctx context.Context, indexName string, documentID string) (_ *ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "get_document", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.GetDocumentByID", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
 	// Create the Get request to Elasticsearch
 	req := esapi.GetRequest{
 		Index:      indexName,
@@ -107,6 +244,9 @@ func (ec *ElasticsearchClient) GetDocumentByID(ctx context.Context, indexName st
 	}
 	defer res.Body.Close() // Ensure body is closed after the operation
 
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, documentID)
+	}
 	// Check if the request was not successful
 	if !res.IsError() {
 		var hit types.Hit
@@ -120,7 +260,11 @@ func (ec *ElasticsearchClient) GetDocumentByID(ctx context.Context, indexName st
 	}
 }
 
-func (ec *ElasticsearchClient) FetchDocumentsByIDs(ctx context.Context, indexName string, documentIDs []string) ([]ResumeSummaryDTO, error) {
+func (ec *ElasticsearchClient) FetchDocumentsByIDs(ctx context.Context, indexName string, documentIDs []string) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "mget", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.FetchDocumentsByIDs", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
 	// Construct the request body for the multi-get API
 	docs := make([]map[string]interface{}, len(documentIDs))
 	for i, id := range documentIDs {
@@ -192,7 +336,223 @@ func (ec *ElasticsearchClient) FetchDocumentsByIDs(ctx context.Context, indexNam
 	return response, nil
 }
 
-func (ec *ElasticsearchClient) DeleteDocumentByID(ctx context.Context, indexName, documentID string) error {
+func (ec *ElasticsearchClient) ScanDocumentIDs(ctx context.Context, indexName string, batchSize int, fn func(documentIDs []string) error) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "scan_ids", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.ScanDocumentIDs", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
+	body := fmt.Sprintf(`{"size": %d, "_source": false, "sort": ["_doc"], "query": {"match_all": {}}}`, batchSize)
+	res, err := esapi.SearchRequest{
+		Index:  []string{indexName},
+		Body:   strings.NewReader(body),
+		Scroll: scanKeepAlive,
+	}.Do(ctx, ec.client)
+	if err != nil {
+		return fmt.Errorf("error performing scan request: %w", err)
+	}
+
+	var scrollID string
+	defer func() {
+		if scrollID != "" {
+			clear := esapi.ClearScrollRequest{Body: strings.NewReader(fmt.Sprintf(`{"scroll_id": %q}`, scrollID))}
+			if res, err := clear.Do(context.Background(), ec.client); err == nil {
+				res.Body.Close()
+			}
+		}
+	}()
+	for {
+		var page struct {
+			ScrollID string `json:"_scroll_id"`
+			Hits     struct {
+				Hits []struct {
+					ID string `json:"_id"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		err := decodeScanPage(res, &page)
+		if err != nil {
+			return err
+		}
+		scrollID = page.ScrollID
+		if len(page.Hits.Hits) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(page.Hits.Hits))
+		for i, hit := range page.Hits.Hits {
+			ids[i] = hit.ID
+		}
+		if err := fn(ids); err != nil {
+			return err
+		}
+
+		// The scroll ID is sent in the body: it can be too long for a URL.
+		res, err = esapi.ScrollRequest{
+			Body:   strings.NewReader(fmt.Sprintf(`{"scroll_id": %q}`, scrollID)),
+			Scroll: scanKeepAlive,
+		}.Do(ctx, ec.client)
+		if err != nil {
+			return fmt.Errorf("error performing scroll request: %w", err)
+		}
+	}
+}
+
+// scanKeepAlive is how long Elasticsearch keeps the scroll of ScanDocumentIDs between two pages.
+const scanKeepAlive = 2 * time.Minute
+
+func decodeScanPage(res *esapi.Response, page interface{}) error {
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("error response from Elasticsearch while scanning: %s", res.String())
+	}
+	if err := json.NewDecoder(res.Body).Decode(page); err != nil {
+		return fmt.Errorf("error decoding response body: %w", err)
+	}
+	return nil
+}
+
+func (ec *ElasticsearchClient) PutResumeMapping(ctx context.Context, indexName string) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "put_mapping", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.PutResumeMapping", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
+	body, err := json.Marshal(ResumeMapping)
+	if err != nil {
+		return fmt.Errorf("error marshaling mapping: %w", err)
+	}
+
+	req := esapi.IndicesPutMappingRequest{
+		Index: []string{indexName},
+		Body:  bytes.NewReader(body),
+	}
+	res, err := req.Do(ctx, ec.client)
+	if err != nil {
+		return fmt.Errorf("error putting mapping: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusBadRequest {
+		return fmt.Errorf("%w: %s", ErrMappingRejected, res.String())
+	}
+	if res.IsError() {
+		return fmt.Errorf("error response from Elasticsearch while putting mapping: %s", res.String())
+	}
+	return nil
+}
+
+func (ec *ElasticsearchClient) ReindexResumes(ctx context.Context, alias, indexName string, replaceIndex bool) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "reindex", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.ReindexResumes",
+		attribute.String("db.elasticsearch.index", alias), attribute.String("db.elasticsearch.target_index", indexName))
+	defer tracing.End(span, &err)
+
+	var aliased map[string]interface{}
+	switch err := ec.do(ctx, esapi.IndicesGetAliasRequest{Name: []string{alias}}, &aliased); {
+	case errors.Is(err, errNotFound):
+		if !replaceIndex {
+			return fmt.Errorf("%s is an index, not an alias: it must be replaced by the alias", alias)
+		}
+	case err != nil:
+		return fmt.Errorf("error getting alias %s: %w", alias, err)
+	}
+
+	var mappings map[string]struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := ec.do(ctx, esapi.IndicesGetMappingRequest{Index: []string{alias}}, &mappings); err != nil {
+		return fmt.Errorf("error getting the mapping of %s: %w", alias, err)
+	}
+	if len(mappings) != 1 {
+		return fmt.Errorf("%s points at %d indices, expected one", alias, len(mappings))
+	}
+	var current string
+	var mapping map[string]interface{}
+	for name, index := range mappings {
+		current, mapping = name, index.Mappings
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"mappings": indexMapping(mapping)})
+	if err != nil {
+		return fmt.Errorf("error marshaling mapping: %w", err)
+	}
+	if err := ec.do(ctx, esapi.IndicesCreateRequest{Index: indexName, Body: bytes.NewReader(body)}, nil); err != nil {
+		return fmt.Errorf("error creating index %s: %w", indexName, err)
+	}
+	if err := ec.do(ctx, esapi.IndicesAddBlockRequest{Index: []string{current}, Block: "write"}, nil); err != nil {
+		return fmt.Errorf("error making %s read-only: %w", current, err)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// The documents stay where they were: let them be changed again.
+		unblock := esapi.IndicesPutSettingsRequest{Index: []string{current}, Body: strings.NewReader(`{"index.blocks.write": false}`)}
+		if unblockErr := ec.do(context.Background(), unblock, nil); unblockErr != nil {
+			err = fmt.Errorf("%w (and %s is still read-only: %v)", err, current, unblockErr)
+		}
+	}()
+
+	wait, refresh := true, true
+	var copied struct {
+		Total    int               `json:"total"`
+		Failures []json.RawMessage `json:"failures"`
+	}
+	body = []byte(fmt.Sprintf(`{"source": {"index": %q}, "dest": {"index": %q}}`, current, indexName))
+	reindex := esapi.ReindexRequest{Body: bytes.NewReader(body), WaitForCompletion: &wait, Refresh: &refresh}
+	if err := ec.do(ctx, reindex, &copied); err != nil {
+		return fmt.Errorf("error copying the documents of %s: %w", current, err)
+	}
+	if len(copied.Failures) > 0 {
+		return fmt.Errorf("%d of %d documents of %s could not be copied, first: %s", len(copied.Failures), copied.Total, current, copied.Failures[0])
+	}
+
+	// Both actions are applied at once: alias never points at no index or at both.
+	actions := []map[string]interface{}{{"add": map[string]string{"index": indexName, "alias": alias}}}
+	if aliased == nil {
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]string{"index": alias}})
+	} else {
+		actions = append(actions, map[string]interface{}{"remove": map[string]string{"index": current, "alias": alias}})
+	}
+	body, err = json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("error marshaling alias actions: %w", err)
+	}
+	if err := ec.do(ctx, esapi.IndicesUpdateAliasesRequest{Body: bytes.NewReader(body)}, nil); err != nil {
+		return fmt.Errorf("error pointing %s at %s: %w", alias, indexName, err)
+	}
+	return nil
+}
+
+// errNotFound is returned by do for a 404 response.
+var errNotFound = errors.New("not found")
+
+// do performs an index administration request and decodes its response into result, unless nil.
+func (ec *ElasticsearchClient) do(ctx context.Context, req esapi.Request, result interface{}) error {
+	res, err := req.Do(ctx, ec.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return errNotFound
+	case res.IsError():
+		return fmt.Errorf("error response from Elasticsearch: %s", res.String())
+	case result == nil:
+		return nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(result); err != nil {
+		return fmt.Errorf("error decoding response body: %w", err)
+	}
+	return nil
+}
+
+func (ec *ElasticsearchClient) DeleteDocumentByID(ctx context.Context, indexName, documentID string) (err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "delete_document", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.DeleteDocumentByID", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
 	// Create the Delete request to Elasticsearch
 	req := esapi.DeleteRequest{
 		Index:      indexName,
@@ -206,14 +566,21 @@ func (ec *ElasticsearchClient) DeleteDocumentByID(ctx context.Context, indexName
 	}
 	defer res.Body.Close() // Ensure body is closed after the operation
 
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: %s", ErrDocumentNotFound, documentID)
+	}
 	if res.IsError() {
-		return fmt.Errorf("error response from Elasticsearch while deleting document: %s", res.String())
+		return writeError(res)
 	}
 
 	return nil
 }
 
-func (ec *ElasticsearchClient) KeywordSearch(ctx context.Context, indexName string, query string) ([]ResumeSummaryDTO, error) {
+func (ec *ElasticsearchClient) KeywordSearch(ctx context.Context, indexName string, query string) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "keyword_search", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.KeywordSearch", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
 	res, err := ec.client.Search().
 		Index(indexName).
 		Query(&types.Query{
@@ -230,7 +597,11 @@ func (ec *ElasticsearchClient) KeywordSearch(ctx context.Context, indexName stri
 	return ConvertHitsToElasticResponses(res.Hits.Hits)
 }
 
-func (ec *ElasticsearchClient) VectorSearch(ctx context.Context, indexName string, vector []float32) ([]ResumeSummaryDTO, error) {
+func (ec *ElasticsearchClient) VectorSearch(ctx context.Context, indexName string, vector []float32) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "vector_search", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.VectorSearch", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
 	res, err := ec.client.Search().
 		Index(indexName).
 		Knn(types.KnnQuery{
@@ -247,8 +618,13 @@ func (ec *ElasticsearchClient) VectorSearch(ctx context.Context, indexName strin
 	return ConvertHitsToElasticResponses(res.Hits.Hits)
 }
 
-// HybridSearchWithBoost perform search combining both semantic and lexiacal search
-func (ec *ElasticsearchClient) HybridSearchWithBoost(ctx context.Context, indexName, query string, queryVector []float32, from, size int, knnBoost float32) ([]ResumeSummaryDTO, error) {
+// HybridSearchWithBoost perform search combining both semantic and lexiacal search.
+// A non-empty filter restricts the kNN candidates, and a sort other than relevance orders them.
+func (ec *ElasticsearchClient) HybridSearchWithBoost(ctx context.Context, indexName, query string, queryVector []float32, from, size int, knnBoost float32, filter *SearchFilter, sort *SearchSort) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "hybrid_search", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "elasticsearch.HybridSearchWithBoost", attribute.String("db.elasticsearch.index", indexName))
+	defer tracing.End(span, &err)
+
 	res, err := ec.client.Search().
 		Index(indexName).
 		From(from).
@@ -258,7 +634,9 @@ func (ec *ElasticsearchClient) HybridSearchWithBoost(ctx context.Context, indexN
 			QueryVector:   queryVector,
 			K:             150,
 			NumCandidates: 200,
+			Filter:        filter.queries(),
 		}).
+		Sort(sort.options()...).
 		Do(ctx)
 
 	if err != nil {
@@ -0,0 +1,130 @@
+package elasticsearch_test
+
+import (
+	"CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/elasticsearch"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dynamicMapping is the mapping of an index whose experience lists and role dates were mapped
+// dynamically before ResumeMapping was put.
+const dynamicMapping = `{"resumes-v1": {"mappings": {"dynamic": "true", "properties": {
+	"embedding": {"type": "dense_vector", "dims": 3},
+	"content": {"properties": {
+		"summary": {"type": "text"},
+		"skill_experience": {"properties": {"name": {"type": "text"}, "years": {"type": "float"}}},
+		"work_experience": {"properties": {"start_date": {"type": "text"}}}
+	}}
+}}}}`
+
+// reindexCluster answers the requests of ReindexResumes and records those that change the cluster.
+type reindexCluster struct {
+	aliased bool
+	changes map[string]map[string]interface{}
+}
+
+func (_this *reindexCluster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	body, _ := io.ReadAll(r.Body)
+	if len(body) > 0 {
+		var decoded map[string]interface{}
+		_ = json.Unmarshal(body, &decoded)
+		_this.changes[r.Method+" "+r.URL.Path] = decoded
+	} else if r.Method != http.MethodGet {
+		_this.changes[r.Method+" "+r.URL.Path] = nil
+	}
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /_alias/resumes":
+		if !_this.aliased {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"error": "alias [resumes] missing", "status": 404}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"resumes-v1": {"aliases": {"resumes": {}}}}`)
+	case "GET /resumes/_mapping":
+		_, _ = io.WriteString(w, dynamicMapping)
+	case "POST /_reindex":
+		_, _ = io.WriteString(w, `{"total": 2, "created": 2, "failures": []}`)
+	default:
+		_, _ = io.WriteString(w, `{"acknowledged": true}`)
+	}
+}
+
+func newReindexClient(t *testing.T, cluster *reindexCluster) elasticsearch.IElasticsearchClient {
+	server := httptest.NewServer(cluster)
+	t.Cleanup(server.Close)
+	config := viper.New()
+	config.Set(cfg.ElasticsearchUrl, server.URL)
+	client, err := elasticsearch.NewElasticsearchClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+func TestElasticsearchClient_ReindexResumesMovesTheAlias(t *testing.T) {
+	cluster := &reindexCluster{aliased: true, changes: map[string]map[string]interface{}{}}
+	client := newReindexClient(t, cluster)
+
+	require.NoError(t, client.ReindexResumes(context.Background(), "resumes", "resumes-v2", false))
+
+	// The new index keeps the fields ResumeMapping does not define and takes its types for the others.
+	created := cluster.changes["PUT /resumes-v2"]["mappings"].(map[string]interface{})
+	assert.Equal(t, "true", created["dynamic"])
+	properties := created["properties"].(map[string]interface{})
+	assert.Equal(t, "dense_vector", properties["embedding"].(map[string]interface{})["type"])
+	content := properties["content"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, "text", content["summary"].(map[string]interface{})["type"])
+	assert.Equal(t, "nested", content["skill_experience"].(map[string]interface{})["type"])
+	work := content["work_experience"].(map[string]interface{})["properties"].(map[string]interface{})
+	assert.Equal(t, "date", work["start_date"].(map[string]interface{})["type"])
+
+	assert.Contains(t, cluster.changes, "PUT /resumes-v1/_block/write")
+	assert.Equal(t, map[string]interface{}{"index": "resumes-v1"}, cluster.changes["POST /_reindex"]["source"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"add": map[string]interface{}{"index": "resumes-v2", "alias": "resumes"}},
+		map[string]interface{}{"remove": map[string]interface{}{"index": "resumes-v1", "alias": "resumes"}},
+	}, cluster.changes["POST /_aliases"]["actions"])
+}
+
+func TestElasticsearchClient_ReindexResumesReplacesAnIndexOnlyWhenAsked(t *testing.T) {
+	cluster := &reindexCluster{changes: map[string]map[string]interface{}{}}
+	client := newReindexClient(t, cluster)
+
+	assert.Error(t, client.ReindexResumes(context.Background(), "resumes", "resumes-v2", false))
+	assert.Empty(t, cluster.changes)
+
+	require.NoError(t, client.ReindexResumes(context.Background(), "resumes", "resumes-v2", true))
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"add": map[string]interface{}{"index": "resumes-v2", "alias": "resumes"}},
+		map[string]interface{}{"remove_index": map[string]interface{}{"index": "resumes"}},
+	}, cluster.changes["POST /_aliases"]["actions"])
+}
+
+func TestElasticsearchClient_WritesToABlockedIndex(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = io.WriteString(w, `{"error": {"type": "cluster_block_exception", "reason": "index [resumes-v1] blocked by: [FORBIDDEN/8/index write (api)];"}, "status": 403}`)
+	}))
+	t.Cleanup(server.Close)
+	config := viper.New()
+	config.Set(cfg.ElasticsearchUrl, server.URL)
+	client, err := elasticsearch.NewElasticsearchClient(config)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.ErrorIs(t, client.IndexDocument(ctx, "resumes", "a", map[string]string{}), elasticsearch.ErrIndexBlocked)
+	assert.ErrorIs(t, client.UpdateDocument(ctx, "resumes", "a", map[string]interface{}{}), elasticsearch.ErrIndexBlocked)
+	assert.ErrorIs(t, client.DeleteDocumentByID(ctx, "resumes", "a"), elasticsearch.ErrIndexBlocked)
+}
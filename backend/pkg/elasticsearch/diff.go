@@ -0,0 +1,188 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FieldChange is a difference between two versions of a resume. Scalar fields and list entries
+// edited in place have Before and After; lists have the entries Added and Removed.
+type FieldChange struct {
+	Field   string      `json:"field"`
+	Before  interface{} `json:"before,omitempty"`
+	After   interface{} `json:"after,omitempty"`
+	Added   []string    `json:"added,omitempty"`
+	Removed []string    `json:"removed,omitempty"`
+}
+
+// DiffResumes lists what changed from one version of a resume to another. List entries are
+// matched case-insensitively: skills, majors and contacts by value, experience by title, company
+// and period, education by degree and institution, languages, certifications, projects and
+// awards by name.
+func DiffResumes(from, to ResumeSummaryDTO) []FieldChange {
+	changes := make([]FieldChange, 0)
+	scalar := func(field string, before, after string) {
+		if strings.TrimSpace(before) != strings.TrimSpace(after) {
+			changes = append(changes, FieldChange{Field: field, Before: before, After: after})
+		}
+	}
+
+	scalar("summary", from.Summary, to.Summary)
+	scalar("basic_info.full_name", from.BasicInfo.FullName, to.BasicInfo.FullName)
+	scalar("basic_info.university", from.BasicInfo.University, to.BasicInfo.University)
+	scalar("basic_info.education_level", from.BasicInfo.EducationLevel, to.BasicInfo.EducationLevel)
+	if !sameGPA(from.BasicInfo.GPA, to.BasicInfo.GPA) {
+		change := FieldChange{Field: "basic_info.gpa"}
+		if from.BasicInfo.GPA != nil {
+			change.Before = *from.BasicInfo.GPA
+		}
+		if to.BasicInfo.GPA != nil {
+			change.After = *to.BasicInfo.GPA
+		}
+		changes = append(changes, change)
+	}
+	changes = appendListChange(changes, "basic_info.majors", keyed(from.BasicInfo.Majors), keyed(to.BasicInfo.Majors))
+	changes = appendListChange(changes, "skills", keyed(from.Skills), keyed(to.Skills))
+
+	changes = appendListChange(changes, "contacts.emails", keyed(from.Contacts.Emails), keyed(to.Contacts.Emails))
+	changes = appendListChange(changes, "contacts.phones", keyed(from.Contacts.Phones), keyed(to.Contacts.Phones))
+	links := func(links []Link) []entry {
+		entries := make([]entry, len(links))
+		for i, link := range links {
+			entries[i] = entry{label: link.URL, value: link.Kind}
+		}
+		return entries
+	}
+	changes = appendListChange(changes, "contacts.links", links(from.Contacts.Links), links(to.Contacts.Links))
+	education := func(education []Education) []entry {
+		entries := make([]entry, len(education))
+		for i, degree := range education {
+			// Entries are compared as text: majors, GPA and year are not comparable as values.
+			value := fmt.Sprintf("%s; GPA %s; %s", strings.Join(degree.Majors, ", "), formatFloat(degree.GPA), formatInt(degree.GraduationYear))
+			entries[i] = entry{label: strings.TrimSpace(degree.Degree + " at " + degree.Institution), value: value}
+		}
+		return entries
+	}
+	changes = appendListChange(changes, "education", education(from.Education), education(to.Education))
+	languages := func(languages []Language) []entry {
+		entries := make([]entry, len(languages))
+		for i, language := range languages {
+			entries[i] = entry{label: language.Language, value: language.Proficiency}
+		}
+		return entries
+	}
+	changes = appendListChange(changes, "languages", languages(from.Languages), languages(to.Languages))
+	certifications := func(certifications []Certification) []entry {
+		entries := make([]entry, len(certifications))
+		for i, certification := range certifications {
+			entries[i] = entry{label: certification.Name, value: fmt.Sprintf("%s (%s)", certification.Issuer, formatInt(certification.Year))}
+		}
+		return entries
+	}
+	changes = appendListChange(changes, "certifications", certifications(from.Certifications), certifications(to.Certifications))
+
+	changes = appendListChange(changes, "work_experience", workEntries(from.WorkExperience), workEntries(to.WorkExperience))
+	projects := func(projects []ProjectExperience) []entry {
+		entries := make([]entry, len(projects))
+		for i, project := range projects {
+			entries[i] = entry{label: project.ProjectName, value: project}
+		}
+		return entries
+	}
+	changes = appendListChange(changes, "project_experience", projects(from.ProjectExperience), projects(to.ProjectExperience))
+	awards := func(awards []Award) []entry {
+		entries := make([]entry, len(awards))
+		for i, award := range awards {
+			entries[i] = entry{label: award.AwardName, value: award}
+		}
+		return entries
+	}
+	changes = appendListChange(changes, "award", awards(from.Award), awards(to.Award))
+
+	scalar("url", from.URL, to.URL)
+	return changes
+}
+
+// entry is a list item identified by its label.
+type entry struct {
+	label string
+	value interface{}
+}
+
+func keyed(values []string) []entry {
+	entries := make([]entry, len(values))
+	for i, value := range values {
+		// Only the case of a value changing is not an edit.
+		entries[i] = entry{label: value, value: strings.ToLower(strings.TrimSpace(value))}
+	}
+	return entries
+}
+
+func workEntries(works []WorkExperience) []entry {
+	entries := make([]entry, len(works))
+	for i, work := range works {
+		label := strings.TrimSpace(fmt.Sprintf("%s at %s", work.JobTitle, work.Company))
+		if period := WorkPeriod(work); period != "" {
+			label += " (" + period + ")"
+		}
+		entries[i] = entry{label: label, value: work}
+	}
+	return entries
+}
+
+// appendListChange adds the entries added to or removed from a list, and one change per entry
+// whose other fields were edited.
+func appendListChange(changes []FieldChange, field string, before, after []entry) []FieldChange {
+	beforeByKey := make(map[string]entry, len(before))
+	for _, item := range before {
+		beforeByKey[strings.ToLower(strings.TrimSpace(item.label))] = item
+	}
+	afterByKey := make(map[string]entry, len(after))
+	for _, item := range after {
+		afterByKey[strings.ToLower(strings.TrimSpace(item.label))] = item
+	}
+
+	list := FieldChange{Field: field}
+	var edited []FieldChange
+	for _, item := range after {
+		previous, found := beforeByKey[strings.ToLower(strings.TrimSpace(item.label))]
+		switch {
+		case !found:
+			list.Added = append(list.Added, item.label)
+		case !reflect.DeepEqual(previous.value, item.value):
+			edited = append(edited, FieldChange{Field: field + ": " + item.label, Before: previous.value, After: item.value})
+		}
+	}
+	for _, item := range before {
+		if _, found := afterByKey[strings.ToLower(strings.TrimSpace(item.label))]; !found {
+			list.Removed = append(list.Removed, item.label)
+		}
+	}
+
+	if len(list.Added) > 0 || len(list.Removed) > 0 {
+		changes = append(changes, list)
+	}
+	return append(changes, edited...)
+}
+
+func formatFloat(f *float64) string {
+	if f == nil {
+		return "N/A"
+	}
+	return fmt.Sprintf("%.2f", *f)
+}
+
+func formatInt(n *int) string {
+	if n == nil {
+		return "N/A"
+	}
+	return fmt.Sprint(*n)
+}
+
+func sameGPA(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
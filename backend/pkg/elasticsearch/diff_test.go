@@ -0,0 +1,77 @@
+package elasticsearch_test
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffResumes(t *testing.T) {
+	gpa := 3.4
+	from := elasticsearch.ResumeSummaryDTO{
+		Summary:   "Backend engineer",
+		Skills:    []string{"Go", "MySQL"},
+		BasicInfo: elasticsearch.BasicInfo{FullName: "Alice Nguyen", University: "HUST", GPA: &gpa},
+		WorkExperience: []elasticsearch.WorkExperience{
+			{JobTitle: "Engineer", Company: "Acme", Duration: "2020-2022", JobSummary: "APIs"},
+			{JobTitle: "Intern", Company: "Beta", Duration: "2019"},
+		},
+		Award: []elasticsearch.Award{{AwardName: "Hackathon winner"}},
+	}
+	to := from
+	to.Summary = "Senior backend engineer"
+	to.Skills = []string{"go", "Kubernetes"}
+	to.BasicInfo.GPA = nil
+	to.WorkExperience = []elasticsearch.WorkExperience{
+		{JobTitle: "Engineer", Company: "Acme", Duration: "2020-2022", JobSummary: "APIs and billing"},
+		{JobTitle: "Senior Engineer", Company: "Gamma", Duration: "2022-"},
+	}
+
+	changes := elasticsearch.DiffResumes(from, to)
+	assert.Equal(t, []elasticsearch.FieldChange{
+		{Field: "summary", Before: "Backend engineer", After: "Senior backend engineer"},
+		{Field: "basic_info.gpa", Before: 3.4},
+		{Field: "skills", Added: []string{"Kubernetes"}, Removed: []string{"MySQL"}},
+		{Field: "work_experience", Added: []string{"Senior Engineer at Gamma (2022-)"}, Removed: []string{"Intern at Beta (2019)"}},
+		{Field: "work_experience: Engineer at Acme (2020-2022)", Before: from.WorkExperience[0], After: to.WorkExperience[0]},
+	}, changes)
+
+	assert.Empty(t, elasticsearch.DiffResumes(from, from))
+}
+
+func TestDiffResumesProfile(t *testing.T) {
+	year := 2020
+	from := elasticsearch.ResumeSummaryDTO{
+		Contacts: elasticsearch.Contacts{Emails: []string{"alice@example.com"}},
+		Education: []elasticsearch.Education{
+			{Institution: "HUST", Degree: "BS", Majors: []string{"Computer Science"}, GraduationYear: &year},
+		},
+		Languages: []elasticsearch.Language{{Language: "English", Proficiency: elasticsearch.ProficiencyIntermediate}},
+	}
+	to := from
+	to.Contacts = elasticsearch.Contacts{
+		Emails: []string{"alice@example.com"},
+		Phones: []string{"+84912345678"},
+	}
+	to.Education = []elasticsearch.Education{
+		{Institution: "HUST", Degree: "BS", Majors: []string{"Computer Science"}, GraduationYear: &year},
+		{Institution: "NUS", Degree: "MS", Majors: []string{"Data Science"}},
+	}
+	to.Languages = []elasticsearch.Language{{Language: "english", Proficiency: elasticsearch.ProficiencyFluent}}
+
+	changes := elasticsearch.DiffResumes(from, to)
+	assert.Equal(t, []elasticsearch.FieldChange{
+		{Field: "contacts.phones", Added: []string{"+84912345678"}},
+		{Field: "education", Added: []string{"MS at NUS"}},
+		{Field: "languages: english", Before: elasticsearch.ProficiencyIntermediate, After: elasticsearch.ProficiencyFluent},
+	}, changes)
+
+	// Equal entries held at different addresses are not changes.
+	otherYear := 2020
+	same := from
+	same.Education = []elasticsearch.Education{
+		{Institution: "HUST", Degree: "BS", Majors: []string{"Computer Science"}, GraduationYear: &otherYear},
+	}
+	assert.Empty(t, elasticsearch.DiffResumes(from, same))
+}
@@ -1,5 +1,10 @@
 package elasticsearch
 
+// ResumeSchemaVersion is the version of ResumeSummaryDTO that new content is produced with. Version
+// 2 added contacts, the education list, certifications and languages, version 3 the dates of
+// roles and the years of experience.
+const ResumeSchemaVersion = 3
+
 type ElkResumeDTO struct {
 	Content   ResumeSummaryDTO `json:"content"`
 	Embedding []float32        `json:"embedding"`
@@ -10,11 +15,33 @@ type ResumeSummaryDTO struct {
 	Summary           string              `json:"summary"`
 	Skills            []string            `json:"skills"`
 	BasicInfo         BasicInfo           `json:"basic_info"`
+	BasicInfoEvidence *BasicInfoEvidence  `json:"basic_info_evidence,omitempty"`
+	Contacts          Contacts            `json:"contacts"`
+	Education         []Education         `json:"education"`
+	Certifications    []Certification     `json:"certifications"`
+	Languages         []Language          `json:"languages"`
 	WorkExperience    []WorkExperience    `json:"work_experience"`
 	ProjectExperience []ProjectExperience `json:"project_experience"`
 	Award             []Award             `json:"award"`
 	URL               string              `json:"url"`
 	Point             float64             `json:"point"`
+	// YearsOfExperience, SkillExperience and TitleExperience are computed from WorkExperience
+	// when the resume is indexed (see ComputeExperience).
+	YearsOfExperience float64           `json:"years_of_experience"`
+	SkillExperience   []ExperienceYears `json:"skill_experience"`
+	TitleExperience   []ExperienceYears `json:"title_experience"`
+	// SchemaVersion is the version of this structure the content was produced with; documents
+	// below ResumeSchemaVersion lack the sections added since.
+	SchemaVersion int `json:"schema_version,omitempty"`
+	// PromptVersion is the version of the prompt the resume was parsed with.
+	PromptVersion string `json:"prompt_version,omitempty"`
+	// ParseModel and EmbeddingModel are the models that produced the content and the embedding.
+	ParseModel     string `json:"parse_model,omitempty"`
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+	// CandidateID is the candidate the resume is a version of. Superseded marks versions replaced
+	// by a newer one; searches skip them unless the filter asks for all versions.
+	CandidateID int64 `json:"candidate_id,omitempty"`
+	Superseded  bool  `json:"superseded,omitempty"`
 }
 
 type BasicInfo struct {
@@ -22,15 +49,74 @@ type BasicInfo struct {
 	University     string   `json:"university"`
 	EducationLevel string   `json:"education_level"` // BS, MS, or PhD
 	Majors         []string `json:"majors"`
-	GPA            float64  `json:"gpa"`
+	GPA            *float64 `json:"gpa"` // nil when the resume does not state it
+}
+
+// BasicInfoEvidence records where each BasicInfo value was found in the resume text. A nil entry
+// means the value is empty or was not extracted with evidence.
+type BasicInfoEvidence struct {
+	FullName       *FieldEvidence `json:"full_name,omitempty"`
+	University     *FieldEvidence `json:"university,omitempty"`
+	EducationLevel *FieldEvidence `json:"education_level,omitempty"`
+	Majors         *FieldEvidence `json:"majors,omitempty"`
+	GPA            *FieldEvidence `json:"gpa,omitempty"`
+}
+
+// FieldEvidence is the model's confidence in a value and the span of resume text supporting it.
+// Start and End are byte offsets of Source in the extracted resume text.
+type FieldEvidence struct {
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+}
+
+// Contacts are the ways to reach the candidate stated in the resume. E-mail addresses are
+// lower-cased, phone numbers keep their digits and a leading + only.
+type Contacts struct {
+	Emails []string `json:"emails"`
+	Phones []string `json:"phones"`
+	// Links are the candidate's profiles and personal pages.
+	Links []Link `json:"links"`
+}
+
+type Link struct {
+	Kind string `json:"kind"` // one of the LinkKind values
+	URL  string `json:"url"`
+}
+
+// Education is one degree or program. BasicInfo holds the highest of them.
+type Education struct {
+	Institution    string   `json:"institution"`
+	Degree         string   `json:"degree"` // BS, MS, PhD, or the stated degree
+	Majors         []string `json:"majors"`
+	GPA            *float64 `json:"gpa"`             // nil when the resume does not state it
+	GraduationYear *int     `json:"graduation_year"` // nil when unknown; may be expected for ongoing studies
+}
+
+type Certification struct {
+	Name   string `json:"name"`
+	Issuer string `json:"issuer"`
+	Year   *int   `json:"year"` // nil when unknown
+}
+
+type Language struct {
+	Language    string `json:"language"`
+	Proficiency string `json:"proficiency"` // one of the Proficiency values, empty when not stated
 }
 
 type WorkExperience struct {
 	JobTitle   string `json:"job_title"`
 	Company    string `json:"company"`
 	Location   string `json:"location"`
-	Duration   string `json:"duration"` // Could be changed to a more structured format if necessary
+	Duration   string `json:"duration"` // as stated in the resume
 	JobSummary string `json:"job_summary"`
+	// StartDate and EndDate are YYYY-MM, or YYYY when the month is not stated, and empty when
+	// unknown (left out of the document, which maps them as dates). A Current role has no EndDate.
+	StartDate string   `json:"start_date,omitempty"`
+	EndDate   string   `json:"end_date,omitempty"`
+	Current   bool     `json:"current"`
+	Skills    []string `json:"skills"` // skills used in the role
 }
 
 type ProjectExperience struct {
@@ -0,0 +1,296 @@
+package elasticsearch
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minWorkYear is the earliest year of a role kept; earlier years are misread dates.
+const minWorkYear = 1950
+
+// ExperienceYears is the time spent in roles with a skill or title.
+type ExperienceYears struct {
+	Name  string  `json:"name"`
+	Years float64 `json:"years"`
+}
+
+var (
+	monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+	// workDatePattern finds the dates of a period such as "Jan 2020 - Present", "03/2018 – 05/2020",
+	// "2020-01 to 2021-06" or "2019-2021". Month-year forms come before bare years so that the
+	// month is kept.
+	workDatePattern = regexp.MustCompile(`(?i)\b(?:` +
+		`(jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.?,?\s*((?:19|20)\d\d)` +
+		`|(0?[1-9]|1[0-2])[/.]((?:19|20)\d\d)` +
+		`|((?:19|20)\d\d)[-/.](0?[1-9]|1[0-2])\b` +
+		`|((?:19|20)\d\d)` +
+		`|(present|current|now|today|ongoing)` +
+		`)\b`)
+	durationYearsPattern  = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*\+?\s*(?:years?|yrs?)\b`)
+	durationMonthsPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:months?|mos?)\b`)
+)
+
+// seniorityWords are left out of titles, so that a promotion does not start a new title.
+var seniorityWords = map[string]bool{
+	"senior": true, "sr": true, "junior": true, "jr": true, "principal": true, "intern": true,
+	"trainee": true, "fresher": true, "mid": true, "mid-level": true, "i": true, "ii": true, "iii": true,
+	"iv": true,
+}
+
+// workDate is a month of a period, or the present.
+type workDate struct {
+	year, month int // month is 0 when only the year is known
+	present     bool
+}
+
+func (d workDate) String() string {
+	if d.month == 0 {
+		return strconv.Itoa(d.year)
+	}
+	return time.Date(d.year, time.Month(d.month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+}
+
+// index counts months from year 0. A year alone stands for its January.
+func (d workDate) index() int {
+	month := d.month
+	if month == 0 {
+		month = 1
+	}
+	return d.year*12 + month - 1
+}
+
+// findWorkDates returns the dates in text, in order.
+func findWorkDates(text string) []workDate {
+	var dates []workDate
+	for _, match := range workDatePattern.FindAllStringSubmatch(text, -1) {
+		var date workDate
+		switch {
+		case match[1] != "":
+			date.year, _ = strconv.Atoi(match[2])
+			for i, name := range monthNames {
+				if strings.EqualFold(match[1], name) {
+					date.month = i + 1
+				}
+			}
+		case match[3] != "":
+			date.month, _ = strconv.Atoi(match[3])
+			date.year, _ = strconv.Atoi(match[4])
+		case match[5] != "":
+			date.year, _ = strconv.Atoi(match[5])
+			date.month, _ = strconv.Atoi(match[6])
+		case match[7] != "":
+			date.year, _ = strconv.Atoi(match[7])
+		default:
+			date.present = true
+		}
+		dates = append(dates, date)
+	}
+	return dates
+}
+
+// parseWorkDate reads a single date, as the model states start and end dates.
+func parseWorkDate(text string) (workDate, bool) {
+	dates := findWorkDates(text)
+	if len(dates) != 1 {
+		return workDate{}, false
+	}
+	return dates[0], true
+}
+
+// NormalizeWorkDates rewrites the start and end dates of a role as YYYY-MM, or YYYY when the month
+// is not known, and sets Current for a role that has not ended. Dates the model left out are read
+// from Duration when it states a period ("Jan 2020 - Present"); dates that cannot be read, or that
+// lie in the future, are cleared.
+func NormalizeWorkDates(work *WorkExperience, now time.Time) {
+	start, startOK := parseWorkDate(work.StartDate)
+	end, endOK := parseWorkDate(work.EndDate)
+	if dates := findWorkDates(work.Duration); len(dates) == 2 {
+		if !startOK {
+			start, startOK = dates[0], true
+		}
+		if !endOK && !work.Current {
+			end, endOK = dates[1], true
+		}
+	}
+
+	nowDate := workDate{year: now.Year(), month: int(now.Month())}
+	valid := func(date workDate) bool {
+		return !date.present && date.year >= minWorkYear && date.index() <= nowDate.index()
+	}
+	if !startOK || !valid(start) {
+		startOK = false
+	}
+	switch {
+	case endOK && end.present:
+		work.Current, endOK = true, false
+	case endOK && !valid(end):
+		// An end in the future is an expected end: the role is ongoing.
+		work.Current = work.Current || end.index() > nowDate.index()
+		endOK = false
+	case endOK:
+		work.Current = false
+	}
+	if startOK && endOK && end.index() < start.index() {
+		endOK = false
+	}
+
+	work.StartDate, work.EndDate = "", ""
+	if startOK {
+		work.StartDate = start.String()
+	}
+	if endOK {
+		work.EndDate = end.String()
+	}
+}
+
+// durationMonths reads the length of a role from text such as "2 years 3 months" or "6 months".
+func durationMonths(text string) int {
+	months := 0.0
+	if match := durationYearsPattern.FindStringSubmatch(text); match != nil {
+		years, _ := strconv.ParseFloat(strings.Replace(match[1], ",", ".", 1), 64)
+		months += years * 12
+	}
+	if match := durationMonthsPattern.FindStringSubmatch(text); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		months += float64(n)
+	}
+	return int(math.Round(months))
+}
+
+// ExperienceTitle normalizes a job title for TitleExperience: lower-cased, without punctuation
+// and seniority words, unless the title is only those ("Intern").
+func ExperienceTitle(title string) string {
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '.' || r == '(' || r == ')' || r == '/' || r == '|'
+	})
+	var kept []string
+	for _, word := range words {
+		if !seniorityWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		kept = words
+	}
+	return strings.Join(kept, " ")
+}
+
+// ExperienceSkill normalizes a skill name for SkillExperience.
+func ExperienceSkill(skill string) string {
+	return strings.ToLower(strings.TrimSpace(skill))
+}
+
+// experienceSpan is the time spent in a set of roles: the months of their periods, and the
+// length of roles known only by their duration.
+type experienceSpan struct {
+	months map[int]bool
+	extra  int
+}
+
+func (s *experienceSpan) add(from, to, length int) {
+	if from < 0 {
+		s.extra += length
+		return
+	}
+	if s.months == nil {
+		s.months = map[int]bool{}
+	}
+	for month := from; month <= to; month++ {
+		s.months[month] = true
+	}
+}
+
+func (s *experienceSpan) years() float64 {
+	return math.Round(float64(len(s.months)+s.extra)/12*10) / 10
+}
+
+// ComputeExperience sets YearsOfExperience, SkillExperience and TitleExperience from the work
+// experience of a resume, as of now. Overlapping roles are counted once. A role counts from its
+// start month to its end month, or to now while current; a role without dates counts for its
+// duration. A role has the skills it lists and the skills of the resume named in its title or
+// summary. Years are rounded to one decimal, and skills and titles are sorted by years.
+func ComputeExperience(resume *ResumeSummaryDTO, now time.Time) {
+	nowIndex := workDate{year: now.Year(), month: int(now.Month())}.index()
+	var total experienceSpan
+	bySkill := map[string]*experienceSpan{}
+	byTitle := map[string]*experienceSpan{}
+	add := func(spans map[string]*experienceSpan, name string, from, to, length int) {
+		if name == "" {
+			return
+		}
+		if spans[name] == nil {
+			spans[name] = &experienceSpan{}
+		}
+		spans[name].add(from, to, length)
+	}
+
+	for _, work := range resume.WorkExperience {
+		from, to, length := -1, -1, 0
+		start, startOK := parseWorkDate(work.StartDate)
+		end, endOK := parseWorkDate(work.EndDate)
+		switch {
+		case startOK && work.Current:
+			from, to = start.index(), nowIndex
+		case startOK && endOK:
+			from, to = start.index(), min(end.index(), nowIndex)
+		case startOK:
+			if length = durationMonths(work.Duration); length > 0 {
+				from, to = start.index(), min(start.index()+length-1, nowIndex)
+			}
+		default:
+			length = durationMonths(work.Duration)
+		}
+		if from < 0 && length <= 0 {
+			continue
+		}
+
+		total.add(from, to, length)
+		add(byTitle, ExperienceTitle(work.JobTitle), from, to, length)
+		skills := map[string]bool{}
+		for _, skill := range work.Skills {
+			skills[ExperienceSkill(skill)] = true
+		}
+		text := strings.ToLower(work.JobTitle + " " + work.JobSummary)
+		for _, skill := range resume.Skills {
+			if name := ExperienceSkill(skill); name != "" && containsWord(text, name) {
+				skills[name] = true
+			}
+		}
+		for skill := range skills {
+			add(bySkill, skill, from, to, length)
+		}
+	}
+
+	resume.YearsOfExperience = total.years()
+	resume.SkillExperience = experienceYears(bySkill)
+	resume.TitleExperience = experienceYears(byTitle)
+}
+
+func experienceYears(spans map[string]*experienceSpan) []ExperienceYears {
+	years := make([]ExperienceYears, 0, len(spans))
+	for name, span := range spans {
+		years = append(years, ExperienceYears{Name: name, Years: span.years()})
+	}
+	sort.Slice(years, func(i, j int) bool {
+		if years[i].Years != years[j].Years {
+			return years[i].Years > years[j].Years
+		}
+		return years[i].Name < years[j].Name
+	})
+	return years
+}
+
+// WorkPeriod describes when a role was held: its dates when known, else its stated duration.
+func WorkPeriod(work WorkExperience) string {
+	switch {
+	case work.StartDate != "" && work.Current:
+		return work.StartDate + " - present"
+	case work.StartDate != "" && work.EndDate != "":
+		return work.StartDate + " - " + work.EndDate
+	}
+	return work.Duration
+}
@@ -0,0 +1,92 @@
+package elasticsearch_test
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeWorkDates(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		work elasticsearch.WorkExperience
+		want elasticsearch.WorkExperience
+	}{
+		{
+			name: "model dates",
+			work: elasticsearch.WorkExperience{StartDate: "March 2019", EndDate: "02/2021"},
+			want: elasticsearch.WorkExperience{StartDate: "2019-03", EndDate: "2021-02"},
+		},
+		{
+			name: "present",
+			work: elasticsearch.WorkExperience{StartDate: "2021-3", EndDate: "Present"},
+			want: elasticsearch.WorkExperience{StartDate: "2021-03", Current: true},
+		},
+		{
+			name: "period in the duration",
+			work: elasticsearch.WorkExperience{Duration: "Jan 2020 – now"},
+			want: elasticsearch.WorkExperience{Duration: "Jan 2020 – now", StartDate: "2020-01", Current: true},
+		},
+		{
+			name: "years only",
+			work: elasticsearch.WorkExperience{Duration: "2017-2019"},
+			want: elasticsearch.WorkExperience{Duration: "2017-2019", StartDate: "2017", EndDate: "2019"},
+		},
+		{
+			name: "expected end",
+			work: elasticsearch.WorkExperience{StartDate: "2023-09", EndDate: "2025-06"},
+			want: elasticsearch.WorkExperience{StartDate: "2023-09", Current: true},
+		},
+		{
+			name: "unreadable and reversed dates",
+			work: elasticsearch.WorkExperience{StartDate: "2022-05", EndDate: "2021-01", Duration: "2 years", Current: true},
+			want: elasticsearch.WorkExperience{StartDate: "2022-05", Duration: "2 years"},
+		},
+		{
+			name: "no dates",
+			work: elasticsearch.WorkExperience{StartDate: "sometime", Duration: "2 years"},
+			want: elasticsearch.WorkExperience{Duration: "2 years"},
+		},
+	}
+	for _, c := range cases {
+		work := c.work
+		elasticsearch.NormalizeWorkDates(&work, now)
+		assert.Equal(t, c.want, work, c.name)
+	}
+}
+
+func TestComputeExperience(t *testing.T) {
+	now := time.Date(2024, time.June, 15, 0, 0, 0, 0, time.UTC)
+	resume := elasticsearch.ResumeSummaryDTO{
+		Skills: []string{"Go", "Kubernetes", "React"},
+		WorkExperience: []elasticsearch.WorkExperience{
+			// Ongoing: 2022-07 to 2024-06, 24 months.
+			{JobTitle: "Senior Backend Engineer", StartDate: "2022-07", Current: true, JobSummary: "Go services on Kubernetes."},
+			// 2019-07 to 2022-06, 36 months.
+			{JobTitle: "Backend Engineer", StartDate: "2019-07", EndDate: "2022-06", Skills: []string{"Go", "MySQL"}},
+			// Overlaps the previous role: only 2019-01 to 2019-06 adds to the total.
+			{JobTitle: "Freelance Developer", StartDate: "2019-01", EndDate: "2019-12", JobSummary: "React apps"},
+			// Known only by its length.
+			{JobTitle: "Intern", Duration: "6 months"},
+			// Not counted.
+			{JobTitle: "Volunteer"},
+		},
+	}
+
+	elasticsearch.ComputeExperience(&resume, now)
+	assert.Equal(t, 6.0, resume.YearsOfExperience)
+	assert.Equal(t, []elasticsearch.ExperienceYears{
+		{Name: "go", Years: 5},
+		{Name: "mysql", Years: 3},
+		{Name: "kubernetes", Years: 2},
+		{Name: "react", Years: 1},
+	}, resume.SkillExperience)
+	assert.Equal(t, []elasticsearch.ExperienceYears{
+		{Name: "backend engineer", Years: 5},
+		{Name: "freelance developer", Years: 1},
+		{Name: "intern", Years: 0.5},
+	}, resume.TitleExperience)
+}
@@ -0,0 +1,187 @@
+package elasticsearch
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+)
+
+// SearchFilter restricts search results on basic_info values and years of experience. Text
+// filters match case-insensitively as a phrase within the stored value. When MinConfidence is set,
+// a basic_info value only satisfies a filter if its evidence confidence reaches it, so that values
+// the model was unsure of (or that were indexed without evidence) are excluded. MinSkillYears and
+// MinTitleYears require the years spent in roles with each skill (matched exactly) or with a title
+// containing each title, as normalized by ExperienceSkill and ExperienceTitle. Superseded resume
+// versions are excluded unless AllVersions is set, also when there is no filter.
+type SearchFilter struct {
+	University     string             `json:"university,omitempty"`
+	EducationLevel string             `json:"education_level,omitempty"`
+	Major          string             `json:"major,omitempty"`
+	MinGPA         *float64           `json:"min_gpa,omitempty"`
+	MinYears       *float64           `json:"min_years,omitempty"`
+	MinSkillYears  map[string]float64 `json:"min_skill_years,omitempty"`
+	MinTitleYears  map[string]float64 `json:"min_title_years,omitempty"`
+	MinConfidence  float64            `json:"min_confidence,omitempty"`
+	AllVersions    bool               `json:"all_versions,omitempty"`
+}
+
+// IsEmpty reports whether the filter does not restrict values.
+func (f *SearchFilter) IsEmpty() bool {
+	return f == nil || (f.University == "" && f.EducationLevel == "" && f.Major == "" && f.MinGPA == nil &&
+		f.MinYears == nil && len(f.MinSkillYears) == 0 && len(f.MinTitleYears) == 0)
+}
+
+// Matches evaluates the filter against a resume, with the same semantics as the query built by queries.
+func (f *SearchFilter) Matches(resume *ResumeSummaryDTO) bool {
+	if resume.Superseded && (f == nil || !f.AllVersions) {
+		return false
+	}
+	if f.IsEmpty() {
+		return true
+	}
+	evidence := resume.BasicInfoEvidence
+	if evidence == nil {
+		evidence = &BasicInfoEvidence{}
+	}
+	info := resume.BasicInfo
+
+	if f.University != "" && (!containsFold(info.University, f.University) || !f.confident(evidence.University)) {
+		return false
+	}
+	if f.EducationLevel != "" && (!containsFold(info.EducationLevel, f.EducationLevel) || !f.confident(evidence.EducationLevel)) {
+		return false
+	}
+	if f.Major != "" {
+		found := false
+		for _, major := range info.Majors {
+			found = found || containsFold(major, f.Major)
+		}
+		if !found || !f.confident(evidence.Majors) {
+			return false
+		}
+	}
+	if f.MinGPA != nil && (info.GPA == nil || *info.GPA < *f.MinGPA || !f.confident(evidence.GPA)) {
+		return false
+	}
+	if f.MinYears != nil && resume.YearsOfExperience < *f.MinYears {
+		return false
+	}
+	for _, skill := range sortedKeys(f.MinSkillYears) {
+		name := ExperienceSkill(skill)
+		if !hasExperience(resume.SkillExperience, f.MinSkillYears[skill], func(got string) bool { return got == name }) {
+			return false
+		}
+	}
+	for _, title := range sortedKeys(f.MinTitleYears) {
+		name := ExperienceTitle(title)
+		if !hasExperience(resume.TitleExperience, f.MinTitleYears[title], func(got string) bool { return containsFold(got, name) }) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasExperience(experience []ExperienceYears, minYears float64, matches func(name string) bool) bool {
+	for _, item := range experience {
+		if matches(item.Name) && item.Years >= minYears {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *SearchFilter) confident(evidence *FieldEvidence) bool {
+	return f.MinConfidence <= 0 || (evidence != nil && evidence.Confidence >= f.MinConfidence)
+}
+
+// queries returns the filter as Elasticsearch queries on the indexed document.
+func (f *SearchFilter) queries() []types.Query {
+	var queries []types.Query
+	if f == nil || !f.AllVersions {
+		queries = append(queries, types.Query{Bool: &types.BoolQuery{MustNot: []types.Query{{
+			Term: map[string]types.TermQuery{"content.superseded": {Value: true}},
+		}}}})
+	}
+	if f.IsEmpty() {
+		return queries
+	}
+
+	var must []types.Query
+	phrase := func(field, value, evidenceField string) {
+		must = append(must, types.Query{MatchPhrase: map[string]types.MatchPhraseQuery{
+			"content.basic_info." + field: {Query: value},
+		}})
+		must = append(must, f.confidenceQuery(evidenceField)...)
+	}
+	if f.University != "" {
+		phrase("university", f.University, "university")
+	}
+	if f.EducationLevel != "" {
+		phrase("education_level", f.EducationLevel, "education_level")
+	}
+	if f.Major != "" {
+		phrase("majors", f.Major, "majors")
+	}
+	if f.MinGPA != nil {
+		minGPA := types.Float64(*f.MinGPA)
+		must = append(must, types.Query{Range: map[string]types.RangeQuery{
+			"content.basic_info.gpa": types.NumberRangeQuery{Gte: &minGPA},
+		}})
+		must = append(must, f.confidenceQuery("gpa")...)
+	}
+	if f.MinYears != nil {
+		minYears := types.Float64(*f.MinYears)
+		must = append(must, types.Query{Range: map[string]types.RangeQuery{
+			"content.years_of_experience": types.NumberRangeQuery{Gte: &minYears},
+		}})
+	}
+	for _, skill := range sortedKeys(f.MinSkillYears) {
+		must = append(must, experienceQuery("content.skill_experience", f.MinSkillYears[skill], types.Query{
+			Term: map[string]types.TermQuery{"content.skill_experience.name": {Value: ExperienceSkill(skill)}},
+		}))
+	}
+	for _, title := range sortedKeys(f.MinTitleYears) {
+		must = append(must, experienceQuery("content.title_experience", f.MinTitleYears[title], types.Query{
+			MatchPhrase: map[string]types.MatchPhraseQuery{"content.title_experience.name": {Query: ExperienceTitle(title)}},
+		}))
+	}
+	return append(queries, types.Query{Bool: &types.BoolQuery{Must: must}})
+}
+
+// experienceQuery matches documents with an entry of the nested experience list at path that
+// matches name and has at least minYears.
+func experienceQuery(path string, minYears float64, name types.Query) types.Query {
+	years := types.Float64(minYears)
+	return types.Query{Nested: &types.NestedQuery{
+		Path: path,
+		Query: &types.Query{Bool: &types.BoolQuery{Must: []types.Query{
+			name,
+			{Range: map[string]types.RangeQuery{path + ".years": types.NumberRangeQuery{Gte: &years}}},
+		}}},
+	}}
+}
+
+func (f *SearchFilter) confidenceQuery(field string) []types.Query {
+	if f.MinConfidence <= 0 {
+		return nil
+	}
+	minConfidence := types.Float64(f.MinConfidence)
+	return []types.Query{{Range: map[string]types.RangeQuery{
+		"content.basic_info_evidence." + field + ".confidence": types.NumberRangeQuery{Gte: &minConfidence},
+	}}}
+}
+
+// sortedKeys returns the keys of m in order, so that the queries built from it are stable.
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsFold(value, substr string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(strings.TrimSpace(substr)))
+}
@@ -0,0 +1,133 @@
+package elasticsearch_test
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchFilter_Matches(t *testing.T) {
+	gpa := 3.6
+	resume := &elasticsearch.ResumeSummaryDTO{
+		BasicInfo: elasticsearch.BasicInfo{
+			University:     "Hanoi University of Technology",
+			EducationLevel: "BS",
+			Majors:         []string{"Computer Science"},
+			GPA:            &gpa,
+		},
+		BasicInfoEvidence: &elasticsearch.BasicInfoEvidence{
+			University: &elasticsearch.FieldEvidence{Confidence: 0.9},
+			Majors:     &elasticsearch.FieldEvidence{Confidence: 0.4},
+			GPA:        &elasticsearch.FieldEvidence{Confidence: 0.95},
+		},
+	}
+	minGPA, highGPA := 3.5, 3.8
+
+	assert.True(t, (*elasticsearch.SearchFilter)(nil).Matches(resume))
+	assert.True(t, (&elasticsearch.SearchFilter{University: "hanoi university"}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{University: "Stanford"}).Matches(resume))
+	assert.True(t, (&elasticsearch.SearchFilter{MinGPA: &minGPA, MinConfidence: 0.9}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{MinGPA: &highGPA}).Matches(resume))
+
+	// Low-confidence or unevidenced values do not satisfy a filter once a minimum confidence is set.
+	assert.True(t, (&elasticsearch.SearchFilter{Major: "computer"}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{Major: "computer", MinConfidence: 0.5}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{EducationLevel: "BS", MinConfidence: 0.5}).Matches(resume))
+
+	// Superseded versions only match when all versions are asked for.
+	resume.Superseded = true
+	assert.False(t, (*elasticsearch.SearchFilter)(nil).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{University: "hanoi"}).Matches(resume))
+	assert.True(t, (&elasticsearch.SearchFilter{University: "hanoi", AllVersions: true}).Matches(resume))
+}
+
+func TestMemoryClient_HybridSearchFilter(t *testing.T) {
+	ctx := context.Background()
+	client, err := elasticsearch.NewMemoryClient("")
+	require.NoError(t, err)
+
+	addDocument := func(university string, confidence float64, embedding []float32) string {
+		id, err := client.AddDocument(ctx, testIndex, elasticsearch.ElkResumeDTO{
+			Content: elasticsearch.ResumeSummaryDTO{
+				BasicInfo: elasticsearch.BasicInfo{University: university},
+				BasicInfoEvidence: &elasticsearch.BasicInfoEvidence{
+					University: &elasticsearch.FieldEvidence{Confidence: confidence, Source: university},
+				},
+			},
+			Embedding: embedding,
+		})
+		require.NoError(t, err)
+		return id
+	}
+	addDocument("MIT", 0.3, []float32{1, 0})
+	confident := addDocument("MIT", 0.9, []float32{0, 1})
+	addDocument("Stanford", 1, []float32{1, 0})
+
+	results, err := client.HybridSearchWithBoost(ctx, testIndex, "", []float32{1, 0}, 0, 10, 1,
+		&elasticsearch.SearchFilter{University: "mit", MinConfidence: 0.8}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, confident, results[0].Id)
+	assert.InDelta(t, 0.9, results[0].BasicInfoEvidence.University.Confidence, 1e-9)
+}
+
+func TestSearchFilter_MatchesExperience(t *testing.T) {
+	resume := &elasticsearch.ResumeSummaryDTO{
+		YearsOfExperience: 6,
+		SkillExperience:   []elasticsearch.ExperienceYears{{Name: "go", Years: 5}, {Name: "react", Years: 1}},
+		TitleExperience:   []elasticsearch.ExperienceYears{{Name: "backend engineer", Years: 5}},
+	}
+	five, seven := 5.0, 7.0
+
+	assert.True(t, (&elasticsearch.SearchFilter{MinYears: &five}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{MinYears: &seven}).Matches(resume))
+	assert.True(t, (&elasticsearch.SearchFilter{MinSkillYears: map[string]float64{" Go ": 5, "react": 1}}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{MinSkillYears: map[string]float64{"go": 5, "react": 2}}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{MinSkillYears: map[string]float64{"golang": 1}}).Matches(resume))
+	// Titles match as a phrase, without seniority words.
+	assert.True(t, (&elasticsearch.SearchFilter{MinTitleYears: map[string]float64{"Senior Backend": 4}}).Matches(resume))
+	assert.False(t, (&elasticsearch.SearchFilter{MinTitleYears: map[string]float64{"frontend engineer": 1}}).Matches(resume))
+}
+
+func TestMemoryClient_HybridSearchSort(t *testing.T) {
+	ctx := context.Background()
+	client, err := elasticsearch.NewMemoryClient("")
+	require.NoError(t, err)
+
+	addDocument := func(name string, years float64, skill *elasticsearch.ExperienceYears, embedding []float32) {
+		content := elasticsearch.ResumeSummaryDTO{
+			BasicInfo:         elasticsearch.BasicInfo{FullName: name},
+			YearsOfExperience: years,
+		}
+		if skill != nil {
+			content.SkillExperience = []elasticsearch.ExperienceYears{*skill}
+		}
+		_, err := client.AddDocument(ctx, testIndex, elasticsearch.ElkResumeDTO{Content: content, Embedding: embedding})
+		require.NoError(t, err)
+	}
+	addDocument("closest", 2, nil, []float32{1, 0})
+	addDocument("senior", 8, &elasticsearch.ExperienceYears{Name: "go", Years: 3}, []float32{1, 1})
+	addDocument("junior", 1, &elasticsearch.ExperienceYears{Name: "go", Years: 1}, []float32{0, 1})
+
+	names := func(sort *elasticsearch.SearchSort, from, size int) []string {
+		results, err := client.HybridSearchWithBoost(ctx, testIndex, "", []float32{1, 0}, from, size, 1, nil, sort)
+		require.NoError(t, err)
+		names := make([]string, len(results))
+		for i, result := range results {
+			names[i] = result.BasicInfo.FullName
+		}
+		return names
+	}
+	assert.Equal(t, []string{"closest", "senior", "junior"}, names(nil, 0, 10))
+	assert.Equal(t, []string{"senior", "closest", "junior"}, names(&elasticsearch.SearchSort{By: elasticsearch.SortYearsOfExperience}, 0, 10))
+	assert.Equal(t, []string{"closest"}, names(&elasticsearch.SearchSort{By: elasticsearch.SortYearsOfExperience}, 1, 1))
+	// Results without years of the skill come last in either order.
+	assert.Equal(t, []string{"junior", "senior", "closest"}, names(&elasticsearch.SearchSort{By: elasticsearch.SortSkillYears, Name: "Go", Ascending: true}, 0, 10))
+
+	assert.NoError(t, (*elasticsearch.SearchSort)(nil).Validate())
+	assert.Error(t, (&elasticsearch.SearchSort{By: elasticsearch.SortTitleYears}).Validate())
+	assert.Error(t, (&elasticsearch.SearchSort{By: "gpa"}).Validate())
+}
@@ -0,0 +1,133 @@
+package elasticsearch
+
+// ResumeMapping holds the explicit mappings of the resume document fields that dynamic mapping
+// would get wrong: contacts are matched exactly rather than as analyzed text, numbers keep their
+// type whichever document is indexed first, role dates are dates, and the years per skill and per
+// title are nested so that a filter pairs each name with its own years.
+//
+// Putting the mapping on an existing index only adds the fields the index has not mapped yet. A
+// field that documents already got a dynamic mapping for cannot change type: the experience lists
+// are plain objects rather than nested, the role dates text rather than dates, and the index
+// rejects the mapping. Such an index is migrated by ReindexResumes into a new index created with
+// the mapping, behind an alias.
+var ResumeMapping = map[string]interface{}{
+	"properties": map[string]interface{}{
+		"content": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"schema_version": map[string]interface{}{"type": "integer"},
+				"contacts": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"emails": map[string]interface{}{"type": "keyword"},
+						"phones": map[string]interface{}{"type": "keyword"},
+						"links": map[string]interface{}{
+							"properties": map[string]interface{}{
+								"kind": map[string]interface{}{"type": "keyword"},
+								"url":  map[string]interface{}{"type": "keyword"},
+							},
+						},
+					},
+				},
+				"education": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"institution":     textWithKeyword,
+						"degree":          textWithKeyword,
+						"majors":          textWithKeyword,
+						"gpa":             map[string]interface{}{"type": "float"},
+						"graduation_year": map[string]interface{}{"type": "integer"},
+					},
+				},
+				"certifications": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"name":   textWithKeyword,
+						"issuer": textWithKeyword,
+						"year":   map[string]interface{}{"type": "integer"},
+					},
+				},
+				"languages": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"language":    textWithKeyword,
+						"proficiency": map[string]interface{}{"type": "keyword"},
+					},
+				},
+				"work_experience": map[string]interface{}{
+					"properties": map[string]interface{}{
+						"start_date": workDateMapping,
+						"end_date":   workDateMapping,
+						"current":    map[string]interface{}{"type": "boolean"},
+						"skills":     textWithKeyword,
+					},
+				},
+				"years_of_experience": map[string]interface{}{"type": "float"},
+				"skill_experience": map[string]interface{}{
+					"type": "nested",
+					"properties": map[string]interface{}{
+						"name":  map[string]interface{}{"type": "keyword"},
+						"years": map[string]interface{}{"type": "float"},
+					},
+				},
+				"title_experience": map[string]interface{}{
+					"type": "nested",
+					"properties": map[string]interface{}{
+						"name":  textWithKeyword,
+						"years": map[string]interface{}{"type": "float"},
+					},
+				},
+			},
+		},
+	},
+}
+
+// textWithKeyword is the mapping dynamic mapping gives strings, for full-text search and exact
+// filters alike.
+var textWithKeyword = map[string]interface{}{
+	"type": "text",
+	"fields": map[string]interface{}{
+		"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+	},
+}
+
+// workDateMapping is the mapping of the dates written by NormalizeWorkDates.
+var workDateMapping = map[string]interface{}{"type": "date", "format": "yyyy-MM||yyyy"}
+
+// indexMapping returns the mappings of a new resume index: those of the current index, which
+// hold the embedding and any field mapped dynamically, with ResumeMapping in place of the fields
+// it defines.
+func indexMapping(current map[string]interface{}) map[string]interface{} {
+	mapping := overrideMapping(current, ResumeMapping)
+	for key, value := range current {
+		if _, ok := mapping[key]; !ok {
+			mapping[key] = value
+		}
+	}
+	return mapping
+}
+
+// overrideMapping returns the field mapping override, with the properties of mapping it does not
+// define kept.
+func overrideMapping(mapping, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(override))
+	for key, value := range override {
+		merged[key] = value
+	}
+	properties, _ := mapping["properties"].(map[string]interface{})
+	overrides, _ := override["properties"].(map[string]interface{})
+	if len(properties) == 0 || overrides == nil {
+		return merged
+	}
+
+	mergedProperties := make(map[string]interface{}, len(properties)+len(overrides))
+	for name, field := range properties {
+		mergedProperties[name] = field
+	}
+	for name, field := range overrides {
+		current, _ := properties[name].(map[string]interface{})
+		fieldOverride, _ := field.(map[string]interface{})
+		if current == nil || fieldOverride == nil {
+			mergedProperties[name] = field
+			continue
+		}
+		mergedProperties[name] = overrideMapping(current, fieldOverride)
+	}
+	merged["properties"] = mergedProperties
+	return merged
+}
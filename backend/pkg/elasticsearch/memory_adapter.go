@@ -0,0 +1,648 @@
+package elasticsearch
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+
+	"CVSeeker/pkg/metrics"
+)
+
+// BackendMemory selects MemoryClient as the ELK_BACKEND.
+const BackendMemory = "memory"
+
+// Sizes used by the search requests of ElasticsearchClient, mirrored by MemoryClient.
+const (
+	defaultSearchSize = 10
+	vectorSearchK     = 10
+	hybridSearchK     = 150
+)
+
+// memoryAdaptorName identifies the in-memory adaptor in metrics.
+const memoryAdaptorName = "elasticsearch_memory"
+
+// MemoryClient is a pure-Go IElasticsearchClient that keeps documents in memory. Search is brute
+// force: lexical match scores documents with BM25 over the text of their "content" field, and kNN
+// ranks them by the cosine similarity of their "embedding" field, scored like Elasticsearch as
+// (1 + cosine) / 2. It suits tests, local development and single-node demos, and is the engine
+// behind the Elasticsearch fake of pkg/fakes.
+//
+// When a file path is given, the documents are loaded from it on start and the whole store is
+// rewritten to it after every change.
+type MemoryClient struct {
+	mu      sync.RWMutex
+	path    string
+	indices map[string]*memoryIndex
+}
+
+type memoryIndex struct {
+	// docs maps document IDs to their source; order keeps insertion order for stable ranking.
+	docs  map[string]json.RawMessage
+	order []string
+}
+
+// memorySnapshot is the on-disk format of MemoryClient.
+type memorySnapshot struct {
+	Indices map[string][]memoryDocument `json:"indices"`
+}
+
+type memoryDocument struct {
+	ID     string          `json:"_id"`
+	Source json.RawMessage `json:"_source"`
+}
+
+// NewMemoryClient returns an empty MemoryClient, or one restored from path when the file exists.
+// An empty path disables persistence.
+func NewMemoryClient(path string) (*MemoryClient, error) {
+	mc := &MemoryClient{path: path, indices: map[string]*memoryIndex{}}
+	if path == "" {
+		return mc, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return mc, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading in-memory index file: %w", err)
+	}
+
+	var snapshot memorySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("error parsing in-memory index file %s: %w", path, err)
+	}
+	for name, docs := range snapshot.Indices {
+		index := mc.index(name)
+		for _, doc := range docs {
+			index.put(doc.ID, doc.Source)
+		}
+	}
+	return mc, nil
+}
+
+// Ping always succeeds.
+func (mc *MemoryClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+// PutResumeMapping does nothing: documents are kept as they are indexed.
+func (mc *MemoryClient) PutResumeMapping(ctx context.Context, indexName string) error {
+	return nil
+}
+
+// ReindexResumes fails: documents are kept as they are indexed, so there is no mapping to migrate.
+func (mc *MemoryClient) ReindexResumes(ctx context.Context, alias, indexName string, replaceIndex bool) error {
+	return fmt.Errorf("the %s backend has no mapping to migrate", BackendMemory)
+}
+
+// AddDocument stores the document under a generated ID.
+func (mc *MemoryClient) AddDocument(ctx context.Context, indexName string, document interface{}) (_ string, err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "add_document", time.Now(), &err)
+
+	source, err := json.Marshal(document)
+	if err != nil {
+		return "", fmt.Errorf("error marshaling document: %w", err)
+	}
+	id, err := newDocumentID()
+	if err != nil {
+		return "", err
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.index(indexName).put(id, source)
+	if err := mc.persist(); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// IndexDocument creates or replaces the document with the given ID.
+func (mc *MemoryClient) IndexDocument(ctx context.Context, indexName, documentID string, document interface{}) (err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "index_document", time.Now(), &err)
+
+	source, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("error marshaling document: %w", err)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.index(indexName).put(documentID, source)
+	return mc.persist()
+}
+
+// UpdateDocument merges fields into the document with the given ID; nested objects are merged
+// rather than replaced, as in Elasticsearch.
+func (mc *MemoryClient) UpdateDocument(ctx context.Context, indexName, documentID string, fields map[string]interface{}) (err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "update_document", time.Now(), &err)
+
+	update, err := json.Marshal(fields)
+	if err != nil {
+		return fmt.Errorf("error marshaling document update: %w", err)
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	source, ok := mc.lookup(indexName, documentID)
+	if !ok {
+		return fmt.Errorf("%w: %s in index %s", ErrDocumentNotFound, documentID, indexName)
+	}
+	var doc, changes map[string]interface{}
+	if err := json.Unmarshal(source, &doc); err != nil {
+		return fmt.Errorf("error decoding document %s: %w", documentID, err)
+	}
+	if err := json.Unmarshal(update, &changes); err != nil {
+		return fmt.Errorf("error decoding document update: %w", err)
+	}
+	merged, err := json.Marshal(mergeObjects(doc, changes))
+	if err != nil {
+		return fmt.Errorf("error marshaling document: %w", err)
+	}
+	mc.index(indexName).put(documentID, merged)
+	return mc.persist()
+}
+
+// GetDocumentByID returns the document with the given ID or an error when it does not exist.
+func (mc *MemoryClient) GetDocumentByID(ctx context.Context, indexName, documentID string) (_ *ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "get_document", time.Now(), &err)
+
+	mc.mu.RLock()
+	source, ok := mc.lookup(indexName, documentID)
+	mc.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s in index %s", ErrDocumentNotFound, documentID, indexName)
+	}
+	return ConvertHitToElasticResponse(&types.Hit{Id_: documentID, Index_: indexName, Source_: source})
+}
+
+// FetchDocumentsByIDs returns the documents that exist among documentIDs, in the requested order.
+func (mc *MemoryClient) FetchDocumentsByIDs(ctx context.Context, indexName string, documentIDs []string) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "mget", time.Now(), &err)
+
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	response := make([]ResumeSummaryDTO, 0, len(documentIDs))
+	for _, id := range documentIDs {
+		source, ok := mc.lookup(indexName, id)
+		if !ok {
+			continue
+		}
+		resume, err := ConvertHitToElasticResponse(&types.Hit{Id_: id, Index_: indexName, Source_: source})
+		if err != nil {
+			return nil, err
+		}
+		// Multi-get does not score documents.
+		resume.Point = 0
+		response = append(response, *resume)
+	}
+	return response, nil
+}
+
+// ScanDocumentIDs calls fn with the IDs of the documents of an index in insertion order. The IDs
+// are read up front, so fn may change the index.
+func (mc *MemoryClient) ScanDocumentIDs(ctx context.Context, indexName string, batchSize int, fn func(documentIDs []string) error) (err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "scan_ids", time.Now(), &err)
+
+	var ids []string
+	mc.mu.RLock()
+	if index, ok := mc.indices[indexName]; ok {
+		ids = append(ids, index.order...)
+	}
+	mc.mu.RUnlock()
+
+	if batchSize <= 0 {
+		batchSize = defaultSearchSize
+	}
+	for len(ids) > 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := batchSize
+		if n > len(ids) {
+			n = len(ids)
+		}
+		if err := fn(ids[:n]); err != nil {
+			return err
+		}
+		ids = ids[n:]
+	}
+	return nil
+}
+
+// DeleteDocumentByID removes a document, failing when it does not exist.
+func (mc *MemoryClient) DeleteDocumentByID(ctx context.Context, indexName, documentID string) (err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "delete_document", time.Now(), &err)
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	index, ok := mc.indices[indexName]
+	if !ok || !index.remove(documentID) {
+		return fmt.Errorf("%w: %s in index %s", ErrDocumentNotFound, documentID, indexName)
+	}
+	return mc.persist()
+}
+
+// KeywordSearch returns the 10 best lexical matches of query in the "content" field.
+func (mc *MemoryClient) KeywordSearch(ctx context.Context, indexName string, query string) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "keyword_search", time.Now(), &err)
+
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.hits(indexName, mc.matchScores(indexName, query), 0, defaultSearchSize)
+}
+
+// VectorSearch returns the 10 nearest neighbours of vector.
+func (mc *MemoryClient) VectorSearch(ctx context.Context, indexName string, vector []float32) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "vector_search", time.Now(), &err)
+
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	// Like the cluster request, the nearest neighbours include superseded versions.
+	return mc.hits(indexName, mc.knnScores(indexName, vector, vectorSearchK, &SearchFilter{AllVersions: true}), 0, defaultSearchSize)
+}
+
+// HybridSearchWithBoost mirrors the request sent by ElasticsearchClient: a kNN query for the 150
+// nearest neighbours of queryVector, paginated with from and size. Like the cluster request, the
+// query text and knnBoost do not affect the ranking. The filter is applied before selecting
+// neighbours, and the neighbours are ordered by sort before paginating.
+func (mc *MemoryClient) HybridSearchWithBoost(ctx context.Context, indexName, query string, queryVector []float32, from, size int, knnBoost float32, filter *SearchFilter, sort *SearchSort) (_ []ResumeSummaryDTO, err error) {
+	defer metrics.ObserveAdaptorCall(memoryAdaptorName, "hybrid_search", time.Now(), &err)
+
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	scored := mc.knnScores(indexName, queryVector, hybridSearchK, filter)
+	if sort.byRelevance() {
+		return mc.hits(indexName, scored, from, size)
+	}
+
+	resumes, err := mc.hits(indexName, scored, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	sort.sortResumes(resumes)
+	if from > len(resumes) {
+		from = len(resumes)
+	}
+	resumes = resumes[from:]
+	if size >= 0 && size < len(resumes) {
+		resumes = resumes[:size]
+	}
+	return resumes, nil
+}
+
+// MemoryHit is a document found by MemoryClient.Search.
+type MemoryHit struct {
+	ID     string
+	Score  float64
+	Source json.RawMessage
+}
+
+// Search serves the search requests of the Elasticsearch REST API on the documents of an index,
+// for fakes that front MemoryClient over HTTP. Documents matching query are scored with BM25 over
+// their "content" field and, when vector is set, the k nearest neighbours of vector are added with
+// their kNN score times knnBoost; a document found by both gets the sum, as in a hybrid request.
+// Without a query or a vector, every document matches with a score of 1, in insertion order.
+func (mc *MemoryClient) Search(indexName, query string, vector []float32, k int, knnBoost float64) []MemoryHit {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	index, ok := mc.indices[indexName]
+	if !ok {
+		return nil
+	}
+
+	var scored []scoredDocument
+	if query == "" && vector == nil {
+		scored = make([]scoredDocument, 0, len(index.order))
+		for _, id := range index.order {
+			scored = append(scored, scoredDocument{id: id, score: 1})
+		}
+	} else {
+		scores := map[string]float64{}
+		for _, doc := range mc.matchScores(indexName, query) {
+			scores[doc.id] += doc.score
+		}
+		if vector != nil {
+			// The request has no filter: superseded versions are neighbours too.
+			for _, doc := range mc.knnScores(indexName, vector, k, &SearchFilter{AllVersions: true}) {
+				scores[doc.id] += knnBoost * doc.score
+			}
+		}
+		for _, id := range index.order {
+			if score, ok := scores[id]; ok {
+				scored = append(scored, scoredDocument{id: id, score: score})
+			}
+		}
+		scored = sortScores(scored)
+	}
+
+	hits := make([]MemoryHit, len(scored))
+	for i, doc := range scored {
+		hits[i] = MemoryHit{ID: doc.id, Score: doc.score, Source: index.docs[doc.id]}
+	}
+	return hits
+}
+
+// Document returns the source of a document as it was indexed, and whether it exists.
+func (mc *MemoryClient) Document(indexName, documentID string) (json.RawMessage, bool) {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.lookup(indexName, documentID)
+}
+
+// Count returns the number of documents in an index.
+func (mc *MemoryClient) Count(indexName string) int {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	if index, ok := mc.indices[indexName]; ok {
+		return len(index.order)
+	}
+	return 0
+}
+
+type scoredDocument struct {
+	id    string
+	score float64
+}
+
+// matchScores scores every document of the index containing at least one query term with BM25.
+func (mc *MemoryClient) matchScores(indexName, query string) []scoredDocument {
+	index, ok := mc.indices[indexName]
+	terms := tokenize(query)
+	if !ok || len(terms) == 0 {
+		return nil
+	}
+
+	// Standard BM25 parameters, as used by Elasticsearch.
+	const k1, b = 1.2, 0.75
+
+	frequencies := make(map[string]map[string]int, len(index.order))
+	lengths := make(map[string]int, len(index.order))
+	documentFrequency := map[string]int{}
+	totalLength := 0
+	for _, id := range index.order {
+		tokens := tokenize(contentText(index.docs[id]))
+		tf := map[string]int{}
+		for _, token := range tokens {
+			tf[token]++
+		}
+		for term := range tf {
+			documentFrequency[term]++
+		}
+		frequencies[id], lengths[id] = tf, len(tokens)
+		totalLength += len(tokens)
+	}
+	n := float64(len(index.order))
+	avgLength := float64(totalLength) / math.Max(n, 1)
+
+	scored := make([]scoredDocument, 0)
+	for _, id := range index.order {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(frequencies[id][term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(documentFrequency[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*float64(lengths[id])/avgLength))
+		}
+		if score > 0 {
+			scored = append(scored, scoredDocument{id: id, score: score})
+		}
+	}
+	return sortScores(scored)
+}
+
+// knnScores returns the k documents matching filter whose embedding is most similar to vector.
+func (mc *MemoryClient) knnScores(indexName string, vector []float32, k int, filter *SearchFilter) []scoredDocument {
+	index, ok := mc.indices[indexName]
+	if !ok {
+		return nil
+	}
+
+	scored := make([]scoredDocument, 0, len(index.order))
+	for _, id := range index.order {
+		var doc ElkResumeDTO
+		if err := json.Unmarshal(index.docs[id], &doc); err != nil || len(doc.Embedding) != len(vector) {
+			continue
+		}
+		if !filter.Matches(&doc.Content) {
+			continue
+		}
+		scored = append(scored, scoredDocument{id: id, score: (1 + cosineSimilarity(doc.Embedding, vector)) / 2})
+	}
+	scored = sortScores(scored)
+	if len(scored) > k {
+		scored = scored[:k]
+	}
+	return scored
+}
+
+// hits converts the page [from, from+size) of scored into DTOs.
+func (mc *MemoryClient) hits(indexName string, scored []scoredDocument, from, size int) ([]ResumeSummaryDTO, error) {
+	if from > len(scored) {
+		from = len(scored)
+	}
+	scored = scored[from:]
+	if size >= 0 && size < len(scored) {
+		scored = scored[:size]
+	}
+
+	var resumes []ResumeSummaryDTO
+	for _, doc := range scored {
+		source, _ := mc.lookup(indexName, doc.id)
+		resume, err := ConvertHitToElasticResponse(&types.Hit{
+			Id_:     doc.id,
+			Index_:  indexName,
+			Score_:  types.Float64(doc.score),
+			Source_: source,
+		})
+		if err != nil {
+			return nil, err
+		}
+		resumes = append(resumes, *resume)
+	}
+	return resumes, nil
+}
+
+// index returns the named index, creating it if needed. mu must be held for writing.
+func (mc *MemoryClient) index(name string) *memoryIndex {
+	index, ok := mc.indices[name]
+	if !ok {
+		index = &memoryIndex{docs: map[string]json.RawMessage{}}
+		mc.indices[name] = index
+	}
+	return index
+}
+
+func (mc *MemoryClient) lookup(indexName, id string) (json.RawMessage, bool) {
+	index, ok := mc.indices[indexName]
+	if !ok {
+		return nil, false
+	}
+	source, ok := index.docs[id]
+	return source, ok
+}
+
+// persist rewrites the store file atomically. mu must be held.
+func (mc *MemoryClient) persist() error {
+	if mc.path == "" {
+		return nil
+	}
+
+	snapshot := memorySnapshot{Indices: make(map[string][]memoryDocument, len(mc.indices))}
+	for name, index := range mc.indices {
+		docs := make([]memoryDocument, 0, len(index.order))
+		for _, id := range index.order {
+			docs = append(docs, memoryDocument{ID: id, Source: index.docs[id]})
+		}
+		snapshot.Indices[name] = docs
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("error marshaling in-memory index: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(mc.path), filepath.Base(mc.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("error persisting in-memory index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error persisting in-memory index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error persisting in-memory index: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), mc.path); err != nil {
+		return fmt.Errorf("error persisting in-memory index: %w", err)
+	}
+	return nil
+}
+
+func (index *memoryIndex) put(id string, source json.RawMessage) {
+	if _, exists := index.docs[id]; !exists {
+		index.order = append(index.order, id)
+	}
+	index.docs[id] = source
+}
+
+func (index *memoryIndex) remove(id string) bool {
+	if _, exists := index.docs[id]; !exists {
+		return false
+	}
+	delete(index.docs, id)
+	for i, docID := range index.order {
+		if docID == id {
+			index.order = append(index.order[:i:i], index.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// mergeObjects merges changes into doc recursively and returns doc.
+func mergeObjects(doc, changes map[string]interface{}) map[string]interface{} {
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+	for key, value := range changes {
+		nested, isObject := value.(map[string]interface{})
+		existing, wasObject := doc[key].(map[string]interface{})
+		if isObject && wasObject {
+			doc[key] = mergeObjects(existing, nested)
+		} else {
+			doc[key] = value
+		}
+	}
+	return doc
+}
+
+// newDocumentID returns a random 20-character URL-safe ID, the format of Elasticsearch generated IDs.
+func newDocumentID() (string, error) {
+	b := make([]byte, 15)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating document ID: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sortScores orders documents by descending score, keeping insertion order for ties.
+func sortScores(scored []scoredDocument) []scoredDocument {
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+	return scored
+}
+
+// metadataFields are content fields that describe how a document was produced rather than the resume.
+var metadataFields = map[string]bool{"prompt_version": true, "parse_model": true, "embedding_model": true}
+
+// contentText returns every string found in the "content" field of a document source, except metadata.
+func contentText(source json.RawMessage) string {
+	var doc struct {
+		Content interface{} `json:"content"`
+	}
+	if err := json.Unmarshal(source, &doc); err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch value := v.(type) {
+		case string:
+			sb.WriteString(value)
+			sb.WriteByte(' ')
+		case []interface{}:
+			for _, item := range value {
+				walk(item)
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				if !metadataFields[key] {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(value[key])
+			}
+		}
+	}
+	walk(doc.Content)
+	return sb.String()
+}
+
+// tokenize lower-cases text and splits it into words, roughly like the standard analyzer.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,207 @@
+package elasticsearch_test
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIndex = "resumes"
+
+func addResume(t *testing.T, client elasticsearch.IElasticsearchClient, name, summary string, skills []string, embedding []float32) string {
+	t.Helper()
+	id, err := client.AddDocument(context.Background(), testIndex, elasticsearch.ElkResumeDTO{
+		Content: elasticsearch.ResumeSummaryDTO{
+			Summary:   summary,
+			Skills:    skills,
+			BasicInfo: elasticsearch.BasicInfo{FullName: name},
+		},
+		Embedding: embedding,
+	})
+	require.NoError(t, err)
+	require.Len(t, id, 20)
+	return id
+}
+
+func TestMemoryClient_Documents(t *testing.T) {
+	ctx := context.Background()
+	client, err := elasticsearch.NewMemoryClient("")
+	require.NoError(t, err)
+
+	alice := addResume(t, client, "Alice", "Backend engineer", []string{"Go"}, []float32{1, 0})
+	bob := addResume(t, client, "Bob", "Frontend developer", []string{"React"}, []float32{0, 1})
+
+	resume, err := client.GetDocumentByID(ctx, testIndex, alice)
+	require.NoError(t, err)
+	assert.Equal(t, alice, resume.Id)
+	assert.Equal(t, "Alice", resume.BasicInfo.FullName)
+
+	resumes, err := client.FetchDocumentsByIDs(ctx, testIndex, []string{bob, "missing", alice})
+	require.NoError(t, err)
+	require.Len(t, resumes, 2)
+	assert.Equal(t, bob, resumes[0].Id)
+	assert.Equal(t, alice, resumes[1].Id)
+
+	err = client.IndexDocument(ctx, testIndex, alice, elasticsearch.ElkResumeDTO{
+		Content: elasticsearch.ResumeSummaryDTO{Summary: "Platform engineer", BasicInfo: elasticsearch.BasicInfo{FullName: "Alice"}},
+	})
+	require.NoError(t, err)
+	resume, err = client.GetDocumentByID(ctx, testIndex, alice)
+	require.NoError(t, err)
+	assert.Equal(t, "Platform engineer", resume.Summary)
+	resumes, err = client.FetchDocumentsByIDs(ctx, testIndex, []string{alice, bob})
+	require.NoError(t, err)
+	assert.Len(t, resumes, 2, "reindexing must replace the document, not add one")
+
+	require.NoError(t, client.DeleteDocumentByID(ctx, testIndex, bob))
+	assert.ErrorIs(t, client.DeleteDocumentByID(ctx, testIndex, bob), elasticsearch.ErrDocumentNotFound)
+	_, err = client.GetDocumentByID(ctx, testIndex, bob)
+	assert.ErrorIs(t, err, elasticsearch.ErrDocumentNotFound)
+}
+
+func TestMemoryClient_ScanDocumentIDs(t *testing.T) {
+	ctx := context.Background()
+	client, err := elasticsearch.NewMemoryClient("")
+	require.NoError(t, err)
+
+	var ids []string
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		ids = append(ids, addResume(t, client, name, "Engineer", nil, []float32{1, 0}))
+	}
+
+	var batches [][]string
+	err = client.ScanDocumentIDs(ctx, testIndex, 2, func(documentIDs []string) error {
+		batches = append(batches, append([]string(nil), documentIDs...))
+		// Deleting while scanning must not disturb the scan.
+		return client.DeleteDocumentByID(ctx, testIndex, documentIDs[0])
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{ids[:2], ids[2:]}, batches)
+
+	stop := errors.New("stop")
+	calls := 0
+	err = client.ScanDocumentIDs(ctx, testIndex, 1, func([]string) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+	assert.NoError(t, client.ScanDocumentIDs(ctx, "missing", 10, func([]string) error {
+		t.Fatal("an unknown index has no documents")
+		return nil
+	}))
+}
+
+func TestMemoryClient_Search(t *testing.T) {
+	ctx := context.Background()
+	client, err := elasticsearch.NewMemoryClient("")
+	require.NoError(t, err)
+
+	alice := addResume(t, client, "Alice", "Backend engineer writing Go services", []string{"Go", "Kubernetes"}, []float32{1, 0, 0})
+	bob := addResume(t, client, "Bob", "Frontend developer", []string{"React"}, []float32{0, 1, 0})
+	carol := addResume(t, client, "Carol", "Platform engineer", []string{"Kubernetes"}, []float32{0.8, 0.6, 0})
+
+	results, err := client.KeywordSearch(ctx, testIndex, "go kubernetes")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, alice, results[0].Id)
+	assert.Equal(t, carol, results[1].Id)
+	assert.Greater(t, results[0].Point, results[1].Point)
+
+	results, err = client.VectorSearch(ctx, testIndex, []float32{0, 1, 0})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, []string{bob, carol, alice}, []string{results[0].Id, results[1].Id, results[2].Id})
+	assert.InDelta(t, 1.0, results[0].Point, 1e-6)
+	assert.InDelta(t, 0.5, results[2].Point, 1e-6)
+
+	results, err = client.HybridSearchWithBoost(ctx, testIndex, "ignored", []float32{1, 0, 0}, 1, 1, 1, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, carol, results[0].Id)
+
+	results, err = client.HybridSearchWithBoost(ctx, "other-index", "go", []float32{1, 0, 0}, 0, 10, 1, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestMemoryClient_SearchRequest(t *testing.T) {
+	client, err := elasticsearch.NewMemoryClient("")
+	require.NoError(t, err)
+
+	alice := addResume(t, client, "Alice", "Backend engineer writing Go services", []string{"Go"}, []float32{1, 0})
+	bob := addResume(t, client, "Bob", "Frontend developer", []string{"React"}, []float32{0, 1})
+	assert.Equal(t, 2, client.Count(testIndex))
+
+	hits := client.Search(testIndex, "", nil, 0, 1)
+	require.Len(t, hits, 2)
+	assert.Equal(t, []string{alice, bob}, []string{hits[0].ID, hits[1].ID})
+	assert.Equal(t, 1.0, hits[0].Score)
+	source, ok := client.Document(testIndex, alice)
+	require.True(t, ok)
+	assert.JSONEq(t, string(source), string(hits[0].Source))
+
+	// Bob is the nearest neighbour, but Alice also matches the query: her scores add up.
+	hits = client.Search(testIndex, "go", []float32{0, 1}, 1, 2)
+	require.Len(t, hits, 2)
+	assert.InDelta(t, 2.0, hits[0].Score, 1e-6)
+	assert.Equal(t, bob, hits[0].ID)
+	assert.Equal(t, alice, hits[1].ID)
+
+	hits = client.Search(testIndex, "", []float32{0, 1}, 1, 1)
+	require.Len(t, hits, 1)
+	assert.Equal(t, bob, hits[0].ID)
+	assert.Empty(t, client.Search("other-index", "go", nil, 0, 1))
+}
+
+func TestMemoryClient_UpdateDocument(t *testing.T) {
+	ctx := context.Background()
+	client, err := elasticsearch.NewMemoryClient("")
+	require.NoError(t, err)
+
+	previous := addResume(t, client, "Alice", "Backend engineer", []string{"Go"}, []float32{1, 0})
+	latest := addResume(t, client, "Alice", "Senior backend engineer", []string{"Go"}, []float32{0.9, 0.1})
+	require.NoError(t, client.UpdateDocument(ctx, testIndex, previous, map[string]interface{}{
+		"content": map[string]interface{}{"candidate_id": 7, "superseded": true},
+	}))
+	assert.Error(t, client.UpdateDocument(ctx, testIndex, "missing", map[string]interface{}{"content": map[string]interface{}{}}))
+
+	resume, err := client.GetDocumentByID(ctx, testIndex, previous)
+	require.NoError(t, err)
+	assert.Equal(t, "Backend engineer", resume.Summary, "an update must keep the other fields")
+	assert.Equal(t, int64(7), resume.CandidateID)
+	assert.True(t, resume.Superseded)
+
+	results, err := client.HybridSearchWithBoost(ctx, testIndex, "", []float32{1, 0}, 0, 10, 1, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 1, "superseded versions are not searched by default")
+	assert.Equal(t, latest, results[0].Id)
+
+	results, err = client.HybridSearchWithBoost(ctx, testIndex, "", []float32{1, 0}, 0, 10, 1, &elasticsearch.SearchFilter{AllVersions: true}, nil)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+}
+
+func TestMemoryClient_Persistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "index.json")
+
+	client, err := elasticsearch.NewMemoryClient(path)
+	require.NoError(t, err)
+	alice := addResume(t, client, "Alice", "Backend engineer", []string{"Go"}, []float32{1, 0})
+	bob := addResume(t, client, "Bob", "Frontend developer", []string{"React"}, []float32{0, 1})
+	require.NoError(t, client.DeleteDocumentByID(ctx, testIndex, alice))
+
+	restored, err := elasticsearch.NewMemoryClient(path)
+	require.NoError(t, err)
+	_, err = restored.GetDocumentByID(ctx, testIndex, alice)
+	assert.Error(t, err)
+	resume, err := restored.GetDocumentByID(ctx, testIndex, bob)
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", resume.BasicInfo.FullName)
+}
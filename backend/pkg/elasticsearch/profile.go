@@ -0,0 +1,108 @@
+package elasticsearch
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Proficiency levels of a Language, from the highest.
+const (
+	ProficiencyNative       = "native"
+	ProficiencyFluent       = "fluent"
+	ProficiencyProfessional = "professional"
+	ProficiencyIntermediate = "intermediate"
+	ProficiencyBasic        = "basic"
+)
+
+// proficiencyWords maps the wording of resumes, CEFR and LinkedIn levels included, to a proficiency
+// level. The first entry found in a stated level wins, so longer phrases come before the words
+// they contain.
+var proficiencyWords = []struct {
+	word  string
+	level string
+}{
+	{"mother tongue", ProficiencyNative},
+	{"native", ProficiencyNative},
+	{"bilingual", ProficiencyNative},
+	{"c2", ProficiencyFluent},
+	{"fluent", ProficiencyFluent},
+	{"fluency", ProficiencyFluent},
+	{"advanced", ProficiencyFluent},
+	{"c1", ProficiencyFluent},
+	{"limited working", ProficiencyIntermediate},
+	{"professional", ProficiencyProfessional},
+	{"b2", ProficiencyIntermediate},
+	{"b1", ProficiencyIntermediate},
+	{"intermediate", ProficiencyIntermediate},
+	{"conversational", ProficiencyIntermediate},
+	{"a2", ProficiencyBasic},
+	{"a1", ProficiencyBasic},
+	{"elementary", ProficiencyBasic},
+	{"beginner", ProficiencyBasic},
+	{"basic", ProficiencyBasic},
+}
+
+// NormalizeProficiency returns the proficiency level a stated level stands for, or "" when it does
+// not name one.
+func NormalizeProficiency(stated string) string {
+	stated = strings.ToLower(strings.TrimSpace(stated))
+	if stated == "" {
+		return ""
+	}
+	for _, candidate := range proficiencyWords {
+		if containsWord(stated, candidate.word) {
+			return candidate.level
+		}
+	}
+	return ""
+}
+
+// containsWord reports whether word is in s and not part of a longer word.
+func containsWord(s, word string) bool {
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start, end := offset+i, offset+i+len(word)
+		if (start == 0 || !isWordByte(s[start-1])) && (end == len(s) || !isWordByte(s[end])) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isWordByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= '0' && b <= '9'
+}
+
+// Kinds of a Link.
+const (
+	LinkKindLinkedIn      = "linkedin"
+	LinkKindGitHub        = "github"
+	LinkKindGitLab        = "gitlab"
+	LinkKindStackOverflow = "stackoverflow"
+	LinkKindWebsite       = "website"
+)
+
+var linkKindHosts = map[string]string{
+	"linkedin.com":      LinkKindLinkedIn,
+	"github.com":        LinkKindGitHub,
+	"gitlab.com":        LinkKindGitLab,
+	"stackoverflow.com": LinkKindStackOverflow,
+}
+
+// LinkKindOf classifies a profile URL by its host; any other page is a website.
+func LinkKindOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return LinkKindWebsite
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for domain, kind := range linkKindHosts {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return kind
+		}
+	}
+	return LinkKindWebsite
+}
@@ -0,0 +1,34 @@
+package elasticsearch_test
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeProficiency(t *testing.T) {
+	levels := map[string]string{
+		"Native":                             elasticsearch.ProficiencyNative,
+		"Native or bilingual proficiency":    elasticsearch.ProficiencyNative,
+		"C1 (Advanced)":                      elasticsearch.ProficiencyFluent,
+		"Full professional proficiency":      elasticsearch.ProficiencyProfessional,
+		"Limited working proficiency":        elasticsearch.ProficiencyIntermediate,
+		"IELTS 6.5, upper-intermediate (B2)": elasticsearch.ProficiencyIntermediate,
+		"a2":                                 elasticsearch.ProficiencyBasic,
+		"":                                   "",
+		"TOEIC 850":                          "",
+		// Letters and digits inside a word are not levels.
+		"abc1": "",
+	}
+	for stated, want := range levels {
+		assert.Equal(t, want, elasticsearch.NormalizeProficiency(stated), stated)
+	}
+}
+
+func TestLinkKindOf(t *testing.T) {
+	assert.Equal(t, elasticsearch.LinkKindLinkedIn, elasticsearch.LinkKindOf("https://vn.linkedin.com/in/alice"))
+	assert.Equal(t, elasticsearch.LinkKindGitHub, elasticsearch.LinkKindOf("https://github.com/alice"))
+	assert.Equal(t, elasticsearch.LinkKindWebsite, elasticsearch.LinkKindOf("https://notgithub.com/alice"))
+	assert.Equal(t, elasticsearch.LinkKindWebsite, elasticsearch.LinkKindOf("https://alice.dev"))
+}
@@ -0,0 +1,127 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/sortmode"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/sortorder"
+)
+
+// Orders of search results.
+const (
+	SortRelevance         = "relevance"
+	SortYearsOfExperience = "years_of_experience"
+	SortSkillYears        = "skill_years"
+	SortTitleYears        = "title_years"
+)
+
+// SearchSort orders search results by years of experience: in total, with the skill Name
+// (matched exactly) or with a title containing Name, as normalized by ExperienceSkill and
+// ExperienceTitle. Results are sorted in descending order unless Ascending is set; results without
+// the years come last, and ties keep their relevance order. An empty By keeps the relevance order.
+type SearchSort struct {
+	By        string `json:"by"`
+	Name      string `json:"name,omitempty"`
+	Ascending bool   `json:"ascending,omitempty"`
+}
+
+// Validate checks that the order is known and has a Name when it needs one.
+func (s *SearchSort) Validate() error {
+	if s == nil {
+		return nil
+	}
+	switch s.By {
+	case "", SortRelevance, SortYearsOfExperience:
+		return nil
+	case SortSkillYears, SortTitleYears:
+		if strings.TrimSpace(s.Name) == "" {
+			return fmt.Errorf("sorting by %s needs a name", s.By)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown sort order %q", s.By)
+}
+
+func (s *SearchSort) byRelevance() bool {
+	return s == nil || s.By == "" || s.By == SortRelevance
+}
+
+// options returns the sort as Elasticsearch sort options, or nil for the relevance order.
+func (s *SearchSort) options() []types.SortCombinations {
+	if s.byRelevance() {
+		return nil
+	}
+	order := sortorder.Desc
+	if s.Ascending {
+		order = sortorder.Asc
+	}
+	field := types.FieldSort{Order: &order, Missing: "_last"}
+	name := "content.years_of_experience"
+	switch s.By {
+	case SortSkillYears:
+		name = "content.skill_experience.years"
+		field.Mode, field.Nested = &sortmode.Max, &types.NestedSortValue{
+			Path: "content.skill_experience",
+			Filter: &types.Query{Term: map[string]types.TermQuery{
+				"content.skill_experience.name": {Value: ExperienceSkill(s.Name)},
+			}},
+		}
+	case SortTitleYears:
+		name = "content.title_experience.years"
+		field.Mode, field.Nested = &sortmode.Max, &types.NestedSortValue{
+			Path: "content.title_experience",
+			Filter: &types.Query{MatchPhrase: map[string]types.MatchPhraseQuery{
+				"content.title_experience.name": {Query: ExperienceTitle(s.Name)},
+			}},
+		}
+	}
+	return []types.SortCombinations{
+		types.SortOptions{SortOptions: map[string]types.FieldSort{name: field}},
+		types.SortOptions{Score_: &types.ScoreSort{Order: &sortorder.Desc}},
+	}
+}
+
+// years returns the years a resume is sorted by, with the same semantics as the options, and
+// whether it has them.
+func (s *SearchSort) years(resume *ResumeSummaryDTO) (float64, bool) {
+	var experience []ExperienceYears
+	var matches func(name string) bool
+	switch s.By {
+	case SortYearsOfExperience:
+		return resume.YearsOfExperience, true
+	case SortSkillYears:
+		name := ExperienceSkill(s.Name)
+		experience, matches = resume.SkillExperience, func(got string) bool { return got == name }
+	case SortTitleYears:
+		name := ExperienceTitle(s.Name)
+		experience, matches = resume.TitleExperience, func(got string) bool { return containsFold(got, name) }
+	}
+	years, found := 0.0, false
+	for _, item := range experience {
+		if matches(item.Name) && (!found || item.Years > years) {
+			years, found = item.Years, true
+		}
+	}
+	return years, found
+}
+
+// sortResumes orders resumes, already in relevance order, by the sort.
+func (s *SearchSort) sortResumes(resumes []ResumeSummaryDTO) {
+	if s.byRelevance() {
+		return
+	}
+	sort.SliceStable(resumes, func(i, j int) bool {
+		a, aFound := s.years(&resumes[i])
+		b, bFound := s.years(&resumes[j])
+		switch {
+		case !aFound || !bFound:
+			return aFound && !bFound
+		case s.Ascending:
+			return a < b
+		}
+		return a > b
+	})
+}
@@ -0,0 +1,100 @@
+// Package extract pulls the text out of resume files, for the uploads that arrive without the
+// text the frontend extracts, such as the files of an imported archive.
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnsupported is returned for files of a type text is not extracted from.
+	ErrUnsupported = errors.New("unsupported file type")
+	// ErrNoText is returned for files without usable text, such as scanned PDFs or PDFs whose
+	// fonts do not map their glyphs to characters.
+	ErrNoText = errors.New("no text could be extracted")
+	// ErrTooLarge is returned for PDFs whose streams decode to more data than a resume could
+	// hold, such as compression bombs.
+	ErrTooLarge = errors.New("the file decodes to too much data")
+	// ErrMalformed is wrapped by the error of a PDF whose structure made the reader fail.
+	ErrMalformed = errors.New("malformed PDF")
+)
+
+// minLetters is the number of letters below which a file is considered to have no text.
+const minLetters = 20
+
+// Supported reports whether text is extracted from files named name.
+func Supported(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".pdf", ".txt", ".md":
+		return true
+	}
+	return false
+}
+
+// IsPDF reports whether a file named name is a PDF.
+func IsPDF(name string) bool {
+	return strings.ToLower(path.Ext(name)) == ".pdf"
+}
+
+// Text returns the text of the file named name with content data. PDFs are read with the text
+// operators of their pages, so that a scanned PDF has no text; text files are read as UTF-8.
+func Text(name string, data []byte) (text string, err error) {
+	if !Supported(name) {
+		return "", ErrUnsupported
+	}
+	if IsPDF(name) {
+		// The file comes from a user: a structure the reader does not expect fails the file
+		// rather than the caller.
+		defer func() {
+			if r := recover(); r != nil {
+				text, err = "", fmt.Errorf("%w: %v", ErrMalformed, r)
+			}
+		}()
+		text, err = pdfText(data)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		text = strings.ToValidUTF8(string(data), "")
+	}
+
+	text = normalize(text)
+	if !usable(text) {
+		return "", ErrNoText
+	}
+	return text, nil
+}
+
+// normalize collapses the spaces of each line and the blank lines between them.
+func normalize(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// usable reports whether text has enough letters and few enough unprintable characters to be
+// the text of a resume rather than glyph codes read as characters.
+func usable(text string) bool {
+	letters, unprintable := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r):
+			letters++
+		case r == utf8.RuneError || !unicode.IsPrint(r) && !unicode.IsSpace(r):
+			unprintable++
+		}
+	}
+	return letters >= minLetters && unprintable*10 < utf8.RuneCountInString(text)
+}
@@ -0,0 +1,142 @@
+package extract_test
+
+import (
+	"CVSeeker/pkg/extract"
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPDF returns a PDF with one page showing content, with the font object font as /F1. Streams
+// are given as "stream:<data>" and are compressed.
+func buildPDF(content string, objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	all := append([]string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 5 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R >>",
+		"stream:" + content,
+	}, objects...)
+	for i, object := range all {
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		if data, ok := bytes.CutPrefix([]byte(object), []byte("stream:")); ok {
+			var compressed bytes.Buffer
+			w := zlib.NewWriter(&compressed)
+			_, _ = w.Write(data)
+			_ = w.Close()
+			fmt.Fprintf(&buf, "<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\n", compressed.Len(), compressed.Bytes())
+		} else {
+			buf.WriteString(object + "\n")
+		}
+		buf.WriteString("endobj\n")
+	}
+	buf.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return buf.Bytes()
+}
+
+func TestTextSimpleFont(t *testing.T) {
+	content := `BT /F1 12 Tf 72 720 Td (Jane Doe) Tj 0 -14 Td [(Senior Go Devel) 20 (oper)] TJ
+0 -14 Td (Ho Chi Minh City \(Remote\)) Tj 60 0 Td (jane@example.com) Tj ET`
+	pdf := buildPDF(content, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+
+	text, err := extract.Text("Jane.PDF", pdf)
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe\nSenior Go Developer\nHo Chi Minh City (Remote) jane@example.com", text)
+}
+
+func TestTextToUnicode(t *testing.T) {
+	cmap := `/CIDInit /ProcSet findresource begin 12 dict begin begincmap
+1 begincodespacerange <0000> <FFFF> endcodespacerange
+1 beginbfchar <0003> <0020> endbfchar
+2 beginbfrange <0010> <0029> <0041> <0030> <0031> [<1EC5> <0111>] endbfrange
+endcmap end end`
+	content := fmt.Sprintf("BT /F1 10 Tf 50 700 Td <%s> Tj 0 -12 Td <%s00300031> Tj ET", cids("NGUYEN VAN AN"), cids("SENIOR GO DEVELOPER "))
+	pdf := buildPDF(content,
+		"<< /Type /Font /Subtype /Type0 /BaseFont /Arial /Encoding /Identity-H /DescendantFonts [7 0 R] /ToUnicode 6 0 R >>",
+		"stream:"+cmap,
+		"<< /Type /Font /Subtype /CIDFontType2 /DW 600 >>")
+
+	text, err := extract.Text("resume.pdf", pdf)
+	require.NoError(t, err)
+	assert.Equal(t, "NGUYEN VAN AN\nSENIOR GO DEVELOPER ễđ", text)
+}
+
+// cids returns the codes of the font of TestTextToUnicode for text in capitals.
+func cids(text string) string {
+	var out string
+	for _, r := range text {
+		if r == ' ' {
+			out += "0003"
+		} else {
+			out += fmt.Sprintf("%04X", 0x10+r-'A')
+		}
+	}
+	return out
+}
+
+func TestTextWithoutText(t *testing.T) {
+	// A scanned resume draws an image and shows no text.
+	pdf := buildPDF("q 612 0 0 792 0 0 cm /Im1 Do Q", "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	_, err := extract.Text("scan.pdf", pdf)
+	assert.ErrorIs(t, err, extract.ErrNoText)
+
+	_, err = extract.Text("broken.pdf", []byte("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages [[[[[ "))
+	assert.Error(t, err)
+}
+
+func TestTextDecodedSizeBudget(t *testing.T) {
+	// A page whose content stream, 64 MiB of spaces compressed to a few KiB, is shown three times.
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	buf.WriteString("2 0 obj\n<< /Type /Pages /Kids [3 0 R] /Count 1 >>\nendobj\n")
+	buf.WriteString("3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents [4 0 R 4 0 R 4 0 R] >>\nendobj\n")
+	var compressed bytes.Buffer
+	w := zlib.NewWriter(&compressed)
+	_, _ = w.Write(bytes.Repeat([]byte(" "), 64<<20))
+	_ = w.Close()
+	fmt.Fprintf(&buf, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream\nendobj\n", compressed.Len(), compressed.Bytes())
+
+	_, err := extract.Text("bomb.pdf", buf.Bytes())
+	assert.ErrorIs(t, err, extract.ErrTooLarge)
+}
+
+// FuzzExtract checks that no PDF makes the reader fail other than with an error.
+func FuzzExtract(f *testing.F) {
+	f.Add(buildPDF(`BT /F1 12 Tf 72 720 Td (Jane Doe) Tj 0 -14 Td [(Senior Go Devel) 20 (oper)] TJ ET`,
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /Differences [32 /space 65 /A /B] >> >>"))
+	f.Add(buildPDF(fmt.Sprintf("BT /F1 10 Tf 50 700 Td <%s> Tj ET", cids("NGUYEN VAN AN")),
+		"<< /Type /Font /Subtype /Type0 /Encoding /Identity-H /DescendantFonts [7 0 R] /ToUnicode 6 0 R >>",
+		"stream:1 begincodespacerange <0000> <FFFF> endcodespacerange 1 beginbfrange <0010> <0029> <0041> endbfrange",
+		"<< /Type /Font /Subtype /CIDFontType2 /W [16 [500 600] 20 30 700] >>"))
+	f.Add(buildPDF("q /Fm1 Do Q", "<< /Type /XObject /Subtype /Form /Resources << >> /Length 0 >>"))
+	f.Add([]byte("%PDF-1.4\n1 0 obj << /Type /Catalog /Pages [[[[[ "))
+	f.Add([]byte("%PDF-1.5\n1 0 obj\n<< /Type /ObjStm /N 2 /First 8 /Length 20 >>\nstream\n2 0 3 5 (a) [1]\nendstream\nendobj\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		text, err := extract.Text("resume.pdf", data)
+		if errors.Is(err, extract.ErrMalformed) {
+			t.Fatalf("the reader failed: %v", err)
+		}
+		if err == nil && !utf8.ValidString(text) {
+			t.Fatalf("invalid UTF-8 text %q", text)
+		}
+	})
+}
+
+func TestTextFiles(t *testing.T) {
+	text, err := extract.Text("notes/jane.txt", []byte("Jane   Doe\r\n\r\n\r\nGo developer with ten years of experience\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "Jane Doe\n\nGo developer with ten years of experience", text)
+
+	_, err = extract.Text("photo.png", []byte("\x89PNG"))
+	assert.ErrorIs(t, err, extract.ErrUnsupported)
+	assert.False(t, extract.Supported("resume.docx"))
+	assert.True(t, extract.Supported("RESUME.PDF"))
+}
@@ -0,0 +1,491 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// The values of a PDF object. Numbers are float64 and booleans and null are keywords.
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfString  []byte
+	pdfRef     int
+	pdfArray   []interface{}
+	pdfDict    map[pdfName]interface{}
+)
+
+// maxStreamSize bounds the decoded size of a stream, and maxDecodedSize that of all the streams of a
+// file, decoded as many times as they are used, so that a small file cannot expand without limit.
+const (
+	maxStreamSize  = 64 << 20
+	maxDecodedSize = 128 << 20
+)
+
+var (
+	errEncrypted         = errors.New("encrypted PDF")
+	errUnsupportedFilter = errors.New("unsupported stream filter")
+	errTooDeep           = errors.New("objects nested too deeply")
+	objectHeader         = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+)
+
+// lexer reads the tokens and values of PDF syntax, in files and in content streams.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isDelimiter(c byte) bool {
+	return isSpace(c) || bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (_this *lexer) skipSpace() {
+	for _this.pos < len(_this.data) {
+		c := _this.data[_this.pos]
+		if c == '%' {
+			for _this.pos < len(_this.data) && _this.data[_this.pos] != '\n' && _this.data[_this.pos] != '\r' {
+				_this.pos++
+			}
+			continue
+		}
+		if !isSpace(c) {
+			return
+		}
+		_this.pos++
+	}
+}
+
+// token returns the next name, number, string or keyword. The delimiters of dictionaries and
+// arrays are keywords.
+func (_this *lexer) token() (interface{}, error) {
+	for {
+		_this.skipSpace()
+		if _this.pos >= len(_this.data) {
+			return nil, io.EOF
+		}
+		c := _this.data[_this.pos]
+		switch {
+		case c == '/':
+			_this.pos++
+			return pdfName(_this.regular()), nil
+		case c == '(':
+			_this.pos++
+			return _this.literal(), nil
+		case c == '<' || c == '>':
+			if _this.pos+1 < len(_this.data) && _this.data[_this.pos+1] == c {
+				_this.pos += 2
+				return pdfKeyword([]byte{c, c}), nil
+			}
+			_this.pos++
+			if c == '<' {
+				return _this.hex(), nil
+			}
+			continue
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			_this.pos++
+			return pdfKeyword([]byte{c}), nil
+		}
+		word := _this.regular()
+		if word == "" {
+			// A delimiter out of place, such as a stray ")": skip it.
+			_this.pos++
+			continue
+		}
+		if c == '+' || c == '-' || c == '.' || c >= '0' && c <= '9' {
+			if n, err := strconv.ParseFloat(word, 64); err == nil {
+				return n, nil
+			}
+		}
+		return pdfKeyword(word), nil
+	}
+}
+
+// regular reads the regular characters up to the next delimiter, decoding the #xx escapes of names.
+func (_this *lexer) regular() string {
+	start := _this.pos
+	for _this.pos < len(_this.data) && !isDelimiter(_this.data[_this.pos]) {
+		_this.pos++
+	}
+	word := _this.data[start:_this.pos]
+	if bytes.IndexByte(word, '#') < 0 {
+		return string(word)
+	}
+	var out []byte
+	for i := 0; i < len(word); i++ {
+		if word[i] == '#' && i+2 < len(word) {
+			if b, err := strconv.ParseUint(string(word[i+1:i+3]), 16, 8); err == nil {
+				out = append(out, byte(b))
+				i += 2
+				continue
+			}
+		}
+		out = append(out, word[i])
+	}
+	return string(out)
+}
+
+// literal reads a string in parentheses, after the opening one.
+func (_this *lexer) literal() pdfString {
+	var out []byte
+	depth := 1
+	for _this.pos < len(_this.data) {
+		c := _this.data[_this.pos]
+		_this.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+		case '\\':
+			if _this.pos >= len(_this.data) {
+				return out
+			}
+			c = _this.data[_this.pos]
+			_this.pos++
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// A backslash at the end of a line continues the string on the next one.
+				if _this.pos < len(_this.data) && _this.data[_this.pos] == '\n' {
+					_this.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					n := int(c - '0')
+					for i := 0; i < 2 && _this.pos < len(_this.data) && _this.data[_this.pos] >= '0' && _this.data[_this.pos] <= '7'; i++ {
+						n = n*8 + int(_this.data[_this.pos]-'0')
+						_this.pos++
+					}
+					c = byte(n)
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// hex reads a string of hexadecimal digits, after the opening "<".
+func (_this *lexer) hex() pdfString {
+	var digits []byte
+	for _this.pos < len(_this.data) && _this.data[_this.pos] != '>' {
+		c := _this.data[_this.pos]
+		if c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F' {
+			digits = append(digits, c)
+		}
+		_this.pos++
+	}
+	_this.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		b, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(b)
+	}
+	return out
+}
+
+// maxNesting bounds the depth of dictionaries and arrays, which real files keep shallow.
+const maxNesting = 64
+
+// object reads the next value, with its dictionaries, arrays and references, or a keyword.
+func (_this *lexer) object() (interface{}, error) {
+	return _this.nested(0)
+}
+
+func (_this *lexer) nested(depth int) (interface{}, error) {
+	if depth > maxNesting {
+		return nil, errTooDeep
+	}
+	tok, err := _this.token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case pdfKeyword("<<"):
+		dict := pdfDict{}
+		for {
+			key, err := _this.nested(depth + 1)
+			if err == errTooDeep {
+				return nil, err
+			}
+			if err != nil || key == pdfKeyword(">>") {
+				return dict, nil
+			}
+			name, ok := key.(pdfName)
+			if !ok {
+				continue
+			}
+			value, err := _this.nested(depth + 1)
+			if err == errTooDeep {
+				return nil, err
+			}
+			if err != nil {
+				return dict, nil
+			}
+			if value == pdfKeyword(">>") {
+				return dict, nil
+			}
+			dict[name] = value
+		}
+	case pdfKeyword("["):
+		var array pdfArray
+		for {
+			value, err := _this.nested(depth + 1)
+			if err == errTooDeep {
+				return nil, err
+			}
+			if err != nil || value == pdfKeyword("]") {
+				return array, nil
+			}
+			array = append(array, value)
+		}
+	}
+
+	// "n g R" is a reference to object n.
+	if n, ok := tok.(float64); ok && n == float64(int(n)) && n >= 0 {
+		pos := _this.pos
+		if g, err := _this.token(); err == nil {
+			if _, ok := g.(float64); ok {
+				if r, err := _this.token(); err == nil && r == pdfKeyword("R") {
+					return pdfRef(n), nil
+				}
+			}
+		}
+		_this.pos = pos
+	}
+	return tok, nil
+}
+
+// pdfObject is an indirect object of a file, with the raw data of its stream if it has one.
+type pdfObject struct {
+	value  interface{}
+	stream []byte
+}
+
+// pdfFile holds the objects of a PDF by number.
+type pdfFile struct {
+	objects map[int]*pdfObject
+	// decoded is the size of the data decoded from the streams so far, up to maxDecodedSize.
+	decoded int
+}
+
+// readPDF reads the objects of a PDF by scanning the file for them rather than through its
+// cross-reference table, so that files with a damaged table are still read. A later definition of
+// an object, from an incremental update, replaces an earlier one.
+func readPDF(data []byte) (*pdfFile, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \r\n\t"), []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+	file := &pdfFile{objects: map[int]*pdfObject{}}
+	end := 0
+	for _, match := range objectHeader.FindAllSubmatchIndex(data, -1) {
+		// Skip the matches inside the streams of objects already read.
+		if match[0] < end {
+			continue
+		}
+		num, err := strconv.Atoi(string(data[match[2]:match[3]]))
+		if err != nil {
+			continue
+		}
+		l := &lexer{data: data, pos: match[1]}
+		value, err := l.object()
+		if err != nil {
+			break
+		}
+		object := &pdfObject{value: value}
+		end = l.pos
+		if dict, ok := value.(pdfDict); ok {
+			next := l.pos
+			if tok, err := l.token(); err == nil && tok == pdfKeyword("stream") {
+				object.stream, end = streamData(data, l.pos, dict)
+			} else {
+				l.pos = next
+			}
+		}
+		file.objects[num] = object
+	}
+	if len(file.objects) == 0 {
+		return nil, fmt.Errorf("no objects found")
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, errEncrypted
+	}
+	file.readObjectStreams()
+	return file, nil
+}
+
+// streamData returns the data of a stream starting after the "stream" keyword at pos, and the
+// position after it. The length in the dictionary is used when it is direct and ends at
+// "endstream"; otherwise the data runs to the next "endstream".
+func streamData(data []byte, pos int, dict pdfDict) ([]byte, int) {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+	if length, ok := dict["Length"].(float64); ok && length >= 0 && pos+int(length) <= len(data) {
+		end := pos + int(length)
+		if bytes.HasPrefix(bytes.TrimLeft(data[end:], " \r\n\t"), []byte("endstream")) {
+			return data[pos:end], end
+		}
+	}
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end < 0 {
+		return data[pos:], len(data)
+	}
+	return bytes.TrimRight(data[pos:pos+end], "\r\n"), pos + end
+}
+
+// readObjectStreams reads the objects compressed into object streams, unless they are also
+// defined directly.
+func (_this *pdfFile) readObjectStreams() {
+	nums := make([]int, 0, len(_this.objects))
+	for num := range _this.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		object := _this.objects[num]
+		dict, ok := object.value.(pdfDict)
+		if !ok || object.stream == nil || dict["Type"] != pdfName("ObjStm") {
+			continue
+		}
+		data, err := _this.decode(object)
+		if err != nil {
+			continue
+		}
+		count, _ := _this.resolve(dict["N"]).(float64)
+		first, _ := _this.resolve(dict["First"]).(float64)
+		header := &lexer{data: data}
+		for i := 0; i < int(count); i++ {
+			objNum, err1 := header.token()
+			offset, err2 := header.token()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			n, ok1 := objNum.(float64)
+			off, ok2 := offset.(float64)
+			if !ok1 || !ok2 || int(first+off) >= len(data) || int(first+off) < 0 {
+				continue
+			}
+			if _, defined := _this.objects[int(n)]; defined {
+				continue
+			}
+			l := &lexer{data: data, pos: int(first + off)}
+			if value, err := l.object(); err == nil {
+				_this.objects[int(n)] = &pdfObject{value: value}
+			}
+		}
+	}
+}
+
+// resolve follows references until it reaches a direct value.
+func (_this *pdfFile) resolve(value interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			return value
+		}
+		object := _this.objects[int(ref)]
+		if object == nil {
+			return nil
+		}
+		value = object.value
+	}
+	return nil
+}
+
+// dict resolves value to a dictionary, or an empty one.
+func (_this *pdfFile) dict(value interface{}) pdfDict {
+	if dict, ok := _this.resolve(value).(pdfDict); ok {
+		return dict
+	}
+	return pdfDict{}
+}
+
+// stream returns the decoded data of the stream value refers to.
+func (_this *pdfFile) stream(value interface{}) ([]byte, error) {
+	for i := 0; i < 32; i++ {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			break
+		}
+		object := _this.objects[int(ref)]
+		if object == nil {
+			return nil, fmt.Errorf("missing object %d", ref)
+		}
+		if object.stream != nil {
+			return _this.decode(object)
+		}
+		value = object.value
+	}
+	return nil, fmt.Errorf("not a stream")
+}
+
+// decode applies the filters of a stream. Only Flate is supported, which is what text and fonts
+// are compressed with; other filters are used for images. Once the streams of the file have been
+// decoded to maxDecodedSize, it fails with ErrTooLarge.
+func (_this *pdfFile) decode(object *pdfObject) ([]byte, error) {
+	dict, _ := object.value.(pdfDict)
+	var filters pdfArray
+	switch filter := _this.resolve(dict["Filter"]).(type) {
+	case pdfName:
+		filters = pdfArray{filter}
+	case pdfArray:
+		filters = filter
+	}
+
+	data := object.stream
+	for _, filter := range filters {
+		switch _this.resolve(filter) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			remaining := maxDecodedSize - _this.decoded
+			// A truncated stream keeps what could be read of it.
+			decoded, err := io.ReadAll(io.LimitReader(r, int64(min(maxStreamSize, remaining+1))))
+			_this.decoded += len(decoded)
+			if _this.decoded > maxDecodedSize {
+				return nil, ErrTooLarge
+			}
+			if err != nil && len(decoded) == 0 {
+				return nil, err
+			}
+			data = decoded
+		default:
+			return nil, errUnsupportedFilter
+		}
+	}
+	return data, nil
+}
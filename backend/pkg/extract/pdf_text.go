@@ -0,0 +1,615 @@
+package extract
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Bounds on the work done for one file, against files built to make the reader loop.
+const (
+	maxPages      = 200
+	maxFormDepth  = 4
+	maxCMapRanges = 1 << 17
+)
+
+// pdfText returns the text shown on the pages of a PDF, a line for each line of text.
+func pdfText(data []byte) (string, error) {
+	file, err := readPDF(data)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	fonts := map[pdfRef]*pdfFont{}
+	for _, page := range file.pages() {
+		content := file.contents(page.dict["Contents"])
+		file.showText(&out, content, page.resources, fonts, 0)
+		out.WriteString("\n\n")
+		if file.decoded > maxDecodedSize {
+			break
+		}
+	}
+	if file.decoded > maxDecodedSize {
+		return "", ErrTooLarge
+	}
+	return out.String(), nil
+}
+
+// pdfPage is a page with the resources it inherits from its parents.
+type pdfPage struct {
+	dict      pdfDict
+	resources pdfDict
+}
+
+// pages returns the pages in the order of the page tree of the catalog. Files without a catalog
+// have their pages in the order of their object numbers.
+func (_this *pdfFile) pages() []pdfPage {
+	var catalog pdfDict
+	nums := make([]int, 0, len(_this.objects))
+	for num := range _this.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		if dict, ok := _this.objects[num].value.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+			catalog = dict
+		}
+	}
+
+	var pages []pdfPage
+	if catalog != nil {
+		visited := map[pdfRef]bool{}
+		var walk func(node interface{}, resources pdfDict)
+		walk = func(node interface{}, resources pdfDict) {
+			if ref, ok := node.(pdfRef); ok {
+				if visited[ref] {
+					return
+				}
+				visited[ref] = true
+			}
+			dict := _this.dict(node)
+			if own, ok := _this.resolve(dict["Resources"]).(pdfDict); ok {
+				resources = own
+			}
+			kids, isTree := _this.resolve(dict["Kids"]).(pdfArray)
+			if !isTree {
+				if dict["Type"] == pdfName("Page") && len(pages) < maxPages {
+					pages = append(pages, pdfPage{dict: dict, resources: resources})
+				}
+				return
+			}
+			for _, kid := range kids {
+				walk(kid, resources)
+			}
+		}
+		walk(catalog["Pages"], pdfDict{})
+	}
+	if len(pages) > 0 {
+		return pages
+	}
+
+	for _, num := range nums {
+		dict, ok := _this.objects[num].value.(pdfDict)
+		if ok && dict["Type"] == pdfName("Page") && len(pages) < maxPages {
+			pages = append(pages, pdfPage{dict: dict, resources: _this.dict(dict["Resources"])})
+		}
+	}
+	return pages
+}
+
+// contents returns the content streams of a page joined together. Streams that cannot be decoded
+// are left out.
+func (_this *pdfFile) contents(value interface{}) []byte {
+	refs := pdfArray{value}
+	if array, ok := _this.resolve(value).(pdfArray); ok {
+		refs = array
+	}
+	var content []byte
+	for _, ref := range refs {
+		if data, err := _this.stream(ref); err == nil {
+			content = append(append(content, data...), '\n')
+		}
+	}
+	return content
+}
+
+// Gaps between pieces of text, in ems of the font, that are read as a space or a line break.
+const (
+	wordGap = 0.15
+	lineGap = 0.5
+)
+
+// textState follows where text is shown, in user space, so that the gaps between the pieces of text
+// a content stream shows can be read as spaces and line breaks.
+type textState struct {
+	out   *strings.Builder
+	font  *pdfFont
+	size  float64
+	scale float64
+	// lineX and lineY are the start of the current line, and x where the next glyph goes.
+	lineX, lineY, x float64
+	leading         float64
+	// endX and endY are where the last text shown ended.
+	endX, endY float64
+	shown      bool
+	newLine    bool
+}
+
+func (_this *textState) moveTo(x, y float64) {
+	_this.lineX, _this.lineY, _this.x = x, y, x
+}
+
+func (_this *textState) em() float64 {
+	return _this.size * _this.scale
+}
+
+// show writes a string shown with the current font, after a space or a line break if it starts
+// away from where the last one ended.
+func (_this *textState) show(value interface{}) {
+	s, ok := value.(pdfString)
+	if !ok || _this.font == nil {
+		return
+	}
+	em := math.Abs(_this.em())
+	if _this.shown {
+		gap := _this.x - _this.endX
+		switch {
+		case _this.newLine || math.Abs(_this.lineY-_this.endY) > lineGap*em:
+			_this.out.WriteByte('\n')
+		case gap > wordGap*em || gap < -em:
+			_this.out.WriteByte(' ')
+		}
+	}
+	_this.out.WriteString(_this.font.decode(s))
+	_this.x += _this.font.width(s) / 1000 * _this.em()
+	_this.endX, _this.endY = _this.x, _this.lineY
+	_this.shown, _this.newLine = true, false
+}
+
+// nextLine moves to the start of the next line.
+func (_this *textState) nextLine() {
+	_this.moveTo(_this.lineX, _this.lineY-_this.leading*_this.scale)
+	_this.newLine = true
+}
+
+// showText writes the text shown by the operators of a content stream.
+func (_this *pdfFile) showText(out *strings.Builder, content []byte, resources pdfDict, fonts map[pdfRef]*pdfFont, depth int) {
+	l := &lexer{data: content}
+	var operands []interface{}
+	state := &textState{out: out, size: 1, scale: 1}
+	number := func(i int) float64 {
+		if i < 0 || i >= len(operands) {
+			return 0
+		}
+		n, _ := operands[i].(float64)
+		return n
+	}
+
+	for {
+		value, err := l.object()
+		if err != nil {
+			return
+		}
+		op, ok := value.(pdfKeyword)
+		if !ok {
+			operands = append(operands, value)
+			continue
+		}
+		last := len(operands) - 1
+		switch op {
+		case "BI":
+			l.skipInlineImage()
+		case "BT":
+			state.scale = 1
+			state.moveTo(0, 0)
+		case "Tf":
+			if len(operands) >= 2 {
+				if name, ok := operands[last-1].(pdfName); ok {
+					state.font = _this.font(resources, name, fonts)
+				}
+				state.size = number(last)
+			}
+		case "TL":
+			state.leading = number(last)
+		case "Td":
+			state.moveTo(state.lineX+number(last-1)*state.scale, state.lineY+number(last)*state.scale)
+		case "TD":
+			state.leading = -number(last)
+			state.moveTo(state.lineX+number(last-1)*state.scale, state.lineY+number(last)*state.scale)
+		case "Tm":
+			state.scale = math.Hypot(number(last-5), number(last-4))
+			state.moveTo(number(last-1), number(last))
+		case "T*":
+			state.nextLine()
+		case "Tj":
+			if last >= 0 {
+				state.show(operands[last])
+			}
+		case "'", "\"":
+			state.nextLine()
+			if last >= 0 {
+				state.show(operands[last])
+			}
+		case "TJ":
+			if last >= 0 {
+				array, _ := operands[last].(pdfArray)
+				for _, item := range array {
+					if n, ok := item.(float64); ok {
+						state.x -= n / 1000 * state.em()
+					}
+					state.show(item)
+				}
+			}
+		case "Do":
+			if last >= 0 && depth < maxFormDepth {
+				name, _ := operands[last].(pdfName)
+				xobject := _this.dict(resources["XObject"])[name]
+				dict := _this.dict(xobject)
+				if dict["Subtype"] == pdfName("Form") {
+					if data, err := _this.stream(xobject); err == nil {
+						formResources := resources
+						if own, ok := _this.resolve(dict["Resources"]).(pdfDict); ok {
+							formResources = own
+						}
+						out.WriteByte('\n')
+						_this.showText(out, data, formResources, fonts, depth+1)
+						out.WriteByte('\n')
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// skipInlineImage skips the data of an inline image, up to its "EI" operator.
+func (_this *lexer) skipInlineImage() {
+	for _this.pos+2 < len(_this.data) {
+		if _this.data[_this.pos] == 'E' && _this.data[_this.pos+1] == 'I' && isSpace(_this.data[_this.pos-1]) &&
+			(_this.pos+2 == len(_this.data) || isDelimiter(_this.data[_this.pos+2])) {
+			_this.pos += 2
+			return
+		}
+		_this.pos++
+	}
+	_this.pos = len(_this.data)
+}
+
+// pdfFont maps the codes of the strings shown with a font to text.
+type pdfFont struct {
+	// toUnicode maps codes to text, from the ToUnicode map of the font.
+	toUnicode map[string]string
+	// lengths are the lengths in bytes of the codes of toUnicode, from shortest.
+	lengths []int
+	// composite fonts have codes of two bytes that without toUnicode cannot be read.
+	composite bool
+	// differences maps the codes of a simple font that its encoding changes.
+	differences map[byte]string
+	// widths are the widths of the glyphs of the codes, in thousandths of an em, and
+	// defaultWidth that of the others.
+	widths       map[int]float64
+	defaultWidth float64
+}
+
+// font returns the font named name in resources, reading it once for each object.
+func (_this *pdfFile) font(resources pdfDict, name pdfName, fonts map[pdfRef]*pdfFont) *pdfFont {
+	value := _this.dict(resources["Font"])[name]
+	ref, isRef := value.(pdfRef)
+	if isRef {
+		if font, ok := fonts[ref]; ok {
+			return font
+		}
+	}
+
+	dict := _this.dict(value)
+	font := &pdfFont{composite: dict["Subtype"] == pdfName("Type0")}
+	if dict["ToUnicode"] != nil {
+		if data, err := _this.stream(dict["ToUnicode"]); err == nil {
+			font.toUnicode, font.lengths = parseCMap(data)
+		}
+	}
+	if encoding, ok := _this.resolve(dict["Encoding"]).(pdfDict); ok {
+		font.differences = differences(_this.resolve(encoding["Differences"]))
+	}
+	_this.readWidths(font, dict)
+	if isRef {
+		fonts[ref] = font
+	}
+	return font
+}
+
+// readWidths reads the widths of the glyphs of a font: the Widths of a simple font, or the W of
+// the descendant font of a composite one. A simple font without widths, such as one of the
+// standard 14 fonts, is given the average width of a letter.
+func (_this *pdfFile) readWidths(font *pdfFont, dict pdfDict) {
+	font.widths = map[int]float64{}
+	if !font.composite {
+		font.defaultWidth = 500
+		if missing, ok := _this.resolve(_this.dict(dict["FontDescriptor"])["MissingWidth"]).(float64); ok && missing > 0 {
+			font.defaultWidth = missing
+		}
+		first, _ := _this.resolve(dict["FirstChar"]).(float64)
+		widths, _ := _this.resolve(dict["Widths"]).(pdfArray)
+		for i, width := range widths {
+			if w, ok := _this.resolve(width).(float64); ok {
+				font.widths[int(first)+i] = w
+			}
+		}
+		return
+	}
+
+	font.defaultWidth = 1000
+	descendants, _ := _this.resolve(dict["DescendantFonts"]).(pdfArray)
+	if len(descendants) == 0 {
+		return
+	}
+	descendant := _this.dict(descendants[0])
+	if dw, ok := _this.resolve(descendant["DW"]).(float64); ok {
+		font.defaultWidth = dw
+	}
+	// W lists "c [w1 w2 ...]" for consecutive codes from c, or "cfirst clast w" for a range.
+	w, _ := _this.resolve(descendant["W"]).(pdfArray)
+	for i := 0; i < len(w); {
+		first, ok := _this.resolve(w[i]).(float64)
+		if !ok || i+1 >= len(w) {
+			return
+		}
+		if list, ok := _this.resolve(w[i+1]).(pdfArray); ok {
+			for j, width := range list {
+				if n, ok := _this.resolve(width).(float64); ok {
+					font.widths[int(first)+j] = n
+				}
+			}
+			i += 2
+			continue
+		}
+		last, ok1 := _this.resolve(w[i+1]).(float64)
+		if i+2 >= len(w) || !ok1 {
+			return
+		}
+		width, _ := _this.resolve(w[i+2]).(float64)
+		for code := int(first); code <= int(last) && code-int(first) < maxCMapRanges; code++ {
+			font.widths[code] = width
+		}
+		i += 3
+	}
+}
+
+// width returns the width of a string shown with the font, in thousandths of an em.
+func (_this *pdfFont) width(s pdfString) float64 {
+	step := 1
+	if _this.composite {
+		step = 2
+	}
+	total := 0.0
+	for i := 0; i+step <= len(s); i += step {
+		code := int(s[i])
+		if step == 2 {
+			code = code<<8 | int(s[i+1])
+		}
+		if w, ok := _this.widths[code]; ok {
+			total += w
+		} else {
+			total += _this.defaultWidth
+		}
+	}
+	return total
+}
+
+// decode returns the text of a string shown with the font. Codes without a mapping are dropped.
+func (_this *pdfFont) decode(s pdfString) string {
+	var out strings.Builder
+	if len(_this.toUnicode) > 0 {
+		step := 1
+		if _this.composite {
+			step = 2
+		}
+		for i := 0; i < len(s); {
+			matched := false
+			for _, n := range _this.lengths {
+				if i+n <= len(s) {
+					if text, ok := _this.toUnicode[string(s[i:i+n])]; ok {
+						out.WriteString(text)
+						i += n
+						matched = true
+						break
+					}
+				}
+			}
+			if !matched {
+				i += step
+			}
+		}
+		return out.String()
+	}
+	if _this.composite {
+		return ""
+	}
+	for _, b := range s {
+		if glyph, ok := _this.differences[b]; ok {
+			out.WriteString(glyph)
+			continue
+		}
+		out.WriteRune(winAnsi(b))
+	}
+	return out.String()
+}
+
+// parseCMap reads the mappings of a ToUnicode CMap.
+func parseCMap(data []byte) (map[string]string, []int) {
+	mapping := map[string]string{}
+	lengths := map[int]bool{}
+	add := func(code []byte, text string) {
+		if len(mapping) < maxCMapRanges {
+			mapping[string(code)] = text
+			lengths[len(code)] = true
+		}
+	}
+
+	l := &lexer{data: data}
+	var operands []interface{}
+	section := ""
+	for {
+		value, err := l.object()
+		if err != nil {
+			break
+		}
+		op, ok := value.(pdfKeyword)
+		if !ok {
+			if section != "" {
+				operands = append(operands, value)
+			}
+			continue
+		}
+		switch op {
+		case "beginbfchar", "beginbfrange":
+			section, operands = string(op), nil
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				code, ok1 := operands[i].(pdfString)
+				text, ok2 := operands[i+1].(pdfString)
+				if ok1 && ok2 {
+					add(code, utf16Text(text))
+				}
+			}
+			section = ""
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(pdfString)
+				hi, ok2 := operands[i+1].(pdfString)
+				if !ok1 || !ok2 || len(lo) != len(hi) || len(lo) == 0 || len(lo) > 4 {
+					continue
+				}
+				first, last := codeValue(lo), codeValue(hi)
+				for code := first; code <= last && code-first < maxCMapRanges; code++ {
+					offset := int(code - first)
+					switch dst := operands[i+2].(type) {
+					case pdfString:
+						add(codeBytes(code, len(lo)), utf16Text(incrementLast(dst, offset)))
+					case pdfArray:
+						if offset < len(dst) {
+							if text, ok := dst[offset].(pdfString); ok {
+								add(codeBytes(code, len(lo)), utf16Text(text))
+							}
+						}
+					}
+				}
+			}
+			section = ""
+		}
+	}
+
+	sorted := make([]int, 0, len(lengths))
+	for n := range lengths {
+		sorted = append(sorted, n)
+	}
+	sort.Ints(sorted)
+	return mapping, sorted
+}
+
+func codeValue(code []byte) uint32 {
+	var n uint32
+	for _, b := range code {
+		n = n<<8 | uint32(b)
+	}
+	return n
+}
+
+func codeBytes(n uint32, length int) []byte {
+	code := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		code[i] = byte(n)
+		n >>= 8
+	}
+	return code
+}
+
+// incrementLast adds offset to the last UTF-16 unit of text, as a bfrange maps consecutive codes
+// to consecutive characters.
+func incrementLast(text pdfString, offset int) pdfString {
+	if offset == 0 || len(text) < 2 {
+		return text
+	}
+	out := append(pdfString(nil), text...)
+	unit := int(out[len(out)-2])<<8 | int(out[len(out)-1]) + offset
+	out[len(out)-2], out[len(out)-1] = byte(unit>>8), byte(unit)
+	return out
+}
+
+func utf16Text(text pdfString) string {
+	units := make([]uint16, 0, len(text)/2)
+	for i := 0; i+1 < len(text); i += 2 {
+		units = append(units, uint16(text[i])<<8|uint16(text[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// differences reads the codes an encoding maps to other glyphs. Only the glyphs of letters and of
+// common punctuation are kept.
+func differences(value interface{}) map[byte]string {
+	array, ok := value.(pdfArray)
+	if !ok {
+		return nil
+	}
+	out := map[byte]string{}
+	code := 0
+	for _, item := range array {
+		switch item := item.(type) {
+		case float64:
+			code = int(item)
+		case pdfName:
+			if code >= 0 && code < 256 {
+				if text, ok := glyphText(string(item)); ok {
+					out[byte(code)] = text
+				}
+			}
+			code++
+		}
+	}
+	return out
+}
+
+var glyphs = map[string]string{
+	"space": " ", "hyphen": "-", "period": ".", "comma": ",", "colon": ":", "semicolon": ";",
+	"slash": "/", "at": "@", "plus": "+", "parenleft": "(", "parenright": ")", "ampersand": "&",
+	"quoteright": "’", "quoteleft": "‘", "quotedblleft": "“", "quotedblright": "”", "quotesingle": "'",
+	"endash": "–", "emdash": "—", "bullet": "•", "fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6", "seven": "7",
+	"eight": "8", "nine": "9",
+}
+
+// glyphText returns the text of a glyph name: a letter, a uniXXXX name or a name of glyphs.
+func glyphText(name string) (string, bool) {
+	if len(name) == 1 && (name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		return name, true
+	}
+	if strings.HasPrefix(name, "uni") && len(name) == 7 {
+		var unit uint16
+		for _, c := range name[3:] {
+			switch {
+			case c >= '0' && c <= '9':
+				unit = unit<<4 | uint16(c-'0')
+			case c >= 'A' && c <= 'F':
+				unit = unit<<4 | uint16(c-'A'+10)
+			default:
+				return "", false
+			}
+		}
+		return string(rune(unit)), true
+	}
+	text, ok := glyphs[name]
+	return text, ok
+}
+
+// winAnsiHigh holds the characters of WinAnsiEncoding from 0x80 to 0x9f, where it differs from Latin-1.
+var winAnsiHigh = []rune("€\u0081‚ƒ„…†‡ˆ‰Š‹Œ\u008dŽ\u008f\u0090‘’“”•–—˜™š›œ\u009džŸ")
+
+// winAnsi reads a byte of a simple font as WinAnsiEncoding, the encoding of most simple fonts.
+func winAnsi(b byte) rune {
+	if b >= 0x80 && b < 0xa0 {
+		return winAnsiHigh[b-0x80]
+	}
+	return rune(b)
+}
@@ -0,0 +1,68 @@
+package fakes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Crawler fakes the LinkedIn crawler's getfulltext endpoint.
+type Crawler struct {
+	mu       sync.RWMutex
+	profiles map[string]string
+	mux      *http.ServeMux
+}
+
+// NewCrawler returns a crawler fake with no registered profiles.
+func NewCrawler() *Crawler {
+	c := &Crawler{profiles: map[string]string{}, mux: http.NewServeMux()}
+	c.mux.HandleFunc("GET /api/getfulltext/", c.getFullText)
+	return c
+}
+
+// SetProfile sets the text returned for url. Unknown URLs get a generated profile.
+func (_this *Crawler) SetProfile(url, text string) {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.profiles[url] = text
+}
+
+func (_this *Crawler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_this.mux.ServeHTTP(w, r)
+}
+
+func (_this *Crawler) getFullText(w http.ResponseWriter, r *http.Request) {
+	list := r.URL.Query().Get("list_url")
+	if list == "" {
+		writeError(w, http.StatusBadRequest, "list_url is required")
+		return
+	}
+
+	_this.mu.RLock()
+	defer _this.mu.RUnlock()
+
+	resumes := make([]map[string]string, 0)
+	for _, url := range strings.Split(list, ",") {
+		url = strings.TrimSpace(url)
+		if url == "" {
+			continue
+		}
+		text, ok := _this.profiles[url]
+		if !ok {
+			text = generatedProfile(url)
+		}
+		resumes = append(resumes, map[string]string{
+			"content":   text,
+			"fileBytes": url,
+			"name":      "",
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"resumes": resumes})
+}
+
+func generatedProfile(url string) string {
+	parts := strings.Split(strings.TrimRight(url, "/"), "/")
+	handle := parts[len(parts)-1]
+	return fmt.Sprintf("%s\nSoftware Engineer\nExperience: Backend Developer at Example Corp, 2 years. Skills: Go, SQL, Docker.", handle)
+}
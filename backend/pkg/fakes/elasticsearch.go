@@ -0,0 +1,388 @@
+package fakes
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Elasticsearch fakes the subset of the Elasticsearch REST API used by the elasticsearch adaptor:
+// ping, put mapping, index, partial update, get, mget, delete and _search with a match query on
+// "content" and/or a kNN query on "embedding", optionally scrolled. It is an HTTP front over an
+// elasticsearch.MemoryClient, which stores, merges and scores the documents; documents are
+// searchable immediately.
+type Elasticsearch struct {
+	engine *elasticsearch.MemoryClient
+	ids    idSequence
+	// mu guards scrolls, which holds the hits left to return for each open scroll.
+	mu      sync.Mutex
+	scrolls map[string]*scrollState
+	mux     *http.ServeMux
+}
+
+type scrollState struct {
+	size int
+	hits []map[string]interface{}
+}
+
+// NewElasticsearch returns an Elasticsearch fake with no indices.
+func NewElasticsearch() *Elasticsearch {
+	// Without a file, the memory client cannot fail to start.
+	engine, _ := elasticsearch.NewMemoryClient("")
+	e := &Elasticsearch{
+		engine:  engine,
+		scrolls: map[string]*scrollState{},
+		mux:     http.NewServeMux(),
+	}
+	e.mux.HandleFunc("GET /{$}", e.info)
+	e.mux.HandleFunc("HEAD /{$}", e.info)
+	e.mux.HandleFunc("PUT /{index}/_mapping", e.putMapping)
+	e.mux.HandleFunc("POST /{index}/_doc", e.index)
+	e.mux.HandleFunc("PUT /{index}/_doc/{id}", e.index)
+	e.mux.HandleFunc("POST /{index}/_update/{id}", e.update)
+	e.mux.HandleFunc("GET /{index}/_doc/{id}", e.get)
+	e.mux.HandleFunc("DELETE /{index}/_doc/{id}", e.delete)
+	e.mux.HandleFunc("GET /{index}/_mget", e.mget)
+	e.mux.HandleFunc("POST /{index}/_mget", e.mget)
+	e.mux.HandleFunc("GET /{index}/_search", e.search)
+	e.mux.HandleFunc("POST /{index}/_search", e.search)
+	e.mux.HandleFunc("POST /_search/scroll", e.scroll)
+	e.mux.HandleFunc("DELETE /_search/scroll", e.clearScroll)
+	return e
+}
+
+func (_this *Elasticsearch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The official client refuses to talk to servers that do not identify as Elasticsearch.
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	_this.mux.ServeHTTP(w, r)
+}
+
+// Count returns the number of documents in an index.
+func (_this *Elasticsearch) Count(index string) int {
+	return _this.engine.Count(index)
+}
+
+func (_this *Elasticsearch) info(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"name":         "fake",
+		"cluster_name": "fake",
+		"version":      map[string]string{"number": "8.13.0"},
+		"tagline":      "You Know, for Search",
+	})
+}
+
+// putMapping accepts any mapping: documents are searched as they are indexed.
+func (_this *Elasticsearch) putMapping(w http.ResponseWriter, r *http.Request) {
+	var mapping json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		writeESError(w, http.StatusBadRequest, "mapper_parsing_exception", "failed to parse mapping")
+		return
+	}
+	if err := _this.engine.PutResumeMapping(r.Context(), r.PathValue("index")); err != nil {
+		writeESError(w, http.StatusInternalServerError, "exception", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"acknowledged": true})
+}
+
+func (_this *Elasticsearch) index(w http.ResponseWriter, r *http.Request) {
+	index := r.PathValue("index")
+	var source json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
+		writeESError(w, http.StatusBadRequest, "mapper_parsing_exception", "failed to parse document")
+		return
+	}
+
+	id := r.PathValue("id")
+	result, status := "updated", http.StatusOK
+	var err error
+	if id == "" {
+		result, status = "created", http.StatusCreated
+		id, err = _this.engine.AddDocument(r.Context(), index, source)
+	} else {
+		if _, exists := _this.engine.Document(index, id); !exists {
+			result, status = "created", http.StatusCreated
+		}
+		err = _this.engine.IndexDocument(r.Context(), index, id, source)
+	}
+	if err != nil {
+		writeESError(w, http.StatusBadRequest, "mapper_parsing_exception", err.Error())
+		return
+	}
+
+	writeJSON(w, status, map[string]interface{}{
+		"_index":   index,
+		"_id":      id,
+		"_version": 1,
+		"result":   result,
+		"_shards":  map[string]int{"total": 1, "successful": 1, "failed": 0},
+	})
+}
+
+func (_this *Elasticsearch) update(w http.ResponseWriter, r *http.Request) {
+	index, id := r.PathValue("index"), r.PathValue("id")
+	var request struct {
+		Doc map[string]interface{} `json:"doc"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeESError(w, http.StatusBadRequest, "parse_exception", "failed to parse update request")
+		return
+	}
+
+	err := _this.engine.UpdateDocument(r.Context(), index, id, request.Doc)
+	if errors.Is(err, elasticsearch.ErrDocumentNotFound) {
+		writeESError(w, http.StatusNotFound, "document_missing_exception", fmt.Sprintf("[%s]: document missing", id))
+		return
+	}
+	if err != nil {
+		writeESError(w, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_index":   index,
+		"_id":      id,
+		"_version": 2,
+		"result":   "updated",
+		"_shards":  map[string]int{"total": 1, "successful": 1, "failed": 0},
+	})
+}
+
+func (_this *Elasticsearch) get(w http.ResponseWriter, r *http.Request) {
+	index, id := r.PathValue("index"), r.PathValue("id")
+	source, ok := _this.engine.Document(index, id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"_index": index, "_id": id, "found": false})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"_index":   index,
+		"_id":      id,
+		"_version": 1,
+		"found":    true,
+		"_source":  source,
+	})
+}
+
+func (_this *Elasticsearch) delete(w http.ResponseWriter, r *http.Request) {
+	index, id := r.PathValue("index"), r.PathValue("id")
+	err := _this.engine.DeleteDocumentByID(r.Context(), index, id)
+	if errors.Is(err, elasticsearch.ErrDocumentNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"_index": index, "_id": id, "result": "not_found"})
+		return
+	}
+	if err != nil {
+		writeESError(w, http.StatusInternalServerError, "exception", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"_index": index, "_id": id, "result": "deleted"})
+}
+
+func (_this *Elasticsearch) mget(w http.ResponseWriter, r *http.Request) {
+	index := r.PathValue("index")
+	var request struct {
+		Docs []struct {
+			ID string `json:"_id"`
+		} `json:"docs"`
+		IDs []string `json:"ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeESError(w, http.StatusBadRequest, "parse_exception", "failed to parse mget request")
+		return
+	}
+	ids := request.IDs
+	for _, doc := range request.Docs {
+		ids = append(ids, doc.ID)
+	}
+
+	docs := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		doc := map[string]interface{}{"_index": index, "_id": id, "found": false}
+		if source, ok := _this.engine.Document(index, id); ok {
+			doc["found"] = true
+			doc["_version"] = 1
+			doc["_source"] = source
+		}
+		docs = append(docs, doc)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"docs": docs})
+}
+
+func (_this *Elasticsearch) search(w http.ResponseWriter, r *http.Request) {
+	index := r.PathValue("index")
+	var request struct {
+		From  *int `json:"from"`
+		Size  *int `json:"size"`
+		Query *struct {
+			Match map[string]json.RawMessage `json:"match"`
+		} `json:"query"`
+		Knn json.RawMessage `json:"knn"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeESError(w, http.StatusBadRequest, "parse_exception", "failed to parse search request")
+			return
+		}
+	}
+
+	// knn is either a single clause or a list of clauses; only the first one is used.
+	var knn *knnClause
+	if len(request.Knn) > 0 {
+		var clauses []knnClause
+		if err := json.Unmarshal(request.Knn, &clauses); err != nil {
+			var clause knnClause
+			if err := json.Unmarshal(request.Knn, &clause); err != nil {
+				writeESError(w, http.StatusBadRequest, "parse_exception", "failed to parse knn clause")
+				return
+			}
+			clauses = []knnClause{clause}
+		}
+		if len(clauses) > 0 {
+			knn = &clauses[0]
+		}
+	}
+
+	from, size := 0, 10
+	if request.From != nil {
+		from = *request.From
+	}
+	if request.Size != nil {
+		size = *request.Size
+	}
+
+	var matchQuery string
+	if request.Query != nil {
+		for _, raw := range request.Query.Match {
+			// A match clause is either {"field": "text"} or {"field": {"query": "text"}}.
+			if err := json.Unmarshal(raw, &matchQuery); err != nil {
+				var clause struct {
+					Query string `json:"query"`
+				}
+				_ = json.Unmarshal(raw, &clause)
+				matchQuery = clause.Query
+			}
+		}
+	}
+
+	var vector []float32
+	k, boost := size, 1.0
+	if knn != nil {
+		vector = knn.QueryVector
+		if knn.K > 0 {
+			k = knn.K
+		}
+		if knn.Boost != nil {
+			boost = *knn.Boost
+		}
+	}
+	hits := _this.engine.Search(index, matchQuery, vector, k, boost)
+	total := len(hits)
+	maxScore := 0.0
+	if total > 0 {
+		maxScore = hits[0].Score
+	}
+	if from > len(hits) {
+		from = len(hits)
+	}
+	hits = hits[from:]
+
+	all := make([]map[string]interface{}, 0, len(hits))
+	for _, hit := range hits {
+		all = append(all, map[string]interface{}{
+			"_index":  index,
+			"_id":     hit.ID,
+			"_score":  hit.Score,
+			"_source": hit.Source,
+		})
+	}
+	page := all
+	if size < len(page) {
+		page = page[:size]
+	}
+	response := searchResponse(total, maxScore, page)
+	if r.URL.Query().Get("scroll") != "" {
+		scrollID := _this.ids.New("scroll")
+		_this.mu.Lock()
+		_this.scrolls[scrollID] = &scrollState{size: size, hits: all[len(page):]}
+		_this.mu.Unlock()
+		response["_scroll_id"] = scrollID
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// scroll returns the next page of an open scroll.
+func (_this *Elasticsearch) scroll(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ScrollID string `json:"scroll_id"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	_this.mu.Lock()
+	state, ok := _this.scrolls[request.ScrollID]
+	var page []map[string]interface{}
+	if ok {
+		page = state.hits
+		if state.size < len(page) {
+			page = page[:state.size]
+		}
+		state.hits = state.hits[len(page):]
+	}
+	_this.mu.Unlock()
+	if !ok {
+		writeESError(w, http.StatusNotFound, "search_context_missing_exception", "No search context found for id ["+request.ScrollID+"]")
+		return
+	}
+
+	response := searchResponse(len(page), 0, page)
+	response["_scroll_id"] = request.ScrollID
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (_this *Elasticsearch) clearScroll(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ScrollID string `json:"scroll_id"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	_this.mu.Lock()
+	_, ok := _this.scrolls[request.ScrollID]
+	delete(_this.scrolls, request.ScrollID)
+	_this.mu.Unlock()
+	freed := 0
+	if ok {
+		freed = 1
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"succeeded": true, "num_freed": freed})
+}
+
+func searchResponse(total int, maxScore float64, page []map[string]interface{}) map[string]interface{} {
+	if page == nil {
+		page = []map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"took":      1,
+		"timed_out": false,
+		"_shards":   map[string]int{"total": 1, "successful": 1, "skipped": 0, "failed": 0},
+		"hits": map[string]interface{}{
+			"total":     map[string]interface{}{"value": total, "relation": "eq"},
+			"max_score": maxScore,
+			"hits":      page,
+		},
+	}
+}
+
+// knnClause is a kNN query; the field searched is always "embedding".
+type knnClause struct {
+	QueryVector []float32 `json:"query_vector"`
+	K           int       `json:"k"`
+	Boost       *float64  `json:"boost"`
+}
+
+func writeESError(w http.ResponseWriter, status int, errType, reason string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error":  map[string]interface{}{"type": errType, "reason": reason},
+		"status": status,
+	})
+}
@@ -0,0 +1,44 @@
+package fakes
+
+import (
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultEmbeddingDims is the size of the vectors returned by the fake feature-extraction API.
+const DefaultEmbeddingDims = 768
+
+// Embed returns a deterministic, L2-normalised bag-of-words vector of text: every lower-cased word is
+// hashed into one of dims buckets. Texts sharing words therefore have a positive cosine similarity,
+// which is enough for kNN search to rank documents sensibly in tests.
+func Embed(text string, dims int) []float32 {
+	vector := make([]float32, dims)
+	for _, token := range Tokenize(text) {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(token))
+		vector[h.Sum32()%uint32(dims)]++
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v * v)
+	}
+	if norm == 0 {
+		return vector
+	}
+	norm = math.Sqrt(norm)
+	for i := range vector {
+		vector[i] = float32(float64(vector[i]) / norm)
+	}
+	return vector
+}
+
+// Tokenize splits text into lower-cased words made of letters, digits and the characters + and #
+// (so that C++ and C# survive).
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+}
@@ -0,0 +1,106 @@
+// Package fakes provides deterministic in-process stand-ins for the HTTP services the application
+// depends on: OpenAI (chat completions and assistants), the Hugging Face feature-extraction API,
+// the LinkedIn crawler and Elasticsearch. They let the ingestion, search and chat flows run
+// offline, in tests or locally, by pointing the *_BASE_URL settings at them.
+package fakes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// Servers runs every fake on its own httptest server.
+type Servers struct {
+	OpenAI        *OpenAI
+	HuggingFace   *HuggingFace
+	Crawler       *Crawler
+	Elasticsearch *Elasticsearch
+
+	OpenAIServer        *httptest.Server
+	HuggingFaceServer   *httptest.Server
+	CrawlerServer       *httptest.Server
+	ElasticsearchServer *httptest.Server
+}
+
+// Start starts all fakes on random local ports.
+func Start() *Servers {
+	s := &Servers{
+		OpenAI:        NewOpenAI(),
+		HuggingFace:   NewHuggingFace(),
+		Crawler:       NewCrawler(),
+		Elasticsearch: NewElasticsearch(),
+	}
+	s.OpenAIServer = httptest.NewServer(s.OpenAI)
+	s.HuggingFaceServer = httptest.NewServer(s.HuggingFace)
+	s.CrawlerServer = httptest.NewServer(s.Crawler)
+	s.ElasticsearchServer = httptest.NewServer(s.Elasticsearch)
+	return s
+}
+
+// Env returns the configuration that points the application at the fakes.
+func (_this *Servers) Env() map[string]string {
+	return map[string]string{
+		"GPT_BASE_URL":         _this.OpenAIServer.URL,
+		"GPT_API_KEY":          "fake",
+		"HUGGINGFACE_BASE_URL": _this.HuggingFaceServer.URL,
+		"HUGGINGFACE_API_KEY":  "fake",
+		"CRAWLER_BASE_URL":     _this.CrawlerServer.URL,
+		"ELK_URL":              _this.ElasticsearchServer.URL,
+	}
+}
+
+// Close shuts every server down.
+func (_this *Servers) Close() {
+	_this.OpenAIServer.Close()
+	_this.HuggingFaceServer.Close()
+	_this.CrawlerServer.Close()
+	_this.ElasticsearchServer.Close()
+}
+
+// idSequence generates deterministic IDs such as thread_1, thread_2...
+type idSequence struct {
+	mu   sync.Mutex
+	next map[string]int
+}
+
+func (_this *idSequence) New(prefix string) string {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	if _this.next == nil {
+		_this.next = map[string]int{}
+	}
+	_this.next[prefix]++
+	return fmt.Sprintf("%s_%d", prefix, _this.next[prefix])
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"message": message},
+	})
+}
+
+// writeEvent writes one server-sent event. An empty event name writes a data-only event.
+func writeEvent(w http.ResponseWriter, event string, data interface{}) {
+	if event != "" {
+		fmt.Fprintf(w, "event: %s\n", event)
+	}
+	switch v := data.(type) {
+	case string:
+		fmt.Fprintf(w, "data: %s\n\n", v)
+	default:
+		b, _ := json.Marshal(v)
+		fmt.Fprintf(w, "data: %s\n\n", b)
+	}
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
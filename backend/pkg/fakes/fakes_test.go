@@ -0,0 +1,158 @@
+package fakes_test
+
+import (
+	"CVSeeker/pkg/elasticsearch"
+	"CVSeeker/pkg/fakes"
+	"CVSeeker/pkg/gpt"
+	"CVSeeker/pkg/huggingface"
+	"CVSeeker/pkg/summarizer"
+	"CVSeeker/pkg/usage"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const indexName = "cvseeker-resumes"
+
+type collectingRecorder struct {
+	mu      sync.Mutex
+	records []usage.Record
+}
+
+func (_this *collectingRecorder) Record(_ context.Context, rec usage.Record) {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.records = append(_this.records, rec)
+}
+
+func (_this *collectingRecorder) Providers() []string {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	providers := make([]string, 0, len(_this.records))
+	for _, rec := range _this.records {
+		providers = append(providers, rec.Provider)
+	}
+	return providers
+}
+
+// TestOfflineFlow ingests two resumes, searches them and chats about them using the real adaptors
+// against the fakes.
+func TestOfflineFlow(t *testing.T) {
+	servers := fakes.Start()
+	defer servers.Close()
+
+	cfgReader := viper.New()
+	for key, value := range servers.Env() {
+		cfgReader.Set(key, value)
+	}
+	recorder := &collectingRecorder{}
+	ctx := context.Background()
+
+	summarizerClient, err := summarizer.NewSummarizerAdaptorClient(cfgReader, recorder)
+	require.NoError(t, err)
+	hfClient, err := huggingface.NewHuggingFaceClient(cfgReader, recorder)
+	require.NoError(t, err)
+	esClient, err := elasticsearch.NewElasticsearchClient(cfgReader)
+	require.NoError(t, err)
+	gptClient, err := gpt.NewGptAdaptorClient(cfgReader, recorder)
+	require.NoError(t, err)
+
+	require.NoError(t, esClient.Ping(ctx))
+	require.NoError(t, esClient.PutResumeMapping(ctx, indexName))
+
+	resumes := map[string]string{
+		"Alice Nguyen": "Alice Nguyen\nBackend engineer building Go and Kubernetes services on AWS.",
+		"Bob Tran":     "Bob Tran\nFrontend developer working with React and TypeScript.",
+	}
+	ids := map[string]string{}
+	for name, text := range resumes {
+		prompt := "Full text of the resume:\n\n" + text + "\n\nPlease transform the above resume text into a well-structured JSON."
+		answer, err := summarizerClient.AskGPT(ctx, prompt, "gpt-3.5-turbo")
+		require.NoError(t, err)
+
+		var content elasticsearch.ResumeSummaryDTO
+		require.NoError(t, json.Unmarshal([]byte(answer), &content))
+		assert.Equal(t, name, content.BasicInfo.FullName)
+
+		embedding, err := hfClient.GetTextEmbedding(ctx, text, "sentence-transformers/all-mpnet-base-v2")
+		require.NoError(t, err)
+		assert.Len(t, embedding, fakes.DefaultEmbeddingDims)
+
+		id, err := esClient.AddDocument(ctx, indexName, elasticsearch.ElkResumeDTO{Content: content, Embedding: embedding})
+		require.NoError(t, err)
+		ids[name] = id
+	}
+	assert.Equal(t, 2, servers.Elasticsearch.Count(indexName))
+
+	query := "Go Kubernetes engineer"
+	queryVector, err := hfClient.GetTextEmbedding(ctx, query, "sentence-transformers/all-mpnet-base-v2")
+	require.NoError(t, err)
+	results, err := esClient.HybridSearchWithBoost(ctx, indexName, query, queryVector, 0, 10, 1, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, ids["Alice Nguyen"], results[0].Id)
+	assert.Contains(t, results[0].Skills, "Kubernetes")
+
+	keywordResults, err := esClient.KeywordSearch(ctx, indexName, "React")
+	require.NoError(t, err)
+	require.Len(t, keywordResults, 1)
+	assert.Equal(t, "Bob Tran", keywordResults[0].BasicInfo.FullName)
+
+	fetched, err := esClient.FetchDocumentsByIDs(ctx, indexName, []string{ids["Bob Tran"], "missing"})
+	require.NoError(t, err)
+	require.Len(t, fetched, 1)
+	assert.Equal(t, ids["Bob Tran"], fetched[0].Id)
+
+	var scanned []string
+	require.NoError(t, esClient.ScanDocumentIDs(ctx, indexName, 1, func(documentIDs []string) error {
+		assert.Len(t, documentIDs, 1)
+		scanned = append(scanned, documentIDs...)
+		return nil
+	}))
+	assert.ElementsMatch(t, []string{ids["Alice Nguyen"], ids["Bob Tran"]}, scanned)
+
+	require.NoError(t, esClient.UpdateDocument(ctx, indexName, ids["Bob Tran"], map[string]interface{}{
+		"content": map[string]interface{}{"superseded": true},
+	}))
+	updated, err := esClient.GetDocumentByID(ctx, indexName, ids["Bob Tran"])
+	require.NoError(t, err)
+	assert.True(t, updated.Superseded)
+	assert.Equal(t, "Bob Tran", updated.BasicInfo.FullName)
+
+	thread, err := gptClient.CreateThread(ctx, gpt.CreateThreadRequest{
+		Messages: []gpt.CreateMessageRequest{{Role: "user", Content: "Here are the candidates."}},
+	})
+	require.NoError(t, err)
+	_, err = gptClient.CreateMessage(ctx, thread.ID, gpt.CreateMessageRequest{Role: "user", Content: "Who knows Go?"})
+	require.NoError(t, err)
+
+	stream, err := gptClient.CreateRunAndStreamResponse(ctx, thread.ID, gpt.CreateRunRequest{AssistantID: "asst_1", Stream: true})
+	require.NoError(t, err)
+	var reply strings.Builder
+	for piece := range stream {
+		reply.WriteString(piece)
+	}
+	assert.Equal(t, "You asked: Who knows Go? (thread has 2 messages)", reply.String())
+
+	messages, err := gptClient.ListMessages(ctx, thread.ID, 10, "desc", "", "")
+	require.NoError(t, err)
+	require.Len(t, messages.Data, 3)
+	assert.Equal(t, "assistant", messages.Data[0].Role)
+
+	require.NoError(t, esClient.DeleteDocumentByID(ctx, indexName, ids["Bob Tran"]))
+	assert.Equal(t, 1, servers.Elasticsearch.Count(indexName))
+	assert.ErrorIs(t, esClient.DeleteDocumentByID(ctx, indexName, ids["Bob Tran"]), elasticsearch.ErrDocumentNotFound)
+
+	_, err = gptClient.DeleteThread(ctx, thread.ID)
+	require.NoError(t, err)
+
+	providers := recorder.Providers()
+	assert.Contains(t, providers, usage.ProviderOpenAI)
+	assert.Contains(t, providers, usage.ProviderHuggingFace)
+}
@@ -0,0 +1,34 @@
+package fakes
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HuggingFace fakes the inference API's feature-extraction pipeline.
+type HuggingFace struct {
+	Dims int
+	mux  *http.ServeMux
+}
+
+// NewHuggingFace returns a fake producing DefaultEmbeddingDims-dimensional embeddings.
+func NewHuggingFace() *HuggingFace {
+	hf := &HuggingFace{Dims: DefaultEmbeddingDims, mux: http.NewServeMux()}
+	hf.mux.HandleFunc("POST /pipeline/feature-extraction/{model...}", hf.featureExtraction)
+	return hf
+}
+
+func (_this *HuggingFace) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_this.mux.ServeHTTP(w, r)
+}
+
+func (_this *HuggingFace) featureExtraction(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Inputs string `json:"inputs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, Embed(request.Inputs, _this.Dims))
+}
@@ -0,0 +1,453 @@
+package fakes
+
+import (
+	"CVSeeker/pkg/usage"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// resumePromptMarker starts the resume text in the ingestion prompt.
+const resumePromptMarker = "Full text of the resume:"
+
+// emailPattern finds the e-mail addresses of resume text for the default completion.
+var emailPattern = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+
+// knownSkills are recognised in resume text by the default completion.
+var knownSkills = []string{
+	"Go", "Golang", "Java", "Python", "JavaScript", "TypeScript", "React", "Node.js", "SQL", "MySQL",
+	"PostgreSQL", "Docker", "Kubernetes", "AWS", "GCP", "Elasticsearch", "Machine Learning", "C++", "C#",
+}
+
+// OpenAI fakes the chat completions and assistants (threads, messages, runs) endpoints.
+// Responses are deterministic and functions of the request only.
+type OpenAI struct {
+	// Completion produces the chat completion for the last user message. Defaults to DefaultCompletion.
+	Completion func(model, prompt string) string
+	// Reply produces the assistant's answer in a thread. Defaults to DefaultReply.
+	Reply func(messages []Message) string
+
+	ids idSequence
+	mu  sync.Mutex
+	// threads holds the messages of each thread, oldest first.
+	threads map[string][]Message
+	runs    map[string]map[string]interface{}
+	mux     *http.ServeMux
+}
+
+// Message is a message stored in a fake thread.
+type Message struct {
+	ID        string
+	Role      string
+	Content   string
+	CreatedAt int64
+}
+
+// NewOpenAI returns an OpenAI fake with no threads.
+func NewOpenAI() *OpenAI {
+	o := &OpenAI{
+		Completion: DefaultCompletion,
+		Reply:      DefaultReply,
+		threads:    map[string][]Message{},
+		runs:       map[string]map[string]interface{}{},
+		mux:        http.NewServeMux(),
+	}
+	o.mux.HandleFunc("POST /v1/chat/completions", o.chatCompletions)
+	o.mux.HandleFunc("POST /v1/assistants", o.createAssistant)
+	o.mux.HandleFunc("POST /v1/files", o.uploadFile)
+	o.mux.HandleFunc("POST /v1/threads", o.createThread)
+	o.mux.HandleFunc("DELETE /v1/threads/{thread}", o.deleteThread)
+	o.mux.HandleFunc("POST /v1/threads/{thread}/messages", o.createMessage)
+	o.mux.HandleFunc("GET /v1/threads/{thread}/messages", o.listMessages)
+	o.mux.HandleFunc("POST /v1/threads/{thread}/runs", o.createRun)
+	o.mux.HandleFunc("GET /v1/threads/{thread}/runs/{run}", o.getRun)
+	return o
+}
+
+func (_this *OpenAI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "missing API key")
+		return
+	}
+	_this.mux.ServeHTTP(w, r)
+}
+
+// Messages returns a copy of the messages of a thread.
+func (_this *OpenAI) Messages(threadID string) []Message {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return append([]Message(nil), _this.threads[threadID]...)
+}
+
+func (_this *OpenAI) chatCompletions(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Model    string `json:"model"`
+		Stream   bool   `json:"stream"`
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid chat completion request")
+		return
+	}
+
+	prompt := request.Messages[len(request.Messages)-1].Content
+	content := _this.Completion(request.Model, prompt)
+	id := _this.ids.New("chatcmpl")
+	created := time.Now().Unix()
+	promptTokens, completionTokens := usage.EstimateTokens(prompt), usage.EstimateTokens(content)
+
+	if !request.Stream {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":      id,
+			"object":  "chat.completion",
+			"created": created,
+			"model":   request.Model,
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       map[string]string{"role": "assistant", "content": content},
+				"finish_reason": "stop",
+			}},
+			"usage": map[string]int{
+				"prompt_tokens":     promptTokens,
+				"completion_tokens": completionTokens,
+				"total_tokens":      promptTokens + completionTokens,
+			},
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	chunk := func(delta map[string]string, finishReason interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   request.Model,
+			"choices": []map[string]interface{}{{"index": 0, "delta": delta, "finish_reason": finishReason}},
+		}
+	}
+	writeEvent(w, "", chunk(map[string]string{"role": "assistant"}, nil))
+	for _, piece := range splitWords(content) {
+		writeEvent(w, "", chunk(map[string]string{"content": piece}, nil))
+	}
+	writeEvent(w, "", chunk(map[string]string{}, "stop"))
+	writeEvent(w, "", "[DONE]")
+}
+
+func (_this *OpenAI) createAssistant(w http.ResponseWriter, r *http.Request) {
+	var request map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&request)
+	request["id"] = _this.ids.New("asst")
+	request["object"] = "assistant"
+	request["created_at"] = time.Now().Unix()
+	writeJSON(w, http.StatusOK, request)
+}
+
+func (_this *OpenAI) uploadFile(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":         _this.ids.New("file"),
+		"object":     "file",
+		"created_at": time.Now().Unix(),
+	})
+}
+
+func (_this *OpenAI) createThread(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Messages []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	threadID := _this.ids.New("thread")
+	_this.mu.Lock()
+	messages := make([]Message, 0, len(request.Messages))
+	for _, m := range request.Messages {
+		messages = append(messages, _this.newMessage(m.Role, m.Content))
+	}
+	_this.threads[threadID] = messages
+	_this.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":         threadID,
+		"object":     "thread",
+		"created_at": time.Now().Unix(),
+		"metadata":   map[string]interface{}{},
+	})
+}
+
+func (_this *OpenAI) deleteThread(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("thread")
+	_this.mu.Lock()
+	_, ok := _this.threads[threadID]
+	delete(_this.threads, threadID)
+	_this.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"id": threadID, "object": "thread.deleted", "deleted": ok})
+}
+
+func (_this *OpenAI) createMessage(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("thread")
+	var request struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid message")
+		return
+	}
+
+	_this.mu.Lock()
+	messages, ok := _this.threads[threadID]
+	if !ok {
+		_this.mu.Unlock()
+		writeError(w, http.StatusNotFound, "no thread found with id "+threadID)
+		return
+	}
+	message := _this.newMessage(request.Role, request.Content)
+	_this.threads[threadID] = append(messages, message)
+	_this.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, messageJSON(threadID, message))
+}
+
+func (_this *OpenAI) listMessages(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("thread")
+	_this.mu.Lock()
+	messages, ok := _this.threads[threadID]
+	_this.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no thread found with id "+threadID)
+		return
+	}
+
+	// Like the real API, messages are listed newest first unless order=asc.
+	data := make([]map[string]interface{}, 0, len(messages))
+	for i := range messages {
+		m := messages[len(messages)-1-i]
+		if r.URL.Query().Get("order") == "asc" {
+			m = messages[i]
+		}
+		data = append(data, messageJSON(threadID, m))
+	}
+	response := map[string]interface{}{"object": "list", "data": data, "has_more": false}
+	if len(data) > 0 {
+		response["first_id"] = data[0]["id"]
+		response["last_id"] = data[len(data)-1]["id"]
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (_this *OpenAI) createRun(w http.ResponseWriter, r *http.Request) {
+	threadID := r.PathValue("thread")
+	var request struct {
+		AssistantID string `json:"assistant_id"`
+		Model       string `json:"model"`
+		Stream      bool   `json:"stream"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&request)
+
+	_this.mu.Lock()
+	messages, ok := _this.threads[threadID]
+	if !ok {
+		_this.mu.Unlock()
+		writeError(w, http.StatusNotFound, "no thread found with id "+threadID)
+		return
+	}
+	reply := _this.Reply(append([]Message(nil), messages...))
+	answer := _this.newMessage("assistant", reply)
+	_this.threads[threadID] = append(messages, answer)
+
+	var prompt strings.Builder
+	for _, m := range messages {
+		prompt.WriteString(m.Content)
+	}
+	promptTokens, completionTokens := usage.EstimateTokens(prompt.String()), usage.EstimateTokens(reply)
+	model := request.Model
+	if model == "" {
+		model = "gpt-3.5-turbo"
+	}
+	now := time.Now().Unix()
+	run := map[string]interface{}{
+		"id":           _this.ids.New("run"),
+		"object":       "thread.run",
+		"created_at":   now,
+		"assistant_id": request.AssistantID,
+		"thread_id":    threadID,
+		"status":       "completed",
+		"started_at":   now,
+		"completed_at": now,
+		"model":        model,
+		"tools":        []interface{}{},
+		"file_ids":     []string{},
+		"metadata":     map[string]interface{}{},
+		"usage": map[string]int{
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+			"total_tokens":      promptTokens + completionTokens,
+		},
+	}
+	_this.runs[run["id"].(string)] = run
+	_this.mu.Unlock()
+
+	if !request.Stream {
+		writeJSON(w, http.StatusOK, run)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	queued := copyMap(run)
+	queued["status"] = "queued"
+	delete(queued, "usage")
+	writeEvent(w, "thread.run.created", queued)
+	for i, piece := range splitWords(reply) {
+		writeEvent(w, "thread.message.delta", map[string]interface{}{
+			"id":     answer.ID,
+			"object": "thread.message.delta",
+			"delta": map[string]interface{}{
+				"content": []map[string]interface{}{{
+					"index": i,
+					"type":  "text",
+					"text":  map[string]string{"value": piece},
+				}},
+			},
+		})
+	}
+	writeEvent(w, "thread.run.completed", run)
+	writeEvent(w, "done", "[DONE]")
+}
+
+func (_this *OpenAI) getRun(w http.ResponseWriter, r *http.Request) {
+	_this.mu.Lock()
+	run, ok := _this.runs[r.PathValue("run")]
+	_this.mu.Unlock()
+	if !ok || run["thread_id"] != r.PathValue("thread") {
+		writeError(w, http.StatusNotFound, "no run found with id "+r.PathValue("run"))
+		return
+	}
+	writeJSON(w, http.StatusOK, run)
+}
+
+// newMessage must be called with mu held.
+func (_this *OpenAI) newMessage(role, content string) Message {
+	return Message{ID: _this.ids.New("msg"), Role: role, Content: content, CreatedAt: time.Now().Unix()}
+}
+
+func messageJSON(threadID string, m Message) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         m.ID,
+		"object":     "thread.message",
+		"created_at": m.CreatedAt,
+		"thread_id":  threadID,
+		"role":       m.Role,
+		"content": []map[string]interface{}{{
+			"type": "text",
+			"text": map[string]interface{}{"value": m.Content, "annotations": []interface{}{}},
+		}},
+		"file_ids": []string{},
+		"metadata": map[string]interface{}{},
+	}
+}
+
+// DefaultCompletion answers resume-structuring prompts with a JSON summary derived from the resume
+// text (first line as the name, quoted as its evidence when asked, e-mail addresses as contacts,
+// recognised skills) and any other
+// prompt with a fixed sentence.
+func DefaultCompletion(model, prompt string) string {
+	start := strings.Index(prompt, resumePromptMarker)
+	if start < 0 {
+		return "This is a deterministic response from the fake OpenAI server."
+	}
+	text := prompt[start+len(resumePromptMarker):]
+	if end := strings.Index(text, "\n\nPlease transform"); end >= 0 {
+		text = text[:end]
+	}
+	text = strings.TrimSpace(text)
+
+	name := text
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		name = text[:i]
+	}
+	summary := strings.Join(strings.Fields(text), " ")
+	if len(summary) > 300 {
+		summary = summary[:300]
+	}
+
+	skills := make([]string, 0)
+	for _, skill := range knownSkills {
+		if regexp.MustCompile(`(?i)(^|[^\w+#])` + regexp.QuoteMeta(skill) + `($|[^\w+#])`).MatchString(text) {
+			skills = append(skills, skill)
+		}
+	}
+
+	name = strings.TrimSpace(name)
+	answer := map[string]interface{}{
+		"summary": summary,
+		"skills":  skills,
+		"basic_info": map[string]interface{}{
+			"full_name":       name,
+			"university":      "",
+			"education_level": "",
+			"majors":          []string{},
+			"gpa":             nil,
+		},
+		"work_experience":    []interface{}{},
+		"project_experience": []interface{}{},
+		"award":              []interface{}{},
+	}
+	// Prompts with the contacts section get the e-mail addresses of the text.
+	if strings.Contains(prompt, `"contacts"`) {
+		answer["contacts"] = map[string]interface{}{
+			"emails": emailPattern.FindAllString(text, -1),
+			"phones": []string{},
+			"links":  []interface{}{},
+		}
+	}
+	// Evidence-mode prompts ask for the text supporting each basic_info value.
+	if strings.Contains(prompt, "basic_info_evidence") {
+		answer["basic_info_evidence"] = map[string]interface{}{
+			"full_name": map[string]interface{}{"confidence": 0.9, "source": name},
+		}
+	}
+	b, _ := json.Marshal(answer)
+	return string(b)
+}
+
+// DefaultReply echoes the last user message and reports how many messages the thread holds.
+func DefaultReply(messages []Message) string {
+	last := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			last = messages[i].Content
+			break
+		}
+	}
+	return fmt.Sprintf("You asked: %s (thread has %d messages)", last, len(messages))
+}
+
+// splitWords splits s into words that keep their trailing space, like streamed tokens.
+func splitWords(s string) []string {
+	var pieces []string
+	for _, word := range strings.SplitAfter(s, " ") {
+		if word != "" {
+			pieces = append(pieces, word)
+		}
+	}
+	return pieces
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -7,8 +7,11 @@ const (
 	AssistantsFilesSuffix = "/files"
 	OpenaiAssistantsV1    = "assistants=v1"
 
-	AssistantEndpoint = "https://api.openai.com/v1/assistants"
-	ThreadEndpoint    = "https://api.openai.com/v1/threads"
+	// DefaultBaseURL is used when GPT_BASE_URL is not set.
+	DefaultBaseURL = "https://api.openai.com"
+	AssistantsPath = "/v1/assistants"
+	ThreadsPath    = "/v1/threads"
+	FilesPath      = "/v1/files"
 )
 
 type Assistant struct {
@@ -183,6 +186,14 @@ type RunResponse struct {
 	Tools          []AssistantTool        `json:"tools"`
 	FileIDs        []string               `json:"file_ids"`
 	Metadata       map[string]interface{} `json:"metadata"`
+	Usage          *RunUsage              `json:"usage,omitempty"`
+}
+
+// RunUsage is the token usage of a run, reported once the run is completed.
+type RunUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 type ToolOutput struct {
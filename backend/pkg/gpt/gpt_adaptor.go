@@ -2,9 +2,14 @@ package gpt
 
 import (
 	"CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/httpclient"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
 	"CVSeeker/pkg/websocket"
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/spf13/viper"
@@ -19,26 +24,43 @@ import (
 	"time"
 )
 
+// adaptorName identifies this adaptor in metrics.
+const adaptorName = "gpt"
+
 type IGptAdaptorClient interface {
-	CreateAssistant(request AssistantRequest) (*AssistantResponse, error)
-	CreateThread(request CreateThreadRequest) (*ThreadResponse, error)
-	DeleteThread(threadID string) (*DeleteThreadResponse, error)
-	ListMessages(threadID string, limit int, order, after, before string) (*ListMessagesResponse, error)
-	GetRunDetails(threadID, runID string) (*RunResponse, error)
-	CreateRunAndStreamResponse(threadID string, request CreateRunRequest) (<-chan string, error)
-	CreateMessage(threadID string, request CreateMessageRequest) (*MessageResponse, error)
-	WaitForRunCompletion(threadID, runID string) (*RunResponse, error)
+	CreateAssistant(ctx context.Context, request AssistantRequest) (*AssistantResponse, error)
+	CreateThread(ctx context.Context, request CreateThreadRequest) (*ThreadResponse, error)
+	DeleteThread(ctx context.Context, threadID string) (*DeleteThreadResponse, error)
+	ListMessages(ctx context.Context, threadID string, limit int, order, after, before string) (*ListMessagesResponse, error)
+	GetRunDetails(ctx context.Context, threadID, runID string) (*RunResponse, error)
+	CreateRunAndStreamResponse(ctx context.Context, threadID string, request CreateRunRequest) (<-chan string, error)
+	CreateMessage(ctx context.Context, threadID string, request CreateMessageRequest) (*MessageResponse, error)
+	WaitForRunCompletion(ctx context.Context, threadID, runID string) (*RunResponse, error)
 }
 
+// httpServiceName is the prefix of the GPT_HTTP_* client settings.
+const httpServiceName = "GPT"
+
 type gptAdaptorClient struct {
-	Client *http.Client
-	ApiKey string
+	Client *httpclient.Client
+	// StreamClient is used for server-sent event responses, which outlive the request timeout.
+	StreamClient *httpclient.Client
+	BaseURL      string
+	ApiKey       string
+	Usage        usage.Recorder
 }
 
-func NewGptAdaptorClient(cfgReader *viper.Viper) (IGptAdaptorClient, error) {
+func NewGptAdaptorClient(cfgReader *viper.Viper, recorder usage.Recorder) (IGptAdaptorClient, error) {
+	defaults := httpclient.DefaultConfig()
+	defaults.Timeout = 60 * time.Second
+	client := httpclient.New(adaptorName, httpclient.LoadConfig(cfgReader, httpServiceName, defaults))
+
 	return &gptAdaptorClient{
-		Client: &http.Client{},
-		ApiKey: cfgReader.GetString(cfg.GptApiKey),
+		Client:       client,
+		StreamClient: client.Streaming(),
+		BaseURL:      cfg.BaseURL(cfgReader, cfg.GptBaseUrl, DefaultBaseURL),
+		ApiKey:       cfgReader.GetString(cfg.GptApiKey),
+		Usage:        recorder,
 	}, nil
 }
 
@@ -48,14 +70,18 @@ func (g *gptAdaptorClient) addCommonHeaders(req *http.Request) {
 	req.Header.Add("OpenAI-Beta", OpenaiAssistantsV1)
 }
 
-func (g *gptAdaptorClient) CreateAssistant(request AssistantRequest) (*AssistantResponse, error) {
-	url := AssistantEndpoint
+func (g *gptAdaptorClient) CreateAssistant(ctx context.Context, request AssistantRequest) (_ *AssistantResponse, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "create_assistant", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "gpt.CreateAssistant")
+	defer tracing.End(span, &err)
+
+	url := g.BaseURL + AssistantsPath
 
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
@@ -89,14 +115,18 @@ func (g *gptAdaptorClient) CreateAssistant(request AssistantRequest) (*Assistant
 	return &response, nil
 }
 
-func (g *gptAdaptorClient) CreateThread(request CreateThreadRequest) (*ThreadResponse, error) {
-	url := ThreadEndpoint
+func (g *gptAdaptorClient) CreateThread(ctx context.Context, request CreateThreadRequest) (_ *ThreadResponse, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "create_thread", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "gpt.CreateThread")
+	defer tracing.End(span, &err)
+
+	url := g.BaseURL + ThreadsPath
 
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
@@ -131,10 +161,14 @@ func (g *gptAdaptorClient) CreateThread(request CreateThreadRequest) (*ThreadRes
 	return &response, nil
 }
 
-func (g *gptAdaptorClient) DeleteThread(threadID string) (*DeleteThreadResponse, error) {
-	url := fmt.Sprintf("%v/%v", ThreadEndpoint, threadID)
+func (g *gptAdaptorClient) DeleteThread(ctx context.Context, threadID string) (_ *DeleteThreadResponse, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "delete_thread", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "gpt.DeleteThread")
+	defer tracing.End(span, &err)
+
+	url := fmt.Sprintf("%v/%v", g.BaseURL+ThreadsPath, threadID)
 
-	req, err := http.NewRequest("DELETE", url, nil)
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -169,14 +203,18 @@ func (g *gptAdaptorClient) DeleteThread(threadID string) (*DeleteThreadResponse,
 	return &response, nil
 }
 
-func (g *gptAdaptorClient) CreateMessage(threadID string, request CreateMessageRequest) (*MessageResponse, error) {
-	url := fmt.Sprintf("%v/%v/messages", ThreadEndpoint, threadID)
+func (g *gptAdaptorClient) CreateMessage(ctx context.Context, threadID string, request CreateMessageRequest) (_ *MessageResponse, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "create_message", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "gpt.CreateMessage")
+	defer tracing.End(span, &err)
+
+	url := fmt.Sprintf("%v/%v/messages", g.BaseURL+ThreadsPath, threadID)
 
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		return nil, err
 	}
@@ -211,8 +249,12 @@ func (g *gptAdaptorClient) CreateMessage(threadID string, request CreateMessageR
 	return &response, nil
 }
 
-func (g *gptAdaptorClient) ListMessages(threadID string, limit int, order, after, before string) (*ListMessagesResponse, error) {
-	urls := fmt.Sprintf("%v/%v/messages", ThreadEndpoint, threadID)
+func (g *gptAdaptorClient) ListMessages(ctx context.Context, threadID string, limit int, order, after, before string) (_ *ListMessagesResponse, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "list_messages", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "gpt.ListMessages")
+	defer tracing.End(span, &err)
+
+	urls := fmt.Sprintf("%v/%v/messages", g.BaseURL+ThreadsPath, threadID)
 
 	// Xây dựng các tham số truy vấn
 	queryParams := url.Values{}
@@ -229,7 +271,7 @@ func (g *gptAdaptorClient) ListMessages(threadID string, limit int, order, after
 		queryParams.Add("before", before)
 	}
 	urls += "?" + queryParams.Encode()
-	req, err := http.NewRequest("GET", urls, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", urls, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -264,29 +306,38 @@ func (g *gptAdaptorClient) ListMessages(threadID string, limit int, order, after
 	return &response, nil
 }
 
-func (g *gptAdaptorClient) CreateRunAndStreamResponse(threadID string, request CreateRunRequest) (<-chan string, error) {
-	url := fmt.Sprintf("%v/%v/runs", ThreadEndpoint, threadID)
+func (g *gptAdaptorClient) CreateRunAndStreamResponse(ctx context.Context, threadID string, request CreateRunRequest) (_ <-chan string, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "create_run", time.Now(), &err)
+	// The span stays open until the stream is fully consumed, so it is only ended here on failure.
+	ctx, span := tracing.Start(ctx, "gpt.CreateRunAndStreamResponse")
+	defer func() {
+		if err != nil {
+			tracing.End(span, &err)
+		}
+	}()
+
+	url := fmt.Sprintf("%v/%v/runs", g.BaseURL+ThreadsPath, threadID)
 
 	requestBody, err := json.Marshal(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(string(requestBody)))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(requestBody)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
 	}
 
 	g.addCommonHeaders(req)
 
-	resp, err := g.Client.Do(req)
+	resp, err := g.StreamClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err)
+		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
 		bodyBytes, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
 		return nil, fmt.Errorf("API request failed with status code %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
@@ -295,6 +346,8 @@ func (g *gptAdaptorClient) CreateRunAndStreamResponse(threadID string, request C
 	go func() {
 		defer close(valueChannel)
 		defer resp.Body.Close()
+		var streamErr error
+		defer tracing.End(span, &streamErr)
 
 		scanner := bufio.NewScanner(resp.Body)
 		var currentEvent string
@@ -318,22 +371,42 @@ func (g *gptAdaptorClient) CreateRunAndStreamResponse(threadID string, request C
 							valueChannel <- content.Text.Value
 						}
 					}
+				} else if currentEvent == "thread.run.completed" {
+					g.recordRunUsage(ctx, data)
 				}
 			}
 		}
 
-		if err := scanner.Err(); err != nil {
-			fmt.Printf("Error reading stream: %v\n", err)
+		if streamErr = scanner.Err(); streamErr != nil {
+			fmt.Printf("Error reading stream: %v\n", streamErr)
 		}
 	}()
 
 	return valueChannel, nil
 }
 
-func (g *gptAdaptorClient) GetRunDetails(threadID, runID string) (*RunResponse, error) {
-	urls := fmt.Sprintf("%v/%v/runs/%v", ThreadEndpoint, threadID, runID)
+// recordRunUsage records the token usage reported with a completed run event.
+func (g *gptAdaptorClient) recordRunUsage(ctx context.Context, data string) {
+	var run RunResponse
+	if err := json.Unmarshal([]byte(data), &run); err != nil || run.Usage == nil {
+		return
+	}
+	g.Usage.Record(ctx, usage.Record{
+		Provider:         usage.ProviderOpenAI,
+		Model:            run.Model,
+		PromptTokens:     run.Usage.PromptTokens,
+		CompletionTokens: run.Usage.CompletionTokens,
+	})
+}
+
+func (g *gptAdaptorClient) GetRunDetails(ctx context.Context, threadID, runID string) (_ *RunResponse, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "get_run", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "gpt.GetRunDetails")
+	defer tracing.End(span, &err)
 
-	req, err := http.NewRequest("GET", urls, nil)
+	urls := fmt.Sprintf("%v/%v/runs/%v", g.BaseURL+ThreadsPath, threadID, runID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", urls, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -370,7 +443,11 @@ func (g *gptAdaptorClient) GetRunDetails(threadID, runID string) (*RunResponse,
 	return &response, nil
 }
 
-func (g *gptAdaptorClient) UploadFile(filePath string) (*UploadFileResponse, error) {
+func (g *gptAdaptorClient) UploadFile(ctx context.Context, filePath string) (_ *UploadFileResponse, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "upload_file", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "gpt.UploadFile")
+	defer tracing.End(span, &err)
+
 	// Mở file
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -407,17 +484,16 @@ func (g *gptAdaptorClient) UploadFile(filePath string) (*UploadFileResponse, err
 	}
 
 	// Tạo và gửi request
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/files", &buffer)
+	req, err := http.NewRequestWithContext(ctx, "POST", g.BaseURL+FilesPath, &buffer)
 	if err != nil {
 		return nil, fmt.Errorf("Error creating request: %v", err)
 	}
 	req.Header.Add("Authorization", "Bearer "+g.ApiKey)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := g.Client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("Error sending request: %v", err)
+		return nil, fmt.Errorf("Error sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -436,7 +512,10 @@ func (g *gptAdaptorClient) UploadFile(filePath string) (*UploadFileResponse, err
 	return &uploadResp, nil
 }
 
-func (g *gptAdaptorClient) WaitForRunCompletion(threadID, runID string) (*RunResponse, error) {
+func (g *gptAdaptorClient) WaitForRunCompletion(ctx context.Context, threadID, runID string) (_ *RunResponse, err error) {
+	ctx, span := tracing.Start(ctx, "gpt.WaitForRunCompletion")
+	defer tracing.End(span, &err)
+
 	timeout := time.NewTimer(2 * time.Minute) // Sets a timer for 2 minutes.
 	ticker := time.NewTicker(5 * time.Second) // Checks every 5 seconds.
 	defer func() {
@@ -446,12 +525,15 @@ func (g *gptAdaptorClient) WaitForRunCompletion(threadID, runID string) (*RunRes
 
 	for {
 		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
 		case <-timeout.C:
 			fmt.Println("Timeout reached. Final status unknown.")
 			return nil, fmt.Errorf("timeout reached. Final status unknown")
 
 		case <-ticker.C:
-			runResponse, err := g.GetRunDetails(threadID, runID)
+			runResponse, err := g.GetRunDetails(ctx, threadID, runID)
 			if err != nil {
 				fmt.Println("Error fetching run details:", err)
 				return nil, fmt.Errorf("error fetching run details: %v", err)
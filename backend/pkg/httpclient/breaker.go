@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"CVSeeker/pkg/metrics"
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateHalfOpen
+	stateOpen
+)
+
+// breaker is a consecutive-failure circuit breaker. Once open it rejects calls until the cooldown
+// has elapsed, then lets a single trial call through: success closes it, failure opens it again.
+type breaker struct {
+	service   string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newBreaker(service string, threshold int, cooldown time.Duration) *breaker {
+	b := &breaker{service: service, threshold: threshold, cooldown: cooldown}
+	metrics.CircuitBreakerState.WithLabelValues(service).Set(float64(stateClosed))
+	return b
+}
+
+func (_this *breaker) allow() bool {
+	if _this.threshold <= 0 {
+		return true
+	}
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	switch _this.state {
+	case stateOpen:
+		if time.Since(_this.openedAt) < _this.cooldown {
+			return false
+		}
+		_this.setState(stateHalfOpen)
+		_this.trial = true
+		return true
+	case stateHalfOpen:
+		if _this.trial {
+			return false
+		}
+		_this.trial = true
+		return true
+	}
+	return true
+}
+
+func (_this *breaker) success() {
+	if _this.threshold <= 0 {
+		return
+	}
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.failures = 0
+	_this.trial = false
+	_this.setState(stateClosed)
+}
+
+func (_this *breaker) failure() {
+	if _this.threshold <= 0 {
+		return
+	}
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.trial = false
+	_this.failures++
+	if _this.state == stateHalfOpen || _this.failures >= _this.threshold {
+		_this.openedAt = time.Now()
+		_this.setState(stateOpen)
+	}
+}
+
+// release ends a trial call whose outcome says nothing about the service, e.g. a cancelled request.
+func (_this *breaker) release() {
+	if _this.threshold <= 0 {
+		return
+	}
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	_this.trial = false
+}
+
+func (_this *breaker) setState(state breakerState) {
+	_this.state = state
+	metrics.CircuitBreakerState.WithLabelValues(_this.service).Set(float64(state))
+}
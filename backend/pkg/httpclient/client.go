@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"CVSeeker/pkg/metrics"
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Client is an outbound HTTP client for one downstream service. It applies a per-attempt timeout,
+// retries transient failures (transport errors, 429 and 5xx) with jittered exponential backoff or
+// the delay asked for by Retry-After, and stops calling the service while its circuit is open.
+//
+// A transport error or a 5xx may come after the service acted on the request, so those are only
+// retried for requests that are safe to send twice: idempotent methods, requests with an
+// Idempotency-Key header, and requests marked with Idempotent. A 429 means the request was not
+// handled, so it is retried for any method.
+//
+// Any 4xx or 5xx response left after retrying is returned as a *StatusError with the body closed.
+type Client struct {
+	service    string
+	conf       Config
+	httpClient *http.Client
+	breaker    *breaker
+}
+
+// New returns a Client for service, which labels metrics and errors.
+func New(service string, conf Config) *Client {
+	return &Client{
+		service:    service,
+		conf:       conf,
+		httpClient: &http.Client{Timeout: conf.Timeout},
+		breaker:    newBreaker(service, conf.BreakerThreshold, conf.BreakerCooldown),
+	}
+}
+
+// Streaming returns a Client for long-lived responses such as server-sent events. The timeout only
+// bounds the wait for response headers, so the body can be read for as long as the stream lasts.
+// It shares retries and the circuit breaker with _this.
+func (_this *Client) Streaming() *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = _this.conf.Timeout
+	return &Client{
+		service:    _this.service,
+		conf:       _this.conf,
+		httpClient: &http.Client{Transport: transport},
+		breaker:    _this.breaker,
+	}
+}
+
+// IdempotencyKeyHeader is the request header that makes a request safe to retry whatever its method.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotentKey struct{}
+
+// Idempotent returns req marked as safe to send twice, for non-idempotent methods used by calls
+// without side effects, such as a POST computing an embedding.
+func Idempotent(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), idempotentKey{}, true))
+}
+
+// Do sends req, retrying it while the failure is transient, the request is safe to send again (see
+// Client) and the body can be replayed. Requests built with http.NewRequest from a bytes or strings
+// reader are always replayable.
+func (_this *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if !_this.breaker.allow() {
+			return nil, fmt.Errorf("%s: %w", _this.service, ErrCircuitOpen)
+		}
+
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				_this.breaker.release()
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		var (
+			wait      time.Duration
+			reason    string
+			unhandled bool // the service did not act on the request
+		)
+		resp, err := _this.httpClient.Do(req)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				_this.breaker.release()
+				return nil, err
+			}
+			_this.breaker.failure()
+			reason = "transport"
+		case resp.StatusCode >= http.StatusBadRequest:
+			statusErr := newStatusError(_this.service, resp)
+			if !statusErr.Retryable() {
+				// The service is healthy; the request itself was rejected.
+				_this.breaker.success()
+				return nil, statusErr
+			}
+			_this.breaker.failure()
+			err, wait, reason = statusErr, statusErr.RetryAfter, fmt.Sprint(statusErr.StatusCode)
+			unhandled = statusErr.StatusCode == http.StatusTooManyRequests
+		default:
+			_this.breaker.success()
+			return resp, nil
+		}
+
+		if attempt >= _this.conf.MaxRetries || !canReplay(req) || (!unhandled && !idempotent(req)) {
+			return nil, err
+		}
+		if wait == 0 {
+			wait = _this.backoff(attempt)
+		} else if _this.conf.MaxRetryAfter > 0 && wait > _this.conf.MaxRetryAfter {
+			return nil, err
+		}
+
+		metrics.HTTPClientRetries.WithLabelValues(_this.service, reason).Inc()
+		trace.SpanFromContext(ctx).AddEvent("http.retry", trace.WithAttributes(
+			attribute.String("http.client.service", _this.service),
+			attribute.Int("http.retry.attempt", attempt+1),
+			attribute.String("http.retry.reason", reason),
+			attribute.String("http.retry.wait", wait.String()),
+		))
+
+		if sleepErr := sleep(ctx, wait); sleepErr != nil {
+			return nil, fmt.Errorf("%w (last attempt: %v)", sleepErr, err)
+		}
+	}
+}
+
+// backoff returns a random delay in [d/2, d] where d doubles with every attempt up to MaxBackoff.
+func (_this *Client) backoff(attempt int) time.Duration {
+	d := _this.conf.InitialBackoff
+	for i := 0; i < attempt && d < _this.conf.MaxBackoff; i++ {
+		d *= 2
+	}
+	if _this.conf.MaxBackoff > 0 && d > _this.conf.MaxBackoff {
+		d = _this.conf.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked || req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+func canReplay(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
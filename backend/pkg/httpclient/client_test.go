@@ -0,0 +1,146 @@
+package httpclient_test
+
+import (
+	"CVSeeker/pkg/httpclient"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testConfig() httpclient.Config {
+	return httpclient.Config{
+		Timeout:          time.Second,
+		MaxRetries:       2,
+		InitialBackoff:   time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		MaxRetryAfter:    2 * time.Second,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Hour,
+	}
+}
+
+func TestClient_RetriesRateLimitAndReplaysBody(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "payload", string(body))
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := httpclient.New("test_retry", testConfig())
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestClient_RetriesServerErrorsOnlyWhenIdempotent(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	conf := testConfig()
+	conf.BreakerThreshold = 100
+	client := httpclient.New("test_idempotent", conf)
+	send := func(req *http.Request) int32 {
+		atomic.StoreInt32(&calls, 0)
+		_, err := client.Do(req)
+		assert.Equal(t, http.StatusBadGateway, httpclient.StatusCode(err))
+		return atomic.LoadInt32(&calls)
+	}
+
+	// The service may have acted on a POST before failing, so it is sent once.
+	req, _ := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	assert.Equal(t, int32(1), send(req))
+
+	req, _ = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	req.Header.Set(httpclient.IdempotencyKeyHeader, "key")
+	assert.Equal(t, int32(3), send(req))
+
+	req, _ = http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("payload"))
+	assert.Equal(t, int32(3), send(httpclient.Idempotent(req)))
+
+	req, _ = http.NewRequest(http.MethodDelete, srv.URL, nil)
+	assert.Equal(t, int32(3), send(req))
+}
+
+func TestClient_DoesNotRetryClientErrors(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "bad input", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	client := httpclient.New("test_client_error", testConfig())
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := client.Do(req)
+
+	var statusErr *httpclient.StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusBadRequest, statusErr.StatusCode)
+	assert.Contains(t, statusErr.Body, "bad input")
+	assert.False(t, httpclient.IsRetryable(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_RetryAfterBeyondLimitFailsFast(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := httpclient.New("test_retry_after", testConfig())
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := client.Do(req)
+
+	var statusErr *httpclient.StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, 120*time.Second, statusErr.RetryAfter)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestClient_CircuitOpensAfterConsecutiveFailures(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	conf := testConfig()
+	conf.MaxRetries = 0
+	client := httpclient.New("test_breaker", conf)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		_, err := client.Do(req)
+		assert.Equal(t, http.StatusBadGateway, httpclient.StatusCode(err))
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	_, err := client.Do(req)
+	assert.ErrorIs(t, err, httpclient.ErrCircuitOpen)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
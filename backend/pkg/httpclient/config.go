@@ -0,0 +1,67 @@
+package httpclient
+
+import (
+	"CVSeeker/pkg/cfg"
+	"fmt"
+	"github.com/spf13/viper"
+	"time"
+)
+
+// Config controls timeouts, retries and circuit breaking for one downstream service.
+type Config struct {
+	// Timeout bounds a single attempt, including reading the response body. Zero means no timeout.
+	Timeout time.Duration
+	// MaxRetries is the number of additional attempts made after a retryable failure.
+	MaxRetries int
+	// InitialBackoff and MaxBackoff bound the jittered exponential delay between attempts.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxRetryAfter is the longest Retry-After the client is willing to wait; longer values fail immediately.
+	MaxRetryAfter time.Duration
+	// BreakerThreshold is the number of consecutive failures that opens the circuit. Zero disables the breaker.
+	BreakerThreshold int
+	// BreakerCooldown is how long the circuit stays open before a trial request is let through.
+	BreakerCooldown time.Duration
+}
+
+// DefaultConfig returns the settings used when a service does not override them.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          30 * time.Second,
+		MaxRetries:       3,
+		InitialBackoff:   500 * time.Millisecond,
+		MaxBackoff:       10 * time.Second,
+		MaxRetryAfter:    60 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// LoadConfig overrides defaults with the <SERVICE>_HTTP_* keys set for service, e.g. GPT_HTTP_TIMEOUT="90s".
+func LoadConfig(cfgReader *viper.Viper, service string, defaults Config) Config {
+	conf := defaults
+	key := func(format string) string { return fmt.Sprintf(format, service) }
+
+	if k := key(cfg.HttpClientTimeout); cfgReader.IsSet(k) {
+		conf.Timeout = cfgReader.GetDuration(k)
+	}
+	if k := key(cfg.HttpClientMaxRetries); cfgReader.IsSet(k) {
+		conf.MaxRetries = cfgReader.GetInt(k)
+	}
+	if k := key(cfg.HttpClientInitialBackoff); cfgReader.IsSet(k) {
+		conf.InitialBackoff = cfgReader.GetDuration(k)
+	}
+	if k := key(cfg.HttpClientMaxBackoff); cfgReader.IsSet(k) {
+		conf.MaxBackoff = cfgReader.GetDuration(k)
+	}
+	if k := key(cfg.HttpClientMaxRetryAfter); cfgReader.IsSet(k) {
+		conf.MaxRetryAfter = cfgReader.GetDuration(k)
+	}
+	if k := key(cfg.HttpClientBreakerThreshold); cfgReader.IsSet(k) {
+		conf.BreakerThreshold = cfgReader.GetInt(k)
+	}
+	if k := key(cfg.HttpClientBreakerCooldown); cfgReader.IsSet(k) {
+		conf.BreakerCooldown = cfgReader.GetDuration(k)
+	}
+	return conf
+}
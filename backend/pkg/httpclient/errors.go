@@ -0,0 +1,91 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxErrorBodySize caps how much of an error response body is kept in StatusError.
+const maxErrorBodySize = 4 << 10
+
+// ErrCircuitOpen is returned without calling the service while its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// StatusError is returned when the service answers with a 4xx or 5xx status code.
+type StatusError struct {
+	Service    string
+	StatusCode int
+	Body       string
+	// RetryAfter is the delay requested by the service through the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+func (_this *StatusError) Error() string {
+	return fmt.Sprintf("%s: request failed with status code %d: %s", _this.Service, _this.StatusCode, _this.Body)
+}
+
+// Retryable reports whether the request may succeed if sent again.
+func (_this *StatusError) Retryable() bool {
+	return isRetryableStatus(_this.StatusCode)
+}
+
+// IsRetryable reports whether err is a transient failure: a retryable status, an open circuit or a transport error.
+func IsRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+	return err != nil
+}
+
+// StatusCode returns the HTTP status carried by err, or 0 if err is not a StatusError.
+func StatusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+func newStatusError(service string, resp *http.Response) *StatusError {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	return &StatusError{
+		Service:    service,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter accepts both forms of Retry-After: delay-seconds and an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -2,37 +2,71 @@ package huggingface
 
 import (
 	"CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/httpclient"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 	"net/http"
+	"time"
 )
 
+// adaptorName identifies this adaptor in metrics.
+const adaptorName = "huggingface"
+
+// defaultBaseURL is used when HUGGINGFACE_BASE_URL is not set.
+const defaultBaseURL = "https://api-inference.huggingface.co"
+
+// httpServiceName is the prefix of the HUGGINGFACE_HTTP_* client settings.
+const httpServiceName = "HUGGINGFACE"
+
 type IHuggingFaceClient interface {
-	GetTextEmbedding(term string, model string) ([]float32, error)
+	GetTextEmbedding(ctx context.Context, term string, model string) ([]float32, error)
 }
 
 type HuggingFaceClient struct {
-	httpClient *http.Client
+	httpClient *httpclient.Client
+	baseURL    string
 	apiKey     string
+	usage      usage.Recorder
 }
 
-func NewHuggingFaceClient(cfgReader *viper.Viper) (IHuggingFaceClient, error) {
+func NewHuggingFaceClient(cfgReader *viper.Viper, recorder usage.Recorder) (IHuggingFaceClient, error) {
+	// The first request after the model is unloaded waits for it to load (wait_for_model).
+	defaults := httpclient.DefaultConfig()
+	defaults.Timeout = 60 * time.Second
+
 	return &HuggingFaceClient{
-		httpClient: &http.Client{},
+		httpClient: httpclient.New(adaptorName, httpclient.LoadConfig(cfgReader, httpServiceName, defaults)),
+		baseURL:    cfg.BaseURL(cfgReader, cfg.HuggingfaceBaseUrl, defaultBaseURL),
 		apiKey:     cfgReader.GetString(cfg.HuggingfaceApiKey),
+		usage:      recorder,
 	}, nil
 }
 
-func (hc *HuggingFaceClient) GetTextEmbedding(term string, model string) ([]float32, error) {
-	posturl := fmt.Sprintf("https://api-inference.huggingface.co/pipeline/feature-extraction/%s", model)
+func (hc *HuggingFaceClient) GetTextEmbedding(ctx context.Context, term string, model string) (_ []float32, err error) {
+	defer metrics.ObserveAdaptorCall(adaptorName, "text_embedding", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "huggingface.GetTextEmbedding", attribute.String("embedding.model", model))
+	defer tracing.End(span, &err)
+
+	posturl := fmt.Sprintf("%s/pipeline/feature-extraction/%s", hc.baseURL, model)
 
 	// Prepare the request body with JSON content
-	body := []byte(fmt.Sprintf(`{"inputs": "%s", "options": {"wait_for_model": true}}`, term))
+	body, err := json.Marshal(map[string]interface{}{
+		"inputs":  term,
+		"options": map[string]bool{"wait_for_model": true},
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Create a HTTP post request
-	req, err := http.NewRequest("POST", posturl, bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", posturl, bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -40,8 +74,8 @@ func (hc *HuggingFaceClient) GetTextEmbedding(term string, model string) ([]floa
 	req.Header.Add("Authorization", "Bearer "+hc.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 
-	// Send the request
-	resp, err := hc.httpClient.Do(req)
+	// Send the request; computing an embedding has no side effect, so it can be retried.
+	resp, err := hc.httpClient.Do(httpclient.Idempotent(req))
 	if err != nil {
 		return nil, err
 	}
@@ -53,5 +87,13 @@ func (hc *HuggingFaceClient) GetTextEmbedding(term string, model string) ([]floa
 		return nil, err
 	}
 
+	// The inference API does not report token usage.
+	hc.usage.Record(ctx, usage.Record{
+		Provider:     usage.ProviderHuggingFace,
+		Model:        model,
+		PromptTokens: usage.EstimateTokens(term),
+		Estimated:    true,
+	})
+
 	return embeddings, nil
 }
@@ -0,0 +1,57 @@
+// Package llmjson turns free-form LLM output into JSON that matches a schema: it extracts the JSON
+// object from fenced or chatty answers, coerces near-miss types (a GPA of "3.6/4.0", a comma
+// separated skill list...) and reports what still does not match so the model can be re-prompted.
+package llmjson
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrNoJSON is returned by Extract when the text does not contain a JSON object.
+var ErrNoJSON = errors.New("no JSON object found in model output")
+
+// fencePattern matches a Markdown code block, optionally tagged with a language.
+var fencePattern = regexp.MustCompile("(?s)```[a-zA-Z]*[ \t]*\r?\n?(.*?)```")
+
+// Extract returns the first complete JSON object in text. A JSON object inside a Markdown code
+// fence is preferred; prose before and after the object is ignored.
+func Extract(text string) (string, error) {
+	for _, match := range fencePattern.FindAllStringSubmatch(text, -1) {
+		if object, err := firstObject(match[1]); err == nil {
+			return object, nil
+		}
+	}
+	return firstObject(text)
+}
+
+// firstObject scans text for the first balanced {...}, skipping braces inside strings.
+func firstObject(text string) (string, error) {
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return "", ErrNoJSON
+	}
+
+	depth, inString, escaped := 0, false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], nil
+			}
+		}
+	}
+	return "", errors.New("unterminated JSON object in model output")
+}
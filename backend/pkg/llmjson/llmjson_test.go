@@ -0,0 +1,106 @@
+package llmjson_test
+
+import (
+	"CVSeeker/pkg/llmjson"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	cases := map[string]string{
+		"bare":          `{"a": 1}`,
+		"fenced":        "Here you go:\n```json\n{\"a\": 1}\n```\nLet me know!",
+		"untagged":      "```\n{\"a\": 1}\n```",
+		"trailing text": `{"a": 1} I hope this helps {with braces}`,
+		"leading text":  `Sure! The JSON is: {"a": 1}`,
+	}
+	for name, text := range cases {
+		t.Run(name, func(t *testing.T) {
+			object, err := llmjson.Extract(text)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"a": 1}`, object)
+		})
+	}
+
+	object, err := llmjson.Extract(`{"text": "a } inside \" a string", "n": {"m": 2}}`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "a } inside \" a string", "n": {"m": 2}}`, object)
+
+	_, err = llmjson.Extract("I could not parse this resume.")
+	assert.ErrorIs(t, err, llmjson.ErrNoJSON)
+
+	_, err = llmjson.Extract(`{"a": 1`)
+	assert.Error(t, err)
+}
+
+var testSchema = &llmjson.Schema{
+	Type:     llmjson.TypeObject,
+	Required: []string{"name", "skills"},
+	Properties: map[string]*llmjson.Schema{
+		"name":   {Type: llmjson.TypeString},
+		"skills": {Type: llmjson.TypeArray, Items: &llmjson.Schema{Type: llmjson.TypeString}},
+		"gpa":    {Type: llmjson.TypeNumber, Nullable: true},
+		"years":  {Type: llmjson.TypeInteger},
+		"jobs": {Type: llmjson.TypeArray, Items: &llmjson.Schema{
+			Type:       llmjson.TypeObject,
+			Properties: map[string]*llmjson.Schema{"title": {Type: llmjson.TypeString}},
+		}},
+	},
+}
+
+type testDocument struct {
+	Name   string   `json:"name"`
+	Skills []string `json:"skills"`
+	GPA    *float64 `json:"gpa"`
+	Years  int      `json:"years"`
+	Jobs   []struct {
+		Title string `json:"title"`
+	} `json:"jobs"`
+}
+
+func TestDecode_Coercion(t *testing.T) {
+	var doc testDocument
+	err := llmjson.Decode("```json\n"+`{"Name": "Alice", "skills": "Go, SQL; Docker", "GPA": "3.6/4.0", "years": "5 years", "jobs": {"title": 42}, "extra": true}`+"\n```", testSchema, &doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Alice", doc.Name)
+	assert.Equal(t, []string{"Go", "SQL", "Docker"}, doc.Skills)
+	require.NotNil(t, doc.GPA)
+	assert.Equal(t, 3.6, *doc.GPA)
+	assert.Equal(t, 5, doc.Years)
+	require.Len(t, doc.Jobs, 1)
+	assert.Equal(t, "42", doc.Jobs[0].Title)
+
+	doc = testDocument{}
+	require.NoError(t, llmjson.Decode(`{"name": "Bob", "skills": null, "gpa": "N/A", "jobs": null}`, testSchema, &doc))
+	assert.Nil(t, doc.GPA)
+	assert.Empty(t, doc.Skills)
+	assert.Empty(t, doc.Jobs)
+}
+
+func TestDecode_ValidationErrors(t *testing.T) {
+	var doc testDocument
+	err := llmjson.Decode(`{"gpa": "excellent", "years": 2.5, "jobs": ["engineer"]}`, testSchema, &doc)
+
+	var errs llmjson.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	paths := make([]string, 0, len(errs))
+	for _, fieldErr := range errs {
+		paths = append(paths, fieldErr.Path)
+	}
+	assert.ElementsMatch(t, []string{"$.name", "$.skills", "$.gpa", "$.years", "$.jobs[0]"}, paths)
+	assert.Empty(t, doc.Name)
+}
+
+func TestSchema_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(&llmjson.Schema{
+		Type:       llmjson.TypeObject,
+		Required:   []string{"gpa"},
+		Properties: map[string]*llmjson.Schema{"gpa": {Type: llmjson.TypeNumber, Nullable: true}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"type": "object", "required": ["gpa"], "properties": {"gpa": {"type": ["number", "null"]}}}`, string(data))
+}
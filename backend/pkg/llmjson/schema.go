@@ -0,0 +1,291 @@
+package llmjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSON Schema types supported by Schema.
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+)
+
+// Schema is the subset of JSON Schema needed to describe LLM output. It marshals to standard JSON
+// Schema so that it can also be sent as an OpenAI structured-output response format.
+type Schema struct {
+	Type        string
+	Description string
+	Properties  map[string]*Schema
+	Required    []string
+	Items       *Schema
+	// Nullable allows null, which is kept as is instead of being coerced to the zero value.
+	Nullable bool
+}
+
+// MarshalJSON encodes the schema as JSON Schema, expressing Nullable as a ["type", "null"] union.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{"type": s.Type}
+	if s.Nullable {
+		out["type"] = []string{s.Type, "null"}
+	}
+	if s.Description != "" {
+		out["description"] = s.Description
+	}
+	if s.Type == TypeObject {
+		properties := s.Properties
+		if properties == nil {
+			properties = map[string]*Schema{}
+		}
+		out["properties"] = properties
+		if len(s.Required) > 0 {
+			out["required"] = s.Required
+		}
+	}
+	if s.Items != nil {
+		out["items"] = s.Items
+	}
+	return json.Marshal(out)
+}
+
+// FieldError describes a value that does not match the schema.
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidationErrors lists every mismatch found in a document.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return "model output does not match the schema: " + strings.Join(messages, "; ")
+}
+
+// numberPattern finds the first number in a string such as "3.6/4.0" or "GPA 3.2".
+var numberPattern = regexp.MustCompile(`[-+]?\d+(?:[.,]\d+)?`)
+
+// listSeparators split a string into items when an array of strings is expected.
+var listSeparators = regexp.MustCompile(`\s*[,;\n]\s*`)
+
+// Coerce converts value to the shape described by the schema where the intent is unambiguous and
+// returns the converted value with the mismatches that could not be fixed. Object keys are matched
+// case-insensitively and renamed to the schema's spelling; unknown keys are dropped.
+func (s *Schema) Coerce(value interface{}) (interface{}, ValidationErrors) {
+	var errs ValidationErrors
+	coerced := s.coerce("$", value, &errs)
+	return coerced, errs
+}
+
+func (s *Schema) coerce(path string, value interface{}, errs *ValidationErrors) interface{} {
+	if value == nil {
+		if s.Nullable {
+			return nil
+		}
+		return s.coerce(path, s.zero(), errs)
+	}
+
+	fail := func(format string, args ...interface{}) interface{} {
+		*errs = append(*errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+		if s.Nullable {
+			return nil
+		}
+		return s.zero()
+	}
+
+	switch s.Type {
+	case TypeObject:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fail("expected an object, got %s", describe(value))
+		}
+		return s.coerceObject(path, object, errs)
+
+	case TypeArray:
+		items, ok := value.([]interface{})
+		if !ok {
+			if text, isString := value.(string); isString && s.Items != nil && s.Items.Type == TypeString {
+				items = splitList(text)
+			} else {
+				items = []interface{}{value}
+			}
+		}
+		out := make([]interface{}, 0, len(items))
+		for i, item := range items {
+			if s.Items == nil {
+				out = append(out, item)
+				continue
+			}
+			out = append(out, s.Items.coerce(fmt.Sprintf("%s[%d]", path, i), item, errs))
+		}
+		return out
+
+	case TypeString:
+		switch v := value.(type) {
+		case string:
+			return v
+		case float64, bool:
+			return fmt.Sprint(v)
+		}
+		return fail("expected a string, got %s", describe(value))
+
+	case TypeNumber, TypeInteger:
+		var number float64
+		switch v := value.(type) {
+		case float64:
+			number = v
+		case string:
+			match := numberPattern.FindString(v)
+			if match == "" {
+				if s.Nullable && isBlankValue(v) {
+					return nil
+				}
+				return fail("expected a number, got %s", describe(value))
+			}
+			parsed, err := strconv.ParseFloat(strings.Replace(match, ",", ".", 1), 64)
+			if err != nil {
+				return fail("expected a number, got %s", describe(value))
+			}
+			number = parsed
+		default:
+			return fail("expected a number, got %s", describe(value))
+		}
+		if s.Type == TypeInteger && number != float64(int64(number)) {
+			return fail("expected an integer, got %v", number)
+		}
+		return number
+
+	case TypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v
+		case string:
+			if parsed, err := strconv.ParseBool(strings.ToLower(strings.TrimSpace(v))); err == nil {
+				return parsed
+			}
+		}
+		return fail("expected a boolean, got %s", describe(value))
+	}
+	return value
+}
+
+func (s *Schema) coerceObject(path string, object map[string]interface{}, errs *ValidationErrors) map[string]interface{} {
+	byLowerKey := make(map[string]interface{}, len(object))
+	for key, v := range object {
+		byLowerKey[strings.ToLower(key)] = v
+	}
+	required := make(map[string]bool, len(s.Required))
+	for _, key := range s.Required {
+		required[key] = true
+	}
+
+	keys := make([]string, 0, len(s.Properties))
+	for key := range s.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	out := make(map[string]interface{}, len(s.Properties))
+	for _, key := range keys {
+		property := s.Properties[key]
+		v, present := byLowerKey[strings.ToLower(key)]
+		if !present && required[key] {
+			*errs = append(*errs, FieldError{Path: path + "." + key, Message: "is required"})
+		}
+		out[key] = property.coerce(path+"."+key, v, errs)
+	}
+	return out
+}
+
+// zero is the value used for a missing or null non-nullable field.
+func (s *Schema) zero() interface{} {
+	switch s.Type {
+	case TypeObject:
+		return map[string]interface{}{}
+	case TypeArray:
+		return []interface{}{}
+	case TypeString:
+		return ""
+	case TypeNumber, TypeInteger:
+		return float64(0)
+	case TypeBoolean:
+		return false
+	}
+	return nil
+}
+
+func splitList(text string) []interface{} {
+	items := make([]interface{}, 0)
+	for _, item := range listSeparators.Split(strings.TrimSpace(text), -1) {
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// isBlankValue reports whether a string is a placeholder for "no value".
+func isBlankValue(text string) bool {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "", "n/a", "na", "none", "null", "unknown", "-":
+		return true
+	}
+	return false
+}
+
+func describe(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return "number " + strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return "boolean " + strconv.FormatBool(v)
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// Decode extracts the JSON object from text, coerces it to the schema and unmarshals it into out.
+// It returns ValidationErrors when the output does not match the schema after coercion, and out is
+// left untouched.
+func Decode(text string, schema *Schema, out interface{}) error {
+	object, err := Extract(text)
+	if err != nil {
+		return err
+	}
+
+	var raw interface{}
+	if err := json.Unmarshal([]byte(object), &raw); err != nil {
+		return fmt.Errorf("model output is not valid JSON: %w", err)
+	}
+
+	coerced, errs := schema.Coerce(raw)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	data, err := json.Marshal(coerced)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
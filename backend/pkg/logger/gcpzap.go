@@ -86,7 +86,7 @@ func (l *gcpZapLogger) WithFields(fields Fields) Logger {
 
 func (l *gcpZapLogger) TraceCtx(ctx context.Context) Logger {
 	if ctx != nil && reflect.TypeOf(ctx).String() != "*context.emptyCtx" {
-		return l.WithFields(Fields{TraceIDField: ctx.Value(ContextTraceID)})
+		return l.WithFields(Fields{TraceIDField: traceIDFromContext(ctx)})
 	}
 	return l
 }
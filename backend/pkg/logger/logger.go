@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"CVSeeker/pkg/tracing"
 	"context"
 	"log"
 	"os"
@@ -80,3 +81,15 @@ func NewLogger() Logger {
 	}
 	return logger
 }
+
+// traceIDFromContext returns the explicit trace ID stored in ctx, falling back to the
+// ID of the active OpenTelemetry span so log lines can be joined with traces.
+func traceIDFromContext(ctx context.Context) interface{} {
+	if traceID := ctx.Value(ContextTraceID); traceID != nil {
+		return traceID
+	}
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		return traceID
+	}
+	return nil
+}
@@ -97,7 +97,7 @@ func (l *zapLogger) WithFields(fields Fields) Logger {
 
 func (l *zapLogger) TraceCtx(ctx context.Context) Logger {
 	if reflect.TypeOf(ctx).String() != "*context.emptyCtx" {
-		return l.WithFields(Fields{TraceIDField: ctx.Value(ContextTraceID)})
+		return l.WithFields(Fields{TraceIDField: traceIDFromContext(ctx)})
 	}
 	return l
 }
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cvseeker"
+
+// Outcome labels used by adaptor call counters.
+const (
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+var (
+	// HTTPRequestDuration tracks the latency of every HTTP request handled by Gin, labelled by route template.
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by method, route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// AdaptorCalls counts calls made to downstream services (OpenAI, Hugging Face, Elasticsearch, S3...).
+	AdaptorCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "adaptor",
+		Name:      "calls_total",
+		Help:      "Number of calls made to downstream services by adaptor, operation and outcome.",
+	}, []string{"adaptor", "operation", "outcome"})
+
+	// AdaptorLatency tracks the latency of calls made to downstream services.
+	AdaptorLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "adaptor",
+		Name:      "call_duration_seconds",
+		Help:      "Latency of calls made to downstream services by adaptor and operation.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80},
+	}, []string{"adaptor", "operation"})
+
+	// HTTPClientRetries counts retried outbound HTTP requests by service and reason (status code or "transport").
+	HTTPClientRetries = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http_client",
+		Name:      "retries_total",
+		Help:      "Number of retried outbound HTTP requests by service and reason.",
+	}, []string{"service", "reason"})
+
+	// CircuitBreakerState is the state of each outbound circuit breaker: 0 closed, 1 half-open, 2 open.
+	CircuitBreakerState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http_client",
+		Name:      "circuit_breaker_state",
+		Help:      "State of outbound circuit breakers (0 closed, 1 half-open, 2 open).",
+	}, []string{"service"})
+
+	// LLMTokens counts tokens consumed by LLM and embedding calls by provider, model, operation and kind (prompt or completion).
+	LLMTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "tokens_total",
+		Help:      "Tokens consumed by LLM and embedding calls.",
+	}, []string{"provider", "model", "operation", "kind"})
+
+	// LLMCost accumulates the estimated cost in USD of LLM and embedding calls.
+	LLMCost = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "llm",
+		Name:      "cost_usd_total",
+		Help:      "Estimated cost in USD of LLM and embedding calls.",
+	}, []string{"provider", "model", "operation"})
+
+	// IngestionQueueDepth is the number of resumes accepted for ingestion that have not finished processing yet.
+	IngestionQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "ingestion",
+		Name:      "queue_depth",
+		Help:      "Number of resumes waiting for or undergoing ingestion.",
+	})
+
+	// WebSocketConnections is the number of currently open WebSocket connections.
+	WebSocketConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "websocket",
+		Name:      "active_connections",
+		Help:      "Number of active WebSocket connections.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(
+		HTTPRequestDuration,
+		AdaptorCalls,
+		AdaptorLatency,
+		HTTPClientRetries,
+		CircuitBreakerState,
+		LLMTokens,
+		LLMCost,
+		IngestionQueueDepth,
+		WebSocketConnections,
+	)
+}
+
+// Handler returns the HTTP handler exposing all registered metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveAdaptorCall records the outcome and latency of a downstream call started at start.
+// It is meant to be deferred with a pointer to the caller's named error result:
+//
+//	defer metrics.ObserveAdaptorCall("gpt", "create_thread", time.Now(), &err)
+func ObserveAdaptorCall(adaptor, operation string, start time.Time, err *error) {
+	outcome := OutcomeSuccess
+	if err != nil && *err != nil {
+		outcome = OutcomeError
+	}
+	AdaptorCalls.WithLabelValues(adaptor, operation, outcome).Inc()
+	AdaptorLatency.WithLabelValues(adaptor, operation).Observe(time.Since(start).Seconds())
+}
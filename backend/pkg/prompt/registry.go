@@ -0,0 +1,212 @@
+// Package prompt manages versioned LLM prompts written as Go text templates. Static versions are
+// shipped as files and more versions can be added at runtime, so that a prompt can be changed
+// without a redeploy while every rendered prompt stays traceable to the version that produced it.
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Sources of a template.
+const (
+	SourceStatic   = "static"
+	SourceDatabase = "database"
+)
+
+// fileExtension is the extension of template files loaded by LoadDir.
+const fileExtension = ".tmpl"
+
+// versionPattern restricts version IDs to something that is safe in file names and URLs.
+var versionPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,32}$`)
+
+// digitsPattern splits a version into runs of digits and non-digits for natural ordering.
+var digitsPattern = regexp.MustCompile(`\d+|\D+`)
+
+// Template is one version of a named prompt.
+type Template struct {
+	Name      string
+	Version   string
+	Body      string
+	Source    string
+	CreatedAt time.Time
+}
+
+// Registry holds the static versions of every prompt and renders templates with a shared set of
+// functions. It is filled once at startup and is safe for concurrent reads afterwards.
+type Registry struct {
+	funcs     template.FuncMap
+	templates map[string]map[string]Template
+}
+
+// NewRegistry returns an empty registry whose templates may call funcs.
+func NewRegistry(funcs template.FuncMap) *Registry {
+	return &Registry{
+		funcs:     funcs,
+		templates: map[string]map[string]Template{},
+	}
+}
+
+// ValidVersion reports whether version can be used as a version ID.
+func ValidVersion(version string) bool {
+	return versionPattern.MatchString(version)
+}
+
+// LoadDir adds every <dir>/<name>/<version>.tmpl file as a static template. The trailing newline
+// that editors add to files is not part of the prompt.
+func (r *Registry) LoadDir(dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*"+fileExtension))
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		if _, err := os.Stat(dir); err != nil {
+			return fmt.Errorf("loading prompts: %w", err)
+		}
+	}
+
+	for _, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("loading prompt %s: %w", file, err)
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("loading prompt %s: %w", file, err)
+		}
+		err = r.Add(Template{
+			Name:      filepath.Base(filepath.Dir(file)),
+			Version:   strings.TrimSuffix(filepath.Base(file), fileExtension),
+			Body:      strings.TrimSuffix(strings.TrimSuffix(string(body), "\n"), "\r"),
+			Source:    SourceStatic,
+			CreatedAt: info.ModTime(),
+		})
+		if err != nil {
+			return fmt.Errorf("loading prompt %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// Add registers a template after checking its version ID and syntax.
+func (r *Registry) Add(t Template) error {
+	if !ValidVersion(t.Version) {
+		return fmt.Errorf("invalid version %q", t.Version)
+	}
+	if _, err := r.Parse(t); err != nil {
+		return err
+	}
+	if t.Source == "" {
+		t.Source = SourceStatic
+	}
+	if r.templates[t.Name] == nil {
+		r.templates[t.Name] = map[string]Template{}
+	}
+	r.templates[t.Name][t.Version] = t
+	return nil
+}
+
+// Get returns a version of a prompt.
+func (r *Registry) Get(name, version string) (Template, bool) {
+	t, ok := r.templates[name][version]
+	return t, ok
+}
+
+// Names returns the names of the registered prompts, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.templates))
+	for name := range r.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Versions returns the versions of a prompt, oldest first according to CompareVersions.
+func (r *Registry) Versions(name string) []Template {
+	versions := make([]Template, 0, len(r.templates[name]))
+	for _, t := range r.templates[name] {
+		versions = append(versions, t)
+	}
+	SortVersions(versions)
+	return versions
+}
+
+// Latest returns the highest version of a prompt.
+func (r *Registry) Latest(name string) (Template, bool) {
+	versions := r.Versions(name)
+	if len(versions) == 0 {
+		return Template{}, false
+	}
+	return versions[len(versions)-1], true
+}
+
+// Parse compiles a template with the registry's functions. Referencing a missing map key is an
+// error rather than an empty string, so that a typo does not silently produce a broken prompt.
+func (r *Registry) Parse(t Template) (*template.Template, error) {
+	parsed, err := template.New(t.Name + "/" + t.Version).Funcs(r.funcs).Option("missingkey=error").Parse(t.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return parsed, nil
+}
+
+// Render executes a template with data.
+func (r *Registry) Render(t Template, data interface{}) (string, error) {
+	parsed, err := r.Parse(t)
+	if err != nil {
+		return "", err
+	}
+	var out bytes.Buffer
+	if err := parsed.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("rendering prompt %s version %s: %w", t.Name, t.Version, err)
+	}
+	return out.String(), nil
+}
+
+// SortVersions orders templates by version, oldest first.
+func SortVersions(templates []Template) {
+	sort.SliceStable(templates, func(i, j int) bool {
+		return CompareVersions(templates[i].Version, templates[j].Version) < 0
+	})
+}
+
+// CompareVersions orders version IDs naturally, comparing runs of digits as numbers so that
+// "v10" comes after "v9". It returns -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	partsA, partsB := digitsPattern.FindAllString(a, -1), digitsPattern.FindAllString(b, -1)
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.ParseUint(partsA[i], 10, 64)
+		numB, errB := strconv.ParseUint(partsB[i], 10, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA < numB {
+					return -1
+				}
+				return 1
+			}
+		case partsA[i] != partsB[i]:
+			if partsA[i] < partsB[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(partsA) < len(partsB):
+		return -1
+	case len(partsA) > len(partsB):
+		return 1
+	}
+	return strings.Compare(a, b)
+}
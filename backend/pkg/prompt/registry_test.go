@@ -0,0 +1,72 @@
+package prompt_test
+
+import (
+	"CVSeeker/pkg/prompt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestLoadDirAndRender(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "greeting", "v1.tmpl"), "Hello {{.Name}}\n")
+	writeFile(t, filepath.Join(dir, "greeting", "v2.tmpl"), "Hi {{upper .Name}}!\n")
+	writeFile(t, filepath.Join(dir, "greeting", "README.md"), "not a template")
+
+	registry := prompt.NewRegistry(template.FuncMap{"upper": strings.ToUpper})
+	require.NoError(t, registry.LoadDir(dir))
+
+	assert.Equal(t, []string{"greeting"}, registry.Names())
+	latest, ok := registry.Latest("greeting")
+	require.True(t, ok)
+	assert.Equal(t, "v2", latest.Version)
+	assert.Equal(t, prompt.SourceStatic, latest.Source)
+
+	text, err := registry.Render(latest, map[string]string{"Name": "ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi ADA!", text)
+
+	v1, ok := registry.Get("greeting", "v1")
+	require.True(t, ok)
+	text, err = registry.Render(v1, map[string]string{"Name": "ada"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hello ada", text, "the trailing newline of the file is not part of the prompt")
+
+	_, err = registry.Render(v1, map[string]string{"Nmae": "ada"})
+	assert.Error(t, err, "a missing variable must fail instead of rendering an empty value")
+}
+
+func TestLoadDirRejectsInvalidTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "broken", "v1.tmpl"), "Hello {{.Name")
+	assert.Error(t, prompt.NewRegistry(nil).LoadDir(dir))
+
+	assert.Error(t, prompt.NewRegistry(nil).LoadDir(filepath.Join(dir, "missing")))
+
+	err := prompt.NewRegistry(nil).Add(prompt.Template{Name: "greeting", Version: "v 1", Body: "Hello"})
+	assert.Error(t, err)
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, -1, prompt.CompareVersions("v9", "v10"))
+	assert.Equal(t, 1, prompt.CompareVersions("v1.10", "v1.2"))
+	assert.Equal(t, -1, prompt.CompareVersions("v1", "v1.1"))
+	assert.Equal(t, 0, prompt.CompareVersions("v2", "v2"))
+	assert.Equal(t, -1, prompt.CompareVersions("2024-01-05", "2024-02-01"))
+
+	templates := []prompt.Template{{Version: "v10"}, {Version: "v2"}, {Version: "v1"}}
+	prompt.SortVersions(templates)
+	assert.Equal(t, "v1", templates[0].Version)
+	assert.Equal(t, "v10", templates[2].Version)
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limit is the capacity of a provider per minute. Zero fields do not limit.
+type Limit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// Limiter holds two token buckets, one for requests and one for tokens. Each is refilled
+// continuously at its rate per minute and holds at most a minute of capacity, so that an idle
+// provider allows a burst of that size.
+type Limiter struct {
+	mu       sync.Mutex
+	limit    Limit
+	requests float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewLimiter returns a limiter with full buckets.
+func NewLimiter(limit Limit) *Limiter {
+	return &Limiter{
+		limit:    limit,
+		requests: float64(limit.RequestsPerMinute),
+		tokens:   float64(limit.TokensPerMinute),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until one request of tokens tokens fits in the limits, and takes it from the buckets.
+// A request larger than a minute of tokens waits for a full bucket. The wait hook of ctx is told
+// when the request starts and stops waiting. It returns ctx.Err() if ctx is done first.
+func (_this *Limiter) Wait(ctx context.Context, tokens int) error {
+	hook := waitHookFrom(ctx)
+	waiting := false
+	defer func() {
+		if waiting && hook != nil {
+			hook(false)
+		}
+	}()
+
+	for {
+		delay := _this.take(tokens)
+		if delay <= 0 {
+			return nil
+		}
+		if !waiting {
+			waiting = true
+			if hook != nil {
+				hook(true)
+			}
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// take takes a request of tokens tokens from the buckets when it fits, and otherwise returns how
+// long until it does.
+func (_this *Limiter) take(tokens int) time.Duration {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(_this.last).Minutes()
+	_this.last = now
+	_this.requests = refill(_this.requests, _this.limit.RequestsPerMinute, elapsed)
+	_this.tokens = refill(_this.tokens, _this.limit.TokensPerMinute, elapsed)
+
+	need := float64(tokens)
+	if need > float64(_this.limit.TokensPerMinute) {
+		need = float64(_this.limit.TokensPerMinute)
+	}
+	var delay time.Duration
+	if _this.limit.RequestsPerMinute > 0 && _this.requests < 1 {
+		delay = maxDuration(delay, minutes((1-_this.requests)/float64(_this.limit.RequestsPerMinute)))
+	}
+	if _this.limit.TokensPerMinute > 0 && _this.tokens < need {
+		delay = maxDuration(delay, minutes((need-_this.tokens)/float64(_this.limit.TokensPerMinute)))
+	}
+	if delay > 0 {
+		return delay
+	}
+
+	if _this.limit.RequestsPerMinute > 0 {
+		_this.requests--
+	}
+	if _this.limit.TokensPerMinute > 0 {
+		_this.tokens -= need
+	}
+	return 0
+}
+
+func refill(level float64, perMinute int, elapsed float64) float64 {
+	if perMinute <= 0 {
+		return level
+	}
+	level += elapsed * float64(perMinute)
+	if level > float64(perMinute) {
+		level = float64(perMinute)
+	}
+	return level
+}
+
+func minutes(m float64) time.Duration {
+	// Rounded up, so that the bucket holds enough when the timer fires.
+	return time.Duration(m*float64(time.Minute)) + time.Millisecond
+}
+
+func maxDuration(a, b time.Duration) time.Duration {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Limiters holds the limiter of each provider.
+type Limiters map[string]*Limiter
+
+// NewLimiters returns a limiter for each provider of limits.
+func NewLimiters(limits map[string]Limit) Limiters {
+	limiters := make(Limiters, len(limits))
+	for provider, limit := range limits {
+		limiters[provider] = NewLimiter(limit)
+	}
+	return limiters
+}
+
+// Wait waits for the limiter of provider, if it has one.
+func (_this Limiters) Wait(ctx context.Context, provider string, tokens int) error {
+	limiter, ok := _this[provider]
+	if !ok {
+		return nil
+	}
+	return limiter.Wait(ctx, tokens)
+}
+
+type waitHookKey struct{}
+
+// WithWaitHook returns a context whose calls report through hook when they start (true) and stop
+// (false) waiting for capacity, such as to show a job as queued meanwhile.
+func WithWaitHook(ctx context.Context, hook func(waiting bool)) context.Context {
+	return context.WithValue(ctx, waitHookKey{}, hook)
+}
+
+func waitHookFrom(ctx context.Context) func(waiting bool) {
+	hook, _ := ctx.Value(waitHookKey{}).(func(waiting bool))
+	return hook
+}
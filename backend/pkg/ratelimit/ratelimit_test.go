@@ -0,0 +1,46 @@
+package ratelimit_test
+
+import (
+	"CVSeeker/pkg/ratelimit"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLimiter_WaitsForRequests(t *testing.T) {
+	// 6,000 requests per minute: a burst of 6,000, then one every 10ms.
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{RequestsPerMinute: 6000})
+	for i := 0; i < 6000; i++ {
+		assert.NoError(t, limiter.Wait(context.Background(), 0))
+	}
+
+	var hooks []bool
+	ctx := ratelimit.WithWaitHook(context.Background(), func(waiting bool) {
+		hooks = append(hooks, waiting)
+	})
+	start := time.Now()
+	assert.NoError(t, limiter.Wait(ctx, 0))
+	assert.GreaterOrEqual(t, time.Since(start), 5*time.Millisecond)
+	assert.Equal(t, []bool{true, false}, hooks)
+}
+
+func TestLimiter_WaitsForTokens(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{TokensPerMinute: 60000})
+	assert.NoError(t, limiter.Wait(context.Background(), 60000))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// 60,000 tokens per minute refill 1,000 per second, so 5,000 tokens take five seconds.
+	assert.ErrorIs(t, limiter.Wait(ctx, 5000), context.DeadlineExceeded)
+	// A request larger than the bucket waits for a full bucket instead of forever.
+	assert.NoError(t, ratelimit.NewLimiter(ratelimit.Limit{TokensPerMinute: 100}).Wait(context.Background(), 1000))
+}
+
+func TestLimiters_UnknownProviderIsUnlimited(t *testing.T) {
+	limiters := ratelimit.NewLimiters(map[string]ratelimit.Limit{"openai": {RequestsPerMinute: 1}})
+	assert.NoError(t, limiters.Wait(context.Background(), "openai", 0))
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, limiters.Wait(context.Background(), "huggingface", 1000))
+	}
+}
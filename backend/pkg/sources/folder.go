@@ -0,0 +1,171 @@
+package sources
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcessedDir is the subdirectory of a watched folder its files are moved to once ingested.
+const ProcessedDir = "processed"
+
+// Folder delivers the files dropped into a directory, one delivery per file. A file is delivered
+// once it has kept its size and modification time between two scans, so that files still being
+// copied are left until they are complete, and is moved to the processed subdirectory when
+// acknowledged. A file that cannot be moved is not delivered again while it is unchanged, until
+// the process restarts. Mail messages (.eml) and mailboxes (.mbox) deliver the attachments accept keeps
+// instead of themselves; other files are delivered whatever their type.
+type Folder struct {
+	dir    string
+	accept func(name string) bool
+
+	mu sync.Mutex
+	// seen holds the files found by the last scan, and acked the files ingested that could not be
+	// moved, as they were when delivered.
+	seen  map[string]fileState
+	acked map[string]fileState
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewFolder returns a source watching dir.
+func NewFolder(dir string, accept func(name string) bool) *Folder {
+	return &Folder{dir: dir, accept: accept, seen: map[string]fileState{}, acked: map[string]fileState{}}
+}
+
+func (_this *Folder) Name() string {
+	return "folder " + _this.dir
+}
+
+func (_this *Folder) Fetch(ctx context.Context) ([]Delivery, error) {
+	entries, err := os.ReadDir(_this.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	seen := make(map[string]fileState, len(entries))
+	var deliveries []Delivery
+	for _, entry := range entries {
+		name := entry.Name()
+		// Hidden files include the temporary files of editors and of some copy tools.
+		if !entry.Type().IsRegular() || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~$") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		state := fileState{size: info.Size(), modTime: info.ModTime()}
+		seen[name] = state
+		if acked, ok := _this.acked[name]; ok && acked == state {
+			continue
+		}
+		if previous, ok := _this.seen[name]; ok && previous == state {
+			deliveries = append(deliveries, _this.read(name))
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	// A file moved away or changed since is delivered again as a new file.
+	for name, acked := range _this.acked {
+		if seen[name] != acked {
+			delete(_this.acked, name)
+		}
+	}
+	_this.seen = seen
+	return deliveries, ctx.Err()
+}
+
+// read returns the delivery of the file name. A file that cannot be read is delivered with the error.
+func (_this *Folder) read(name string) Delivery {
+	path := filepath.Join(_this.dir, name)
+	delivery := Delivery{ID: name}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		delivery.Files = []File{{Name: name, Kind: KindFolder, Origin: path, Err: err}}
+		return delivery
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".eml":
+		message, err := ParseMessage(bytes.NewReader(data))
+		if err != nil {
+			delivery.Files = []File{{Name: name, Kind: KindMail, Origin: path, Err: fmt.Errorf("malformed message: %w", err)}}
+			return delivery
+		}
+		delivery.Files = _this.attachments(message, path)
+	case ".mbox":
+		n := 0
+		_ = ReadMbox(bytes.NewReader(data), func(message *Message, err error) error {
+			n++
+			if err != nil {
+				label := fmt.Sprintf("%s message %d", name, n)
+				delivery.Files = append(delivery.Files, File{Name: label, Kind: KindMail, Origin: path, Err: fmt.Errorf("malformed message: %w", err)})
+				return nil
+			}
+			delivery.Files = append(delivery.Files, _this.attachments(message, path)...)
+			return nil
+		})
+	default:
+		delivery.Files = []File{{Name: name, Data: data, Kind: KindFolder, Origin: path}}
+	}
+	return delivery
+}
+
+// attachments returns the files attached to a message that accept keeps.
+func (_this *Folder) attachments(message *Message, path string) []File {
+	origin := fmt.Sprintf("mail from %s, %q, in %s", message.From, message.Subject, path)
+	var files []File
+	for _, attachment := range message.Attachments {
+		if _this.accept != nil && !_this.accept(attachment.Name) {
+			continue
+		}
+		files = append(files, File{Name: attachment.Name, Data: attachment.Data, Kind: KindMail, Origin: origin})
+	}
+	return files
+}
+
+// Ack moves an ingested file to the processed subdirectory, under a name it does not share with
+// an earlier file. A file that cannot be moved, for example on a read-only mount, is left in place
+// and remembered, so that it is not ingested again on every scan.
+func (_this *Folder) Ack(ctx context.Context, delivery Delivery, err error) error {
+	if err != nil {
+		return nil
+	}
+	moveErr := _this.move(delivery.ID)
+
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	if moveErr != nil {
+		if state, ok := _this.seen[delivery.ID]; ok {
+			_this.acked[delivery.ID] = state
+		}
+		return fmt.Errorf("%w; the file is not delivered again until it changes", moveErr)
+	}
+	delete(_this.seen, delivery.ID)
+	return nil
+}
+
+// move moves the file name to the processed subdirectory.
+func (_this *Folder) move(name string) error {
+	processed := filepath.Join(_this.dir, ProcessedDir)
+	if err := os.MkdirAll(processed, 0o755); err != nil {
+		return err
+	}
+	target := filepath.Join(processed, name)
+	if _, err := os.Stat(target); err == nil {
+		target = filepath.Join(processed, fmt.Sprintf("%s-%s", time.Now().Format("20060102-150405"), name))
+	}
+	return os.Rename(filepath.Join(_this.dir, name), target)
+}
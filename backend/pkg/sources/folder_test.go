@@ -0,0 +1,82 @@
+package sources_test
+
+import (
+	"CVSeeker/pkg/sources"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFolder(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jane.pdf"), []byte("%PDF-1.7"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mail.eml"), []byte(message), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".jane.pdf.part"), []byte("%PDF"), 0o644))
+	folder := sources.NewFolder(dir, func(name string) bool { return strings.HasSuffix(name, ".pdf") })
+
+	deliveries, err := folder.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries, "files are delivered once they are unchanged between two scans")
+
+	deliveries, err = folder.Fetch(ctx)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	assert.Equal(t, "jane.pdf", deliveries[0].ID)
+	assert.Equal(t, []sources.File{{Name: "jane.pdf", Data: []byte("%PDF-1.7"), Kind: sources.KindFolder, Origin: filepath.Join(dir, "jane.pdf")}}, deliveries[0].Files)
+	require.Len(t, deliveries[1].Files, 1, "only the attachments accepted are delivered")
+	assert.Equal(t, "Jane Doe.pdf", deliveries[1].Files[0].Name)
+	assert.Equal(t, sources.KindMail, deliveries[1].Files[0].Kind)
+	assert.Contains(t, deliveries[1].Files[0].Origin, "jane@example.com")
+
+	require.NoError(t, folder.Ack(ctx, deliveries[0], nil))
+	require.NoError(t, folder.Ack(ctx, deliveries[1], assert.AnError))
+	assert.FileExists(t, filepath.Join(dir, sources.ProcessedDir, "jane.pdf"))
+
+	deliveries, err = folder.Fetch(ctx)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1, "a delivery acknowledged with an error is delivered again")
+	assert.Equal(t, "mail.eml", deliveries[0].ID)
+}
+
+func TestFolder_FilesThatCannotBeMovedAreNotDeliveredAgain(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jane.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.7"), 0o644))
+	// A file in place of the processed subdirectory makes every move fail.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, sources.ProcessedDir), nil, 0o644))
+	folder := sources.NewFolder(dir, nil)
+
+	_, err := folder.Fetch(ctx)
+	require.NoError(t, err)
+	// The file in place of the subdirectory is delivered as well, and cannot be moved either.
+	deliveries, err := folder.Fetch(ctx)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+	for _, delivery := range deliveries {
+		assert.Error(t, folder.Ack(ctx, delivery, nil))
+	}
+	assert.FileExists(t, path)
+
+	for i := 0; i < 2; i++ {
+		deliveries, err = folder.Fetch(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, deliveries)
+	}
+
+	// A new file under the same name is delivered once it is complete.
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.7 updated"), 0o644))
+	deliveries, err = folder.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+	deliveries, err = folder.Fetch(ctx)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, "jane.pdf", deliveries[0].ID)
+}
@@ -0,0 +1,177 @@
+package sources
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// maxPartDepth bounds the nesting of multipart bodies, which real messages keep shallow.
+const maxPartDepth = 8
+
+// Message is a mail message with the files attached to it.
+type Message struct {
+	ID          string
+	From        string
+	Subject     string
+	Date        time.Time
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a message.
+type Attachment struct {
+	Name string
+	Data []byte
+}
+
+var wordDecoder = new(mime.WordDecoder)
+
+// ParseMessage reads a message in the Internet Message Format, as saved in .eml files, and the files
+// attached to it: the parts with a file name, at any depth of multipart. Attached messages are not
+// opened.
+func ParseMessage(r io.Reader) (*Message, error) {
+	msg, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	message := &Message{
+		ID:      strings.Trim(msg.Header.Get("Message-Id"), "<> "),
+		Subject: decodeHeader(msg.Header.Get("Subject")),
+	}
+	if from, err := msg.Header.AddressList("From"); err == nil && len(from) > 0 {
+		message.From = from[0].Address
+	} else {
+		message.From = decodeHeader(msg.Header.Get("From"))
+	}
+	message.Date, _ = msg.Header.Date()
+
+	if err := message.readPart(textproto.MIMEHeader(msg.Header), msg.Body, 0); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+func (_this *Message) readPart(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxPartDepth || params["boundary"] == "" {
+			return nil
+		}
+		parts := multipart.NewReader(body, params["boundary"])
+		for {
+			// The raw part keeps its transfer encoding, which is decoded here for every encoding.
+			part, err := parts.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := _this.readPart(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	name := attachmentName(header, params)
+	if name == "" || mediaType == "message/rfc822" {
+		return nil
+	}
+	data, err := io.ReadAll(decodeBody(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return fmt.Errorf("reading attachment %s: %w", name, err)
+	}
+	_this.Attachments = append(_this.Attachments, Attachment{Name: name, Data: data})
+	return nil
+}
+
+// attachmentName returns the file name of a part, from its disposition or else its type, without
+// the directories some clients send.
+func attachmentName(header textproto.MIMEHeader, typeParams map[string]string) string {
+	name := ""
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		name = params["filename"]
+	}
+	if name == "" {
+		name = typeParams["name"]
+	}
+	name = decodeHeader(name)
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSpace(name)
+}
+
+func decodeHeader(value string) string {
+	if decoded, err := wordDecoder.DecodeHeader(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+func decodeBody(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		// The decoder skips the line breaks of the encoded body.
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// ReadMbox calls fn with each message of a mailbox in the mbox format, or with the error it could
+// not be read with, in order. Lines of the messages escaped as ">From " are restored. ReadMbox stops
+// at the first error fn returns and returns it.
+func ReadMbox(r io.Reader, fn func(message *Message, err error) error) error {
+	reader := bufio.NewReader(r)
+	var buf bytes.Buffer
+	started, blank := false, true
+
+	flush := func() error {
+		if !started {
+			return nil
+		}
+		message, err := ParseMessage(bytes.NewReader(buf.Bytes()))
+		buf.Reset()
+		return fn(message, err)
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			switch {
+			// A message starts with a "From " line, at the start of the file or after a blank line.
+			case blank && bytes.HasPrefix(line, []byte("From ")):
+				if err := flush(); err != nil {
+					return err
+				}
+				started = true
+			case started:
+				if unescaped := bytes.TrimLeft(line, ">"); len(unescaped) < len(line) && bytes.HasPrefix(unescaped, []byte("From ")) {
+					line = line[1:]
+				}
+				buf.Write(line)
+			}
+			blank = len(bytes.TrimRight(line, "\r\n")) == 0
+		}
+		if err == io.EOF {
+			return flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
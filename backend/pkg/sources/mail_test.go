@@ -0,0 +1,67 @@
+package sources_test
+
+import (
+	"CVSeeker/pkg/sources"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const message = "From: Jane Doe <jane@example.com>\r\n" +
+	"Subject: =?UTF-8?Q?CV_=E2=80=93_Go_developer?=\r\n" +
+	"Message-ID: <abc@example.com>\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain\r\n" +
+	"\r\n" +
+	"Please find my CV attached.\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf; name=\"ignored.pdf\"\r\n" +
+	"Content-Disposition: attachment; filename=\"C:\\\\Users\\\\jane\\\\Jane Doe.pdf\"\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"JVBERi0x\r\nLjc=\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Disposition: attachment; filename*=UTF-8''Nguy%E1%BB%85n.txt\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Nguy=E1=BB=85n V=C4=83n An\r\n" +
+	"--outer--\r\n"
+
+func TestParseMessage(t *testing.T) {
+	msg, err := sources.ParseMessage(strings.NewReader(message))
+	require.NoError(t, err)
+
+	assert.Equal(t, "abc@example.com", msg.ID)
+	assert.Equal(t, "jane@example.com", msg.From)
+	assert.Equal(t, "CV – Go developer", msg.Subject)
+	require.Len(t, msg.Attachments, 2)
+	assert.Equal(t, "Jane Doe.pdf", msg.Attachments[0].Name)
+	assert.Equal(t, "%PDF-1.7", string(msg.Attachments[0].Data))
+	assert.Equal(t, "Nguyễn.txt", msg.Attachments[1].Name)
+	assert.Equal(t, "Nguyễn Văn An", string(msg.Attachments[1].Data))
+}
+
+func TestReadMbox(t *testing.T) {
+	mbox := "From jane@example.com Mon Jan  1 00:00:00 2024\n" +
+		"From: jane@example.com\nSubject: first\n\nHello\n>From the start\n\n" +
+		"From john@example.com Mon Jan  1 00:00:00 2024\n" +
+		"From: john@example.com\nSubject: second\n\nBye\n"
+
+	var subjects []string
+	err := sources.ReadMbox(strings.NewReader(mbox), func(msg *sources.Message, err error) error {
+		require.NoError(t, err)
+		subjects = append(subjects, msg.Subject)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, subjects)
+}
@@ -0,0 +1,47 @@
+// Package sources delivers resume files from places other than the upload API: a watched folder,
+// and the attachments of mail messages saved as .eml or mbox files. A connector to a mail server
+// implements Source over its messages, reading their attachments with ParseMessage.
+package sources
+
+import (
+	"context"
+)
+
+// Kinds of source, recorded with the resumes they deliver.
+const (
+	KindFolder = "folder"
+	KindMail   = "mail"
+)
+
+// File is a file delivered by a source.
+type File struct {
+	Name string
+	Data []byte
+	// Kind is the kind of source the file came through, which for a mail message dropped in a
+	// folder is mail.
+	Kind string
+	// Origin describes where the file came from: its path, or the message it was attached to.
+	Origin string
+	// Err is why the file could not be read, such as a malformed message; it has no data.
+	Err error
+}
+
+// Delivery is what a source delivers and is told the outcome of at once: a dropped file, or a
+// mailbox file with the attachments of all its messages.
+type Delivery struct {
+	// ID identifies the delivery to its source, such as the name of the file.
+	ID    string
+	Files []File
+}
+
+// Source is a place resumes arrive at.
+type Source interface {
+	// Name identifies the source in logs and imports.
+	Name() string
+	// Fetch returns the deliveries ready to be ingested.
+	Fetch(ctx context.Context) ([]Delivery, error)
+	// Ack is called once the files of a delivery have been queued for ingestion, or with the error
+	// that stopped them. A delivery that is not acknowledged, or acknowledged with an error, is
+	// delivered again by a later Fetch.
+	Ack(ctx context.Context, delivery Delivery, err error) error
+}
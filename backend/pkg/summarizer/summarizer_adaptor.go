@@ -2,29 +2,51 @@ package summarizer
 
 import (
 	"CVSeeker/pkg/cfg"
+	"CVSeeker/pkg/httpclient"
+	"CVSeeker/pkg/metrics"
+	"CVSeeker/pkg/tracing"
+	"CVSeeker/pkg/usage"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
 	"net/http"
+	"time"
 )
 
+// adaptorName identifies this adaptor in metrics.
+const adaptorName = "summarizer"
+
 type ISummarizerAdaptorClient interface {
-	AskGPT(prompt, model string) (string, error)
+	AskGPT(ctx context.Context, prompt, model string) (string, error)
+	Chat(ctx context.Context, request ChatRequest) (string, error)
 }
 
+// defaultBaseURL is used when GPT_BASE_URL is not set.
+const defaultBaseURL = "https://api.openai.com"
+
+// httpServiceName is the prefix of the client settings; the summarizer shares the GPT_HTTP_* keys.
+const httpServiceName = "GPT"
+
 type SummarizerAdaptorClient struct {
-	Client  *http.Client
+	Client  *httpclient.Client
 	BaseURL string
 	ApiKey  string
+	Usage   usage.Recorder
 }
 
 // NewSummarizerAdaptorClient initializes a new client for interacting with GPT models.
-func NewSummarizerAdaptorClient(cfgReader *viper.Viper) (ISummarizerAdaptorClient, error) {
+func NewSummarizerAdaptorClient(cfgReader *viper.Viper, recorder usage.Recorder) (ISummarizerAdaptorClient, error) {
+	defaults := httpclient.DefaultConfig()
+	defaults.Timeout = 60 * time.Second
+
 	return &SummarizerAdaptorClient{
-		Client:  &http.Client{},
-		BaseURL: "https://api.openai.com",
+		Client:  httpclient.New(adaptorName, httpclient.LoadConfig(cfgReader, httpServiceName, defaults)),
+		BaseURL: cfg.BaseURL(cfgReader, cfg.GptBaseUrl, defaultBaseURL),
 		ApiKey:  cfgReader.GetString(cfg.GptApiKey),
+		Usage:   recorder,
 	}, nil
 }
 
@@ -35,36 +57,43 @@ func (g *SummarizerAdaptorClient) addCommonHeaders(req *http.Request) {
 }
 
 // AskGPT sends a prompt to the GPT-3.5 API and returns the generated response.
-func (g *SummarizerAdaptorClient) AskGPT(prompt, model string) (string, error) {
-	endpoint := fmt.Sprintf("%s/v1/chat/completions", g.BaseURL)
-	body := map[string]interface{}{
-		"model": model,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-		"temperature": 0.0, // Adjust the temperature if needed
-	}
+func (g *SummarizerAdaptorClient) AskGPT(ctx context.Context, prompt, model string) (string, error) {
+	return g.Chat(ctx, ChatRequest{
+		Model:       model,
+		Messages:    []ChatMessage{{Role: "user", Content: prompt}},
+		Temperature: 0.0, // Adjust the temperature if needed
+	})
+}
+
+// Chat sends a conversation to the chat completions API and returns the content of the first choice.
+func (g *SummarizerAdaptorClient) Chat(ctx context.Context, request ChatRequest) (_ string, err error) {
+	model := request.Model
+	defer metrics.ObserveAdaptorCall(adaptorName, "ask_gpt", time.Now(), &err)
+	ctx, span := tracing.Start(ctx, "summarizer.Chat", attribute.String("llm.model", model))
+	defer tracing.End(span, &err)
 
-	bodyBytes, err := json.Marshal(body)
+	endpoint := fmt.Sprintf("%s/v1/chat/completions", g.BaseURL)
+	bodyBytes, err := json.Marshal(request)
 	if err != nil {
 		return "", fmt.Errorf("could not encode request body: %v", err)
 	}
 
-	req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(bodyBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(bodyBytes))
 	if err != nil {
 		return "", fmt.Errorf("could not create request: %v", err)
 	}
 
 	g.addCommonHeaders(req)
 
-	resp, err := g.Client.Do(req)
+	// A completion creates nothing on the service, so it can be retried.
+	resp, err := g.Client.Do(httpclient.Idempotent(req))
 	if err != nil {
-		return "", fmt.Errorf("could not send request to GPT API: %v", err)
+		return "", fmt.Errorf("could not send request to GPT API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := json.Marshal(body) // assuming error handling omitted for brevity
+		bodyBytes, _ := json.Marshal(request) // assuming error handling omitted for brevity
 		return "", fmt.Errorf("GPT API returned non-OK status code: %d, message: %s", resp.StatusCode, string(bodyBytes))
 	}
 
@@ -75,6 +104,11 @@ func (g *SummarizerAdaptorClient) AskGPT(prompt, model string) (string, error) {
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Model string `json:"model"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 
 	err = json.NewDecoder(resp.Body).Decode(&response)
@@ -82,6 +116,16 @@ func (g *SummarizerAdaptorClient) AskGPT(prompt, model string) (string, error) {
 		return "", fmt.Errorf("could not decode response body: %v", err)
 	}
 
+	if response.Model == "" {
+		response.Model = model
+	}
+	g.Usage.Record(ctx, usage.Record{
+		Provider:         usage.ProviderOpenAI,
+		Model:            response.Model,
+		PromptTokens:     response.Usage.PromptTokens,
+		CompletionTokens: response.Usage.CompletionTokens,
+	})
+
 	if len(response.Choices) > 0 {
 		return response.Choices[0].Message.Content, nil
 	}
@@ -0,0 +1,129 @@
+package tracing
+
+import (
+	"CVSeeker/pkg/cfg"
+	"context"
+	"fmt"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName         = "CVSeeker"
+	defaultServiceName = "cvseeker-server"
+)
+
+// Provider owns the process-wide tracer provider. When tracing is disabled it is a no-op.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+// NewProvider configures OpenTelemetry from configuration and installs it globally.
+// Spans are exported over OTLP/HTTP when OTEL_ENABLED is true; otherwise the global
+// no-op provider is kept and only incoming trace context is propagated.
+func NewProvider(cfgReader *viper.Viper) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfgReader.GetBool(cfg.OtelEnabled) {
+		return &Provider{}, nil
+	}
+
+	serviceName := cfgReader.GetString(cfg.OtelServiceName)
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+
+	// The exporter also honours the standard OTEL_EXPORTER_OTLP_* environment variables;
+	// OTEL_COLLECTOR_ENDPOINT (host:port) takes precedence when set.
+	opts := []otlptracehttp.Option{}
+	if endpoint := cfgReader.GetString(cfg.OtelExporterEndpoint); endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+	}
+	if cfgReader.GetBool(cfg.OtelExporterInsecure) {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	sampleRatio := 1.0
+	if cfgReader.IsSet(cfg.OtelSampleRatio) {
+		sampleRatio = cfgReader.GetFloat64(cfg.OtelSampleRatio)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return &Provider{tp: tp}, nil
+}
+
+// Shutdown flushes pending spans and stops the exporter.
+func (_this *Provider) Shutdown(ctx context.Context) error {
+	if _this == nil || _this.tp == nil {
+		return nil
+	}
+	return _this.tp.Shutdown(ctx)
+}
+
+// Tracer returns the application tracer from the global provider.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start starts a span named spanName as a child of any span carried by ctx.
+func Start(ctx context.Context, spanName string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, spanName, trace.WithAttributes(attrs...))
+}
+
+// End records the error pointed to by err (if any) on span and ends it.
+// It is meant to be deferred with a pointer to the caller's named error result:
+//
+//	ctx, span := tracing.Start(ctx, "gpt.CreateThread")
+//	defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// Detach returns a context that keeps the values of ctx (including the active span)
+// but is never cancelled, for work that outlives the request that started it.
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// TraceID returns the trace ID of the span carried by ctx, or an empty string.
+func TraceID(ctx context.Context) string {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.HasTraceID() {
+		return ""
+	}
+	return spanCtx.TraceID().String()
+}
@@ -0,0 +1,68 @@
+package usage
+
+import (
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+// Price is the cost in USD per 1,000 tokens.
+type Price struct {
+	Prompt     float64
+	Completion float64
+}
+
+// PriceTable maps model names to prices.
+type PriceTable map[string]Price
+
+// DefaultPrices returns list prices of the OpenAI models the application is usually configured with.
+// Embeddings from the Hugging Face inference API are free and therefore not listed.
+func DefaultPrices() PriceTable {
+	return PriceTable{
+		"gpt-3.5-turbo": {Prompt: 0.0005, Completion: 0.0015},
+		"gpt-4":         {Prompt: 0.03, Completion: 0.06},
+		"gpt-4-turbo":   {Prompt: 0.01, Completion: 0.03},
+		"gpt-4o":        {Prompt: 0.005, Completion: 0.015},
+		"gpt-4o-mini":   {Prompt: 0.00015, Completion: 0.0006},
+	}
+}
+
+// NewPriceTable returns the default prices overridden by overrides, which is the LLM_PRICES
+// configuration table: model name to {prompt = ..., completion = ...}.
+func NewPriceTable(overrides map[string]interface{}) PriceTable {
+	prices := DefaultPrices()
+	for model, raw := range overrides {
+		values := cast.ToStringMap(raw)
+		prices[strings.ToLower(model)] = Price{
+			Prompt:     cast.ToFloat64(values["prompt"]),
+			Completion: cast.ToFloat64(values["completion"]),
+		}
+	}
+	return prices
+}
+
+// Cost returns the estimated cost of a call. Versioned model names such as gpt-3.5-turbo-0125 use the
+// price of the longest matching prefix; unknown models cost nothing.
+func (_this PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := _this.lookup(strings.ToLower(model))
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1000
+}
+
+func (_this PriceTable) lookup(model string) (Price, bool) {
+	if price, ok := _this[model]; ok {
+		return price, true
+	}
+	var (
+		best    Price
+		bestLen int
+	)
+	for name, price := range _this {
+		if strings.HasPrefix(model, name+"-") && len(name) > bestLen {
+			best, bestLen = price, len(name)
+		}
+	}
+	return best, bestLen > 0
+}
@@ -0,0 +1,26 @@
+package usage_test
+
+import (
+	"CVSeeker/pkg/usage"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriceTable_Cost(t *testing.T) {
+	prices := usage.NewPriceTable(map[string]interface{}{
+		"my-model": map[string]interface{}{"prompt": 1.0, "completion": 2.0},
+	})
+
+	assert.InDelta(t, 0.0035, prices.Cost("gpt-3.5-turbo", 1000, 2000), 1e-9)
+	// Dated versions fall back to the longest matching prefix.
+	assert.InDelta(t, 0.00075, prices.Cost("gpt-4o-mini-2024-07-18", 1000, 1000), 1e-9)
+	assert.InDelta(t, 0.005, prices.Cost("my-model", 1, 2), 1e-9)
+	assert.Zero(t, prices.Cost("unknown-model", 1000, 1000))
+}
+
+func TestEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, usage.EstimateTokens(""))
+	assert.Equal(t, 1, usage.EstimateTokens("abc"))
+	assert.Equal(t, 3, usage.EstimateTokens("hello world!"))
+}
@@ -0,0 +1,108 @@
+package usage
+
+import (
+	"context"
+	"time"
+)
+
+// Providers of metered calls.
+const (
+	ProviderOpenAI      = "openai"
+	ProviderHuggingFace = "huggingface"
+)
+
+// Operations that consume tokens. They are attached to the context by services so that adaptors
+// do not need to know why they are called.
+const (
+	OperationUnknown         = "unknown"
+	OperationResumeSummarize = "resume.summarize"
+	OperationResumeEmbedding = "resume.embedding"
+	OperationSearchEmbedding = "search.embedding"
+	OperationChatRun         = "chat.run"
+)
+
+// Record is the token usage of one LLM or embedding call.
+type Record struct {
+	Provider         string
+	Model            string
+	Operation        string
+	UserID           string
+	TenantID         string
+	PromptTokens     int
+	CompletionTokens int
+	// Estimated is true when the provider does not report usage and tokens were estimated from the input.
+	Estimated bool
+	// Cost is the estimated cost in USD.
+	Cost      float64
+	TraceID   string
+	CreatedAt time.Time
+}
+
+// TotalTokens returns prompt plus completion tokens.
+func (_this Record) TotalTokens() int {
+	return _this.PromptTokens + _this.CompletionTokens
+}
+
+// Recorder stores usage records. Adaptors only fill provider, model and token counts; the recorder
+// completes the record with the tags carried by ctx and the estimated cost.
+// Implementations must not fail the call being recorded, so Record does not return an error.
+type Recorder interface {
+	Record(ctx context.Context, rec Record)
+}
+
+// NopRecorder discards every record.
+type NopRecorder struct{}
+
+func (NopRecorder) Record(context.Context, Record) {}
+
+type contextKey int
+
+const (
+	operationKey contextKey = iota
+	userKey
+	tenantKey
+)
+
+// WithOperation tags ctx with the operation on whose behalf calls are made.
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey, operation)
+}
+
+// WithUser tags ctx with the user who triggered the calls.
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey, userID)
+}
+
+// WithTenant tags ctx with the tenant that is billed for the calls.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenantID)
+}
+
+// OperationFrom returns the operation carried by ctx, or OperationUnknown.
+func OperationFrom(ctx context.Context) string {
+	if operation, ok := ctx.Value(operationKey).(string); ok && operation != "" {
+		return operation
+	}
+	return OperationUnknown
+}
+
+// UserFrom returns the user carried by ctx, or an empty string.
+func UserFrom(ctx context.Context) string {
+	userID, _ := ctx.Value(userKey).(string)
+	return userID
+}
+
+// TenantFrom returns the tenant carried by ctx, or an empty string.
+func TenantFrom(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantKey).(string)
+	return tenantID
+}
+
+// EstimateTokens approximates the token count of text for providers that do not report usage,
+// using the common rule of thumb of four characters per token.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
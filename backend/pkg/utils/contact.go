@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Bounds of the number of digits in a phone number: local numbers without an area code are too
+// short to reach anyone, and E.164 numbers have at most 15 digits.
+const (
+	minPhoneDigits = 8
+	maxPhoneDigits = 15
+)
+
+var (
+	phoneCharsPattern = regexp.MustCompile(`^\+?[\d\s().\-/]+$`)
+	// yearRangePattern matches periods such as 2019-2023, which have the digits of a phone number.
+	yearRangePattern = regexp.MustCompile(`^(19|20)\d\d\s*[-/]\s*(19|20)\d\d$`)
+	emailDomainLabel = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+)
+
+// NormalizeEmail returns an e-mail address lower-cased, without a mailto: prefix, and whether it
+// is a plain address (no display name) on a domain with a top-level part.
+func NormalizeEmail(email string) (string, bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	email = strings.TrimPrefix(email, "mailto:")
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Name != "" || address.Address != email {
+		return "", false
+	}
+	at := strings.LastIndexByte(email, '@')
+	labels := strings.Split(email[at+1:], ".")
+	if len(labels) < 2 || len(labels[len(labels)-1]) < 2 {
+		return "", false
+	}
+	for _, label := range labels {
+		if !emailDomainLabel.MatchString(label) {
+			return "", false
+		}
+	}
+	return email, true
+}
+
+// NormalizePhone returns a phone number as its digits, keeping a leading + of international
+// numbers, and whether it is one: only digits and the usual separators, with 8 to 15 digits, and
+// not a range of years.
+func NormalizePhone(phone string) (string, bool) {
+	phone = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(phone), "tel:"))
+	if !phoneCharsPattern.MatchString(phone) || yearRangePattern.MatchString(phone) {
+		return "", false
+	}
+	var digits strings.Builder
+	if strings.HasPrefix(phone, "+") {
+		digits.WriteByte('+')
+	}
+	n := 0
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+			n++
+		}
+	}
+	if n < minPhoneDigits || n > maxPhoneDigits {
+		return "", false
+	}
+	return digits.String(), true
+}
+
+// NormalizeURL returns a web address with its scheme, https when it has none, and whether it is
+// an http(s) URL on a host with a top-level domain.
+func NormalizeURL(rawURL string) (string, bool) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" || strings.ContainsAny(rawURL, " \t\n") {
+		return "", false
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.User != nil {
+		return "", false
+	}
+	host := parsed.Hostname()
+	if dot := strings.LastIndexByte(host, '.'); dot <= 0 || dot == len(host)-1 {
+		return "", false
+	}
+	parsed.Host = strings.ToLower(parsed.Host)
+	return strings.TrimSuffix(parsed.String(), "/"), true
+}
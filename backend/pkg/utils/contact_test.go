@@ -0,0 +1,60 @@
+package utils_test
+
+import (
+	"CVSeeker/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	valid := map[string]string{
+		"Jane.Doe@Example.com":             "jane.doe@example.com",
+		" mailto:jane+cv@mail.example.vn ": "jane+cv@mail.example.vn",
+	}
+	for input, want := range valid {
+		got, ok := utils.NormalizeEmail(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "jane", "jane@localhost", "jane@example.c", "Jane <jane@example.com>", "jane@exa_mple.com", "jane@-example.com"} {
+		_, ok := utils.NormalizeEmail(input)
+		assert.False(t, ok, input)
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	valid := map[string]string{
+		"+84 912 345 678":  "+84912345678",
+		"(028) 3823-4567":  "02838234567",
+		"tel:0912.345.678": "0912345678",
+	}
+	for input, want := range valid {
+		got, ok := utils.NormalizePhone(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "12345", "+1 234 567 890 123 456", "0912 345 678 ext 2", "2019-2023"} {
+		_, ok := utils.NormalizePhone(input)
+		assert.False(t, ok, input)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	valid := map[string]string{
+		"linkedin.com/in/jane-doe/":    "https://linkedin.com/in/jane-doe",
+		"http://GitHub.com/janedoe":    "http://github.com/janedoe",
+		"https://jane.dev/portfolio?x": "https://jane.dev/portfolio?x",
+	}
+	for input, want := range valid {
+		got, ok := utils.NormalizeURL(input)
+		assert.True(t, ok, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "jane doe", "ftp://example.com", "https://localhost", "https://user:pw@example.com", "example."} {
+		_, ok := utils.NormalizeURL(input)
+		assert.False(t, ok, input)
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// FindTextSpan locates quote in text ignoring case and differences in whitespace, and returns the
+// byte offsets [start, end) of the match in text.
+func FindTextSpan(text, quote string) (start, end int, ok bool) {
+	normalizedQuote, _ := normalizeForSearch(quote)
+	if normalizedQuote == "" {
+		return 0, 0, false
+	}
+	normalizedText, offsets := normalizeForSearch(text)
+
+	i := strings.Index(normalizedText, normalizedQuote)
+	if i < 0 {
+		return 0, 0, false
+	}
+	last := i + len(normalizedQuote) - 1
+	_, size := utf8.DecodeRuneInString(text[offsets[last]:])
+	return offsets[i], offsets[last] + size, true
+}
+
+// normalizeForSearch lower-cases s and collapses whitespace runs into single spaces, trimming both
+// ends. offsets maps each byte of the result to the offset of the rune it came from in s.
+func normalizeForSearch(s string) (string, []int) {
+	var sb strings.Builder
+	offsets := make([]int, 0, len(s))
+	pendingSpace := false
+	for i, r := range s {
+		if unicode.IsSpace(r) {
+			pendingSpace = sb.Len() > 0
+			continue
+		}
+		if pendingSpace {
+			sb.WriteByte(' ')
+			offsets = append(offsets, i)
+			pendingSpace = false
+		}
+		lower := string(unicode.ToLower(r))
+		// Keep the mapping aligned when lower-casing changes the encoded length.
+		if utf8.RuneLen(unicode.ToLower(r)) != utf8.RuneLen(r) {
+			lower = string(r)
+		}
+		sb.WriteString(lower)
+		for j := 0; j < len(lower); j++ {
+			offsets = append(offsets, i)
+		}
+	}
+	return sb.String(), offsets
+}
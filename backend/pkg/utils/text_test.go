@@ -0,0 +1,29 @@
+package utils_test
+
+import (
+	"CVSeeker/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFindTextSpan(t *testing.T) {
+	text := "JOHN DOE\nBachelor of  Science,\n  Hanoi University of Technology — GPA 3.6/4.0"
+
+	start, end, ok := utils.FindTextSpan(text, "hanoi university of technology")
+	assert.True(t, ok)
+	assert.Equal(t, "Hanoi University of Technology", text[start:end])
+
+	start, end, ok = utils.FindTextSpan(text, "Bachelor of Science, Hanoi")
+	assert.True(t, ok)
+	assert.Equal(t, "Bachelor of  Science,\n  Hanoi", text[start:end])
+
+	start, end, ok = utils.FindTextSpan(text, "— GPA 3.6/4.0")
+	assert.True(t, ok)
+	assert.Equal(t, "— GPA 3.6/4.0", text[start:end])
+
+	_, _, ok = utils.FindTextSpan(text, "Stanford University")
+	assert.False(t, ok)
+
+	_, _, ok = utils.FindTextSpan(text, "  ")
+	assert.False(t, ok)
+}
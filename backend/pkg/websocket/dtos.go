@@ -1,6 +1,9 @@
 package websocket
 
+// Message is the JSON frame sent to clients. Payload carries the structured body of events, such
+// as the progress of a batch; Data keeps a human-readable text for clients that only show it.
 type Message struct {
-	Type string `json:"type"`
-	Data string `json:"data"`
+	Type    string      `json:"type"`
+	Data    string      `json:"data"`
+	Payload interface{} `json:"payload,omitempty"`
 }
@@ -1,6 +1,7 @@
 package websocket
 
 import (
+	"CVSeeker/pkg/metrics"
 	"encoding/json"
 	"fmt"
 	"github.com/gorilla/websocket"
@@ -22,22 +23,27 @@ var (
 	connMutex   sync.Mutex
 )
 
+// WebSocketConnection is an open client connection. UserID is the user who opened it, empty for
+// anonymous clients.
 type WebSocketConnection struct {
 	Conn   *websocket.Conn
+	UserID string
 	send   chan []byte
 	closed bool
 	mu     sync.Mutex
 }
 
-func NewWebSocketConnection(conn *websocket.Conn) *WebSocketConnection {
+func NewWebSocketConnection(conn *websocket.Conn, userID string) *WebSocketConnection {
 	wc := &WebSocketConnection{
 		Conn:   conn,
+		UserID: userID,
 		send:   make(chan []byte, 256), // Buffered channel for outgoing messages
 		closed: false,
 	}
 	connMutex.Lock()
 	connections = append(connections, wc)
 	connMutex.Unlock()
+	metrics.WebSocketConnections.Inc()
 	return wc
 }
 
@@ -47,6 +53,7 @@ func (wc *WebSocketConnection) cleanup() {
 		close(wc.send)
 		wc.Conn.Close()
 		wc.closed = true
+		metrics.WebSocketConnections.Dec()
 	}
 	wc.mu.Unlock()
 }
@@ -110,12 +117,13 @@ func (wc *WebSocketConnection) writePump() {
 	}
 }
 
-func HandleWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocketConnection, error) {
+// HandleWebSocket upgrades the request and registers the connection as opened by userID.
+func HandleWebSocket(w http.ResponseWriter, r *http.Request, userID string) (*WebSocketConnection, error) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return nil, err
 	}
-	wc := NewWebSocketConnection(conn)
+	wc := NewWebSocketConnection(conn, userID)
 	go wc.writePump()
 	go wc.readPump()
 	return wc, nil
@@ -151,6 +159,42 @@ func BroadcastNotification(notification string) {
 	}
 }
 
+// SendToUser sends an event to the connections opened by userID only. Anonymous submitters reach
+// the anonymous connections.
+func SendToUser(userID, eventType, text string, payload interface{}) {
+	msg, err := (&Message{Type: eventType, Data: text, Payload: payload}).Encode()
+	if err != nil {
+		fmt.Println("Error encoding event message:", err)
+		return
+	}
+
+	connMutex.Lock()
+	defer connMutex.Unlock()
+	for i := len(connections) - 1; i >= 0; i-- {
+		conn := connections[i]
+		if conn.UserID != userID {
+			continue
+		}
+		if err := conn.sendSafe(msg); err != nil {
+			fmt.Println("Error sending event:", err)
+			connections = append(connections[:i], connections[i+1:]...)
+		}
+	}
+}
+
+// CloseAll sends a going-away close frame to every open connection and closes it.
+// It is used on shutdown after background jobs have delivered their last notifications.
+func CloseAll() {
+	connMutex.Lock()
+	defer connMutex.Unlock()
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for _, conn := range connections {
+		_ = conn.Conn.WriteControl(websocket.CloseMessage, closeMessage, time.Now().Add(time.Second))
+		conn.cleanup()
+	}
+	connections = nil
+}
+
 func NewMessage(t, data string) *Message {
 	return &Message{
 		Type: t,
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrShuttingDown is returned when a job is submitted after Shutdown has been called.
+var ErrShuttingDown = errors.New("worker group is shutting down")
+
+// Group tracks background jobs so that they can be drained when the process stops.
+type Group struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	closing bool
+
+	// stopCtx is cancelled when draining times out, which cancels every running job.
+	stopCtx context.Context
+	stop    context.CancelFunc
+}
+
+// NewGroup returns an empty Group that accepts jobs.
+func NewGroup() *Group {
+	stopCtx, stop := context.WithCancel(context.Background())
+	return &Group{stopCtx: stopCtx, stop: stop}
+}
+
+// Go runs fn in a new goroutine. The context passed to fn keeps the values of ctx and is
+// cancelled if the group is forced to stop before fn returns.
+func (_this *Group) Go(ctx context.Context, fn func(ctx context.Context)) error {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	if _this.closing {
+		return ErrShuttingDown
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	stopJob := context.AfterFunc(_this.stopCtx, cancel)
+
+	_this.wg.Add(1)
+	go func() {
+		defer _this.wg.Done()
+		defer stopJob()
+		defer cancel()
+		fn(jobCtx)
+	}()
+	return nil
+}
+
+// ShuttingDown reports whether Shutdown has been called.
+func (_this *Group) ShuttingDown() bool {
+	_this.mu.Lock()
+	defer _this.mu.Unlock()
+	return _this.closing
+}
+
+// Shutdown stops accepting new jobs and waits for running ones to finish. If ctx is done first,
+// the contexts of running jobs are cancelled and ctx.Err() is returned without waiting further.
+func (_this *Group) Shutdown(ctx context.Context) error {
+	_this.mu.Lock()
+	_this.closing = true
+	_this.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		_this.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		_this.stop()
+		return nil
+	case <-ctx.Done():
+		_this.stop()
+		return ctx.Err()
+	}
+}
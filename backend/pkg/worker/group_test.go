@@ -0,0 +1,47 @@
+package worker_test
+
+import (
+	"CVSeeker/pkg/worker"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestGroup_ShutdownWaitsForJobs(t *testing.T) {
+	g := worker.NewGroup()
+	done := make(chan struct{})
+	err := g.Go(context.Background(), func(ctx context.Context) {
+		time.Sleep(20 * time.Millisecond)
+		close(done)
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, g.Shutdown(context.Background()))
+	select {
+	case <-done:
+	default:
+		t.Fatal("Shutdown returned before the job finished")
+	}
+
+	assert.ErrorIs(t, g.Go(context.Background(), func(context.Context) {}), worker.ErrShuttingDown)
+}
+
+func TestGroup_ShutdownTimeoutCancelsJobs(t *testing.T) {
+	g := worker.NewGroup()
+	cancelled := make(chan struct{})
+	_ = g.Go(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, g.Shutdown(ctx), context.DeadlineExceeded)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("job context was not cancelled")
+	}
+}
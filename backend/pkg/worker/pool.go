@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"context"
+)
+
+// Pool bounds how many jobs run at once. Jobs hold a slot from Acquire until Release.
+type Pool struct {
+	slots chan struct{}
+}
+
+// NewPool returns a pool of size slots, at least one.
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	return &Pool{slots: make(chan struct{}, size)}
+}
+
+// Acquire waits for a free slot and takes it. It returns ctx.Err() if ctx is done first.
+func (_this *Pool) Acquire(ctx context.Context) error {
+	select {
+	case _this.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (_this *Pool) Release() {
+	<-_this.slots
+}
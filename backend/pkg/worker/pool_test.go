@@ -0,0 +1,21 @@
+package worker_test
+
+import (
+	"CVSeeker/pkg/worker"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPool_AcquireWaitsForSlot(t *testing.T) {
+	p := worker.NewPool(1)
+	assert.NoError(t, p.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Acquire(ctx), context.DeadlineExceeded)
+
+	p.Release()
+	assert.NoError(t, p.Acquire(context.Background()))
+}
@@ -102,9 +102,10 @@ const UploadPage = () => {
     };
 
     const handleSocketMessage = (message) => {
-        if (message === '{"type":"notification","data":"All documents have been processed successfully."}') {
+        const event = JSON.parse(message);
+        if (event.type === 'batch.completed') {
             disconnect();
-            showFinishProcessToast('Complete profile processing!');
+            showFinishProcessToast(event.data);
             getUploadedFiles()
                 .then((res) => {
                     setUploadedFiles(res);
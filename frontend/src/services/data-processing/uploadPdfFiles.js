@@ -1,22 +1,36 @@
 
 import axiosInstance from '../configs'
+import { v4 as uuidv4 } from 'uuid';
 
+// maxAttempts is how many times a submission is sent when the network fails before a response.
+const maxAttempts = 3;
+
+// The submission is sent again with the same Idempotency-Key, so that a retry returns the batch of
+// the first attempt instead of uploading the files twice.
 export default async function uploadPdfFiles(textFiles) {
+    const idempotencyKey = uuidv4();
 
-    try {
-        let res = await axiosInstance.post(`/batch/upload`, {
-            resumes: [...textFiles]
-        });
+    for (let attempt = 1; ; attempt++) {
+        try {
+            let res = await axiosInstance.post(`/batch/upload`, {
+                resumes: [...textFiles]
+            }, {
+                headers: { 'Idempotency-Key': idempotencyKey }
+            });
 
-        res = res.data.meta.code === 200 ? res.data.data : { error: res.data.meta.message }
-        
-        return res
-    }
+            res = res.data.meta.code === 200 ? res.data.data : { error: res.data.meta.message }
+
+            return res
+        }
 
-    catch (err) {
-        if (err.response) {
-            console.log(err.response.data.message);
-            return { error: err.response.data.message };
+        catch (err) {
+            if (err.response) {
+                console.log(err.response.data.message);
+                return { error: err.response.data.message };
+            }
+            if (attempt >= maxAttempts) {
+                return { error: err.message };
+            }
         }
     }
-}
\ No newline at end of file
+}
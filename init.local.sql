@@ -3,12 +3,30 @@ USE TalentAcquisition;
 
 CREATE TABLE `resumes` (
                            `resume_id` int NOT NULL AUTO_INCREMENT,
+                           `document_id` varchar(100) DEFAULT NULL,
                            `full_text` text,
                            `download_link` varchar(255) DEFAULT NULL,
                            `vector_embedding` text,
+                           `file_key` varchar(255) DEFAULT NULL,
+                           `source` varchar(20) DEFAULT NULL,
+                           `content` longtext,
+                           `prompt_version` varchar(50) DEFAULT NULL,
+                           `parse_model` varchar(100) DEFAULT NULL,
+                           `embedding_model` varchar(255) DEFAULT NULL,
+                           `file_hash` char(64) DEFAULT NULL,
+                           `text_hash` char(64) DEFAULT NULL,
+                           `name_key` varchar(255) DEFAULT NULL,
+                           `emails` varchar(1024) DEFAULT NULL,
+                           `phones` varchar(512) DEFAULT NULL,
+                           `merged_from` varchar(100) NOT NULL DEFAULT '',
                            `created_at` datetime DEFAULT NULL,
                            `updated_at` datetime DEFAULT NULL,
-                           PRIMARY KEY (`resume_id`)
+                           `crawled_at` datetime DEFAULT NULL,
+                           PRIMARY KEY (`resume_id`),
+                           KEY `idx_document_id` (`document_id`),
+                           KEY `idx_file_hash` (`file_hash`),
+                           KEY `idx_text_hash` (`text_hash`),
+                           KEY `idx_name_key` (`name_key`)
 );
 
 CREATE TABLE `threads` (
@@ -34,7 +52,191 @@ CREATE TABLE `upload` (
                           `status` varchar(100) NOT NULL,
                           `name` varchar(255) DEFAULT NULL,
                           `uuid` varchar(255) DEFAULT NULL,
+                          `batch_id` bigint DEFAULT NULL,
+                          `error` text DEFAULT NULL,
+                          `content` longtext DEFAULT NULL,
+                          `file_key` varchar(255) DEFAULT NULL,
+                          `url` varchar(1024) DEFAULT NULL,
+                          `on_duplicate` varchar(20) DEFAULT NULL,
+                          `source` varchar(20) DEFAULT NULL,
+                          `origin` varchar(1024) DEFAULT NULL,
+                          `file_hash` char(64) DEFAULT NULL,
+                          `duplicate_of` int DEFAULT NULL,
                           `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
                           `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-                          PRIMARY KEY (`id`)
-);
\ No newline at end of file
+                          PRIMARY KEY (`id`),
+                          KEY `idx_upload_batch_id` (`batch_id`),
+                          KEY `idx_upload_file_hash` (`file_hash`, `created_at`),
+                          KEY `idx_upload_duplicate_of` (`duplicate_of`)
+);
+CREATE TABLE `llm_usage` (
+                             `id` bigint NOT NULL AUTO_INCREMENT,
+                             `provider` varchar(50) NOT NULL,
+                             `model` varchar(255) NOT NULL,
+                             `operation` varchar(100) NOT NULL,
+                             `user_id` varchar(255) NOT NULL DEFAULT '',
+                             `tenant_id` varchar(100) NOT NULL DEFAULT '',
+                             `prompt_tokens` int NOT NULL DEFAULT 0,
+                             `completion_tokens` int NOT NULL DEFAULT 0,
+                             `total_tokens` int NOT NULL DEFAULT 0,
+                             `estimated` tinyint(1) NOT NULL DEFAULT 0,
+                             `cost` decimal(12,6) NOT NULL DEFAULT 0,
+                             `trace_id` varchar(64) DEFAULT NULL,
+                             `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                             PRIMARY KEY (`id`),
+                             KEY `idx_tenant_created_at` (`tenant_id`,`created_at`),
+                             KEY `idx_created_at` (`created_at`)
+);
+
+CREATE TABLE `llm_budgets` (
+                               `tenant_id` varchar(100) NOT NULL,
+                               `monthly_limit` decimal(12,2) NOT NULL,
+                               `policy` varchar(20) NOT NULL DEFAULT 'reject',
+                               `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                               `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+                               PRIMARY KEY (`tenant_id`)
+);
+
+CREATE TABLE `prompt_templates` (
+                                    `id` bigint NOT NULL AUTO_INCREMENT,
+                                    `name` varchar(100) NOT NULL,
+                                    `version` varchar(32) NOT NULL,
+                                    `body` text NOT NULL,
+                                    `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                    PRIMARY KEY (`id`),
+                                    UNIQUE KEY `uk_prompt_templates_name_version` (`name`, `version`)
+);
+
+CREATE TABLE `prompt_rollouts` (
+                                   `name` varchar(100) NOT NULL,
+                                   `version` varchar(32) NOT NULL,
+                                   `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+                                   PRIMARY KEY (`name`)
+);
+
+CREATE TABLE `reprocess_jobs` (
+                                  `id` bigint NOT NULL AUTO_INCREMENT,
+                                  `mode` varchar(20) NOT NULL,
+                                  `selection` text NOT NULL,
+                                  `status` varchar(20) NOT NULL,
+                                  `total` int NOT NULL DEFAULT 0,
+                                  `succeeded` int NOT NULL DEFAULT 0,
+                                  `skipped` int NOT NULL DEFAULT 0,
+                                  `failed` int NOT NULL DEFAULT 0,
+                                  `cursor_upload_id` int NOT NULL DEFAULT 0,
+                                  `last_error` text,
+                                  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  `finished_at` datetime DEFAULT NULL,
+                                  PRIMARY KEY (`id`),
+                                  KEY `idx_status` (`status`)
+);
+
+CREATE TABLE `duplicate_candidates` (
+                                        `id` bigint NOT NULL AUTO_INCREMENT,
+                                        `document_id` varchar(100) NOT NULL,
+                                        `duplicate_of` varchar(100) NOT NULL,
+                                        `upload_id` int DEFAULT NULL,
+                                        `reason` varchar(20) NOT NULL,
+                                        `score` double NOT NULL DEFAULT 0,
+                                        `status` varchar(20) NOT NULL,
+                                        `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                        `resolved_at` datetime DEFAULT NULL,
+                                        PRIMARY KEY (`id`),
+                                        KEY `idx_status` (`status`),
+                                        KEY `idx_document_id` (`document_id`),
+                                        KEY `idx_duplicate_of` (`duplicate_of`)
+);
+
+CREATE TABLE `candidates` (
+                              `id` bigint NOT NULL AUTO_INCREMENT,
+                              `full_name` varchar(255) DEFAULT NULL,
+                              `latest_document_id` varchar(100) DEFAULT NULL,
+                              `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                              `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                              PRIMARY KEY (`id`)
+);
+
+CREATE TABLE `resume_versions` (
+                                   `id` bigint NOT NULL AUTO_INCREMENT,
+                                   `candidate_id` bigint NOT NULL,
+                                   `version` int NOT NULL,
+                                   `document_id` varchar(100) NOT NULL,
+                                   `upload_id` int DEFAULT NULL,
+                                   `file_url` varchar(1024) DEFAULT NULL,
+                                   `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                   PRIMARY KEY (`id`),
+                                   UNIQUE KEY `uk_candidate_version` (`candidate_id`,`version`),
+                                   UNIQUE KEY `uk_document_id` (`document_id`)
+);
+
+CREATE TABLE `outbox` (
+                          `id` bigint NOT NULL AUTO_INCREMENT,
+                          `topic` varchar(100) NOT NULL,
+                          `aggregate_id` varchar(255) NOT NULL,
+                          `payload` longtext,
+                          `attempts` int NOT NULL DEFAULT 0,
+                          `last_error` text,
+                          `available_at` datetime NOT NULL,
+                          `locked_by` varchar(64) NOT NULL DEFAULT '',
+                          `locked_until` datetime DEFAULT NULL,
+                          `processed_at` datetime DEFAULT NULL,
+                          `failed_at` datetime DEFAULT NULL,
+                          `created_at` datetime NOT NULL,
+                          PRIMARY KEY (`id`),
+                          KEY `idx_pending` (`processed_at`,`failed_at`,`available_at`),
+                          KEY `idx_aggregate_id` (`aggregate_id`,`id`),
+                          KEY `idx_locked_by` (`locked_by`)
+);
+
+CREATE TABLE `reconcile_runs` (
+                                  `id` bigint NOT NULL AUTO_INCREMENT,
+                                  `trigger_type` varchar(20) NOT NULL,
+                                  `policy` text NOT NULL,
+                                  `dry_run` tinyint(1) NOT NULL DEFAULT 0,
+                                  `status` varchar(20) NOT NULL,
+                                  `uploads` int NOT NULL DEFAULT 0,
+                                  `records` int NOT NULL DEFAULT 0,
+                                  `documents` int NOT NULL DEFAULT 0,
+                                  `objects` int NOT NULL DEFAULT 0,
+                                  `found` int NOT NULL DEFAULT 0,
+                                  `fixed` int NOT NULL DEFAULT 0,
+                                  `failed` int NOT NULL DEFAULT 0,
+                                  `counts` text,
+                                  `issues` longtext,
+                                  `last_error` text,
+                                  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  `updated_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  `finished_at` datetime DEFAULT NULL,
+                                  PRIMARY KEY (`id`),
+                                  KEY `idx_status` (`status`)
+);
+
+CREATE TABLE `batches` (
+                           `id` bigint NOT NULL AUTO_INCREMENT,
+                           `user_id` varchar(255) NOT NULL DEFAULT '',
+                           `source` varchar(20) NOT NULL,
+                           `total` int NOT NULL DEFAULT 0,
+                           `idempotency_key` varchar(255) DEFAULT NULL,
+                           `request_hash` char(64) NOT NULL DEFAULT '',
+                           `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           `finished_at` datetime DEFAULT NULL,
+                           PRIMARY KEY (`id`),
+                           KEY `idx_user_id` (`user_id`),
+                           UNIQUE KEY `uk_user_idempotency_key` (`user_id`, `idempotency_key`)
+);
+
+CREATE TABLE `imports` (
+                           `id` bigint NOT NULL AUTO_INCREMENT,
+                           `user_id` varchar(255) NOT NULL DEFAULT '',
+                           `batch_id` bigint DEFAULT NULL,
+                           `source` varchar(255) NOT NULL DEFAULT '',
+                           `file_name` varchar(255) NOT NULL DEFAULT '',
+                           `files` int NOT NULL DEFAULT 0,
+                           `queued` int NOT NULL DEFAULT 0,
+                           `skipped` int NOT NULL DEFAULT 0,
+                           `entries` longtext,
+                           `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           PRIMARY KEY (`id`),
+                           KEY `idx_batch_id` (`batch_id`)
+);